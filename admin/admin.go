@@ -0,0 +1,143 @@
+// Package admin protects operator-only endpoints with a shared token,
+// records every admin request in an audit log and holds the feature flags
+// operators can switch at runtime.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// TokenArg is the request argument carrying the admin token
+const TokenArg = "adminToken"
+
+// Guard checks the admin token on every admin request and records each one
+// in its audit log
+type Guard struct {
+	token string
+	audit *AuditLog
+	clock clock.Clock
+}
+
+// NewGuard creates a guard for token that records requests in audit. With an
+// empty token every admin endpoint is disabled rather than left open.
+func NewGuard(token string, audit *AuditLog) *Guard {
+	return &Guard{token: token, audit: audit, clock: clock.System}
+}
+
+// WithClock replaces the system clock that stamps audit entries; call it
+// before the guard is used
+func (g *Guard) WithClock(c clock.Clock) *Guard {
+	g.clock = clock.OrSystem(c)
+	return g
+}
+
+// Enabled reports whether an admin token is configured
+func (g *Guard) Enabled() bool {
+	return g.token != ""
+}
+
+// ErrDisabled is returned by admin resolvers when no admin token is
+// configured
+var ErrDisabled = errors.New("admin endpoints are disabled: set PLUGIN_ADMIN_TOKEN")
+
+// ErrUnauthorized is returned by admin resolvers called without the correct
+// token
+var ErrUnauthorized = errors.New("invalid or missing admin token")
+
+// Wrap rejects requests without the correct token with a 401 envelope (403
+// when admin endpoints are disabled). The token is removed from args before
+// the handler runs. Every request that reaches the guard is audited,
+// including rejected ones.
+func (g *Guard) Wrap(name string, handler sdk.RESTHandlerFunc) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		forwarded, entry, err := g.authorize(name, args)
+		if err != nil {
+			return deny(entry.Status, err.Error()), nil
+		}
+
+		result, err := handler(ctx, forwarded)
+		entry.Outcome, entry.Status = OutcomeOK, 200
+		if response, ok := result.(map[string]interface{}); ok {
+			if status, ok := response["statusCode"].(int); ok {
+				entry.Status = status
+			}
+		}
+		if err != nil {
+			entry.Outcome, entry.Status, entry.Error = OutcomeFailed, 500, err.Error()
+		} else if entry.Status >= 400 {
+			entry.Outcome = OutcomeFailed
+		}
+		g.record(entry)
+		return result, err
+	}
+}
+
+// WrapResolver guards a GraphQL resolver like Wrap guards a REST handler,
+// returning ErrUnauthorized or ErrDisabled instead of an envelope
+func (g *Guard) WrapResolver(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		forwarded, entry, err := g.authorize(name, args)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := resolver(ctx, forwarded)
+		entry.Outcome, entry.Status = OutcomeOK, 200
+		if err != nil {
+			entry.Outcome, entry.Status, entry.Error = OutcomeFailed, 500, err.Error()
+		}
+		g.record(entry)
+		return result, err
+	}
+}
+
+// authorize checks the token in args and returns args without it, with the
+// request's audit entry. Rejected requests are audited here, with the
+// entry's Status set to 401 or 403.
+func (g *Guard) authorize(name string, args map[string]interface{}) (map[string]interface{}, AuditEntry, error) {
+	forwarded := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if key != TokenArg {
+			forwarded[key] = value
+		}
+	}
+	entry := AuditEntry{Time: g.clock.Now().UTC(), Endpoint: name, Args: auditArgs(forwarded)}
+
+	if !g.Enabled() {
+		entry.Outcome, entry.Status = OutcomeDenied, 403
+		g.record(entry)
+		return nil, entry, ErrDisabled
+	}
+
+	supplied, _ := args[TokenArg].(string)
+	if subtle.ConstantTimeCompare([]byte(supplied), []byte(g.token)) != 1 {
+		log.Printf("🔒 [hc-hello-world-plugin] event=admin_denied endpoint=%s", name)
+		entry.Outcome, entry.Status = OutcomeDenied, 401
+		g.record(entry)
+		return nil, entry, ErrUnauthorized
+	}
+	return forwarded, entry, nil
+}
+
+func (g *Guard) record(entry AuditEntry) {
+	if g.audit != nil {
+		g.audit.Record(entry)
+	}
+}
+
+func deny(status int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"statusCode": status,
+		"headers":    map[string]interface{}{"Content-Type": "application/json"},
+		"body": map[string]interface{}{
+			"error": message,
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/logging"
+)
+
+// Audit outcomes
+const (
+	OutcomeOK     = "ok"
+	OutcomeFailed = "failed"
+	OutcomeDenied = "denied"
+)
+
+// AuditEntry records one admin request
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Endpoint string    `json:"endpoint"`
+	Outcome  string    `json:"outcome"`
+	// Status is the response status code, 200 unless the handler set one
+	Status int `json:"status"`
+	// Args are the request arguments with sensitive fields redacted
+	Args  string `json:"args"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLog keeps the most recent admin requests in memory and writes each
+// one to the plugin log as it happens
+type AuditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+	next    int
+	full    bool
+}
+
+// NewAuditLog creates a log holding the last size entries
+func NewAuditLog(size int) *AuditLog {
+	return &AuditLog{entries: make([]AuditEntry, max(size, 1))}
+}
+
+// Record appends e, overwriting the oldest entry once the log is full
+func (a *AuditLog) Record(e AuditEntry) {
+	if e.Error != "" {
+		log.Printf("📝 [hc-hello-world-plugin] event=admin_audit endpoint=%s outcome=%s status=%d args=%s error=%q",
+			e.Endpoint, e.Outcome, e.Status, e.Args, e.Error)
+	} else {
+		log.Printf("📝 [hc-hello-world-plugin] event=admin_audit endpoint=%s outcome=%s status=%d args=%s",
+			e.Endpoint, e.Outcome, e.Status, e.Args)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries[a.next] = e
+	a.next = (a.next + 1) % len(a.entries)
+	if a.next == 0 {
+		a.full = true
+	}
+}
+
+// Entries returns up to limit entries, newest first; limit <= 0 returns all
+func (a *AuditLog) Entries(limit int) []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	count := a.next
+	if a.full {
+		count = len(a.entries)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	result := make([]AuditEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		result = append(result, a.entries[(a.next-i+len(a.entries))%len(a.entries)])
+	}
+	return result
+}
+
+// auditArgs renders request args for the audit log, leaving out the host's
+// context_ values
+func auditArgs(args map[string]interface{}) string {
+	body := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if !strings.HasPrefix(key, "context_") {
+			body[key] = value
+		}
+	}
+	return logging.Redact(body)
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Flag is a feature operators can switch on or off at runtime
+type Flag struct {
+	name        string
+	description string
+	enabled     atomic.Bool
+}
+
+// Enabled reports whether the flag is on
+func (f *Flag) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// Set switches the flag and returns its previous state
+func (f *Flag) Set(enabled bool) bool {
+	return f.enabled.Swap(enabled)
+}
+
+// FlagState is a flag as listed by Flags.List
+type FlagState struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Flags holds the runtime-switchable features by name
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]*Flag
+}
+
+// NewFlags creates an empty flag set
+func NewFlags() *Flags {
+	return &Flags{flags: make(map[string]*Flag)}
+}
+
+// Define adds a flag; defining the same name twice panics, as that is a
+// programming error
+func (fs *Flags) Define(name, description string, enabled bool) *Flag {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, dup := fs.flags[name]; dup {
+		panic(fmt.Sprintf("admin: flag %q defined twice", name))
+	}
+	f := &Flag{name: name, description: description}
+	f.enabled.Store(enabled)
+	fs.flags[name] = f
+	return f
+}
+
+// Set switches the named flag and returns its previous state
+func (fs *Flags) Set(name string, enabled bool) (bool, error) {
+	fs.mu.RLock()
+	f, ok := fs.flags[name]
+	fs.mu.RUnlock()
+	if !ok {
+		return false, fmt.Errorf("unknown flag %q", name)
+	}
+	return f.Set(enabled), nil
+}
+
+// List returns every flag sorted by name
+func (fs *Flags) List() []FlagState {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	states := make([]FlagState, 0, len(fs.flags))
+	for _, f := range fs.flags {
+		states = append(states, FlagState{Name: f.name, Description: f.description, Enabled: f.Enabled()})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
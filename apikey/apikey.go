@@ -0,0 +1,310 @@
+// Package apikey authenticates REST requests with API keys. Keys are
+// random, shown once when created and stored only as their SHA-256 hash;
+// each key carries scopes naming the endpoints it may call.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrInvalidKey is returned for unknown and revoked keys alike
+var ErrInvalidKey = errors.New("invalid or revoked API key")
+
+// ErrNotFound is returned when revoking an unknown key ID
+var ErrNotFound = errors.New("API key not found")
+
+// ErrInvalidScope is returned when creating a key with a malformed scope
+var ErrInvalidScope = errors.New("invalid API key scope")
+
+// Prefix starts every key, so leaked keys are easy to recognise
+const Prefix = "hwk_"
+
+// Header is the request header carrying the key
+const Header = "X-API-Key"
+
+// Arg is the request argument carrying the key when the header cannot be
+// set; it is removed before the handler runs
+const Arg = "apiKey"
+
+// hintLength is how much of a key is kept in clear to tell keys apart
+const hintLength = len(Prefix) + 4
+
+// keyHeaders are the argument/context keys checked for the key
+var keyHeaders = []string{Header, "X-Api-Key", "x-api-key", Arg}
+
+// Key is a provisioned API key; the key itself is never stored
+type Key struct {
+	ID   string
+	Name string
+	// Hint is the start of the key, e.g. "hwk_Ab3x"
+	Hint string
+	// Scopes are the endpoints the key may call, see Allows
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+// Revoked reports whether the key was revoked
+func (k Key) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Config configures a Store
+type Config struct {
+	// Required makes every REST endpoint not matched by Exempt demand a key;
+	// otherwise keys are checked only when one is sent
+	Required bool
+	// Exempt are scopes of endpoints that never need a key, such as health
+	// checks and the admin endpoints, which have their own token
+	Exempt []string
+	// NewID generates key IDs
+	NewID func() string
+	// Clock stamps key creation, use and revocation; nil uses the system
+	// clock
+	Clock clock.Clock
+}
+
+// Store holds the provisioned keys in memory, indexed by hash
+type Store struct {
+	cfg Config
+
+	mu     sync.RWMutex
+	byID   map[string]*Key
+	byHash map[string]string
+}
+
+// New creates an empty store
+func New(cfg Config) *Store {
+	cfg.Clock = clock.OrSystem(cfg.Clock)
+	return &Store{
+		cfg:    cfg,
+		byID:   make(map[string]*Key),
+		byHash: make(map[string]string),
+	}
+}
+
+// Required reports whether REST endpoints demand a key
+func (s *Store) Required() bool {
+	return s.cfg.Required
+}
+
+// Exempt reports whether the endpoint named "METHOD /path" never needs a
+// key
+func (s *Store) Exempt(endpoint string) bool {
+	return Allows(s.cfg.Exempt, endpoint)
+}
+
+// Create provisions a key named name limited to scopes. It returns the key
+// record and the key itself, which cannot be recovered later.
+func (s *Store) Create(name string, scopes []string) (Key, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Key{}, "", errors.New("name is required")
+	}
+	if len(scopes) == 0 {
+		return Key{}, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	cleaned := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope, err := ParseScope(scope)
+		if err != nil {
+			return Key{}, "", err
+		}
+		cleaned = append(cleaned, scope)
+	}
+
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return Key{}, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := Prefix + base64.RawURLEncoding.EncodeToString(random[:])
+	key := &Key{
+		ID:        s.cfg.NewID(),
+		Name:      name,
+		Hint:      secret[:hintLength],
+		Scopes:    cleaned,
+		CreatedAt: s.cfg.Clock.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byID[key.ID] = key
+	s.byHash[hash(secret)] = key.ID
+	return *key, secret, nil
+}
+
+// Revoke disables a key; revoking it again keeps the first revocation time
+func (s *Store) Revoke(id string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.byID[id]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if !key.Revoked() {
+		key.RevokedAt = s.cfg.Clock.Now().UTC()
+	}
+	return *key, nil
+}
+
+// List returns every key, newest first
+func (s *Store) List() []Key {
+	s.mu.RLock()
+	result := make([]Key, 0, len(s.byID))
+	for _, key := range s.byID {
+		result = append(result, *key)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Authenticate returns the active key matching secret and records its use
+func (s *Store) Authenticate(secret string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.byID[s.byHash[hash(secret)]]
+	if !ok || key.Revoked() {
+		return Key{}, ErrInvalidKey
+	}
+	key.LastUsedAt = s.cfg.Clock.Now().UTC()
+	return *key, nil
+}
+
+// hash returns the hex SHA-256 of a key. Keys carry 256 random bits, so a
+// fast unsalted hash is enough and lets keys be looked up by hash.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Resolver checks the key of REST handlers, named "METHOD /path", and
+// answers a missing or invalid key with 401 and a key lacking the
+// endpoint's scope with 403. It has the registry.Middleware signature;
+// resolvers, functions and exempt endpoints are returned as is.
+func (s *Store) Resolver(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	if !strings.Contains(name, " /") || s.Exempt(name) {
+		return handler
+	}
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		secret := lookup(ctx, args)
+		if secret == "" && !s.cfg.Required {
+			return handler(ctx, args)
+		}
+		if secret == "" {
+			return deny(401, "missing API key: send it in the "+Header+" header"), nil
+		}
+		key, err := s.Authenticate(secret)
+		if err != nil {
+			log.Printf("🔒 [hc-hello-world-plugin] event=api_key_denied endpoint=%q reason=%q", name, err)
+			return deny(401, err.Error()), nil
+		}
+		if !Allows(key.Scopes, name) {
+			log.Printf("🔒 [hc-hello-world-plugin] event=api_key_denied endpoint=%q key_id=%s reason=%q", name, key.ID, "missing scope")
+			return deny(403, fmt.Sprintf("API key %s is not allowed to call %s", key.Hint, name)), nil
+		}
+
+		forwarded := args
+		if _, ok := args[Arg]; ok {
+			forwarded = make(map[string]interface{}, len(args))
+			for k, v := range args {
+				if k != Arg {
+					forwarded[k] = v
+				}
+			}
+		}
+		return handler(ctx, forwarded)
+	}
+}
+
+// lookup reads the key from the args, a nested "headers" map, or the
+// request context
+func lookup(ctx context.Context, args map[string]interface{}) string {
+	sources := []map[string]interface{}{args}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		sources = append(sources, headers)
+	}
+	if headers, ok := ctx.Value("headers").(map[string]interface{}); ok {
+		sources = append(sources, headers)
+	}
+	for _, values := range sources {
+		for _, name := range keyHeaders {
+			if value, ok := values[name].(string); ok && strings.TrimSpace(value) != "" {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+func deny(status int, message string) map[string]interface{} {
+	headers := map[string]interface{}{"Content-Type": "application/json"}
+	if status == 401 {
+		headers["WWW-Authenticate"] = `ApiKey header="` + Header + `"`
+	}
+	return map[string]interface{}{
+		"statusCode": status,
+		"headers":    headers,
+		"body":       map[string]interface{}{"error": message},
+	}
+}
+
+// methods are the methods a scope may name
+var methods = map[string]bool{"*": true, "GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// ParseScope validates and normalises a scope. A scope is "*" for every
+// endpoint or "METHOD /path", where METHOD may be "*" and a path ending in
+// "*" matches every path it prefixes, e.g. "GET /emails/*".
+func ParseScope(scope string) (string, error) {
+	scope = strings.TrimSpace(scope)
+	if scope == "*" {
+		return scope, nil
+	}
+	method, path, ok := strings.Cut(scope, " ")
+	method, path = strings.ToUpper(method), strings.TrimSpace(path)
+	if !ok || !methods[method] || !strings.HasPrefix(path, "/") || strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+		return "", fmt.Errorf("%w %q, expected \"*\" or \"METHOD /path\" with an optional trailing *", ErrInvalidScope, scope)
+	}
+	return method + " " + path, nil
+}
+
+// Allows reports whether any of scopes covers the endpoint named
+// "METHOD /path"
+func Allows(scopes []string, endpoint string) bool {
+	method, path, _ := strings.Cut(endpoint, " ")
+	for _, scope := range scopes {
+		if scope == "*" {
+			return true
+		}
+		scopeMethod, pattern, _ := strings.Cut(scope, " ")
+		if scopeMethod != "*" && scopeMethod != method {
+			continue
+		}
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard && strings.HasPrefix(path, prefix) || pattern == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,620 @@
+// Package app is the plugin's application container: every shared
+// component - configuration, clock, logger, store, cache, event bus and the
+// services built on them - is constructed and wired together here, once,
+// and handed to the handlers that need it instead of living in package
+// globals. A real plugin adds its own components as fields and builds them
+// in New.
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/admin"
+	"hc-hello-world-plugin/apikey"
+	"hc-hello-world-plugin/atrest"
+	"hc-hello-world-plugin/auth"
+	"hc-hello-world-plugin/buildinfo"
+	"hc-hello-world-plugin/cache"
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/compat"
+	"hc-hello-world-plugin/config"
+	"hc-hello-world-plugin/dbpool"
+	"hc-hello-world-plugin/email"
+	"hc-hello-world-plugin/errreport"
+	"hc-hello-world-plugin/events"
+	"hc-hello-world-plugin/extapi"
+	"hc-hello-world-plugin/fakedata"
+	"hc-hello-world-plugin/files"
+	"hc-hello-world-plugin/gdpr"
+	"hc-hello-world-plugin/health"
+	"hc-hello-world-plugin/idgen"
+	"hc-hello-world-plugin/importjob"
+	"hc-hello-world-plugin/inflight"
+	"hc-hello-world-plugin/instrument"
+	"hc-hello-world-plugin/jobs"
+	"hc-hello-world-plugin/limits"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/metrics"
+	"hc-hello-world-plugin/mutationid"
+	"hc-hello-world-plugin/oauth"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/pii"
+	"hc-hello-world-plugin/quota"
+	"hc-hello-world-plugin/relay"
+	"hc-hello-world-plugin/render"
+	"hc-hello-world-plugin/resumable"
+	"hc-hello-world-plugin/retry"
+	"hc-hello-world-plugin/sanitize"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/tracecontext"
+	"hc-hello-world-plugin/upload"
+	"hc-hello-world-plugin/usage"
+	"hc-hello-world-plugin/watchdog"
+	"hc-hello-world-plugin/weather"
+	"hc-hello-world-plugin/webhook"
+)
+
+// Options are the parts of the wiring that depend on the plugin rather
+// than on its configuration
+type Options struct {
+	// Name identifies the plugin to the host and in forwarded logs
+	Name string
+	// Clock replaces the system clock, e.g. with a clock.Fake in tests
+	Clock clock.Clock
+	// IsClientError tells errors caused by the request, which are not
+	// reported, from server errors
+	IsClientError func(error) bool
+	// QuotaExempt names the handlers that are neither rate limited nor
+	// counted against a tenant's monthly budget
+	QuotaExempt []string
+	// APIKeyExempt are the scopes of the REST endpoints that never need an
+	// API key
+	APIKeyExempt []string
+}
+
+// App holds the plugin's shared components. Build it with New; the fields
+// are set once and safe to read from any handler.
+type App struct {
+	// Name identifies the plugin to the host
+	Name   string
+	Config config.Config
+	// Clock tells the time everywhere in the plugin
+	Clock clock.Clock
+	// Logger is the standard logger, configured from PLUGIN_LOG_*
+	Logger *log.Logger
+	// StartedAt is when the container was built, for uptime reporting
+	StartedAt time.Time
+
+	// Store holds users, products, groups and comments in the datasource
+	// PLUGIN_DATASOURCE names; Events publishes its changes as domain events
+	// such as UserCreated
+	Store  store.Datasource
+	Events *events.Bus
+	// Sealer encrypts the store at rest when PLUGIN_STORE_ENCRYPTION_KEY is
+	// set; it is nil otherwise
+	Sealer *atrest.Sealer
+	// PII masks users' emails and phone numbers for roles not in
+	// PLUGIN_PII_ROLES, and encrypts them in the store when
+	// PLUGIN_FIELD_ENCRYPTION_KEY is set
+	PII *pii.Protector
+	// Cache holds results of read-only resolvers; Inflight coalesces
+	// identical concurrent calls of them
+	Cache    *cache.Cache
+	Inflight *inflight.Group
+	// DBPool sizes every SQL connection pool the plugin opens, and DBPools
+	// reports those pools on /metrics
+	DBPool  dbpool.Config
+	DBPools *dbpool.Registry
+	// IDs creates IDs for new records
+	IDs idgen.Generator
+	// Limits caps list sizes and query complexity
+	Limits limits.Config
+	// Sanitizer cleans user-supplied strings before they are echoed back
+	Sanitizer *sanitize.Sanitizer
+	// SampleData is the demo data generated at startup and by
+	// /admin/store/reset
+	SampleData fakedata.Config
+	// Usage counts every instrumented resolver call per hour
+	Usage *usage.Recorder
+	// Metrics counts every handler call since startup for /metrics
+	Metrics *metrics.Calls
+
+	// Credentials holds password hashes and signs login tokens
+	Credentials *auth.CredentialStore
+	// APIKeys authenticates REST requests
+	APIKeys *apikey.Store
+	// Quotas enforces per-tenant rate limits and monthly call budgets
+	Quotas *quota.Limiter
+	// Receipts signs the receipts of eraseUser
+	Receipts *gdpr.Signer
+
+	// Uploads stores uploaded files such as avatars; UploadOptions limits
+	// POST /graphql/upload
+	Uploads       *files.Store
+	UploadOptions upload.Options
+	// UploadSessions receives resumable uploads into Uploads
+	UploadSessions *resumable.Manager
+	// Imports tracks importUsers runs for getImportJob
+	Imports *importjob.Tracker
+	// MutationIDs replays mutations repeated with the same clientMutationId
+	MutationIDs *mutationid.Cache
+	// Templates renders the HTML templates; in debug mode it re-reads
+	// PLUGIN_TEMPLATE_DIR on every render
+	Templates *render.Set
+	// Jobs runs background work such as sending emails through Mailer
+	Jobs   *jobs.Queue
+	Mailer email.Sender
+
+	// Webhooks POSTs every store event to PLUGIN_WEBHOOK_URL
+	Webhooks *webhook.Dispatcher
+	// HTTPFixtures is the fixtures mode and directory every outbound call
+	// uses; Reload can switch them
+	HTTPFixtures *outbound.Fixtures
+	// OAuth obtains access tokens for ExternalAPI
+	OAuth       *oauth.TokenSource
+	ExternalAPI *extapi.Client
+	// Weather backs getWeather with the Open-Meteo APIs
+	Weather *weather.Service
+	// ErrorReporter sends panics and server errors to PLUGIN_SENTRY_DSN
+	ErrorReporter *errreport.Reporter
+
+	// Audit records every request to an /admin/* endpoint, which Guard
+	// protects
+	Audit *admin.AuditLog
+	Guard *admin.Guard
+	// Flags are the features operators can switch through /admin/flags
+	Flags              *admin.Flags
+	ResponseCacheFlag  *admin.Flag
+	WelcomeEmailsFlag  *admin.Flag
+	DebugEndpointsFlag *admin.Flag
+
+	// Watchdog sheds expensive work under memory pressure
+	Watchdog *watchdog.Watchdog
+	// Dependencies tracks optional dependencies for /health; Readiness
+	// gates serving on the store being loaded and them having been checked
+	Dependencies *health.Monitor
+	Readiness    *health.Readiness
+	// Heartbeat writes both to PLUGIN_HEALTH_FILE for --healthcheck
+	Heartbeat *health.Beater
+	// Host is the negotiated host version
+	Host *compat.Host
+	// Nodes resolves Relay global IDs to plugin objects
+	Nodes *relay.Registry
+
+	// effective is Config with the settings Reload has applied since
+	// startup
+	reloadMu  sync.Mutex
+	effective config.Config
+}
+
+// New builds every component from cfg and wires them together. Nothing is
+// started; the caller starts Watchdog and Dependencies.
+func New(cfg config.Config, opts Options) *App {
+	a := &App{
+		Name:   opts.Name,
+		Config: cfg,
+		Clock:  clock.OrSystem(opts.Clock),
+		Logger: newLogger(cfg, opts.Name),
+
+		effective: cfg,
+	}
+	a.StartedAt = a.Clock.Now()
+
+	a.Sealer = newSealer(cfg)
+	a.PII = newProtector(cfg)
+	a.DBPool = dbpool.Config{
+		MaxOpenConns:     cfg.DBMaxOpenConns,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  cfg.DBConnMaxLifetime,
+		StatementTimeout: cfg.DBStatementTimeout,
+	}
+	a.DBPools = new(dbpool.Registry)
+	a.Store = newDatasource(cfg, a.Sealer, a.PII, a.DBPool, a.Clock)
+	// SQL datasources report their connection pool on /metrics
+	if ds, ok := a.Store.(*store.SQLStore); ok {
+		a.DBPools.Add(ds.Kind(), ds.DBStats)
+	}
+	a.Events = events.NewBus()
+	a.Cache = cache.New(cfg.CacheTTL, cfg.CacheMaxEntries).WithStaleWindow(cfg.CacheStaleWindow).WithClock(a.Clock)
+	a.Inflight = new(inflight.Group)
+	a.IDs = newIDGenerator(cfg, a.Clock)
+	a.Limits = limits.Config{
+		MaxPageSize:   cfg.MaxPageSize,
+		MaxItems:      cfg.MaxItems,
+		MaxComplexity: cfg.MaxComplexity,
+		MaxJSONDepth:  cfg.MaxJSONDepth,
+		MaxJSONBytes:  cfg.MaxJSONBytes,
+	}
+	a.Sanitizer = sanitize.New(sanitize.DefaultOptions())
+	a.SampleData = fakedata.Config{
+		Users:    cfg.SampleUsers,
+		Products: cfg.SampleProducts,
+		Comments: cfg.SampleComments,
+		Seed:     cfg.SampleSeed,
+	}
+	a.Usage = usage.NewRecorder(a.Store).WithClock(a.Clock)
+	a.Metrics = metrics.NewCalls()
+
+	if cfg.TokenSecret == "" {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] PLUGIN_TOKEN_SECRET not set - login tokens will not survive restarts")
+	}
+	a.Credentials = auth.NewCredentialStore(auth.NewTokenIssuer(cfg.TokenSecret, cfg.TokenTTL).WithClock(a.Clock))
+	if cfg.ReceiptSecret == "" {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] PLUGIN_RECEIPT_SECRET not set - erasure receipts will not verify after a restart")
+	}
+	a.Receipts = gdpr.NewSigner(cfg.ReceiptSecret)
+	a.APIKeys = apikey.New(apikey.Config{
+		Required: cfg.APIKeysRequired,
+		Exempt:   opts.APIKeyExempt,
+		NewID:    a.newID,
+		Clock:    a.Clock,
+	})
+	if cfg.APIKeysRequired {
+		a.Logger.Printf("🔑 [hc-hello-world-plugin] REST endpoints require an API key in %s", apikey.Header)
+	}
+	a.Quotas = newQuotas(cfg, opts.QuotaExempt, a.Store, a.Clock)
+
+	a.Uploads = files.NewStore(files.NewMemoryDriver()).WithClock(a.Clock)
+	a.UploadOptions = upload.Options{
+		MaxFileBytes: int64(cfg.UploadMaxFileBytes),
+		MaxFiles:     cfg.UploadMaxFiles,
+		NewID:        a.newID,
+	}
+	a.UploadSessions = resumable.New(resumable.Config{
+		Dir:      cfg.UploadSessionDir,
+		MaxBytes: int64(cfg.UploadMaxFileBytes),
+		TTL:      cfg.UploadSessionTTL,
+		NewID:    a.newID,
+		Clock:    a.Clock,
+	}, a.Uploads)
+	a.Imports = importjob.NewTracker(50, a.Clock)
+	a.MutationIDs = mutationid.New(mutationid.Config{
+		Window:     cfg.MutationIDWindow,
+		MaxEntries: 10000,
+		Clock:      a.Clock,
+	})
+	a.Templates = newTemplates(cfg)
+	a.Jobs = jobs.NewQueue(2, 100, 30*time.Second).WithClock(a.Clock)
+
+	// Every outbound HTTP call goes through httpDoer, which records the
+	// responses as fixtures or replays them offline when asked to
+	a.HTTPFixtures = outbound.NewFixtures(fixturesMode(cfg, a.Logger), cfg.HTTPFixturesDir)
+	httpDoer := newHTTPDoer(a.HTTPFixtures)
+	a.Webhooks = newWebhooks(cfg, httpDoer, a.newID, a.Clock)
+	a.OAuth = oauth.New(oauth.Config{
+		TokenURL:     cfg.OAuthTokenURL,
+		ClientID:     cfg.OAuthClientID,
+		ClientSecret: cfg.OAuthClientSecret,
+		Scopes:       cfg.OAuthScopes,
+		Audience:     cfg.OAuthAudience,
+		Leeway:       30 * time.Second,
+		Policy:       retry.DefaultPolicy(),
+		Doer:         tracecontext.Doer(httpDoer(cfg.ExternalAPITimeout)),
+		Clock:        a.Clock,
+	})
+	a.ExternalAPI = a.newAPIClient(httpDoer, "external API", cfg.ExternalAPIURL, a.OAuth, 0)
+	a.Weather = weather.New(
+		a.newAPIClient(httpDoer, "geocoding API", cfg.WeatherGeocodingURL, nil, cfg.WeatherCacheTTL),
+		a.newAPIClient(httpDoer, "forecast API", cfg.WeatherForecastURL, nil, cfg.WeatherCacheTTL),
+	)
+	a.ErrorReporter = a.newErrorReporter(httpDoer, opts.IsClientError)
+
+	a.Audit = admin.NewAuditLog(500)
+	a.Guard = admin.NewGuard(cfg.AdminToken, a.Audit).WithClock(a.Clock)
+	a.Flags = admin.NewFlags()
+	a.ResponseCacheFlag = a.Flags.Define("responseCache", "Serve read-only resolvers from the response cache", true)
+	a.WelcomeEmailsFlag = a.Flags.Define("welcomeEmails", "Queue a welcome email for every created user", true)
+	a.DebugEndpointsFlag = a.Flags.Define("debugEndpoints", "Serve /debug/stats; initially PLUGIN_DEBUG_MODE", cfg.DebugMode)
+
+	a.Watchdog = watchdog.New(watchdog.Config{
+		SoftLimitBytes: uint64(max(cfg.MemorySoftLimitMB, 0)) << 20,
+		HardLimitBytes: uint64(max(cfg.MemoryHardLimitMB, 0)) << 20,
+		Interval:       cfg.MemoryCheckInterval,
+	})
+	a.Readiness = health.NewReadiness("store", "dependencies").WithClock(a.Clock)
+	a.Host = compat.Detect(cfg.HostSDKVersion, sdk.Version)
+	a.Nodes = relay.NewRegistry()
+
+	a.wireEvents()
+	a.wireDependencies()
+	a.Heartbeat = health.NewBeater(cfg.HealthFile, cfg.HealthInterval, a.heartbeat).WithClock(a.Clock)
+	instrument.Configure(instrument.Config{
+		SlowThreshold:   cfg.SlowResolverThreshold,
+		RepeatThreshold: cfg.RepeatedLookupThreshold,
+		OnCall:          a.Usage.Record,
+	})
+	return a
+}
+
+// heartbeat reports readiness and dependencies for the heartbeat file
+func (a *App) heartbeat() health.Heartbeat {
+	return health.Heartbeat{
+		Readiness:    a.Readiness.Report(),
+		Dependencies: a.Dependencies.Report().Status,
+	}
+}
+
+// newID generates a record ID; components take it rather than IDs itself
+func (a *App) newID() string {
+	return a.IDs.NewID()
+}
+
+// newLogger applies the PLUGIN_LOG_* settings to the standard logger,
+// which the plugin's packages log through, and returns it
+func newLogger(cfg config.Config, name string) *log.Logger {
+	if level, err := logging.ParseLevel(cfg.LogLevel); err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] %v - using info", err)
+	} else {
+		logging.SetLevel(level)
+	}
+	logging.SetPatterns(cfg.LogRedactPatterns)
+	if cfg.LogForward {
+		logging.Forward(name)
+	}
+	return log.Default()
+}
+
+// newIDGenerator picks the ID generator used by create mutations
+func newIDGenerator(cfg config.Config, clk clock.Clock) idgen.Generator {
+	gen, err := idgen.New(cfg.IDStrategy, clk)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] %v - falling back to %s", err, idgen.StrategyUUIDv7)
+		return idgen.NewUUIDv7().WithClock(clk)
+	}
+	log.Printf("🆔 [hc-hello-world-plugin] Using %s ID generator", cfg.IDStrategy)
+	return gen
+}
+
+// newDatasource opens the configured datasource. Unlike the optional
+// dependencies there is no fallback: serving from memory while a database
+// is configured would silently lose every write.
+func newDatasource(cfg config.Config, sealer *atrest.Sealer, protector *pii.Protector, pool dbpool.Config, clk clock.Clock) store.Datasource {
+	dsCfg := store.DatasourceConfig{
+		Kind:          cfg.Datasource,
+		DSN:           cfg.DatasourceDSN,
+		FlushInterval: cfg.DatasourceFlushInterval,
+		Sealer:        sealer,
+		Pool:          pool,
+		Clock:         clk,
+	}
+	// Without a key the protector has nothing to seal
+	if protector.Enabled() {
+		dsCfg.Fields = protector
+	}
+	ds, err := store.Open(dsCfg)
+	if err != nil {
+		log.Fatalf("❌ [hc-hello-world-plugin] Startup aborted: %v", err)
+	}
+	log.Printf("🗄️  [hc-hello-world-plugin] Using %s datasource", cfg.Datasource)
+	return ds
+}
+
+// newSealer derives the encryption key for the store. A key that is set but
+// unusable aborts startup, since the data would otherwise be written in
+// the clear.
+func newSealer(cfg config.Config) *atrest.Sealer {
+	sealer, err := atrest.New(cfg.StoreEncryptionKey)
+	if err != nil {
+		log.Fatalf("❌ [hc-hello-world-plugin] Startup aborted: PLUGIN_STORE_ENCRYPTION_KEY: %v", err)
+	}
+	if sealer.Enabled() {
+		log.Printf("🔐 [hc-hello-world-plugin] Snapshots and datasource data are encrypted at rest")
+	}
+	return sealer
+}
+
+// newProtector sets up masking by role and derives the field encryption
+// keys. Like newSealer, a key that is set but unusable aborts startup.
+func newProtector(cfg config.Config) *pii.Protector {
+	protector, err := pii.New(pii.Config{Secret: cfg.FieldEncryptionKey, Roles: cfg.PIIRoles})
+	if err != nil {
+		log.Fatalf("❌ [hc-hello-world-plugin] Startup aborted: PLUGIN_FIELD_ENCRYPTION_KEY: %v", err)
+	}
+	if protector.Enabled() {
+		log.Printf("🔐 [hc-hello-world-plugin] User emails and phone numbers are encrypted; shown in clear to roles %v", cfg.PIIRoles)
+	} else {
+		log.Printf("🔒 [hc-hello-world-plugin] User emails and phone numbers are stored in clear; shown in clear to roles %v", cfg.PIIRoles)
+	}
+	return protector
+}
+
+func newQuotas(cfg config.Config, exempt []string, s store.Datasource, clk clock.Clock) *quota.Limiter {
+	var overrides map[string]int
+	if parsed, err := quota.ParseOverrides(cfg.QuotaOverrides); err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] %v - ignoring PLUGIN_QUOTA_OVERRIDES", err)
+	} else {
+		overrides = parsed
+	}
+	return quota.New(quota.Config{
+		RatePerSecond: cfg.RateLimitPerSecond,
+		Burst:         cfg.RateLimitBurst,
+		MonthlyCalls:  cfg.QuotaMonthlyCalls,
+		Overrides:     overrides,
+		Exempt:        exempt,
+		Clock:         clk,
+	}, s)
+}
+
+// newTemplates returns the embedded templates, or in debug mode ones
+// re-read from PLUGIN_TEMPLATE_DIR on every render
+func newTemplates(cfg config.Config) *render.Set {
+	if !cfg.DebugMode {
+		return render.Embedded()
+	}
+	set, err := render.FromDir(cfg.TemplateDir)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Template reload disabled, using embedded templates: %v", err)
+		return render.Embedded()
+	}
+	log.Printf("🔧 [hc-hello-world-plugin] Reloading templates from %s on every render", cfg.TemplateDir)
+	return set
+}
+
+// newHTTPDoer returns the constructor of the Doers outbound calls go
+// through, recording or replaying fixtures as fixtures currently asks
+func newHTTPDoer(fixtures *outbound.Fixtures) func(timeout time.Duration) outbound.Doer {
+	return func(timeout time.Duration) outbound.Doer {
+		return fixtures.Wrap(&http.Client{Timeout: timeout})
+	}
+}
+
+// fixturesMode parses PLUGIN_HTTP_FIXTURES_MODE, falling back to sending
+// requests as usual
+func fixturesMode(cfg config.Config, logger *log.Logger) outbound.Mode {
+	mode, err := outbound.ParseMode(cfg.HTTPFixturesMode)
+	if err != nil {
+		logger.Printf("⚠️  [hc-hello-world-plugin] %v - sending requests as usual", err)
+	} else if mode != outbound.Live {
+		logger.Printf("📼 [hc-hello-world-plugin] event=http_fixtures mode=%s dir=%s", mode, cfg.HTTPFixturesDir)
+	}
+	return mode
+}
+
+func newWebhooks(cfg config.Config, httpDoer func(time.Duration) outbound.Doer, newID func() string, clk clock.Clock) *webhook.Dispatcher {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = cfg.WebhookMaxAttempts
+	policy.InitialBackoff = time.Second
+	policy.MaxBackoff = 5 * time.Minute
+	return webhook.New(webhook.Config{
+		URL:     cfg.WebhookURL,
+		Secret:  cfg.WebhookSecret,
+		Policy:  policy,
+		Timeout: cfg.WebhookTimeout,
+		History: cfg.WebhookHistory,
+		NewID:   newID,
+		Doer:    httpDoer(cfg.WebhookTimeout),
+		Clock:   clk,
+	})
+}
+
+// newAPIClient builds an external API client; every one shares the
+// timeout, retry policy and circuit breaker settings
+func (a *App) newAPIClient(httpDoer func(time.Duration) outbound.Doer, name, baseURL string, tokens *oauth.TokenSource, cacheTTL time.Duration) *extapi.Client {
+	client, err := extapi.New(extapi.Config{
+		Name:             name,
+		BaseURL:          baseURL,
+		Timeout:          a.Config.ExternalAPITimeout,
+		Tokens:           tokens,
+		Policy:           retry.DefaultPolicy(),
+		BreakerThreshold: a.Config.ExternalAPIBreakerThreshold,
+		BreakerCooldown:  a.Config.ExternalAPIBreakerCooldown,
+		CacheTTL:         cacheTTL,
+		CacheMaxEntries:  500,
+		Doer:             httpDoer(0),
+		Clock:            a.Clock,
+	})
+	if err != nil {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] %s disabled: %v", name, err)
+	}
+	return client
+}
+
+// newErrorReporter returns the reporter for PLUGIN_SENTRY_DSN, or a
+// disabled one
+func (a *App) newErrorReporter(httpDoer func(time.Duration) outbound.Doer, isClientError func(error) bool) *errreport.Reporter {
+	reporter, err := errreport.New(errreport.Config{
+		DSN:           a.Config.SentryDSN,
+		SampleRate:    a.Config.SentrySampleRate,
+		Release:       buildinfo.Version,
+		Environment:   a.Config.SentryEnvironment,
+		IsClientError: isClientError,
+		Timeout:       5 * time.Second,
+		Doer:          httpDoer(5 * time.Second),
+		Clock:         a.Clock,
+	})
+	if err != nil {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] Error reporting disabled: %v", err)
+		return reporter
+	}
+	if reporter.Enabled() {
+		a.Logger.Printf("🚨 [hc-hello-world-plugin] Reporting panics and server errors (sample rate %g)", a.Config.SentrySampleRate)
+	}
+	return reporter
+}
+
+// wireEvents publishes every store change as an event before the mutation
+// returns. Cached results built from the changed record are dropped right
+// away instead of being served until their TTL runs out.
+func (a *App) wireEvents() {
+	a.Store.OnChange(func(c store.Change) { a.Events.Publish(events.FromChange(c)) })
+	a.Events.Subscribe(events.All, a.invalidateCachedResults)
+	if a.Webhooks.Enabled() {
+		a.Events.Subscribe(events.All, a.sendWebhook)
+	}
+	a.Events.Subscribe(events.UserDeleted, func(e events.Event) {
+		a.Uploads.DeleteOwned(context.Background(), e.ID)
+	})
+}
+
+// invalidateCachedResults drops cached results that e makes stale
+func (a *App) invalidateCachedResults(e events.Event) {
+	removed := 0
+	for _, tag := range events.Tags(e) {
+		removed += a.Cache.InvalidateTag(tag)
+	}
+	if removed > 0 {
+		a.Logger.Printf("🧹 [hc-hello-world-plugin] event=cache_invalidated cause=%s id=%s removed=%d", e.Name, e.ID, removed)
+	}
+}
+
+// sendWebhook queues e for delivery to the webhook endpoint
+func (a *App) sendWebhook(e events.Event) {
+	// Store changes carry no request context, so these deliveries start
+	// without a trace
+	payload := map[string]interface{}{
+		"event":  e.Name,
+		"entity": e.Entity,
+		"id":     e.ID,
+		"op":     e.Op,
+		"seq":    e.Seq,
+		"at":     timeutil.FormatUTC(e.At),
+	}
+	if e.Data != nil {
+		payload["data"] = e.Data
+	}
+	_, err := a.Webhooks.Send(context.Background(), e.Name, payload)
+	if err != nil {
+		a.Logger.Printf("❌ [hc-hello-world-plugin] event=webhook_failed name=%s id=%s error=%q", e.Name, e.ID, err)
+	}
+}
+
+// wireDependencies sets up the mailer and the optional dependencies. They
+// never fail startup: while one is down the plugin falls back to in-memory
+// behaviour and /health reports it degraded.
+func (a *App) wireDependencies() {
+	smtpConfig := email.Config{
+		Host:     a.Config.SMTPHost,
+		Port:     a.Config.SMTPPort,
+		Username: a.Config.SMTPUsername,
+		Password: a.Config.SMTPPassword,
+		From:     a.Config.SMTPFrom,
+	}
+	a.Mailer = email.NewSender(smtpConfig)
+
+	a.Dependencies = health.NewMonitor(health.Config{Interval: a.Config.DependencyCheckInterval, Clock: a.Clock})
+	a.Dependencies.Add("storage", "the store is kept in memory only and /admin/snapshot fails", func(ctx context.Context) error {
+		return store.CheckSnapshotDir(a.Config.SnapshotPath)
+	})
+	if smtpConfig.Enabled() {
+		a.Dependencies.Add("smtp", "emails are logged instead of sent", email.Probe(smtpConfig))
+		a.Mailer = &email.FallbackSender{
+			Primary:   a.Mailer,
+			Fallback:  email.LogSender{},
+			Available: func() bool { return a.Dependencies.Healthy("smtp") },
+			OnFailure: func(err error) { a.Dependencies.MarkFailed("smtp", err) },
+		}
+	}
+	if a.OAuth.Enabled() {
+		a.Dependencies.Add("oauth", "external API calls fail until a token can be obtained", func(ctx context.Context) error {
+			_, err := a.OAuth.Token(ctx)
+			return err
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import "hc-hello-world-plugin/registry"
+
+// Registration groups; config's PLUGIN_ENV picks the environment and
+// PLUGIN_GROUPS_ENABLED and PLUGIN_GROUPS_DISABLED override it per group
+var (
+	// DemoGroup holds the example resolvers, routes and functions that only
+	// show off the SDK
+	DemoGroup = registry.Group{
+		Name:         "demo",
+		Description:  "Example resolvers, REST routes and functions",
+		Environments: []string{"development", "staging"},
+	}
+
+	// DebugGroup holds the routes exposing the plugin's internals
+	DebugGroup = registry.Group{
+		Name:         "debug",
+		Description:  "Runtime stats and the raw registrations",
+		Environments: []string{"development"},
+	}
+
+	// AdminGroup holds the routes and operations guarded by
+	// PLUGIN_ADMIN_TOKEN; disable it where nobody administers the plugin
+	AdminGroup = registry.Group{
+		Name:        "admin",
+		Description: "Snapshots, feature flags, cache and store resets, log level, reload, audit log and API keys",
+	}
+)
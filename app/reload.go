@@ -0,0 +1,79 @@
+package app
+
+import (
+	"strings"
+
+	"hc-hello-world-plugin/config"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/outbound"
+)
+
+// liveSettings are the config fields Reload applies; every other change
+// is logged but waits for a restart
+var liveSettings = map[string]bool{
+	"LogLevel":          true,
+	"LogRedactPatterns": true,
+	"HTTPFixturesMode":  true,
+	"HTTPFixturesDir":   true,
+}
+
+// ReloadReport is what Reload changed
+type ReloadReport struct {
+	// Changes differ from the effective configuration, applied or not
+	Changes []config.Change `json:"changes"`
+	// RestartRequired names the changed fields Reload could not apply
+	RestartRequired []string `json:"restartRequired"`
+	// CacheFlushed is the number of cached responses dropped
+	CacheFlushed int `json:"cacheFlushed"`
+}
+
+// Reload flushes the response cache, re-reads the configuration and applies
+// the log level, redaction patterns and HTTP fixtures settings, so fixtures
+// recorded since startup are picked up too. It logs every setting that
+// differs from the effective configuration and returns the differences;
+// those not applied are reported again on the next reload until a restart
+// picks them up.
+func (a *App) Reload() ReloadReport {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	report := ReloadReport{CacheFlushed: a.Cache.Clear(), RestartRequired: []string{}}
+	loaded := config.Load()
+	report.Changes = config.Diff(a.effective, loaded)
+
+	next := a.effective
+	if level, err := logging.ParseLevel(loaded.LogLevel); err != nil {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] %v - keeping %s", err, logging.CurrentLevel())
+	} else {
+		logging.SetLevel(level)
+		next.LogLevel = loaded.LogLevel
+	}
+	logging.SetPatterns(loaded.LogRedactPatterns)
+	next.LogRedactPatterns = loaded.LogRedactPatterns
+	a.HTTPFixtures.Set(fixturesMode(loaded, a.Logger), loaded.HTTPFixturesDir)
+	next.HTTPFixturesMode, next.HTTPFixturesDir = loaded.HTTPFixturesMode, loaded.HTTPFixturesDir
+	a.effective = next
+
+	mode, dir := a.HTTPFixtures.Settings()
+	a.Logger.Printf("🔄 [hc-hello-world-plugin] event=config_reloaded changes=%d cache_flushed=%d fixtures_mode=%s fixtures_dir=%s",
+		len(report.Changes), report.CacheFlushed, fixturesModeName(mode), dir)
+	for _, c := range report.Changes {
+		applied := liveSettings[c.Field]
+		if !applied {
+			report.RestartRequired = append(report.RestartRequired, c.Field)
+		}
+		a.Logger.Printf("🔄 [hc-hello-world-plugin] event=config_changed field=%s before=%q after=%q applied=%t", c.Field, c.Before, c.After, applied)
+	}
+	if len(report.RestartRequired) > 0 {
+		a.Logger.Printf("⚠️  [hc-hello-world-plugin] event=restart_required fields=%s", strings.Join(report.RestartRequired, ","))
+	}
+	return report
+}
+
+// fixturesModeName names outbound.Live, which is the empty string
+func fixturesModeName(mode outbound.Mode) string {
+	if mode == outbound.Live {
+		return "live"
+	}
+	return string(mode)
+}
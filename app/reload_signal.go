@@ -0,0 +1,22 @@
+//go:build !windows
+
+package app
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnSignal calls Reload whenever the process receives SIGHUP. Once
+// handled, SIGHUP no longer terminates the plugin, so the host connection
+// stays up.
+func (a *App) ReloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			a.Reload()
+		}
+	}()
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"os"
+	"os/signal"
+)
+
+// ReloadOnSignal calls Reload whenever the process receives os.Interrupt,
+// which is how Windows delivers Ctrl+C and Ctrl+Break; it has no SIGHUP.
+// The plugin server already ignores interrupts, so the plugin keeps
+// serving. POST /admin/reload does the same on every platform.
+func (a *App) ReloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		for range signals {
+			a.Reload()
+		}
+	}()
+}
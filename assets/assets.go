@@ -0,0 +1,122 @@
+// Package assets holds the static files served under /assets: the plugin's
+// logo, its documentation page and sample CSV templates. They are embedded
+// into the binary, so the plugin needs no files next to it.
+//
+// REST bodies travel to the host as strings, so assets are text: the logo
+// is an SVG rather than a PNG.
+package assets
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed static
+var embedded embed.FS
+
+// ErrNotFound is returned for names that are not an embedded asset
+var ErrNotFound = errors.New("asset not found")
+
+// contentTypes are the types of the extensions in static; anything else
+// falls back to the system MIME table
+var contentTypes = map[string]string{
+	".html": "text/html; charset=utf-8",
+	".css":  "text/css; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".json": "application/json",
+	".svg":  "image/svg+xml",
+	".csv":  "text/csv; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+}
+
+// Asset is one embedded file
+type Asset struct {
+	// Name is the path below /assets, e.g. users-template.csv
+	Name        string
+	ContentType string
+	// ETag is a strong ETag of Body
+	ETag string
+	Body []byte
+}
+
+// Download reports whether browsers should save the asset rather than show
+// it, as for the CSV templates
+func (a Asset) Download() bool {
+	return strings.HasPrefix(a.ContentType, "text/csv")
+}
+
+// all is every asset by name; the embedded files never change, so their
+// ETags are computed once
+var all = load()
+
+func load() map[string]Asset {
+	fsys, err := fs.Sub(embedded, "static")
+	if err != nil {
+		panic(err)
+	}
+	result := make(map[string]Asset)
+	err = fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		result[name] = Asset{
+			Name:        name,
+			ContentType: contentType(name),
+			ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+			Body:        body,
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func contentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Get returns the asset at name, a slash-separated path below /assets. A
+// leading slash is ignored; paths leaving the asset directory are not
+// found.
+func Get(name string) (Asset, error) {
+	name = strings.TrimPrefix(name, "/")
+	if !fs.ValidPath(name) {
+		return Asset{}, ErrNotFound
+	}
+	asset, ok := all[name]
+	if !ok {
+		return Asset{}, ErrNotFound
+	}
+	return asset, nil
+}
+
+// Names lists the embedded assets, sorted
+func Names() []string {
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,226 @@
+// Package auth shows how plugins should handle credentials: passwords are
+// bcrypt-hashed before storage, never returned, and exchanged for a
+// short-lived signed token on login.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrInvalidCredentials is returned for unknown users and wrong passwords
+// alike, so callers cannot probe which usernames exist
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// dummyHash is compared against when the user does not exist, keeping login
+// timing the same for known and unknown usernames
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("timing-equalizer"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Credential is the stored login data for a user
+type Credential struct {
+	UserID       string
+	Username     string
+	PasswordHash string
+}
+
+// CredentialStore keeps one credential per user in memory, keyed by user
+// ID, with an index from username for logins. A user who changes their
+// handle keeps their password; see Rename.
+type CredentialStore struct {
+	mu          sync.RWMutex
+	byUserID    map[string]Credential
+	userIDs     map[string]string // username -> user ID
+	tokenIssuer *TokenIssuer
+}
+
+// NewCredentialStore creates an empty store that issues tokens with issuer
+func NewCredentialStore(issuer *TokenIssuer) *CredentialStore {
+	return &CredentialStore{
+		byUserID:    make(map[string]Credential),
+		userIDs:     make(map[string]string),
+		tokenIssuer: issuer,
+	}
+}
+
+// Save stores or replaces the credential of its user. Usernames are
+// unique, so a credential another user left under the same username, e.g.
+// a deleted user's, is removed.
+func (s *CredentialStore) Save(cred Credential) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteLocked(cred.UserID)
+	if previous, ok := s.userIDs[cred.Username]; ok {
+		s.deleteLocked(previous)
+	}
+	s.byUserID[cred.UserID] = cred
+	s.userIDs[cred.Username] = cred.UserID
+}
+
+// Rename moves userID's credential to username, after the user changed
+// their handle, and reports whether they had one. Like Save it removes
+// another user's credential under username.
+func (s *CredentialStore) Rename(userID, username string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cred, ok := s.byUserID[userID]
+	if !ok {
+		return false
+	}
+	if cred.Username == username {
+		return true
+	}
+	if previous, taken := s.userIDs[username]; taken {
+		s.deleteLocked(previous)
+	}
+	delete(s.userIDs, cred.Username)
+	cred.Username = username
+	s.byUserID[userID] = cred
+	s.userIDs[username] = userID
+	return true
+}
+
+// Usernames returns the usernames userID can log in with: none, or the
+// one their credential is saved under
+func (s *CredentialStore) Usernames(userID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if cred, ok := s.byUserID[userID]; ok {
+		return []string{cred.Username}
+	}
+	return nil
+}
+
+// DeleteUser removes the credential of userID and returns how many there
+// were, 0 or 1; tokens already issued stay valid until they expire
+func (s *CredentialStore) DeleteUser(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byUserID[userID]; !ok {
+		return 0
+	}
+	s.deleteLocked(userID)
+	return 1
+}
+
+// deleteLocked removes userID's credential, if any; the caller holds mu
+func (s *CredentialStore) deleteLocked(userID string) {
+	if cred, ok := s.byUserID[userID]; ok {
+		delete(s.userIDs, cred.Username)
+		delete(s.byUserID, userID)
+	}
+}
+
+// Login verifies the password for username and returns a signed token
+func (s *CredentialStore) Login(username, password string) (Token, error) {
+	s.mu.RLock()
+	userID, ok := s.userIDs[username]
+	cred := s.byUserID[userID]
+	s.mu.RUnlock()
+
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return Token{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(cred.PasswordHash), []byte(password)); err != nil {
+		return Token{}, ErrInvalidCredentials
+	}
+
+	return s.tokenIssuer.Issue(cred.UserID), nil
+}
+
+// Token is a demo bearer token handed out after a successful login
+type Token struct {
+	Value     string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+// TokenIssuer signs demo tokens of the form base64(userID|expiry).signature.
+// Real plugins should use the host's auth or a standard format such as JWT.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	clock  clock.Clock
+}
+
+// NewTokenIssuer creates an issuer; an empty secret generates a random one,
+// which invalidates tokens whenever the plugin restarts
+func NewTokenIssuer(secret string, ttl time.Duration) *TokenIssuer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("auth: crypto/rand failed: %v", err))
+		}
+	}
+	return &TokenIssuer{secret: key, ttl: ttl, clock: clock.System}
+}
+
+// WithClock replaces the system clock that sets and checks expiry; call it
+// before the issuer is used
+func (t *TokenIssuer) WithClock(c clock.Clock) *TokenIssuer {
+	t.clock = clock.OrSystem(c)
+	return t
+}
+
+// Issue creates a token for userID that expires after the issuer's TTL
+func (t *TokenIssuer) Issue(userID string) Token {
+	expiresAt := t.clock.Now().Add(t.ttl).UTC()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + "|" + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return Token{
+		Value:     payload + "." + t.sign(payload),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Verify checks a token's signature and expiry and returns its user ID
+func (t *TokenIssuer) Verify(token string) (string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(t.sign(payload))) {
+		return "", errors.New("invalid token")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errors.New("invalid token")
+	}
+	userID, expiry, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return "", errors.New("invalid token")
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || t.clock.Now().Unix() > unix {
+		return "", errors.New("token expired")
+	}
+
+	return userID, nil
+}
+
+func (t *TokenIssuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestStore(t *testing.T) *CredentialStore {
+	t.Helper()
+	return NewCredentialStore(NewTokenIssuer("secret", time.Hour))
+}
+
+func hash(t *testing.T, password string) string {
+	t.Helper()
+	// The minimum cost keeps the test fast
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(h)
+}
+
+func TestRenameMovesLogin(t *testing.T) {
+	s := newTestStore(t)
+	s.Save(Credential{UserID: "u1", Username: "ann", PasswordHash: hash(t, "pw")})
+
+	if !s.Rename("u1", "annie") {
+		t.Fatal("Rename found no credential")
+	}
+	if _, err := s.Login("ann", "pw"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("login with the old handle: %v, want ErrInvalidCredentials", err)
+	}
+	token, err := s.Login("annie", "pw")
+	if err != nil || token.UserID != "u1" {
+		t.Fatalf("login with the new handle = %+v, %v", token, err)
+	}
+	if got := s.Usernames("u1"); !slices.Equal(got, []string{"annie"}) {
+		t.Errorf("Usernames = %v, want [annie]", got)
+	}
+	if s.Rename("u2", "bob") {
+		t.Error("Rename of a user without a credential reported one")
+	}
+}
+
+func TestSaveReplacesStaleUsername(t *testing.T) {
+	s := newTestStore(t)
+	s.Save(Credential{UserID: "u1", Username: "ann", PasswordHash: hash(t, "old")})
+	// Another user takes the handle after u1 gave it up
+	s.Rename("u1", "annie")
+	s.Save(Credential{UserID: "u2", Username: "ann", PasswordHash: hash(t, "new")})
+
+	if token, err := s.Login("ann", "new"); err != nil || token.UserID != "u2" {
+		t.Fatalf("login as the handle's new owner = %+v, %v", token, err)
+	}
+	if token, err := s.Login("annie", "old"); err != nil || token.UserID != "u1" {
+		t.Fatalf("login as the renamed user = %+v, %v", token, err)
+	}
+
+	if n := s.DeleteUser("u1"); n != 1 {
+		t.Errorf("DeleteUser removed %d credentials, want 1", n)
+	}
+	if _, err := s.Login("annie", "old"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("login after DeleteUser: %v, want ErrInvalidCredentials", err)
+	}
+	if got := s.Usernames("u1"); len(got) != 0 {
+		t.Errorf("Usernames after DeleteUser = %v, want none", got)
+	}
+	if _, err := s.Login("ann", "new"); err != nil {
+		t.Errorf("DeleteUser of u1 affected u2: %v", err)
+	}
+}
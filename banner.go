@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// bannerWidth is the inside width of the debug banner's box
+const bannerWidth = 46
+
+// bannerSegment is text printed in one style
+type bannerSegment struct {
+	text  string
+	style *color.Color
+}
+
+// printDebugBanner tells whoever started the plugin how to attach a
+// debugger. fatih/color drops the colors when stdout is not a terminal or
+// NO_COLOR is set, and translates them for Windows consoles that do not
+// understand ANSI escapes.
+func printDebugBanner(pid int) {
+	frame := color.New(color.Bold, color.FgCyan)
+	value := color.New(color.Bold, color.FgGreen)
+	heading := color.New(color.FgYellow)
+	command := color.New(color.FgGreen)
+	hint := color.New(color.FgMagenta)
+
+	// A nil row is drawn as a separator, an empty one as a blank line
+	rows := [][]bannerSegment{
+		{{"           🐛 DEBUG MODE ENABLED", frame}},
+		nil,
+		{{"Plugin PID: ", frame}, {fmt.Sprint(pid), value}},
+		{{"Ready for delve attachment! 🎯", frame}},
+		{},
+		{{"To attach delve:", heading}},
+	}
+	for _, line := range attachCommand(pid) {
+		rows = append(rows, []bannerSegment{{line, command}})
+	}
+	rows = append(rows,
+		[]bannerSegment{},
+		[]bannerSegment{{"Then in VSCode:", heading}},
+		[]bannerSegment{{"'Attach to HashiCorp Plugin (Remote Debug)'", hint}},
+	)
+
+	out := color.Output
+	fmt.Fprintln(out)
+	frame.Fprintln(out, "╔"+strings.Repeat("═", bannerWidth)+"╗")
+	for _, row := range rows {
+		if row == nil {
+			frame.Fprintln(out, "╠"+strings.Repeat("═", bannerWidth)+"╣")
+			continue
+		}
+		frame.Fprint(out, "║ ")
+		width := 1
+		for _, segment := range row {
+			segment.style.Fprint(out, segment.text)
+			width += displayWidth(segment.text)
+		}
+		frame.Fprintln(out, strings.Repeat(" ", max(bannerWidth-width, 0))+"║")
+	}
+	frame.Fprintln(out, "╚"+strings.Repeat("═", bannerWidth)+"╝")
+	fmt.Fprintln(out)
+}
+
+// attachCommand is how to attach delve to pid, one line per banner row.
+// runDebug.sh needs a POSIX shell and lsof, so Windows gets the dlv
+// command it runs.
+func attachCommand(pid int) []string {
+	if runtime.GOOS == "windows" {
+		return []string{
+			fmt.Sprintf("dlv attach %d --headless=true", pid),
+			"  --listen=:40000 --api-version=2",
+			"  --accept-multiclient",
+		}
+	}
+	return []string{fmt.Sprintf("./runDebug.sh %d", pid)}
+}
+
+// displayWidth counts terminal columns, taking emoji as two
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1F000:
+			width += 2
+		case r == 0xFE0F:
+			// Variation selectors take no space of their own
+		default:
+			width++
+		}
+	}
+	return width
+}
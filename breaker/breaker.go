@@ -0,0 +1,141 @@
+// Package breaker is a circuit breaker for outbound calls. After a run of
+// consecutive failures it opens and fails calls at once for a cooldown,
+// sparing both the plugin and the struggling service; then a single trial
+// call decides whether it closes again.
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrOpen is returned while the breaker rejects calls
+var ErrOpen = errors.New("circuit breaker is open")
+
+// States
+const (
+	// Closed lets every call through
+	Closed = "closed"
+	// Open rejects every call until the cooldown ends
+	Open = "open"
+	// HalfOpen lets one trial call through after the cooldown
+	HalfOpen = "half-open"
+)
+
+// Config configures a Breaker
+type Config struct {
+	// Threshold is how many consecutive failures open the breaker; 0
+	// disables it
+	Threshold int
+	// Cooldown is how long the breaker stays open
+	Cooldown time.Duration
+	// OnStateChange is called with the old and new state, outside the lock
+	OnStateChange func(from, to string)
+	// Clock times the cooldown; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Breaker is safe for concurrent use
+type Breaker struct {
+	cfg   Config
+	clock clock.Clock
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// trial is set while the half-open trial call is in flight
+	trial bool
+}
+
+// New creates a closed breaker
+func New(cfg Config) *Breaker {
+	return &Breaker{cfg: cfg, clock: clock.OrSystem(cfg.Clock), state: Closed}
+}
+
+// State returns the current state; an open breaker whose cooldown has
+// ended reports HalfOpen
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && b.clock.Since(b.openedAt) >= b.cfg.Cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Do runs fn unless the breaker is open. fn's error counts as a failure
+// when failed reports true; other errors, such as a rejected request, show
+// the service is up and count as a success.
+func (b *Breaker) Do(fn func() error, failed func(error) bool) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(err != nil && failed(err))
+	return err
+}
+
+// allow admits a call, moving an open breaker whose cooldown has ended to
+// half-open for one trial call
+func (b *Breaker) allow() error {
+	if b.cfg.Threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	var from string
+	switch b.state {
+	case Open:
+		if wait := b.cfg.Cooldown - b.clock.Since(b.openedAt); wait > 0 {
+			b.mu.Unlock()
+			return fmt.Errorf("%w: retry in %s", ErrOpen, time.Duration(math.Ceil(wait.Seconds()))*time.Second)
+		}
+		from, b.state, b.trial = Open, HalfOpen, true
+	case HalfOpen:
+		if b.trial {
+			b.mu.Unlock()
+			return fmt.Errorf("%w: a trial call is in flight", ErrOpen)
+		}
+		b.trial = true
+	}
+	b.mu.Unlock()
+	b.changed(from, HalfOpen)
+	return nil
+}
+
+// record counts a call's outcome
+func (b *Breaker) record(failed bool) {
+	if b.cfg.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case !failed:
+		b.state, b.failures = Closed, 0
+	case b.state == HalfOpen:
+		b.state, b.openedAt = Open, b.clock.Now()
+	default:
+		b.failures++
+		if b.failures >= b.cfg.Threshold {
+			b.state, b.openedAt = Open, b.clock.Now()
+		}
+	}
+	b.trial = false
+	to := b.state
+	b.mu.Unlock()
+	if from != to {
+		b.changed(from, to)
+	}
+}
+
+func (b *Breaker) changed(from, to string) {
+	if from != "" && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
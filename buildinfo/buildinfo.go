@@ -0,0 +1,78 @@
+// Package buildinfo reports which build of the plugin is running. Release
+// builds stamp the version, commit and build time with -ldflags; anything
+// left unset is filled in from the Go toolchain's embedded build info.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// Set at link time, e.g.
+//
+//	go build -ldflags "-X hc-hello-world-plugin/buildinfo.Commit=$(git rev-parse HEAD)"
+var (
+	Version   = "2.0.0-sdk"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// sdkModule is the SDK's module path in the dependency list
+const sdkModule = "github.com/apito-io/go-apito-plugin-sdk"
+
+// Info describes the running build
+type Info struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	BuildTime  string `json:"buildTime"`
+	Modified   bool   `json:"modified"`
+	GoVersion  string `json:"goVersion"`
+	SDKVersion string `json:"sdkVersion"`
+}
+
+// Get returns the build metadata. Commit and build time fall back to the
+// VCS stamp Go embeds when building a module from a repository; both stay
+// empty for builds without either.
+func Get() Info {
+	info := Info{
+		Version:    Version,
+		Commit:     Commit,
+		BuildTime:  BuildTime,
+		GoVersion:  runtime.Version(),
+		SDKVersion: sdk.Version,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range build.Deps {
+		if dep.Path != sdkModule {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		// Local replacements report "(devel)"; keep the SDK's own constant
+		if dep.Version != "" && dep.Version != "(devel)" {
+			info.SDKVersion = dep.Version
+		}
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
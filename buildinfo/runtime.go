@@ -0,0 +1,80 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Runtime describes the process running the build: where it runs, how busy
+// the Go runtime is and which build tags the binary was compiled with
+type Runtime struct {
+	OS           string   `json:"os"`
+	Arch         string   `json:"arch"`
+	NumCPU       int      `json:"numCPU"`
+	GOMAXPROCS   int      `json:"gomaxprocs"`
+	NumGoroutine int      `json:"numGoroutine"`
+	BuildTags    []string `json:"buildTags"`
+	CgoEnabled   bool     `json:"cgoEnabled"`
+	Memory       Memory   `json:"memory"`
+}
+
+// Memory is the subset of runtime.MemStats worth watching across
+// environments
+type Memory struct {
+	// HeapAlloc is the bytes of allocated heap objects
+	HeapAlloc uint64 `json:"heapAlloc"`
+	// HeapInuse is the bytes in in-use heap spans
+	HeapInuse uint64 `json:"heapInuse"`
+	// Sys is the bytes obtained from the OS
+	Sys        uint64        `json:"sys"`
+	NumGC      uint32        `json:"numGC"`
+	PauseTotal time.Duration `json:"pauseTotal"`
+	// LastGC is zero before the first collection
+	LastGC time.Time `json:"lastGC"`
+}
+
+// GetRuntime returns the current runtime state. Reading the memory
+// statistics stops the world briefly, so do not call it on a hot path.
+func GetRuntime() Runtime {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	rt := Runtime{
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+		BuildTags:    []string{},
+		Memory: Memory{
+			HeapAlloc:  mem.HeapAlloc,
+			HeapInuse:  mem.HeapInuse,
+			Sys:        mem.Sys,
+			NumGC:      mem.NumGC,
+			PauseTotal: time.Duration(mem.PauseTotalNs),
+		},
+	}
+	if mem.LastGC != 0 {
+		rt.Memory.LastGC = time.Unix(0, int64(mem.LastGC)).UTC()
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return rt
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "-tags":
+			for _, tag := range strings.Split(setting.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					rt.BuildTags = append(rt.BuildTags, tag)
+				}
+			}
+		case "CGO_ENABLED":
+			rt.CgoEnabled = setting.Value == "1"
+		}
+	}
+	return rt
+}
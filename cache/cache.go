@@ -0,0 +1,308 @@
+// Package cache is a small in-memory response cache with per-entry TTL and
+// LRU eviction, used for read-only resolvers. Entries can carry tags naming
+// the records they were built from, so a change to a record invalidates
+// exactly the results that depend on it. With a stale window, expired
+// entries can still be served while the caller refreshes them in the
+// background (stale-while-revalidate).
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// Stats is a snapshot of cache counters
+type Stats struct {
+	Entries   int   `json:"entries"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	// StaleHits counts expired entries served within the stale window; they
+	// are included in Hits
+	StaleHits int64 `json:"staleHits"`
+	Refreshes int64 `json:"refreshes"`
+}
+
+// State is the outcome of Lookup
+type State int
+
+// Lookup outcomes
+const (
+	// Miss means there is no usable entry
+	Miss State = iota
+	// Fresh means the entry has not expired
+	Fresh
+	// Stale means the entry expired and is already being refreshed
+	Stale
+	// Refresh means the entry expired and the caller should refresh it,
+	// calling EndRefresh if it cannot
+	Refresh
+)
+
+// HitRatio returns hits / (hits + misses), or 0 before any lookups
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type entry struct {
+	key        string
+	value      interface{}
+	expiresAt  time.Time
+	tags       []string
+	refreshing bool
+}
+
+// Cache is safe for concurrent use
+type Cache struct {
+	ttl         time.Duration
+	staleWindow time.Duration
+	maxEntries  int
+	clock       clock.Clock
+
+	mu    sync.Mutex
+	order *list.List // front = most recently used
+	items map[string]*list.Element
+
+	// generation counts invalidations, so a value computed before one is
+	// never stored after it
+	generation atomic.Uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+	staleHits atomic.Int64
+	refreshes atomic.Int64
+}
+
+// New creates a cache; entries expire after ttl and the least recently used
+// entry is evicted once maxEntries is reached
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		clock:      clock.System,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// WithStaleWindow keeps expired entries for window longer so Lookup can
+// serve them while they are refreshed; call it before the cache is used
+func (c *Cache) WithStaleWindow(window time.Duration) *Cache {
+	c.staleWindow = max(window, 0)
+	return c
+}
+
+// WithClock replaces the system clock that expires entries; call it before
+// the cache is used
+func (c *Cache) WithClock(clk clock.Clock) *Cache {
+	c.clock = clock.OrSystem(clk)
+	return c
+}
+
+// Key builds a cache key from a resolver name and its arguments. Map keys
+// are sorted by encoding/json, so equal args always give equal keys. The
+// second result is false when the args cannot be encoded and must not be cached.
+func Key(resolver string, args map[string]interface{}) (string, bool) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return resolver + ":" + string(data), true
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if now := c.clock.Now(); now.After(e.expiresAt) {
+		if now.After(e.expiresAt.Add(c.staleWindow)) {
+			c.removeElement(el)
+		}
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+// Lookup is Get that also returns expired entries still within the stale
+// window. Only one caller per expired entry gets Refresh; the others get
+// Stale until the entry is replaced by Set or SetSince.
+func (c *Cache) Lookup(key string) (interface{}, State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, Miss
+	}
+
+	e := el.Value.(*entry)
+	now := c.clock.Now()
+	if now.After(e.expiresAt.Add(c.staleWindow)) {
+		c.removeElement(el)
+		c.misses.Add(1)
+		return nil, Miss
+	}
+
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	if !now.After(e.expiresAt) {
+		return e.value, Fresh
+	}
+	c.staleHits.Add(1)
+	if e.refreshing {
+		return e.value, Stale
+	}
+	e.refreshing = true
+	c.refreshes.Add(1)
+	return e.value, Refresh
+}
+
+// EndRefresh lets the next Lookup of an expired key refresh it again, after
+// a refresh failed or its result could not be stored
+func (c *Cache) EndRefresh(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry).refreshing = false
+	}
+}
+
+// Set stores value under key with the given tags, evicting the least
+// recently used entry if full
+func (c *Cache) Set(key string, value interface{}, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(key, value, tags)
+}
+
+// Generation returns the invalidation counter to pass to SetSince
+func (c *Cache) Generation() uint64 {
+	return c.generation.Load()
+}
+
+// SetSince is Set for a value computed after Generation returned gen. The
+// value is dropped if anything was invalidated meanwhile, since it may have
+// been read before the change that caused the invalidation.
+func (c *Cache) SetSince(gen uint64, key string, value interface{}, tags ...string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation.Load() != gen {
+		return false
+	}
+	c.setLocked(key, value, tags)
+	return true
+}
+
+func (c *Cache) setLocked(key string, value interface{}, tags []string) {
+	expiresAt := c.clock.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		e.tags = tags
+		e.refreshing = false
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt, tags: tags})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// InvalidatePrefix removes every entry whose key starts with prefix (for
+// example a resolver name) and returns how many were removed
+func (c *Cache) InvalidatePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation.Add(1)
+
+	removed := 0
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+			removed++
+		}
+	}
+	return removed
+}
+
+// InvalidateTag removes every entry tagged with tag and returns how many
+// were removed
+func (c *Cache) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation.Add(1)
+
+	removed := 0
+	for _, el := range c.items {
+		for _, t := range el.Value.(*entry).tags {
+			if t == tag {
+				c.removeElement(el)
+				removed++
+				break
+			}
+		}
+	}
+	return removed
+}
+
+// Clear removes all entries and returns how many there were
+func (c *Cache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation.Add(1)
+	removed := c.order.Len()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	return removed
+}
+
+// Stats returns the current counters
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Entries:   entries,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		StaleHits: c.staleHits.Load(),
+		Refreshes: c.refreshes.Load(),
+	}
+}
+
+// removeElement deletes el; callers must hold c.mu
+func (c *Cache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
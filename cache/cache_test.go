@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+func TestEntriesExpireAfterTTL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := New(time.Minute, 10).WithClock(clk)
+	c.Set("k", "v")
+
+	clk.Advance(time.Minute)
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get at the TTL = %v, %t; want v, true", v, ok)
+	}
+	clk.Advance(time.Nanosecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get after the TTL found the entry")
+	}
+}
+
+func TestLookupServesStaleWithinWindow(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := New(time.Minute, 10).WithStaleWindow(30 * time.Second).WithClock(clk)
+	c.Set("k", "v")
+
+	if _, state := c.Lookup("k"); state != Fresh {
+		t.Fatalf("Lookup before the TTL = %v, want Fresh", state)
+	}
+	clk.Advance(time.Minute + time.Second)
+	if _, state := c.Lookup("k"); state != Refresh {
+		t.Fatalf("first Lookup after the TTL = %v, want Refresh", state)
+	}
+	if v, state := c.Lookup("k"); state != Stale || v != "v" {
+		t.Fatalf("second Lookup after the TTL = %v, %v; want v, Stale", v, state)
+	}
+	clk.Advance(30 * time.Second)
+	if _, state := c.Lookup("k"); state != Miss {
+		t.Fatalf("Lookup after the stale window = %v, want Miss", state)
+	}
+}
@@ -0,0 +1,134 @@
+// Package clock abstracts the current time so timestamps, TTLs, backoff
+// timers and change cursors can be tested deterministically. Production
+// code uses System; tests use a Fake and move it forward by hand.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and schedules functions
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// AfterFunc calls f in its own goroutine once d has passed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a function scheduled with AfterFunc
+type Timer interface {
+	// Stop cancels the call, reporting whether it was still pending
+	Stop() bool
+}
+
+// System is the real clock
+var System Clock = system{}
+
+type system struct{}
+
+func (system) Now() time.Time                  { return time.Now() }
+func (system) Since(t time.Time) time.Duration { return time.Since(t) }
+
+func (system) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// OrSystem returns c, or System when c is nil
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+// Fake is a clock that only moves when told to. Functions scheduled with
+// AfterFunc run in order of their due time, on the goroutine calling
+// Advance or Set, once it reaches that time. It is safe for concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	fake *Fake
+	due  time.Time
+	f    func()
+}
+
+// NewFake creates a fake clock reading start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake time
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t
+func (c *Fake) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// AfterFunc schedules f for d after the fake time; d <= 0 runs it on the
+// next Advance or Set
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{fake: c, due: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and runs the functions that became
+// due
+func (c *Fake) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, which may be in the past, and runs the
+// functions that became due
+func (c *Fake) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	var due, pending []*fakeTimer
+	for _, timer := range c.timers {
+		if timer.due.After(t) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].due.Before(due[j].due) })
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+// Pending returns how many scheduled functions have not run yet
+func (c *Fake) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.fake
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
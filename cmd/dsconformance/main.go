@@ -0,0 +1,86 @@
+// Command dsconformance runs the storetest conformance cases against every
+// datasource, so the memory, SQLite and PostgreSQL implementations stay
+// behaviourally identical. SQL datasources whose driver was not built in
+// are skipped, as is PostgreSQL without a DSN.
+//
+//	go run -tags sqlite,postgres ./cmd/dsconformance -postgres-dsn postgres://localhost/plugin_test
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/store/storetest"
+)
+
+func main() {
+	sqliteDSN := flag.String("sqlite-dsn", "", "SQLite database to run against (default: a file in a temporary directory)")
+	postgresDSN := flag.String("postgres-dsn", os.Getenv("PLUGIN_DATASOURCE_TEST_POSTGRES_DSN"), "PostgreSQL database to run against; its plugin_snapshot table is overwritten")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: dsconformance [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if !run(*sqliteDSN, *postgresDSN) {
+		os.Exit(1)
+	}
+}
+
+// run reports whether every datasource that could be opened passed
+func run(sqliteDSN, postgresDSN string) bool {
+	if sqliteDSN == "" {
+		dir, err := os.MkdirTemp("", "dsconformance")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		sqliteDSN = filepath.Join(dir, "store.db")
+	}
+	dsns := map[string]string{
+		store.DatasourceSQLite:   sqliteDSN,
+		store.DatasourcePostgres: postgresDSN,
+	}
+
+	failed := false
+	for _, kind := range store.Datasources {
+		cfg := store.DatasourceConfig{Kind: kind, DSN: dsns[kind]}
+		open := func() (store.Datasource, error) { return store.Open(cfg) }
+
+		if kind != store.DatasourceMemory {
+			if cfg.DSN == "" {
+				log.Printf("⏭️  %s: skipped, no DSN given", kind)
+				continue
+			}
+			ds, err := open()
+			if errors.Is(err, store.ErrNoDriver) {
+				log.Printf("⏭️  %s: skipped, %v", kind, err)
+				continue
+			}
+			if err != nil {
+				log.Printf("❌ %s: %v", kind, err)
+				failed = true
+				continue
+			}
+			ds.Close()
+		}
+
+		err := storetest.Run(open)
+		if err == nil && kind != store.DatasourceMemory {
+			err = storetest.CheckPersistence(open)
+		}
+		if err != nil {
+			log.Printf("❌ %s:\n%v", kind, err)
+			failed = true
+			continue
+		}
+		log.Printf("✅ %s: %d cases passed", kind, len(storetest.Cases))
+	}
+
+	return !failed
+}
@@ -0,0 +1,675 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generator turns a document into Go source: request and response structs,
+// an interface with one handler per operation and a function registering
+// them all
+type generator struct {
+	doc       *document
+	source    string // document file name, for comments
+	pkg       string
+	module    string // module path, for the imports
+	api       string // exported API name, e.g. Petstore
+	receiver  string // type the stubs are methods of
+	types     []string
+	declared  map[string]bool
+	structs   map[string]bool
+	resolving map[string]bool
+	endpoints []endpoint
+}
+
+// endpoint is one operation, ready to be written out
+type endpoint struct {
+	method      string
+	path        string
+	description string
+	name        string // exported operation name, e.g. ListPets
+	request     string
+	response    string
+	schema      map[string]interface{}
+}
+
+func (e endpoint) handler() string { return lowerFirst(e.name) + "RESTHandler" }
+
+// field is a struct field
+type field struct {
+	name string
+	typ  string
+	tag  string
+	doc  string
+}
+
+// methodOrder sorts an item's operations the way they are usually listed
+var methodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4, "HEAD": 5, "OPTIONS": 6}
+
+func newGenerator(doc *document) *generator {
+	return &generator{
+		doc:       doc,
+		declared:  make(map[string]bool),
+		structs:   make(map[string]bool),
+		resolving: make(map[string]bool),
+	}
+}
+
+// collect builds the endpoint and types of every operation
+func (g *generator) collect() error {
+	paths := make([]string, 0, len(g.doc.Paths))
+	for path := range g.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := g.doc.Paths[path]
+		ops := item.operations()
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Slice(methods, func(i, j int) bool { return methodOrder[methods[i]] < methodOrder[methods[j]] })
+
+		for _, method := range methods {
+			if err := g.operation(method, path, item, ops[method]); err != nil {
+				return fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+	if len(g.endpoints) == 0 {
+		return fmt.Errorf("%s has no operations", g.source)
+	}
+	return nil
+}
+
+func (g *generator) operation(method, path string, item *pathItem, op *operation) error {
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(method) + " " + strings.NewReplacer("{", " by ", "}", "").Replace(path)
+	}
+	e := endpoint{method: method, path: path, name: goName(name), description: op.Summary}
+	if e.description == "" {
+		e.description, _, _ = strings.Cut(strings.TrimSpace(op.Description), "\n")
+	}
+	if op.Deprecated {
+		e.description = strings.TrimSpace("Deprecated. " + e.description)
+	}
+
+	// Operation parameters override path item parameters of the same name
+	params := make(map[string]*parameter)
+	var order []string
+	for _, list := range [][]*parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			resolved, err := g.doc.parameter(p)
+			if err != nil {
+				return err
+			}
+			if resolved.In == "header" || resolved.In == "cookie" {
+				log.Printf("%s %s: skipping %s parameter %s; plugins only receive path, query and body args", method, path, resolved.In, resolved.Name)
+				continue
+			}
+			if _, ok := params[resolved.Name]; !ok {
+				order = append(order, resolved.Name)
+			}
+			params[resolved.Name] = resolved
+		}
+	}
+
+	body, err := g.doc.requestBody(op.RequestBody)
+	if err != nil {
+		return err
+	}
+
+	var fields []field
+	properties := make(map[string]interface{})
+	var required []interface{}
+	closed := false
+	for _, paramName := range order {
+		p := params[paramName]
+		fields = append(fields, field{name: goName(p.Name), typ: "string", tag: jsonTag(p.Name, p.Required), doc: p.Description})
+		properties[p.Name] = paramSchema(p)
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+
+	if body != nil {
+		s := jsonSchema(body.Content)
+		if s == nil {
+			return fmt.Errorf("request body has no application/json content")
+		}
+		target := s
+		if s.Ref != "" {
+			if _, target, err = g.doc.schemaRef(s.Ref); err != nil {
+				return err
+			}
+		}
+		if !target.isObject() {
+			return fmt.Errorf("request body must be an object: plugins receive its properties as args")
+		}
+
+		if s.Ref != "" {
+			typ, err := g.goType(s, "")
+			if err != nil {
+				return err
+			}
+			if !g.structs[typ] {
+				return fmt.Errorf("request body %s must list its properties", s.Ref)
+			}
+			fields = append(fields, field{typ: typ, doc: body.Description})
+		} else {
+			bodyFields, err := g.objectFields(s, e.name+"Request")
+			if err != nil {
+				return err
+			}
+			fields = append(fields, bodyFields...)
+		}
+
+		props, bodyRequired, bodyClosed, err := g.flatten(s)
+		if err != nil {
+			return err
+		}
+		for propName, prop := range props {
+			properties[propName] = g.validationSchema(prop)
+		}
+		if body.Required {
+			for _, propName := range bodyRequired {
+				required = append(required, propName)
+			}
+		}
+		closed = bodyClosed
+	}
+
+	e.request = e.name + "Request"
+	if err := g.declareStruct(e.request, fmt.Sprintf("%s is the request of %s %s", e.request, method, path), fields); err != nil {
+		return err
+	}
+
+	e.schema = map[string]interface{}{}
+	if len(properties) > 0 {
+		e.schema = map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			e.schema["required"] = required
+		}
+		if closed {
+			e.schema["additionalProperties"] = false
+		}
+	}
+
+	if e.response, err = g.responseType(e, op.Responses); err != nil {
+		return err
+	}
+	g.endpoints = append(g.endpoints, e)
+	return nil
+}
+
+// responseType is the Go type of the first 2xx response's JSON body, or of
+// the default response
+func (g *generator) responseType(e endpoint, responses map[string]*response) (string, error) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var chosen *response
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			chosen = responses[code]
+			break
+		}
+	}
+	if chosen == nil {
+		chosen = responses["default"]
+	}
+	chosen, err := g.doc.response(chosen)
+	if err != nil {
+		return "", err
+	}
+
+	name := e.name + "Response"
+	var s *schema
+	if chosen != nil {
+		s = jsonSchema(chosen.Content)
+	}
+	if s == nil {
+		return name, g.declareStruct(name, fmt.Sprintf("%s is the response of %s %s, which has no JSON body", name, e.method, e.path), nil)
+	}
+	return g.goType(s, name)
+}
+
+// goType is the Go type for s. Inline objects become structs named hint,
+// components structs named after the component.
+func (g *generator) goType(s *schema, hint string) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+	if s.Ref != "" {
+		name, target, err := g.doc.schemaRef(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		typeName := goName(name)
+		if g.resolving[typeName] {
+			if g.structs[typeName] {
+				return typeName, nil
+			}
+			return "interface{}", nil // a list or map containing itself
+		}
+		g.resolving[typeName] = true
+		defer delete(g.resolving, typeName)
+		if g.declared[typeName] {
+			return typeName, nil
+		}
+		if len(target.Properties) > 0 || len(target.AllOf) > 0 {
+			g.structs[typeName] = true
+			fields, err := g.objectFields(target, typeName)
+			if err != nil {
+				return "", err
+			}
+			return typeName, g.declareStruct(typeName, g.typeDoc(typeName, target.Description), fields)
+		}
+		return g.goType(target, typeName)
+	}
+
+	switch {
+	case len(s.Properties) > 0 || len(s.AllOf) > 0:
+		g.structs[hint] = true
+		fields, err := g.objectFields(s, hint)
+		if err != nil {
+			return "", err
+		}
+		return hint, g.declareStruct(hint, g.typeDoc(hint, s.Description), fields)
+	case s.Type == "object":
+		if values := s.valueSchema(); values != nil {
+			typ, err := g.goType(values, hint+"Value")
+			return "map[string]" + typ, err
+		}
+		return "map[string]interface{}", nil
+	case s.Type == "array":
+		typ, err := g.goType(s.Items, hint+"Item")
+		return "[]" + typ, err
+	case s.Type == "string":
+		return "string", nil
+	case s.Type == "integer":
+		if s.Format == "int32" || s.Format == "int64" {
+			return s.Format, nil
+		}
+		return "int", nil
+	case s.Type == "number":
+		return "float64", nil
+	case s.Type == "boolean":
+		return "bool", nil
+	default:
+		return "interface{}", nil
+	}
+}
+
+// objectFields are the fields of a struct for s, sorted by name. Optional
+// struct fields are pointers, so they can be left out.
+func (g *generator) objectFields(s *schema, typeName string) ([]field, error) {
+	props, required, _, err := g.flatten(s)
+	if err != nil {
+		return nil, err
+	}
+	isRequired := make(map[string]bool, len(required))
+	for _, name := range required {
+		isRequired[name] = true
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]field, 0, len(names))
+	for _, name := range names {
+		typ, err := g.goType(props[name], typeName+goName(name))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typeName, name, err)
+		}
+		if !isRequired[name] && g.structs[typ] {
+			typ = "*" + typ
+		}
+		fields = append(fields, field{name: goName(name), typ: typ, tag: jsonTag(name, isRequired[name]), doc: props[name].Description})
+	}
+	return fields, nil
+}
+
+// flatten merges an object schema with the schemas in its allOf
+func (g *generator) flatten(s *schema) (props map[string]*schema, required []string, closed bool, err error) {
+	props = make(map[string]*schema)
+	var walk func(s *schema, depth int) error
+	walk = func(s *schema, depth int) error {
+		if depth > 32 {
+			return fmt.Errorf("allOf nests too deeply")
+		}
+		if s.Ref != "" {
+			_, target, err := g.doc.schemaRef(s.Ref)
+			if err != nil {
+				return err
+			}
+			return walk(target, depth+1)
+		}
+		for name, prop := range s.Properties {
+			props[name] = prop
+		}
+		required = append(required, s.Required...)
+		closed = closed || s.closed()
+		for _, part := range s.AllOf {
+			if err := walk(part, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = walk(s, 0)
+	return props, required, closed, err
+}
+
+// validationSchema is s with references resolved, keeping the keywords the
+// jsonschema package checks
+func (g *generator) validationSchema(s *schema) map[string]interface{} {
+	return g.resolve(s, make(map[string]bool))
+}
+
+func (g *generator) resolve(s *schema, seen map[string]bool) map[string]interface{} {
+	if s.Ref != "" {
+		name, target, err := g.doc.schemaRef(s.Ref)
+		if err != nil || seen[name] {
+			return map[string]interface{}{}
+		}
+		seen[name] = true
+		defer delete(seen, name)
+		return g.resolve(target, seen)
+	}
+
+	out := make(map[string]interface{})
+	if len(s.AllOf) > 0 {
+		props, required, closed, err := g.flatten(s)
+		if err != nil {
+			return out
+		}
+		out["type"] = "object"
+		resolved := make(map[string]interface{}, len(props))
+		for name, prop := range props {
+			resolved[name] = g.resolve(prop, seen)
+		}
+		out["properties"] = resolved
+		if len(required) > 0 {
+			out["required"] = stringList(required)
+		}
+		if closed {
+			out["additionalProperties"] = false
+		}
+		return out
+	}
+
+	if s.Type != "" {
+		out["type"] = string(s.Type)
+	} else if len(s.Properties) > 0 {
+		out["type"] = "object"
+	}
+	if len(s.Properties) > 0 {
+		resolved := make(map[string]interface{}, len(s.Properties))
+		for name, prop := range s.Properties {
+			resolved[name] = g.resolve(prop, seen)
+		}
+		out["properties"] = resolved
+	}
+	if len(s.Required) > 0 {
+		out["required"] = stringList(s.Required)
+	}
+	if s.closed() {
+		out["additionalProperties"] = false
+	}
+	if s.Items != nil {
+		out["items"] = g.resolve(s.Items, seen)
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+	}
+	if s.Pattern != "" {
+		out["pattern"] = s.Pattern
+	}
+	for keyword, value := range map[string]*float64{
+		"minLength": s.MinLength, "maxLength": s.MaxLength,
+		"minimum": s.Minimum, "maximum": s.Maximum,
+		"minItems": s.MinItems, "maxItems": s.MaxItems,
+	} {
+		if value != nil {
+			out[keyword] = *value
+		}
+	}
+	return out
+}
+
+// paramSchema validates a path or query parameter. The host passes them as
+// strings whatever their declared type, so only string keywords apply.
+func paramSchema(p *parameter) map[string]interface{} {
+	out := map[string]interface{}{"type": "string"}
+	s := p.Schema
+	if s == nil {
+		return out
+	}
+	if len(s.Enum) > 0 {
+		enum := make([]interface{}, len(s.Enum))
+		for i, value := range s.Enum {
+			enum[i] = fmt.Sprint(value)
+		}
+		out["enum"] = enum
+	}
+	if s.Pattern != "" {
+		out["pattern"] = s.Pattern
+	}
+	if s.MinLength != nil {
+		out["minLength"] = *s.MinLength
+	}
+	if s.MaxLength != nil {
+		out["maxLength"] = *s.MaxLength
+	}
+	return out
+}
+
+func (g *generator) declareStruct(name, comment string, fields []field) error {
+	if g.declared[name] {
+		return fmt.Errorf("type %s is generated twice; rename the operation or schema", name)
+	}
+	g.declared[name] = true
+	g.structs[name] = true
+
+	var b strings.Builder
+	writeComment(&b, "", comment)
+	if len(fields) == 0 {
+		fmt.Fprintf(&b, "type %s struct{}\n", name)
+		g.types = append(g.types, b.String())
+		return nil
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, f := range fields {
+		writeComment(&b, "\t", f.doc)
+		if f.name == "" {
+			fmt.Fprintf(&b, "\t%s\n", f.typ)
+		} else {
+			fmt.Fprintf(&b, "\t%s %s `%s`\n", f.name, f.typ, f.tag)
+		}
+	}
+	b.WriteString("}\n")
+	g.types = append(g.types, b.String())
+	return nil
+}
+
+// generated is the generated file: the interface, the registration
+// function and the types
+func (g *generator) generated() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by openapigen from %s. DO NOT EDIT.\n\n", g.source)
+	fmt.Fprintf(&b, "package %s\n\n", g.pkg)
+	fmt.Fprintf(&b, "import (\n\t\"context\"\n\n\tsdk \"github.com/apito-io/go-apito-plugin-sdk\"\n\n")
+	fmt.Fprintf(&b, "\t\"%[1]s/jsonschema\"\n\t\"%[1]s/registry\"\n\t\"%[1]s/typedfn\"\n)\n\n", g.module)
+
+	iface := lowerFirst(g.api) + "API"
+	title := g.doc.Info.Title
+	if title == "" {
+		title = g.source
+	}
+	fmt.Fprintf(&b, "// %s is implemented by the handlers of %s %s\n", iface, title, g.doc.Info.Version)
+	fmt.Fprintf(&b, "type %s interface {\n", iface)
+	for _, e := range g.endpoints {
+		writeComment(&b, "\t", e.handlerDoc())
+		fmt.Fprintf(&b, "\t%s(ctx context.Context, req %s) (%s, error)\n", e.handler(), e.request, e.response)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "// register%sAPI registers the endpoints of %s with plugin.\n", g.api, g.source)
+	b.WriteString("// Args are validated against the document before they are decoded for api.\n")
+	fmt.Fprintf(&b, "func register%sAPI(plugin *registry.Plugin, api %s) {\n", g.api, iface)
+	b.WriteString("\tregister := func(endpoint sdk.RESTEndpoint, handler sdk.RESTHandlerFunc) {\n")
+	b.WriteString("\t\tif jsonschema.IsSchema(endpoint.Schema) {\n\t\t\thandler = jsonschema.ValidateBody(endpoint.Schema, handler)\n\t\t}\n")
+	b.WriteString("\t\tplugin.RegisterRESTAPI(endpoint, handler)\n\t}\n")
+	for _, e := range g.endpoints {
+		b.WriteString("\n\tregister(sdk.RESTEndpoint{\n")
+		fmt.Fprintf(&b, "\t\tMethod: %q,\n\t\tPath: %q,\n\t\tDescription: %q,\n\t\tSchema: ", e.method, e.path, e.description)
+		writeValue(&b, e.schema)
+		fmt.Fprintf(&b, ",\n\t}, typedfn.REST(%q, api.%s))\n", e.method+" "+e.path, e.handler())
+	}
+	b.WriteString("}\n")
+
+	for _, typ := range g.types {
+		b.WriteString("\n" + typ)
+	}
+	return formatSource(b.Bytes())
+}
+
+// stubs writes a handler for every operation that returns
+// errors.ErrUnsupported, which typedfn.REST turns into a 501
+func (g *generator) stubs() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s\n\nimport (\n\t\"context\"\n\t\"errors\"\n\t\"fmt\"\n)\n\n", g.pkg)
+	fmt.Fprintf(&b, "// Handlers of the endpoints in %s, registered by\n", g.source)
+	fmt.Fprintf(&b, "// register%sAPI. They start out answering 501 Not Implemented.\n", g.api)
+	for _, e := range g.endpoints {
+		b.WriteString("\n")
+		writeComment(&b, "", e.handlerDoc())
+		fmt.Fprintf(&b, "func (h *%s) %s(ctx context.Context, req %s) (%s, error) {\n", g.receiver, e.handler(), e.request, e.response)
+		fmt.Fprintf(&b, "\treturn %s, fmt.Errorf(\"%s: %%w\", errors.ErrUnsupported)\n}\n", zeroValue(e.response, g.structs), lowerFirst(e.name))
+	}
+	return formatSource(b.Bytes())
+}
+
+func (e endpoint) handlerDoc() string {
+	if e.description == "" {
+		return fmt.Sprintf("%s handles %s %s", e.handler(), e.method, e.path)
+	}
+	return fmt.Sprintf("%s handles %s %s: %s", e.handler(), e.method, e.path, e.description)
+}
+
+// formatSource gofmts src, returning it unformatted with the error so the
+// problem can be found
+func formatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return src, fmt.Errorf("generated code does not parse: %w", err)
+	}
+	return formatted, nil
+}
+
+func zeroValue(typ string, structs map[string]bool) string {
+	switch {
+	case structs[typ]:
+		return typ + "{}"
+	case typ == "string":
+		return `""`
+	case typ == "bool":
+		return "false"
+	case typ == "int" || typ == "int32" || typ == "int64" || typ == "float64":
+		return "0"
+	default:
+		return "nil"
+	}
+}
+
+func jsonTag(name string, required bool) string {
+	if required {
+		return fmt.Sprintf(`json:"%s"`, name)
+	}
+	return fmt.Sprintf(`json:"%s,omitempty"`, name)
+}
+
+// typeDoc is the comment of a type generated from a schema
+func (g *generator) typeDoc(name, description string) string {
+	if description == "" {
+		return fmt.Sprintf("%s is generated from %s", name, g.source)
+	}
+	return name + ": " + description
+}
+
+func writeComment(b interface{ WriteString(string) (int, error) }, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString(indent + "// " + line + "\n")
+		}
+	}
+}
+
+func stringList(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+// writeValue writes a decoded JSON value as a Go literal, with map keys
+// sorted so the output is stable
+func writeValue(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("map[string]interface{}{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("map[string]interface{}{\n")
+		for _, key := range keys {
+			fmt.Fprintf(b, "%q: ", key)
+			writeValue(b, v[key])
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+	case []interface{}:
+		b.WriteString("[]interface{}{")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeValue(b, item)
+		}
+		b.WriteString("}")
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			b.WriteString(strconv.FormatInt(int64(v), 10))
+		} else {
+			b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("nil")
+	default:
+		fmt.Fprintf(b, "%#v", v)
+	}
+}
@@ -0,0 +1,152 @@
+// Command openapigen generates REST endpoint registrations from an OpenAPI
+// 3 document, so the JSON Schemas and arg handling of REST-heavy plugins
+// are not maintained by hand. For each operation it writes a request struct
+// holding the path and query parameters and the body's properties, which
+// the host passes as one args map, a response struct, and a handler in an
+// interface. register<Name>API registers every endpoint with its schema
+// and decodes the args before calling the handler.
+//
+// The generated file is rewritten on every run. The stubs file, holding a
+// method per handler that answers 501 until it is filled in, is only
+// written when it does not exist yet.
+//
+//	go run ./cmd/openapigen -o rest/petstore_gen.go -stubs rest/petstore.go petstore.json
+//
+// Documents must be JSON; convert YAML first, e.g. with yq -o=json.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	out := flag.String("o", "", "generated file (default: <document>_gen.go)")
+	stubs := flag.String("stubs", "", "handler stubs file, written only if it does not exist")
+	pkg := flag.String("package", "", "package name (default: the output directory's name)")
+	name := flag.String("name", "", "API name used for the interface and register function (default: from info.title)")
+	receiver := flag.String("receiver", "handlers", "type the stub handlers are methods of")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: openapigen [flags] openapi.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	log.SetFlags(0)
+	log.SetPrefix("openapigen: ")
+
+	source := flag.Arg(0)
+	doc, err := readDocument(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(source, filepath.Ext(source)) + "_gen.go"
+	}
+	dir, err := filepath.Abs(filepath.Dir(*out))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *pkg == "" {
+		*pkg = filepath.Base(dir)
+	}
+	if *name == "" {
+		*name = doc.Info.Title
+	}
+	if *name = goName(*name); *name == "X" {
+		log.Fatal("the document has no info.title; name the API with -name")
+	}
+	module, err := modulePath(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := newGenerator(doc)
+	g.source = filepath.Base(source)
+	g.pkg = *pkg
+	g.module = module
+	g.api = *name
+	g.receiver = *receiver
+	if err := g.collect(); err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := g.generated()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d endpoints to %s", len(g.endpoints), *out)
+
+	if *stubs == "" {
+		return
+	}
+	if _, err := os.Stat(*stubs); err == nil {
+		log.Printf("kept %s; add handlers for new operations by hand", *stubs)
+		return
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+	if src, err = g.stubs(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*stubs, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote handler stubs to %s", *stubs)
+}
+
+func readDocument(path string) (*document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("%s: only JSON documents are supported", path)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s: expected an OpenAPI 3 document, got openapi %q", path, doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// modulePath reads the module path from the go.mod in dir or above it
+func modulePath(dir string) (string, error) {
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+					return strings.Trim(strings.TrimSpace(rest), `"`), nil
+				}
+			}
+			return "", fmt.Errorf("%s has no module line", f.Name())
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no go.mod found above the output file")
+		}
+		dir = parent
+	}
+}
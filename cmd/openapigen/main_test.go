@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestPetstoreGolden generates code for testdata/petstore.json and
+// compares it with the checked-in output, so any change to what the
+// generator writes shows up as a diff
+func TestPetstoreGolden(t *testing.T) {
+	doc, err := readDocument(filepath.Join("testdata", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newGenerator(doc)
+	g.source = "petstore.json"
+	g.pkg = "rest"
+	g.module = "hc-hello-world-plugin"
+	g.api = goName(doc.Info.Title)
+	g.receiver = "handlers"
+	if err := g.collect(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, generate := range map[string]func() ([]byte, error){
+		"petstore_gen.go.golden":   g.generated,
+		"petstore_stubs.go.golden": g.stubs,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", name)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v; run go test -run %s -update to create it", err, t.Name())
+			}
+			if string(got) != string(want) {
+				t.Errorf("%s is out of date; review the change and run go test -update\n\ngot:\n%s", path, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// document is the part of an OpenAPI 3 document the generator reads
+type document struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]*pathItem `json:"paths"`
+	Components struct {
+		Schemas       map[string]*schema      `json:"schemas"`
+		Parameters    map[string]*parameter   `json:"parameters"`
+		RequestBodies map[string]*requestBody `json:"requestBodies"`
+		Responses     map[string]*response    `json:"responses"`
+	} `json:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters"`
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Delete     *operation   `json:"delete"`
+	Patch      *operation   `json:"patch"`
+	Head       *operation   `json:"head"`
+	Options    *operation   `json:"options"`
+}
+
+// operations returns the item's operations by HTTP method
+func (p *pathItem) operations() map[string]*operation {
+	ops := map[string]*operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"PATCH": p.Patch, "HEAD": p.Head, "OPTIONS": p.Options,
+	}
+	for method, op := range ops {
+		if op == nil {
+			delete(ops, method)
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Required    bool                 `json:"required"`
+	Content     map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+// jsonSchema returns the schema of the application/json content, if any
+func jsonSchema(content map[string]mediaType) *schema {
+	for contentType, media := range content {
+		if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 schemaType         `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []interface{}      `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	MinLength            *float64           `json:"minLength"`
+	MaxLength            *float64           `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinItems             *float64           `json:"minItems"`
+	MaxItems             *float64           `json:"maxItems"`
+}
+
+// schemaType is a schema's type. OpenAPI 3.1 allows a list such as
+// ["string", "null"]; the first type other than null is kept.
+type schemaType string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaType(single)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	for _, typ := range list {
+		if typ != "null" {
+			*t = schemaType(typ)
+			break
+		}
+	}
+	return nil
+}
+
+// closed reports whether the schema forbids properties it does not list
+func (s *schema) closed() bool {
+	var allowed bool
+	return json.Unmarshal(s.AdditionalProperties, &allowed) == nil && !allowed
+}
+
+// valueSchema is the schema of a map's values, or nil
+func (s *schema) valueSchema() *schema {
+	var values schema
+	if len(s.AdditionalProperties) == 0 || json.Unmarshal(s.AdditionalProperties, &values) != nil {
+		return nil
+	}
+	return &values
+}
+
+func (s *schema) isObject() bool {
+	return s.Type == "object" || len(s.Properties) > 0 || len(s.AllOf) > 0
+}
+
+// refName is the component a local reference points to, e.g. "Pet" for
+// "#/components/schemas/Pet"
+func refName(ref, section string) (string, error) {
+	prefix := "#/components/" + section + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q: only %s* is supported", ref, prefix)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *document) schemaRef(ref string) (string, *schema, error) {
+	name, err := refName(ref, "schemas")
+	if err != nil {
+		return "", nil, err
+	}
+	s, ok := d.Components.Schemas[name]
+	if !ok {
+		return "", nil, fmt.Errorf("reference %q: no such schema", ref)
+	}
+	return name, s, nil
+}
+
+func (d *document) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Parameters[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("reference %q: no such parameter", p.Ref)
+}
+
+func (d *document) requestBody(b *requestBody) (*requestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.RequestBodies[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("reference %q: no such request body", b.Ref)
+}
+
+func (d *document) response(r *response) (*response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Responses[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("reference %q: no such response", r.Ref)
+}
+
+// initialisms are written in capitals in Go names, e.g. PetID
+var initialisms = map[string]bool{"id": true, "url": true, "uri": true, "api": true, "http": true, "json": true, "html": true, "ip": true, "uuid": true, "sql": true}
+
+// goName turns an OpenAPI name such as "pet_id", "petId" or "list-pets"
+// into an exported Go identifier
+func goName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	result := b.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// lowerFirst unexports a Go name: ListPets becomes listPets and API api
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i-- // keep the capital that starts the next word: APIKey -> apiKey
+	}
+	return strings.ToLower(string(runes[:i])) + string(runes[i:])
+}
@@ -0,0 +1,106 @@
+// Command recordfixtures records the HTTP fixtures the plugin replays when
+// started with PLUGIN_HTTP_FIXTURES_MODE=replay. It looks up the weather in
+// each city given, through the same Open-Meteo endpoints getWeather uses,
+// and fetches each -get URL, saving every response in the fixtures
+// directory.
+//
+//	go run ./cmd/recordfixtures -dir testdata/http Berlin Tokyo
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/extapi"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/weather"
+)
+
+// urls collects repeated -get flags
+type urls []string
+
+func (u *urls) String() string { return strings.Join(*u, ",") }
+
+func (u *urls) Set(value string) error {
+	*u = append(*u, value)
+	return nil
+}
+
+func main() {
+	dir := flag.String("dir", "testdata/http", "directory the fixtures are written to")
+	geocodingURL := flag.String("geocoding-url", "https://geocoding-api.open-meteo.com", "Open-Meteo geocoding API")
+	forecastURL := flag.String("forecast-url", "https://api.open-meteo.com", "Open-Meteo forecast API")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each request")
+	var gets urls
+	flag.Var(&gets, "get", "URL to fetch and record; may be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: recordfixtures [flags] [city ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 && len(gets) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	recorder := outbound.NewRecorder(&http.Client{Timeout: *timeout}, *dir)
+	failed := false
+	ctx := context.Background()
+
+	if flag.NArg() > 0 {
+		client := func(name, baseURL string) *extapi.Client {
+			client, err := extapi.New(extapi.Config{Name: name, BaseURL: baseURL, Timeout: *timeout, Doer: recorder})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return client
+		}
+		service := weather.New(client("geocoding API", *geocodingURL), client("forecast API", *forecastURL))
+		for _, city := range flag.Args() {
+			report, err := service.Current(ctx, weather.NormalizeCity(city))
+			if err != nil {
+				log.Printf("⚠️  %s: %v", city, err)
+				failed = true
+				continue
+			}
+			log.Printf("📼 %s, %s: %s, %.1f°C", report.City, report.Country, report.Description, report.TemperatureC)
+		}
+	}
+
+	for _, target := range gets {
+		if err := get(ctx, recorder, target); err != nil {
+			log.Printf("⚠️  %s: %v", target, err)
+			failed = true
+			continue
+		}
+		log.Printf("📼 %s", target)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	log.Printf("✅ Fixtures written to %s", *dir)
+}
+
+// get fetches target through d; any response, even an error status, is
+// recorded
+func get(ctx context.Context, d outbound.Doer, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
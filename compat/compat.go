@@ -0,0 +1,157 @@
+// Package compat negotiates schema features with the host engine. Older
+// engines reject some argument and type shapes the SDK can produce, so
+// features are gated on the host's plugin SDK version instead of letting
+// registration fail.
+package compat
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is a schema capability that needs a minimum host SDK version
+type Feature struct {
+	Name string
+	// Since is the first host SDK version that supports the feature
+	Since string
+	// Reason explains what goes wrong on older hosts
+	Reason string
+}
+
+// Known features, with the SDK release that made each work end to end
+var (
+	PaginatedTypes = Feature{
+		Name:   "paginated types",
+		Since:  "0.1.6",
+		Reason: "older engines reject the nested object references in PaginatedResponseType",
+	}
+	ArrayObjectArgs = Feature{
+		Name:   "array object arguments",
+		Since:  "0.1.8",
+		Reason: "older engines cannot convert ArrayObjectArg item schemas",
+	}
+)
+
+// Known lists every feature that can be gated
+var Known = []Feature{PaginatedTypes, ArrayObjectArgs}
+
+// Gate records a feature that was disabled for the host
+type Gate struct {
+	Feature  string `json:"feature"`
+	Since    string `json:"since"`
+	Reason   string `json:"reason"`
+	Fallback string `json:"fallback"`
+}
+
+// Host is the negotiated host version
+type Host struct {
+	// Version is the host's plugin SDK version
+	Version string
+	// Detected is false when the host did not report a version; the plugin's
+	// own SDK version is assumed and nothing is gated
+	Detected bool
+
+	version [3]int
+	mu      sync.Mutex
+	gates   []Gate
+}
+
+// Detect negotiates with a host reporting reported; an empty or unparsable
+// version assumes the host matches current, the SDK the plugin was built
+// with, so nothing is gated
+func Detect(reported, current string) *Host {
+	h := &Host{Version: current}
+	if reported != "" {
+		if v, err := parseVersion(reported); err != nil {
+			log.Printf("⚠️  [hc-hello-world-plugin] Ignoring host SDK version: %v - assuming %s", err, current)
+		} else {
+			h.Version, h.Detected, h.version = reported, true, v
+		}
+	}
+	log.Printf("🤝 [hc-hello-world-plugin] Host SDK version %s (detected=%t, plugin built with %s)", h.Version, h.Detected, current)
+	return h
+}
+
+// Supports reports whether the host supports f. When it does not, the gate
+// is recorded and logged together with what the plugin does instead.
+func (h *Host) Supports(f Feature, fallback string) bool {
+	if h.Enabled(f) {
+		return true
+	}
+
+	h.mu.Lock()
+	h.gates = append(h.gates, Gate{Feature: f.Name, Since: f.Since, Reason: f.Reason, Fallback: fallback})
+	h.mu.Unlock()
+	log.Printf("🚧 [hc-hello-world-plugin] Gated %s: host SDK %s < %s (%s) - %s", f.Name, h.Version, f.Since, f.Reason, fallback)
+	return false
+}
+
+// Enabled reports whether the host supports f without recording a gate
+func (h *Host) Enabled(f Feature) bool {
+	if !h.Detected {
+		return true
+	}
+	since, err := parseVersion(f.Since)
+	return err != nil || compareVersions(h.version, since) >= 0
+}
+
+// Status is whether the host supports a feature
+type Status struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Since   string `json:"since"`
+	Reason  string `json:"reason"`
+}
+
+// Features reports every known feature and whether the host supports it
+func (h *Host) Features() []Status {
+	features := make([]Status, 0, len(Known))
+	for _, f := range Known {
+		features = append(features, Status{Name: f.Name, Enabled: h.Enabled(f), Since: f.Since, Reason: f.Reason})
+	}
+	return features
+}
+
+// Gates returns the features disabled so far
+func (h *Host) Gates() []Gate {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Gate{}, h.gates...)
+}
+
+// parseVersion accepts "1.2.3", "v1.2" and "1.2.3-rc1"; pre-release and
+// build suffixes are ignored
+func parseVersion(s string) ([3]int, error) {
+	var v [3]int
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,601 @@
+// Package config loads plugin settings from environment variables passed by
+// the Apito engine.
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/logging"
+)
+
+// Config holds the plugin's runtime settings
+type Config struct {
+	// DebugMode enables the delve attachment banner (PLUGIN_DEBUG_MODE)
+	DebugMode bool
+	// TemplateDir holds the HTML templates; in debug mode they are read from
+	// here on every render instead of the embedded copies (PLUGIN_TEMPLATE_DIR)
+	TemplateDir string
+	// IDStrategy selects the ID generator: uuidv7 or ulid (PLUGIN_ID_STRATEGY)
+	IDStrategy string
+	// TokenSecret signs login tokens; random per process when empty (PLUGIN_TOKEN_SECRET)
+	TokenSecret string
+	// TokenTTL is how long login tokens stay valid (PLUGIN_TOKEN_TTL)
+	TokenTTL time.Duration
+
+	// SMTP settings for outgoing email (PLUGIN_SMTP_*); the password may
+	// also be read from a mounted secret via PLUGIN_SMTP_PASSWORD_FILE
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Outbound webhooks (PLUGIN_WEBHOOK_URL, PLUGIN_WEBHOOK_SECRET or
+	// PLUGIN_WEBHOOK_SECRET_FILE); every store event is POSTed to the URL,
+	// and nothing is sent when it is empty. Failed deliveries are retried up
+	// to WebhookMaxAttempts times (PLUGIN_WEBHOOK_MAX_ATTEMPTS), each request
+	// limited to WebhookTimeout (PLUGIN_WEBHOOK_TIMEOUT); the last
+	// WebhookHistory deliveries are kept for inspection (PLUGIN_WEBHOOK_HISTORY).
+	WebhookURL         string
+	WebhookSecret      string
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+	WebhookHistory     int
+
+	// Outbound HTTP calls are sent as usual, or recorded as fixtures to, or
+	// replayed offline from, HTTPFixturesDir when HTTPFixturesMode is
+	// "record" or "replay" (PLUGIN_HTTP_FIXTURES_MODE, PLUGIN_HTTP_FIXTURES_DIR)
+	HTTPFixturesMode string
+	HTTPFixturesDir  string
+
+	// External API client (PLUGIN_EXTERNAL_API_URL, PLUGIN_EXTERNAL_API_TIMEOUT);
+	// disabled when the URL is empty
+	ExternalAPIURL     string
+	ExternalAPITimeout time.Duration
+	// Every external API client opens its circuit breaker after
+	// ExternalAPIBreakerThreshold consecutive failures, 0 to disable, and
+	// keeps it open for ExternalAPIBreakerCooldown
+	// (PLUGIN_EXTERNAL_API_BREAKER_THRESHOLD, PLUGIN_EXTERNAL_API_BREAKER_COOLDOWN)
+	ExternalAPIBreakerThreshold int
+	ExternalAPIBreakerCooldown  time.Duration
+	// getWeather calls the Open-Meteo geocoding and forecast APIs
+	// (PLUGIN_WEATHER_GEOCODING_URL, PLUGIN_WEATHER_FORECAST_URL), caching
+	// their responses for WeatherCacheTTL (PLUGIN_WEATHER_CACHE_TTL)
+	WeatherGeocodingURL string
+	WeatherForecastURL  string
+	WeatherCacheTTL     time.Duration
+	// OAuth2 client credentials for the external API; requests are sent
+	// without a token when OAuthTokenURL is empty (PLUGIN_OAUTH_TOKEN_URL,
+	// PLUGIN_OAUTH_CLIENT_ID, PLUGIN_OAUTH_CLIENT_SECRET or
+	// PLUGIN_OAUTH_CLIENT_SECRET_FILE). Scopes are comma-separated
+	// (PLUGIN_OAUTH_SCOPES); some servers also need an audience
+	// (PLUGIN_OAUTH_AUDIENCE).
+	OAuthTokenURL     string
+	OAuthClientID     string
+	OAuthClientSecret string
+	OAuthScopes       []string
+	OAuthAudience     string
+
+	// Request guardrails (PLUGIN_MAX_PAGE_SIZE, PLUGIN_MAX_ITEMS, PLUGIN_MAX_COMPLEXITY)
+	MaxPageSize   int
+	MaxItems      int
+	MaxComplexity int
+	// Free-form JSON arguments such as createUser's metadata are limited in
+	// nesting and encoded size (PLUGIN_MAX_JSON_DEPTH, PLUGIN_MAX_JSON_BYTES)
+	MaxJSONDepth int
+	MaxJSONBytes int
+	// MaxAvatarBytes caps the decoded size of uploaded avatars (PLUGIN_MAX_AVATAR_BYTES)
+	MaxAvatarBytes int
+	// Multipart uploads through POST /graphql/upload
+	// (PLUGIN_UPLOAD_MAX_FILE_BYTES, PLUGIN_UPLOAD_MAX_FILES)
+	UploadMaxFileBytes int
+	UploadMaxFiles     int
+	// Resumable uploads through POST /uploads keep their chunks in
+	// UploadSessionDir, the system temp dir when empty, and are dropped
+	// after UploadSessionTTL without a chunk (PLUGIN_UPLOAD_SESSION_DIR,
+	// PLUGIN_UPLOAD_SESSION_TTL)
+	UploadSessionDir string
+	UploadSessionTTL time.Duration
+	// MutationIDWindow is how long a mutation repeated with the same
+	// clientMutationId returns the first result; 0 only echoes IDs
+	// (PLUGIN_MUTATION_ID_WINDOW)
+	MutationIDWindow time.Duration
+
+	// Response cache for read-only resolvers (PLUGIN_CACHE_TTL, PLUGIN_CACHE_MAX_ENTRIES)
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+	// CacheStaleWindow is how long past its TTL a result may still be served
+	// while it is refreshed in the background; 0 disables stale-while-revalidate
+	// (PLUGIN_CACHE_STALE_WINDOW)
+	CacheStaleWindow time.Duration
+
+	// SQL connection pool (PLUGIN_DB_MAX_OPEN_CONNS, PLUGIN_DB_MAX_IDLE_CONNS,
+	// PLUGIN_DB_CONN_MAX_LIFETIME) and the bound on each statement
+	// (PLUGIN_DB_STATEMENT_TIMEOUT)
+	DBMaxOpenConns     int
+	DBMaxIdleConns     int
+	DBConnMaxLifetime  time.Duration
+	DBStatementTimeout time.Duration
+
+	// Per-tenant limits: a token bucket of RateLimitBurst calls refilled at
+	// RateLimitPerSecond, 0 to disable (PLUGIN_RATE_LIMIT_PER_SECOND,
+	// PLUGIN_RATE_LIMIT_BURST), and a monthly call budget, 0 for unlimited
+	// (PLUGIN_QUOTA_MONTHLY_CALLS). QuotaOverrides sets other budgets for
+	// individual tenants as tenant=calls, comma-separated (PLUGIN_QUOTA_OVERRIDES).
+	RateLimitPerSecond float64
+	RateLimitBurst     int
+	QuotaMonthlyCalls  int
+	QuotaOverrides     []string
+
+	// Error reporting to Sentry or a compatible service; disabled when the
+	// DSN is empty (PLUGIN_SENTRY_DSN or PLUGIN_SENTRY_DSN_FILE).
+	// SentrySampleRate is the fraction (0..1) of resolver errors sent; panics
+	// are always sent (PLUGIN_SENTRY_SAMPLE_RATE, PLUGIN_SENTRY_ENVIRONMENT).
+	SentryDSN         string
+	SentrySampleRate  float64
+	SentryEnvironment string
+
+	// Instrumentation thresholds (PLUGIN_SLOW_RESOLVER_THRESHOLD, PLUGIN_REPEATED_LOOKUP_THRESHOLD)
+	SlowResolverThreshold   time.Duration
+	RepeatedLookupThreshold int
+
+	// Memory watchdog (PLUGIN_MEMORY_SOFT_LIMIT_MB, PLUGIN_MEMORY_HARD_LIMIT_MB,
+	// PLUGIN_MEMORY_CHECK_INTERVAL); 0 disables a limit
+	MemorySoftLimitMB   int
+	MemoryHardLimitMB   int
+	MemoryCheckInterval time.Duration
+
+	// Generated demo data (PLUGIN_SAMPLE_USERS, PLUGIN_SAMPLE_PRODUCTS,
+	// PLUGIN_SAMPLE_COMMENTS, PLUGIN_SAMPLE_SEED); a non-zero seed makes the data reproducible
+	SampleUsers    int
+	SampleProducts int
+	SampleComments int
+	SampleSeed     uint64
+
+	// APIKeysRequired makes REST endpoints other than the health checks and
+	// /admin/* demand an API key in X-API-Key; otherwise a key is only
+	// checked when one is sent (PLUGIN_API_KEYS_REQUIRED)
+	APIKeysRequired bool
+
+	// AdminToken authorizes /admin/* endpoints, which are disabled when it is
+	// empty (PLUGIN_ADMIN_TOKEN or PLUGIN_ADMIN_TOKEN_FILE)
+	AdminToken string
+	// SnapshotPath is where /admin/snapshot writes the store (PLUGIN_SNAPSHOT_PATH)
+	SnapshotPath string
+	// RestoreOnStart loads SnapshotPath at startup instead of generating data
+	// when the file exists (PLUGIN_RESTORE_ON_START)
+	RestoreOnStart bool
+	// Datasource is memory, sqlite or postgres (PLUGIN_DATASOURCE). The SQL
+	// datasources persist a snapshot of the whole store as one JSON row,
+	// not a table per entity
+	Datasource string
+	// DatasourceDSN is the SQLite file or PostgreSQL URL the sqlite and
+	// postgres datasources connect to (PLUGIN_DATASOURCE_DSN or
+	// PLUGIN_DATASOURCE_DSN_FILE)
+	DatasourceDSN string
+	// DatasourceFlushInterval is how often SQL datasources rewrite their
+	// snapshot row after a change (PLUGIN_DATASOURCE_FLUSH_INTERVAL)
+	DatasourceFlushInterval time.Duration
+	// StoreEncryptionKey encrypts snapshot files and the SQL datasources'
+	// data with AES-256-GCM; empty stores them as plain JSON
+	// (PLUGIN_STORE_ENCRYPTION_KEY or PLUGIN_STORE_ENCRYPTION_KEY_FILE)
+	StoreEncryptionKey string
+	// FieldEncryptionKey encrypts users' emails and phone numbers in the
+	// store; empty stores them as given (PLUGIN_FIELD_ENCRYPTION_KEY or
+	// PLUGIN_FIELD_ENCRYPTION_KEY_FILE)
+	FieldEncryptionKey string
+	// PIIRoles are the host roles that see those fields in clear; they are
+	// masked for every other caller, with or without FieldEncryptionKey
+	// (PLUGIN_PII_ROLES)
+	PIIRoles []string
+	// ReceiptSecret signs the receipts eraseUser returns; random per process
+	// when empty (PLUGIN_RECEIPT_SECRET or PLUGIN_RECEIPT_SECRET_FILE)
+	ReceiptSecret string
+
+	// LogLevel is debug or info; debug logs every request and response with
+	// sensitive fields redacted (PLUGIN_LOG_LEVEL)
+	LogLevel string
+	// LogRedactPatterns are the field name fragments redacted from logs,
+	// comma-separated (PLUGIN_LOG_REDACT)
+	LogRedactPatterns []string
+	// LogForward sends logs to the host as structured records so they appear
+	// in the engine's console under the plugin's name (PLUGIN_LOG_FORWARD)
+	LogForward bool
+
+	// DependencyCheckInterval is how often optional dependencies such as SMTP
+	// are re-checked while healthy; degraded ones are retried sooner with
+	// backoff (PLUGIN_DEPENDENCY_CHECK_INTERVAL)
+	DependencyCheckInterval time.Duration
+
+	// RequiredDependencies are dependencies startup waits for before any
+	// handler is registered, e.g. "smtp"; comma-separated
+	// (PLUGIN_REQUIRED_DEPENDENCIES). DependencyWait bounds the wait
+	// (PLUGIN_DEPENDENCY_WAIT).
+	RequiredDependencies []string
+	DependencyWait       time.Duration
+	// HealthFile is where the running plugin writes a heartbeat every
+	// HealthInterval for --healthcheck to read; without it --healthcheck
+	// exercises the core subsystems instead (PLUGIN_HEALTH_FILE,
+	// PLUGIN_HEALTH_INTERVAL)
+	HealthFile     string
+	HealthInterval time.Duration
+
+	// ReadyTimeout bounds how long startup waits for the plugin to become
+	// ready before giving up (PLUGIN_READY_TIMEOUT)
+	ReadyTimeout time.Duration
+	// HandlerTimeout is the deadline every resolver, REST handler and
+	// function call gets; 0 disables it (PLUGIN_HANDLER_TIMEOUT)
+	HandlerTimeout time.Duration
+
+	// Environment names the deployment, e.g. development or production;
+	// registration groups such as the demo resolvers are enabled per
+	// environment (PLUGIN_ENV). EnabledGroups and DisabledGroups override
+	// that for the named groups; comma-separated (PLUGIN_GROUPS_ENABLED,
+	// PLUGIN_GROUPS_DISABLED).
+	Environment    string
+	EnabledGroups  []string
+	DisabledGroups []string
+
+	// NamePrefix is prepended to every query and mutation name the host
+	// sees, e.g. hw_, so fields of plugins installed side by side do not
+	// collide (PLUGIN_NAME_PREFIX)
+	NamePrefix string
+
+	// HostSDKVersion is the plugin SDK version the engine speaks; features
+	// newer engines need are gated when it is older than the plugin's SDK.
+	// Unset assumes the host matches (PLUGIN_HOST_SDK_VERSION).
+	HostSDKVersion string
+}
+
+// Load reads the configuration from the environment and from the file
+// PLUGIN_CONFIG_FILE names, whose settings take precedence. Unlike the
+// environment the file can change while the plugin runs, so it is re-read
+// on every Load.
+func Load() Config {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	declare("PLUGIN_CONFIG_FILE", "path", "", false)
+	fileValues = nil
+	if path := strings.TrimSpace(os.Getenv("PLUGIN_CONFIG_FILE")); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			log.Printf("⚠️  [hc-hello-world-plugin] Ignoring PLUGIN_CONFIG_FILE: %v", err)
+		}
+		fileValues = values
+	}
+	defer func() { fileValues = nil }()
+	return load()
+}
+
+func load() Config {
+	return Config{
+		DebugMode:   getBool("PLUGIN_DEBUG_MODE", false),
+		TemplateDir: getString("PLUGIN_TEMPLATE_DIR", "render/templates"),
+		IDStrategy:  getString("PLUGIN_ID_STRATEGY", "uuidv7"),
+		TokenSecret: getString("PLUGIN_TOKEN_SECRET", ""),
+		TokenTTL:    getDuration("PLUGIN_TOKEN_TTL", time.Hour),
+
+		SMTPHost:     getString("PLUGIN_SMTP_HOST", ""),
+		SMTPPort:     getInt("PLUGIN_SMTP_PORT", 587),
+		SMTPUsername: getString("PLUGIN_SMTP_USERNAME", ""),
+		SMTPPassword: getSecret("PLUGIN_SMTP_PASSWORD"),
+		SMTPFrom:     getString("PLUGIN_SMTP_FROM", ""),
+
+		WebhookURL:         getString("PLUGIN_WEBHOOK_URL", ""),
+		WebhookSecret:      getSecret("PLUGIN_WEBHOOK_SECRET"),
+		WebhookMaxAttempts: getInt("PLUGIN_WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookTimeout:     getDuration("PLUGIN_WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookHistory:     getInt("PLUGIN_WEBHOOK_HISTORY", 200),
+
+		HTTPFixturesMode: getString("PLUGIN_HTTP_FIXTURES_MODE", ""),
+		HTTPFixturesDir:  getString("PLUGIN_HTTP_FIXTURES_DIR", "testdata/http"),
+
+		ExternalAPIURL:     getString("PLUGIN_EXTERNAL_API_URL", ""),
+		ExternalAPITimeout: getDuration("PLUGIN_EXTERNAL_API_TIMEOUT", 10*time.Second),
+
+		ExternalAPIBreakerThreshold: getInt("PLUGIN_EXTERNAL_API_BREAKER_THRESHOLD", 5),
+		ExternalAPIBreakerCooldown:  getDuration("PLUGIN_EXTERNAL_API_BREAKER_COOLDOWN", 30*time.Second),
+		WeatherGeocodingURL:         getString("PLUGIN_WEATHER_GEOCODING_URL", "https://geocoding-api.open-meteo.com"),
+		WeatherForecastURL:          getString("PLUGIN_WEATHER_FORECAST_URL", "https://api.open-meteo.com"),
+		WeatherCacheTTL:             getDuration("PLUGIN_WEATHER_CACHE_TTL", 10*time.Minute),
+
+		OAuthTokenURL:     getString("PLUGIN_OAUTH_TOKEN_URL", ""),
+		OAuthClientID:     getString("PLUGIN_OAUTH_CLIENT_ID", ""),
+		OAuthClientSecret: getSecret("PLUGIN_OAUTH_CLIENT_SECRET"),
+		OAuthScopes:       getList("PLUGIN_OAUTH_SCOPES", nil),
+		OAuthAudience:     getString("PLUGIN_OAUTH_AUDIENCE", ""),
+
+		MaxPageSize:        getInt("PLUGIN_MAX_PAGE_SIZE", 100),
+		MaxItems:           getInt("PLUGIN_MAX_ITEMS", 1000),
+		MaxComplexity:      getInt("PLUGIN_MAX_COMPLEXITY", 1000),
+		MaxJSONDepth:       getInt("PLUGIN_MAX_JSON_DEPTH", 10),
+		MaxJSONBytes:       getInt("PLUGIN_MAX_JSON_BYTES", 16<<10),
+		MaxAvatarBytes:     getInt("PLUGIN_MAX_AVATAR_BYTES", 1<<20),
+		UploadMaxFileBytes: getInt("PLUGIN_UPLOAD_MAX_FILE_BYTES", 10<<20),
+		UploadMaxFiles:     getInt("PLUGIN_UPLOAD_MAX_FILES", 10),
+		UploadSessionDir:   getString("PLUGIN_UPLOAD_SESSION_DIR", ""),
+		UploadSessionTTL:   getDuration("PLUGIN_UPLOAD_SESSION_TTL", 24*time.Hour),
+		MutationIDWindow:   getDuration("PLUGIN_MUTATION_ID_WINDOW", 5*time.Minute),
+
+		CacheTTL:         getDuration("PLUGIN_CACHE_TTL", 30*time.Second),
+		CacheMaxEntries:  getInt("PLUGIN_CACHE_MAX_ENTRIES", 500),
+		CacheStaleWindow: getDuration("PLUGIN_CACHE_STALE_WINDOW", 0),
+
+		DBMaxOpenConns:     getInt("PLUGIN_DB_MAX_OPEN_CONNS", 10),
+		DBMaxIdleConns:     getInt("PLUGIN_DB_MAX_IDLE_CONNS", 2),
+		DBConnMaxLifetime:  getDuration("PLUGIN_DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBStatementTimeout: getDuration("PLUGIN_DB_STATEMENT_TIMEOUT", 10*time.Second),
+
+		RateLimitPerSecond: getFloat("PLUGIN_RATE_LIMIT_PER_SECOND", 0),
+		RateLimitBurst:     getInt("PLUGIN_RATE_LIMIT_BURST", 0),
+		QuotaMonthlyCalls:  getInt("PLUGIN_QUOTA_MONTHLY_CALLS", 0),
+		QuotaOverrides:     getList("PLUGIN_QUOTA_OVERRIDES", nil),
+
+		SentryDSN:         getSecret("PLUGIN_SENTRY_DSN"),
+		SentrySampleRate:  getFloat("PLUGIN_SENTRY_SAMPLE_RATE", 1),
+		SentryEnvironment: getString("PLUGIN_SENTRY_ENVIRONMENT", "production"),
+
+		SlowResolverThreshold:   getDuration("PLUGIN_SLOW_RESOLVER_THRESHOLD", 500*time.Millisecond),
+		RepeatedLookupThreshold: getInt("PLUGIN_REPEATED_LOOKUP_THRESHOLD", 2),
+
+		MemorySoftLimitMB:   getInt("PLUGIN_MEMORY_SOFT_LIMIT_MB", 0),
+		MemoryHardLimitMB:   getInt("PLUGIN_MEMORY_HARD_LIMIT_MB", 0),
+		MemoryCheckInterval: getDuration("PLUGIN_MEMORY_CHECK_INTERVAL", 5*time.Second),
+
+		SampleUsers:    getInt("PLUGIN_SAMPLE_USERS", 3),
+		SampleProducts: getInt("PLUGIN_SAMPLE_PRODUCTS", 3),
+		SampleComments: getInt("PLUGIN_SAMPLE_COMMENTS", 12),
+		SampleSeed:     getUint64("PLUGIN_SAMPLE_SEED", 0),
+
+		APIKeysRequired: getBool("PLUGIN_API_KEYS_REQUIRED", false),
+
+		AdminToken:     getSecret("PLUGIN_ADMIN_TOKEN"),
+		SnapshotPath:   getString("PLUGIN_SNAPSHOT_PATH", "snapshot.json"),
+		RestoreOnStart: getBool("PLUGIN_RESTORE_ON_START", false),
+
+		Datasource:              getString("PLUGIN_DATASOURCE", "memory"),
+		DatasourceDSN:           getSecret("PLUGIN_DATASOURCE_DSN"),
+		DatasourceFlushInterval: getDuration("PLUGIN_DATASOURCE_FLUSH_INTERVAL", time.Second),
+		StoreEncryptionKey:      getSecret("PLUGIN_STORE_ENCRYPTION_KEY"),
+		FieldEncryptionKey:      getSecret("PLUGIN_FIELD_ENCRYPTION_KEY"),
+		PIIRoles:                getList("PLUGIN_PII_ROLES", []string{"admin"}),
+		ReceiptSecret:           getSecret("PLUGIN_RECEIPT_SECRET"),
+
+		LogLevel:          getString("PLUGIN_LOG_LEVEL", "info"),
+		LogRedactPatterns: getList("PLUGIN_LOG_REDACT", logging.DefaultPatterns),
+		LogForward:        getBool("PLUGIN_LOG_FORWARD", false),
+
+		DependencyCheckInterval: getDuration("PLUGIN_DEPENDENCY_CHECK_INTERVAL", 30*time.Second),
+		RequiredDependencies:    getList("PLUGIN_REQUIRED_DEPENDENCIES", nil),
+		DependencyWait:          getDuration("PLUGIN_DEPENDENCY_WAIT", time.Minute),
+		HealthFile:              getString("PLUGIN_HEALTH_FILE", ""),
+		HealthInterval:          getDuration("PLUGIN_HEALTH_INTERVAL", 10*time.Second),
+		ReadyTimeout:            getDuration("PLUGIN_READY_TIMEOUT", 30*time.Second),
+		HandlerTimeout:          getDuration("PLUGIN_HANDLER_TIMEOUT", time.Minute),
+
+		Environment:    getString("PLUGIN_ENV", "development"),
+		EnabledGroups:  getList("PLUGIN_GROUPS_ENABLED", nil),
+		DisabledGroups: getList("PLUGIN_GROUPS_DISABLED", nil),
+
+		NamePrefix: getString("PLUGIN_NAME_PREFIX", ""),
+
+		HostSDKVersion: getString("PLUGIN_HOST_SDK_VERSION", ""),
+	}
+}
+
+// Key describes an environment variable read by Load
+type Key struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default,omitempty"`
+	Secret  bool   `json:"secret,omitempty"`
+}
+
+// keys records every variable the getters have looked up, so the list is
+// always exactly what Load reads
+var (
+	keysMu sync.Mutex
+	keys   = make(map[string]Key)
+)
+
+// declare records a lookup. getSecret declares its variables before reading
+// them through getString, so the first declaration wins.
+func declare(name, typ string, def interface{}, secret bool) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if _, seen := keys[name]; seen {
+		return
+	}
+	key := Key{Name: name, Type: typ, Secret: secret}
+	// Zero defaults are left out
+	if s := fmt.Sprint(def); s != "" && s != "0" && s != "0s" && s != "false" {
+		key.Default = s
+	}
+	keys[name] = key
+}
+
+// Keys returns the variables read by Load, sorted by name. Load must have
+// been called at least once.
+func Keys() []Key {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	result := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// fileValues holds PLUGIN_CONFIG_FILE's settings while Load runs; loadMu
+// serializes Loads so the getters see the right file
+var (
+	loadMu     sync.Mutex
+	fileValues map[string]string
+)
+
+// lookup returns key from the config file, or else from the environment
+func lookup(key string) (string, bool) {
+	if value, ok := fileValues[key]; ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
+
+// readFile parses KEY=VALUE lines; blank lines and lines starting with #
+// are skipped and values may be quoted
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		if !ok || key == "" {
+			log.Printf("⚠️  [hc-hello-world-plugin] %s:%d: expected KEY=VALUE", path, i+1)
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// getString returns the trimmed value of key, or def when unset
+func getString(key, def string) string {
+	declare(key, "string", def, false)
+	if value, ok := lookup(key); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return def
+}
+
+// getList splits a comma-separated value, dropping empty items; unset or
+// empty values fall back to def
+func getList(key string, def []string) []string {
+	declare(key, "list", strings.Join(def, ","), false)
+	var items []string
+	for _, item := range strings.Split(getString(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return def
+	}
+	return items
+}
+
+// getBool returns true for "true", "1" or "yes" (case-insensitive)
+func getBool(key string, def bool) bool {
+	declare(key, "bool", def, false)
+	value, ok := lookup(key)
+	if !ok {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// getDuration parses values like "30s" or "1h"; invalid values fall back to def
+func getDuration(key string, def time.Duration) time.Duration {
+	declare(key, "duration", def, false)
+	value, ok := lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		log.Printf("⚠️  [hc-hello-world-plugin] Invalid %s=%q, using %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// getInt parses an integer value; invalid values fall back to def
+func getInt(key string, def int) int {
+	declare(key, "int", def, false)
+	value, ok := lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Invalid %s=%q, using %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// getUint64 parses an unsigned integer value; invalid values fall back to def
+func getUint64(key string, def uint64) uint64 {
+	declare(key, "uint", def, false)
+	value, ok := lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Invalid %s=%q, using %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// getFloat parses a decimal value; invalid values fall back to def
+func getFloat(key string, def float64) float64 {
+	declare(key, "float", def, false)
+	value, ok := lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Invalid %s=%q, using %g", key, value, def)
+		return def
+	}
+	return f
+}
+
+// getSecret reads key directly, or from the file named by key_FILE so
+// secrets can be mounted instead of passed in the environment
+func getSecret(key string) string {
+	declare(key, "string", "", true)
+	declare(key+"_FILE", "path", "", false)
+	if value := getString(key, ""); value != "" {
+		return value
+	}
+	path := getString(key+"_FILE", "")
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Failed to read %s_FILE: %v", key, err)
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// secretFields are redacted from Diff so reloads never log credentials
+var secretFields = map[string]bool{
+	"TokenSecret":        true,
+	"SMTPPassword":       true,
+	"WebhookSecret":      true,
+	"OAuthClientSecret":  true,
+	"SentryDSN":          true,
+	"AdminToken":         true,
+	"DatasourceDSN":      true,
+	"StoreEncryptionKey": true,
+	"FieldEncryptionKey": true,
+	"ReceiptSecret":      true,
+}
+
+// Change is one setting that differs between two configurations
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Diff lists the fields that differ between before and after, in
+// declaration order. Secrets are shown only as set, unset or changed.
+func Diff(before, after Config) []Change {
+	var changes []Change
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < b.NumField(); i++ {
+		name := b.Type().Field(i).Name
+		if reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			continue
+		}
+		change := Change{Field: name, Before: fmt.Sprint(b.Field(i).Interface()), After: fmt.Sprint(a.Field(i).Interface())}
+		if secretFields[name] {
+			change.Before, change.After = redact(b.Field(i)), redact(a.Field(i))
+			if change.Before == change.After {
+				change.After = "(changed)"
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func redact(v reflect.Value) string {
+	if v.IsZero() {
+		return "(unset)"
+	}
+	return "(set)"
+}
@@ -0,0 +1,62 @@
+// Package consent names the purposes users are asked to consent to and
+// the error returned when processing a user needs a consent they have not
+// given. The decisions themselves are kept in the store (store.Consent);
+// resolvers check them before doing the processing a purpose covers.
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Purposes users consent to
+const (
+	// Marketing covers promotional emails such as sendMarketingEmail's
+	Marketing = "marketing"
+	// Location covers using a user's address coordinates, as nearbyUsers
+	// does to find them
+	Location = "location"
+	// Analytics covers including a user in usage analysis
+	Analytics = "analytics"
+)
+
+// descriptions explain each purpose, as shown to the user when asking
+var descriptions = map[string]string{
+	Marketing: "Receive promotional emails",
+	Location:  "Be found by others near the location of your address",
+	Analytics: "Have your usage included in product analytics",
+}
+
+// ErrRequired is returned, wrapped, when processing a user needs a consent
+// they have not granted or have revoked
+var ErrRequired = errors.New("consent required")
+
+// Required returns the error for userID lacking consent to purpose
+func Required(userID, purpose string) error {
+	return fmt.Errorf("user %s has not consented to %s: %w", userID, purpose, ErrRequired)
+}
+
+// Purposes returns the known purposes, sorted
+func Purposes() []string {
+	purposes := make([]string, 0, len(descriptions))
+	for purpose := range descriptions {
+		purposes = append(purposes, purpose)
+	}
+	sort.Strings(purposes)
+	return purposes
+}
+
+// Describe returns what purpose covers, or "" for unknown purposes
+func Describe(purpose string) string {
+	return descriptions[purpose]
+}
+
+// Validate rejects unknown purposes
+func Validate(purpose string) error {
+	if _, ok := descriptions[purpose]; !ok {
+		return fmt.Errorf("purpose must be one of %s", strings.Join(Purposes(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package csvimport streams CSV input row by row, mapping each record onto
+// the header so large files are never held in memory as a whole. Pipe
+// parses ahead of a slow consumer by a bounded number of rows.
+package csvimport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrMaxRows is returned when the input has more data rows than allowed
+var ErrMaxRows = errors.New("too many rows")
+
+// Row is one data record keyed by lowercased header name. Line is the
+// 1-based line number in the input (the header is line 1).
+type Row struct {
+	Line   int
+	Values map[string]string
+}
+
+// Get returns the trimmed value of column, or "" when absent
+func (r Row) Get(column string) string {
+	return strings.TrimSpace(r.Values[column])
+}
+
+// Base64Reader decodes standard base64 from s as it is read
+func Base64Reader(s string) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.TrimSpace(s)))
+}
+
+// Each reads the header, checks that all required columns are present, then
+// calls fn for every data row. Malformed rows are passed to onError and
+// skipped. Reading stops at the first error from fn, when ctx is cancelled,
+// or with ErrMaxRows once maxRows data rows have been read (0 = unlimited).
+func Each(ctx context.Context, r io.Reader, required []string, maxRows int, fn func(Row) error, onError func(line int, err error)) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("input is empty, expected a header row")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make([]string, len(header))
+	present := make(map[string]bool, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		present[columns[i]] = true
+	}
+	for _, column := range required {
+		if !present[column] {
+			return fmt.Errorf("header is missing required column %q", column)
+		}
+	}
+	// Rows may have fewer fields than the header; missing ones read as ""
+	reader.FieldsPerRecord = -1
+
+	rows := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				onError(parseErr.StartLine, parseErr.Err)
+				continue
+			}
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows++
+		if maxRows > 0 && rows > maxRows {
+			return fmt.Errorf("%w: the maximum is %d", ErrMaxRows, maxRows)
+		}
+
+		values := make(map[string]string, len(columns))
+		for i, value := range record {
+			if i < len(columns) {
+				values[columns[i]] = value
+			}
+		}
+		if err := fn(Row{Line: line, Values: values}); err != nil {
+			return err
+		}
+	}
+}
+
+// item is a parsed row, or a malformed one when err is set, on its way
+// from the parser to the consumer
+type item struct {
+	row Row
+	err error
+}
+
+// Pipe is Each with the parsing on its own goroutine: rows reach fn and
+// onError through a channel of buffer rows, in input order, on the calling
+// goroutine. The parser runs ahead of fn by at most buffer rows and then
+// waits, so a slow consumer bounds memory instead of letting rows pile up.
+// An error from fn stops the parser and is returned.
+func Pipe(ctx context.Context, r io.Reader, required []string, maxRows, buffer int, fn func(Row) error, onError func(line int, err error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan item, buffer)
+	parsed := make(chan error, 1)
+	send := func(it item) error {
+		select {
+		case items <- it:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	go func() {
+		defer close(items)
+		parsed <- Each(ctx, r, required, maxRows,
+			func(row Row) error { return send(item{row: row}) },
+			func(line int, err error) { send(item{row: Row{Line: line}, err: err}) })
+	}()
+
+	for it := range items {
+		if it.err != nil {
+			onError(it.row.Line, it.err)
+			continue
+		}
+		if err := fn(it.row); err != nil {
+			cancel()
+			for range items {
+			}
+			return err
+		}
+	}
+	return <-parsed
+}
+
+// Counter counts the bytes read through it, e.g. to report how far a
+// parser got. Bytes may be called while another goroutine reads.
+type Counter struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+// NewCounter counts the bytes read from r
+func NewCounter(r io.Reader) *Counter {
+	return &Counter{r: r}
+}
+
+func (c *Counter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// Bytes returns how many bytes have been read so far
+func (c *Counter) Bytes() int64 {
+	return c.n.Load()
+}
@@ -0,0 +1,92 @@
+// Package dbpool tunes database/sql connection pools from configuration and
+// collects their statistics for the metrics endpoint.
+package dbpool
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// DefaultStatementTimeout bounds a statement when Config.StatementTimeout
+// is unset
+const DefaultStatementTimeout = 10 * time.Second
+
+// Config sizes a connection pool; zero fields keep database/sql's defaults
+type Config struct {
+	// MaxOpenConns caps the open connections, idle or in use
+	MaxOpenConns int
+	// MaxIdleConns is how many idle connections are kept for reuse
+	MaxIdleConns int
+	// ConnMaxLifetime is how long a connection is reused before it is closed
+	ConnMaxLifetime time.Duration
+	// StatementTimeout bounds every statement run through the pool
+	StatementTimeout time.Duration
+}
+
+// Apply sets the pool limits on db
+func (c Config) Apply(db *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+}
+
+// Timeout returns StatementTimeout, or DefaultStatementTimeout when it is
+// unset
+func (c Config) Timeout() time.Duration {
+	if c.StatementTimeout <= 0 {
+		return DefaultStatementTimeout
+	}
+	return c.StatementTimeout
+}
+
+// Registry holds the pools reported by the metrics endpoint. It is safe for
+// concurrent use; the zero value is ready to use.
+type Registry struct {
+	mu    sync.Mutex
+	pools map[string]func() sql.DBStats
+}
+
+// Add reports the pool whose statistics stats returns under name, replacing
+// any pool already added with that name. *sql.DB's Stats method fits.
+func (r *Registry) Add(name string, stats func() sql.DBStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pools == nil {
+		r.pools = make(map[string]func() sql.DBStats)
+	}
+	r.pools[name] = stats
+}
+
+// Stats returns each pool's statistics keyed by name
+func (r *Registry) Stats() map[string]interface{} {
+	r.mu.Lock()
+	pools := make(map[string]func() sql.DBStats, len(r.pools))
+	for name, stats := range r.pools {
+		pools[name] = stats
+	}
+	r.mu.Unlock()
+
+	result := make(map[string]interface{}, len(pools))
+	for name, stats := range pools {
+		s := stats()
+		result[name] = map[string]interface{}{
+			"maxOpen":           s.MaxOpenConnections,
+			"open":              s.OpenConnections,
+			"inUse":             s.InUse,
+			"idle":              s.Idle,
+			"waitCount":         s.WaitCount,
+			"waitDuration":      s.WaitDuration.String(),
+			"maxIdleClosed":     s.MaxIdleClosed,
+			"maxIdleTimeClosed": s.MaxIdleTimeClosed,
+			"maxLifetimeClosed": s.MaxLifetimeClosed,
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package dbpool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// connector opens a pool without a real database; nothing here connects
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("no database")
+}
+
+func (connector) Driver() driver.Driver { return nil }
+
+func TestApplyAndReport(t *testing.T) {
+	db := sql.OpenDB(connector{})
+	defer db.Close()
+
+	Config{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute}.Apply(db)
+
+	var pools Registry
+	pools.Add("sqlite", db.Stats)
+	stats, ok := pools.Stats()["sqlite"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no stats for the added pool: %v", pools.Stats())
+	}
+	if stats["maxOpen"] != 7 {
+		t.Errorf("maxOpen = %v, want 7", stats["maxOpen"])
+	}
+}
+
+func TestZeroConfigKeepsDefaults(t *testing.T) {
+	db := sql.OpenDB(connector{})
+	defer db.Close()
+
+	Config{}.Apply(db)
+	if got := db.Stats().MaxOpenConnections; got != 0 {
+		t.Errorf("MaxOpenConnections = %d, want unlimited", got)
+	}
+	if got := (Config{}).Timeout(); got != DefaultStatementTimeout {
+		t.Errorf("Timeout() = %v, want %v", got, DefaultStatementTimeout)
+	}
+	if got := (Config{StatementTimeout: time.Second}).Timeout(); got != time.Second {
+		t.Errorf("Timeout() = %v, want 1s", got)
+	}
+}
@@ -0,0 +1,179 @@
+// Package email renders templated messages and delivers them over SMTP.
+package email
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/retry"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("email").Option("missingkey=error").ParseFS(templateFS, "templates/*.tmpl"))
+
+// ErrUnknownTemplate is returned when rendering a template that does not exist
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// SampleData holds example data for each template, for previewing it
+// without a real recipient
+var SampleData = map[string]map[string]interface{}{
+	"welcome": {"Name": "Jane Doe", "Username": "jane"},
+}
+
+// Config holds SMTP connection settings
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether enough settings are present to send real mail
+func (c Config) Enabled() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// Message is a rendered email ready to send
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender returns an SMTP sender when cfg is complete, otherwise a sender
+// that only logs messages so the plugin works without a mail server
+func NewSender(cfg Config) Sender {
+	if !cfg.Enabled() {
+		log.Printf("📭 [hc-hello-world-plugin] SMTP not configured - emails will be logged instead of sent")
+		return LogSender{}
+	}
+	log.Printf("📬 [hc-hello-world-plugin] SMTP configured: %s:%d (from %s)", cfg.Host, cfg.Port, cfg.From)
+	return &SMTPSender{cfg: cfg}
+}
+
+// Render executes the <name>.subject.tmpl and <name>.body.tmpl templates
+func Render(name, to string, data interface{}) (Message, error) {
+	if templates.Lookup(name+".body.tmpl") == nil {
+		return Message{}, fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+	}
+	var subject, body bytes.Buffer
+	if err := templates.ExecuteTemplate(&subject, name+".subject.tmpl", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := templates.ExecuteTemplate(&body, name+".body.tmpl", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s body: %w", name, err)
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    body.String(),
+	}, nil
+}
+
+// SMTPSender sends mail through an SMTP server, retrying transient failures
+type SMTPSender struct {
+	cfg Config
+}
+
+// Send delivers msg, retrying with backoff on failure
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	return retry.Do(ctx, retry.DefaultPolicy(), func(ctx context.Context) error {
+		// net/smtp has no context support, so honour cancellation between attempts
+		if err := ctx.Err(); err != nil {
+			return retry.Permanent(err)
+		}
+		return smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, s.buildMessage(msg))
+	})
+}
+
+func (s *SMTPSender) buildMessage(msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(s.cfg.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// headerValue strips line breaks so user data cannot inject extra headers
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+// LogSender writes messages to the log instead of sending them
+type LogSender struct{}
+
+// Send logs msg
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("📧 [hc-hello-world-plugin] (not sent) To: %s | Subject: %s\n%s", logging.MaskEmail(msg.To), msg.Subject, msg.Body)
+	return nil
+}
+
+// Probe returns a check that connects to the SMTP server, for monitoring
+// whether mail can be delivered
+func Probe(cfg Config) func(ctx context.Context) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("SMTP server %s unreachable: %w", addr, err)
+		}
+		return conn.Close()
+	}
+}
+
+// FallbackSender delivers through Primary while Available reports true and
+// through Fallback otherwise. When Primary fails the message still goes to
+// Fallback so it is not lost, the error is passed to OnFailure and returned.
+type FallbackSender struct {
+	Primary   Sender
+	Fallback  Sender
+	Available func() bool
+	OnFailure func(err error)
+}
+
+// Send delivers msg
+func (s *FallbackSender) Send(ctx context.Context, msg Message) error {
+	if !s.Available() {
+		return s.Fallback.Send(ctx, msg)
+	}
+	err := s.Primary.Send(ctx, msg)
+	if err == nil {
+		return nil
+	}
+	if s.OnFailure != nil {
+		s.OnFailure(err)
+	}
+	s.Fallback.Send(ctx, msg)
+	return err
+}
@@ -0,0 +1,258 @@
+// Package errreport sends panics and server-side handler errors to Sentry
+// or a service speaking its protocol. Each report carries the handler
+// name, an args fingerprint, the tenant and the release, never the args
+// themselves.
+//
+// Errors caused by the request, such as invalid arguments or unknown IDs,
+// are left out, and the remaining errors are sampled so a failing
+// dependency does not flood the service. Panics are always reported.
+package errreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/instrument"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/tracecontext"
+)
+
+// maxInFlight bounds the reports being sent at once; further reports are
+// dropped until one finishes
+const maxInFlight = 10
+
+// maxStackDepth is the most frames captured for a panic
+const maxStackDepth = 64
+
+// Config configures a Reporter
+type Config struct {
+	// DSN identifies the project reports are sent to; empty disables
+	// reporting
+	DSN string
+	// SampleRate is the fraction (0..1) of errors sent
+	SampleRate float64
+	// Release and Environment tag every report
+	Release     string
+	Environment string
+	// IsClientError reports errors caused by the request, which are never
+	// sent; nil treats every error as a server error
+	IsClientError func(error) bool
+	// Timeout bounds sending one report
+	Timeout time.Duration
+	// Doer sends the reports; nil uses a client limited to Timeout
+	Doer outbound.Doer
+	// Clock stamps the reports; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Report is one captured failure
+type Report struct {
+	// Handler is the resolver or function name, or "METHOD /path"
+	Handler string
+	// Fingerprint is the instrument.Fingerprint of the handler's args
+	Fingerprint string
+	Tenant      string
+	Err         error
+	// Stack is set for panics, innermost call first
+	Stack []uintptr
+	Trace tracecontext.Context
+}
+
+// Panicked reports whether the failure was a panic
+func (r Report) Panicked() bool {
+	return r.Stack != nil
+}
+
+// Reporter sends reports in the background
+type Reporter struct {
+	cfg      Config
+	dsn      DSN
+	hostname string
+	slots    chan struct{}
+}
+
+// New creates a reporter. An empty DSN returns a disabled reporter; an
+// invalid one returns a disabled reporter and the error.
+func New(cfg Config) (*Reporter, error) {
+	r := &Reporter{cfg: cfg}
+	if cfg.DSN == "" {
+		return r, nil
+	}
+	dsn, err := ParseDSN(cfg.DSN)
+	if err != nil {
+		return r, err
+	}
+	if r.cfg.Doer == nil {
+		r.cfg.Doer = &http.Client{Timeout: cfg.Timeout}
+	}
+	r.cfg.Clock = clock.OrSystem(cfg.Clock)
+	r.dsn = dsn
+	r.hostname, _ = os.Hostname()
+	r.slots = make(chan struct{}, maxInFlight)
+	return r, nil
+}
+
+// Enabled reports whether reports are sent
+func (r *Reporter) Enabled() bool {
+	return r.dsn.StoreURL != ""
+}
+
+// Resolver reports panics and server errors of handler. A panic is turned
+// into an error so one bad call does not take the plugin down. REST
+// envelopes with a 5xx status count as errors too. It has the
+// registry.Middleware signature, so it covers resolvers, REST handlers and
+// functions alike; when reporting is disabled handler is returned as is.
+func (r *Reporter) Resolver(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	if !r.Enabled() {
+		return handler
+	}
+	return func(ctx context.Context, args map[string]interface{}) (result interface{}, err error) {
+		report := func(err error, stack []uintptr) {
+			rep := Report{
+				Handler:     name,
+				Fingerprint: instrument.Fingerprint(args),
+				Tenant:      sdk.GetTenantID(args),
+				Err:         err,
+				Stack:       stack,
+			}
+			if rep.Tenant == "" {
+				rep.Tenant = sdk.GetTenantIDFromContext(ctx)
+			}
+			rep.Trace, _ = tracecontext.FromContext(ctx)
+			r.Capture(rep)
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				// Skip runtime.Callers, this function and runtime.gopanic
+				pcs := make([]uintptr, maxStackDepth)
+				pcs = pcs[:runtime.Callers(3, pcs)]
+				report(fmt.Errorf("panic: %v", p), pcs)
+				result, err = nil, fmt.Errorf("internal error in %s", name)
+			}
+		}()
+
+		result, err = handler(ctx, args)
+		if err != nil {
+			report(err, nil)
+		} else if status, message := restStatus(result); status >= 500 {
+			report(fmt.Errorf("status %d: %s", status, message), nil)
+		}
+		return result, err
+	}
+}
+
+// restStatus returns the status and error message of a REST envelope
+func restStatus(result interface{}) (int, string) {
+	envelope, ok := result.(map[string]interface{})
+	if !ok {
+		return 0, ""
+	}
+	status, _ := envelope["statusCode"].(int)
+	message := ""
+	if body, ok := envelope["body"].(map[string]interface{}); ok {
+		message, _ = body["error"].(string)
+	}
+	return status, message
+}
+
+// Capture sends rep in the background unless it is a client error or
+// sampled out, and reports whether it was sent
+func (r *Reporter) Capture(rep Report) bool {
+	if !r.Enabled() {
+		return false
+	}
+	if !rep.Panicked() {
+		if r.cfg.IsClientError != nil && r.cfg.IsClientError(rep.Err) {
+			return false
+		}
+		if rand.Float64() >= r.cfg.SampleRate {
+			return false
+		}
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		log.Printf("⚠️  [hc-hello-world-plugin] event=error_report_dropped handler=%s reason=%q", rep.Handler, "too many reports in flight")
+		return false
+	}
+
+	event := r.event(rep)
+	go func() {
+		defer func() { <-r.slots }()
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+		defer cancel()
+		if err := send(ctx, r.cfg.Doer, r.cfg.Clock.Now(), r.dsn, "hc-hello-world-plugin/"+r.cfg.Release, event); err != nil {
+			log.Printf("⚠️  [hc-hello-world-plugin] event=error_report_failed handler=%s event_id=%s error=%q", rep.Handler, event.EventID, err)
+			return
+		}
+		log.Printf("🚨 [hc-hello-world-plugin] event=error_reported handler=%s event_id=%s panic=%t", rep.Handler, event.EventID, rep.Panicked())
+	}()
+	return true
+}
+
+func (r *Reporter) event(rep Report) sentryEvent {
+	exception := sentryException{
+		Type:      errorType(rep.Err),
+		Value:     rep.Err.Error(),
+		Mechanism: sentryMechanism{Type: "generic", Handled: true},
+	}
+	level := "error"
+	if rep.Panicked() {
+		exception.Type = "panic"
+		exception.Mechanism = sentryMechanism{Type: "recover", Handled: false}
+		exception.Stacktrace = stacktrace(rep.Stack)
+		level = "fatal"
+	}
+
+	event := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   r.cfg.Clock.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "hc-hello-world-plugin",
+		Release:     r.cfg.Release,
+		Environment: r.cfg.Environment,
+		ServerName:  r.hostname,
+		Transaction: rep.Handler,
+		Tags: map[string]string{
+			"handler":          rep.Handler,
+			"args_fingerprint": rep.Fingerprint,
+		},
+		Exception: &sentryExceptions{Values: []sentryException{exception}},
+	}
+	if rep.Tenant != "" {
+		event.Tags["tenant"] = rep.Tenant
+	}
+	if rep.Trace.Valid() {
+		event.Contexts = map[string]interface{}{
+			"trace": map[string]string{"trace_id": rep.Trace.TraceID, "span_id": rep.Trace.ParentID},
+		}
+	}
+	return event
+}
+
+// errorType names the type of the innermost error err wraps; plain
+// errors.New and fmt.Errorf errors are just "error"
+func errorType(err error) string {
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(err) {
+		err = next
+	}
+	switch typ := fmt.Sprintf("%T", err); typ {
+	case "*errors.errorString", "*fmt.wrapError", "*fmt.wrapErrors":
+		return "error"
+	default:
+		return typ
+	}
+}
@@ -0,0 +1,173 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/outbound"
+)
+
+// sentryVersion is the Sentry protocol version spoken by send
+const sentryVersion = "7"
+
+// appModule prefixes the plugin's own packages, whose frames are in-app
+const appModule = "hc-hello-world-plugin/"
+
+// DSN is a parsed Sentry DSN such as https://key@o1.ingest.sentry.io/42
+type DSN struct {
+	PublicKey string
+	// StoreURL is the project's event endpoint
+	StoreURL string
+}
+
+// ParseDSN validates a DSN and derives its event endpoint. Self-hosted DSNs
+// with a path prefix, e.g. https://key@host/sentry/42, are supported.
+func ParseDSN(raw string) (DSN, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return DSN{}, fmt.Errorf("invalid DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return DSN{}, errors.New("invalid DSN: scheme must be http or https")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return DSN{}, errors.New("invalid DSN: public key is missing")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if project == "" {
+		return DSN{}, errors.New("invalid DSN: project ID is missing")
+	}
+	endpoint := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path[:slash] + "/api/" + project + "/store/"}
+	return DSN{PublicKey: u.User.Username(), StoreURL: endpoint.String()}, nil
+}
+
+// sentryEvent is the subset of the Sentry event payload the plugin sends
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Transaction string                 `json:"transaction,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+	Contexts    map[string]interface{} `json:"contexts,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Mechanism  sentryMechanism   `json:"mechanism"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryMechanism struct {
+	Type    string `json:"type"`
+	Handled bool   `json:"handled"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// stacktrace converts program counters to frames, oldest call first as
+// Sentry expects
+func stacktrace(pcs []uintptr) *sentryStacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []sentryFrame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		module, function := splitFunction(frame.Function)
+		frames = append(frames, sentryFrame{
+			Function: function,
+			Module:   module,
+			Filename: frame.File,
+			Lineno:   frame.Line,
+			InApp:    module == "main" || strings.HasPrefix(module, appModule),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &sentryStacktrace{Frames: frames}
+}
+
+// splitFunction splits "hc-hello-world-plugin/store.(*Store).Get" into its
+// package path and function name
+func splitFunction(name string) (string, string) {
+	lastSlash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[lastSlash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += lastSlash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// newEventID returns 32 random hex digits
+func newEventID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// send posts event to the DSN's store endpoint; sentAt is the time the
+// request claims to be sent
+func send(ctx context.Context, client outbound.Doer, sentAt time.Time, dsn DSN, clientName string, event sentryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dsn.StoreURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=%s, sentry_client=%s, sentry_timestamp=%d, sentry_key=%s",
+		sentryVersion, clientName, sentAt.Unix(), dsn.PublicKey))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
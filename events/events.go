@@ -0,0 +1,136 @@
+// Package events publishes the store's changes as named domain events such
+// as UserCreated or ProductUpdated to in-process subscribers, along with
+// the progress of bulk operations.
+package events
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/store"
+)
+
+// All subscribes to every event
+const All = "*"
+
+// Event names for the store's entities
+const (
+	UserCreated       = "UserCreated"
+	UserDeleted       = "UserDeleted"
+	ProductCreated    = "ProductCreated"
+	ProductUpdated    = "ProductUpdated"
+	GroupCreated      = "GroupCreated"
+	MembershipCreated = "MembershipCreated"
+	MembershipDeleted = "MembershipDeleted"
+	CommentCreated    = "CommentCreated"
+)
+
+// Events for bulk operations, which change nothing in the store; ID is the
+// operation's ID and Data holds its counts
+const (
+	BulkTagsProgress  = "BulkTagsProgress"
+	BulkTagsCompleted = "BulkTagsCompleted"
+)
+
+// Event is one change to a stored record
+type Event struct {
+	Name   string
+	Entity string
+	// ID is the record ID; memberships use "userID:groupID"
+	ID  string
+	Op  string
+	Seq uint64
+	At  time.Time
+	// Data holds the details of events that are not store changes
+	Data map[string]interface{}
+}
+
+// FromChange names a store change, e.g. a created user becomes UserCreated
+func FromChange(c store.Change) Event {
+	return Event{
+		Name:   title(c.Entity) + title(c.Op),
+		Entity: c.Entity,
+		ID:     c.ID,
+		Op:     c.Op,
+		Seq:    c.Seq,
+		At:     c.At,
+	}
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Tag is the cache tag for one record, or for every record of an entity
+// when id is empty; results listing an entity carry the bare entity tag
+func Tag(entity, id string) string {
+	if id == "" {
+		return entity
+	}
+	return entity + ":" + id
+}
+
+// Tags returns the cache tags whose results e makes stale: the record
+// itself and every list of its entity. A membership also changes both the
+// user's and the group's records. Events without an entity make nothing
+// stale.
+func Tags(e Event) []string {
+	if e.Entity == "" {
+		return nil
+	}
+	tags := []string{Tag(e.Entity, ""), Tag(e.Entity, e.ID)}
+	if e.Entity == store.EntityMembership {
+		if userID, groupID, ok := strings.Cut(e.ID, ":"); ok {
+			tags = append(tags, Tag(store.EntityUser, userID), Tag(store.EntityGroup, groupID))
+		}
+	}
+	return tags
+}
+
+// Handler receives published events
+type Handler func(Event)
+
+// Bus delivers events to subscribers synchronously, in subscription order
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe calls h for every event named name, or for every event when
+// name is All
+func (b *Bus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], h)
+}
+
+// Publish delivers e to its subscribers before returning. A panicking
+// handler is logged and does not stop the others.
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[e.Name]...), b.handlers[All]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		deliver(h, e)
+	}
+}
+
+func deliver(h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ [hc-hello-world-plugin] event=handler_panic name=%s id=%s panic=%q", e.Name, e.ID, r)
+		}
+	}()
+	h(e)
+}
@@ -0,0 +1,235 @@
+// Package extapi is the client for the external HTTP APIs the plugin
+// integrates with. Requests carry the caller's trace context and, when
+// OAuth2 client credentials are configured, an access token. Failed
+// requests are retried with backoff, a circuit breaker stops calling an
+// API that keeps failing, and successful responses are cached.
+package extapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/breaker"
+	"hc-hello-world-plugin/cache"
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/oauth"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/retry"
+	"hc-hello-world-plugin/tracecontext"
+)
+
+// ErrNotConfigured is returned when no base URL is configured
+var ErrNotConfigured = errors.New("external API is not configured")
+
+// maxResponseBody bounds the responses read from the API
+const maxResponseBody = 1 << 20
+
+// maxErrorBody is how much of an error response is kept in StatusError
+const maxErrorBody = 512
+
+// Config configures a Client
+type Config struct {
+	// Name identifies the API in logs and errors
+	Name string
+	// BaseURL prefixes every request path; the client is disabled when empty
+	BaseURL string
+	// Timeout bounds each request
+	Timeout time.Duration
+	// Tokens authorizes requests; nil or disabled sends them without a token
+	Tokens *oauth.TokenSource
+	// Policy retries requests that fail with a network error, 408, 429 or 5xx
+	Policy retry.Policy
+	// BreakerThreshold consecutive failed requests open the circuit breaker
+	// for BreakerCooldown; 0 disables it
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// CacheTTL is how long successful responses are reused, keyed by URL;
+	// 0 disables caching
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+	// Doer sends the requests; nil uses a plain *http.Client
+	Doer outbound.Doer
+	// Clock times the circuit breaker and the cache; nil uses the system
+	// clock
+	Clock clock.Clock
+}
+
+// Client calls one external API
+type Client struct {
+	name    string
+	base    *url.URL
+	doer    outbound.Doer
+	timeout time.Duration
+	policy  retry.Policy
+	breaker *breaker.Breaker
+	cache   *cache.Cache
+}
+
+// StatusError is returned for responses outside 2xx
+type StatusError struct {
+	Code int
+	// Body is the start of the response body
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("external API responded with status %d", e.Code)
+	}
+	return fmt.Sprintf("external API responded with status %d: %s", e.Code, e.Body)
+}
+
+// New creates a client. An empty base URL returns a disabled client; an
+// invalid one returns a disabled client and the error.
+func New(cfg Config) (*Client, error) {
+	c := &Client{name: cfg.Name}
+	if cfg.BaseURL == "" {
+		return c, nil
+	}
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return c, fmt.Errorf("invalid %s URL %q", cfg.Name, cfg.BaseURL)
+	}
+
+	doer := cfg.Doer
+	if doer == nil {
+		doer = &http.Client{}
+	}
+	if cfg.Tokens != nil && cfg.Tokens.Enabled() {
+		doer = cfg.Tokens.Doer(doer)
+	}
+	c.base = base
+	c.doer = tracecontext.Doer(doer)
+	c.timeout = cfg.Timeout
+	c.policy = cfg.Policy
+	c.breaker = breaker.New(breaker.Config{
+		Threshold: cfg.BreakerThreshold,
+		Cooldown:  cfg.BreakerCooldown,
+		Clock:     cfg.Clock,
+		OnStateChange: func(from, to string) {
+			log.Printf("🔌 [hc-hello-world-plugin] event=circuit_breaker api=%s from=%s to=%s", cfg.Name, from, to)
+		},
+	})
+	if cfg.CacheTTL > 0 {
+		c.cache = cache.New(cfg.CacheTTL, max(cfg.CacheMaxEntries, 1)).WithClock(cfg.Clock)
+	}
+	return c, nil
+}
+
+// Enabled reports whether a base URL is configured
+func (c *Client) Enabled() bool {
+	return c.base != nil
+}
+
+// BreakerState returns the circuit breaker's state, see package breaker
+func (c *Client) BreakerState() string {
+	if !c.Enabled() {
+		return breaker.Closed
+	}
+	return c.breaker.State()
+}
+
+// CacheStats returns the response cache's counters
+func (c *Client) CacheStats() cache.Stats {
+	if c.cache == nil {
+		return cache.Stats{}
+	}
+	return c.cache.Stats()
+}
+
+// GetJSON requests path, relative to the base URL, with query and decodes
+// the JSON response into out. A cached response is used while fresh.
+func (c *Client) GetJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if !c.Enabled() {
+		return fmt.Errorf("%w: %s", ErrNotConfigured, c.name)
+	}
+	target := *c.base
+	target.Path = c.base.Path + "/" + strings.TrimPrefix(path, "/")
+	target.RawQuery = query.Encode()
+	key := target.String()
+
+	if c.cache != nil {
+		if cached, ok := c.cache.Get(key); ok {
+			return decode(cached.([]byte), out)
+		}
+	}
+
+	var body []byte
+	err := retry.Do(ctx, c.policy, func(ctx context.Context) error {
+		err := c.breaker.Do(func() error {
+			var err error
+			body, err = c.get(ctx, key)
+			return err
+		}, failed)
+		switch {
+		case errors.Is(err, breaker.ErrOpen):
+			return retry.Permanent(fmt.Errorf("%s: %w", c.name, err))
+		case err != nil && !failed(err):
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if c.cache != nil {
+		c.cache.Set(key, body)
+	}
+	return decode(body, out)
+}
+
+// get makes one request and returns the body of a 2xx response
+func (c *Client) get(ctx context.Context, target string) ([]byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// failed reports whether err means the API is struggling: a network
+// error, a timeout, throttling or a server error. Other client errors mean
+// the request was wrong and are neither retried nor counted by the breaker,
+// and neither is a request no fixture was recorded for.
+func failed(err error) bool {
+	var status *StatusError
+	if !errors.As(err, &status) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, outbound.ErrNoFixture)
+	}
+	return status.Code >= 500 || status.Code == http.StatusRequestTimeout || status.Code == http.StatusTooManyRequests
+}
+
+func decode(body []byte, out interface{}) error {
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response from external API: %w", err)
+	}
+	return nil
+}
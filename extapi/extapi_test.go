@@ -0,0 +1,114 @@
+package extapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/breaker"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/retry"
+)
+
+// The fixtures in testdata were recorded in the format outbound.Recorder
+// writes; the client is tested against them without any network access.
+
+type forecast struct {
+	City string `json:"city"`
+	Days []struct {
+		Date  string  `json:"date"`
+		HighC float64 `json:"highC"`
+	} `json:"days"`
+}
+
+// countingDoer counts the requests sent through it
+type countingDoer struct {
+	next  outbound.Doer
+	calls int
+}
+
+func (d *countingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.calls++
+	return d.next.Do(req)
+}
+
+func newReplayClient(t *testing.T) (*Client, *countingDoer) {
+	t.Helper()
+	doer := &countingDoer{next: outbound.NewReplayer("testdata")}
+	client, err := New(Config{
+		Name:             "forecast API",
+		BaseURL:          "https://api.example.com/v1/",
+		Timeout:          time.Second,
+		Policy:           retry.Policy{MaxAttempts: 3, Multiplier: 1},
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+		CacheTTL:         time.Minute,
+		CacheMaxEntries:  10,
+		Doer:             doer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, doer
+}
+
+func TestGetJSONFromFixture(t *testing.T) {
+	client, doer := newReplayClient(t)
+
+	var out forecast
+	query := url.Values{"city": {"Paris"}, "days": {"2"}}
+	if err := client.GetJSON(context.Background(), "/forecast", query, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.City != "Paris" || len(out.Days) != 2 || out.Days[0].HighC != 8.5 {
+		t.Errorf("decoded %+v", out)
+	}
+
+	// The second call is served from the cache
+	if err := client.GetJSON(context.Background(), "forecast", query, &out); err != nil {
+		t.Fatal(err)
+	}
+	if doer.calls != 1 {
+		t.Errorf("sent %d requests, want 1", doer.calls)
+	}
+	if stats := client.CacheStats(); stats.Hits != 1 {
+		t.Errorf("cache hits %d, want 1", stats.Hits)
+	}
+}
+
+func TestClientErrorFromFixtureIsNotRetried(t *testing.T) {
+	client, doer := newReplayClient(t)
+
+	err := client.GetJSON(context.Background(), "forecast", url.Values{"city": {"Atlantis"}, "days": {"2"}}, &forecast{})
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusNotFound {
+		t.Fatalf("got %v, want a 404 StatusError", err)
+	}
+	if status.Body != `{"error":"unknown city"}` {
+		t.Errorf("error body %q", status.Body)
+	}
+	if doer.calls != 1 {
+		t.Errorf("sent %d requests, want 1", doer.calls)
+	}
+	if state := client.BreakerState(); state != breaker.Closed {
+		t.Errorf("breaker %s after a client error, want closed", state)
+	}
+}
+
+func TestMissingFixtureIsNotRetried(t *testing.T) {
+	client, doer := newReplayClient(t)
+
+	err := client.GetJSON(context.Background(), "forecast", url.Values{"city": {"Oslo"}}, &forecast{})
+	if !errors.Is(err, outbound.ErrNoFixture) {
+		t.Fatalf("got %v, want ErrNoFixture", err)
+	}
+	if doer.calls != 1 {
+		t.Errorf("sent %d requests, want 1", doer.calls)
+	}
+	if state := client.BreakerState(); state != breaker.Closed {
+		t.Errorf("breaker %s after a missing fixture, want closed", state)
+	}
+}
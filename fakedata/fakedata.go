@@ -0,0 +1,197 @@
+// Package fakedata fills the store with realistic generated users and
+// products. A non-zero seed makes the output fully deterministic for a given
+// base time, so tests and screenshots stay stable.
+package fakedata
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+
+	"hc-hello-world-plugin/consent"
+	"hc-hello-world-plugin/money"
+	"hc-hello-world-plugin/store"
+)
+
+// Config controls how much data is generated
+type Config struct {
+	// Users and Products are the number of records to generate
+	Users    int
+	Products int
+	// Comments are spread over a few posts as threaded discussions; they
+	// need at least one user to author them
+	Comments int
+	// Seed makes generation reproducible; 0 picks a random seed
+	Seed uint64
+}
+
+// commentPosts is the number of posts generated comments are spread over
+const commentPosts = 3
+
+var (
+	departments = []string{"engineering", "design", "marketing", "sales", "support", "finance"}
+	levels      = []string{"junior", "mid", "senior", "lead"}
+	// consentPurposes are granted to about two in three users each
+	consentPurposes = []string{consent.Analytics, consent.Location, consent.Marketing}
+)
+
+// Seed generates cfg.Users users and cfg.Products products into s. IDs are
+// sequential ("1", "2", ...) and creation times fall within the 90 days
+// before now.
+func Seed(s store.Datasource, cfg Config, now time.Time) error {
+	faker := gofakeit.New(cfg.Seed)
+
+	// One group per department; every user joins their department's group
+	groupIDs := make(map[string]string, len(departments))
+	for i, department := range departments {
+		id := strconv.Itoa(i + 1)
+		if _, err := s.CreateGroup(store.Group{
+			ID:          id,
+			Name:        department,
+			Description: "Everyone in " + department,
+			CreatedAt:   now.Add(-90 * 24 * time.Hour).UTC(),
+		}); err != nil {
+			return fmt.Errorf("failed to seed group %s: %w", department, err)
+		}
+		groupIDs[department] = id
+	}
+
+	for i := 1; i <= cfg.Users; i++ {
+		u, err := s.CreateUser(user(faker, strconv.Itoa(i), now))
+		if err != nil {
+			return fmt.Errorf("failed to seed user %d: %w", i, err)
+		}
+		for _, tag := range u.Tags {
+			if groupID, ok := groupIDs[tag.Val]; ok && tag.Key == "department" {
+				if _, err := s.AddUserToGroup(u.ID, groupID); err != nil {
+					return fmt.Errorf("failed to seed membership of user %d: %w", i, err)
+				}
+			}
+		}
+	}
+	for i := 1; i <= cfg.Products; i++ {
+		s.PutProduct(product(faker, strconv.Itoa(i)))
+	}
+
+	comments := 0
+	if cfg.Users > 0 {
+		comments = cfg.Comments
+	}
+	for i := 1; i <= comments; i++ {
+		if _, err := s.AddComment(comment(faker, s, strconv.Itoa(i), cfg.Users, now)); err != nil {
+			return fmt.Errorf("failed to seed comment %d: %w", i, err)
+		}
+	}
+
+	// Consents are drawn last, so a seed still generates the same records
+	// as before they existed
+	for i := 1; i <= cfg.Users; i++ {
+		for _, purpose := range consentPurposes {
+			if faker.IntN(3) == 0 {
+				continue
+			}
+			if _, err := s.SetConsent(store.Consent{
+				UserID:    strconv.Itoa(i),
+				Purpose:   purpose,
+				Granted:   true,
+				Source:    "sample data",
+				UpdatedAt: now.UTC(),
+			}); err != nil {
+				return fmt.Errorf("failed to seed consent of user %d: %w", i, err)
+			}
+		}
+	}
+
+	log.Printf("🌱 [hc-hello-world-plugin] Generated %d users, %d products, %d groups and %d comments (seed=%d)", cfg.Users, cfg.Products, len(departments), comments, cfg.Seed)
+	return nil
+}
+
+// user builds one user. The sequential id is appended to the handle so
+// handles and emails are unique however many users are generated.
+func user(faker *gofakeit.Faker, id string, now time.Time) store.User {
+	first, last := faker.FirstName(), faker.LastName()
+	base := slug(first+"."+last, 24)
+	if base == "" {
+		base = "user"
+	}
+	handle := base + id
+	return store.User{
+		ID:       id,
+		Name:     first + " " + last,
+		Email:    handle + "@example.com",
+		Username: handle,
+		Address: &store.Address{
+			Street: faker.Street(),
+			City:   faker.City(),
+			State:  faker.StateAbr(),
+			Zip:    faker.Zip(),
+			// Addresses are US-style, so keep coordinates within the
+			// contiguous United States where nearbyUsers will find neighbours
+			Latitude:  ptr(faker.Float64Range(25, 49)),
+			Longitude: ptr(faker.Float64Range(-124, -67)),
+		},
+		Tags: []store.Tag{
+			{Key: "department", Val: faker.RandomString(departments)},
+			{Key: "level", Val: faker.RandomString(levels)},
+		},
+		// Roughly four in five users are active
+		Active:    faker.IntN(5) != 0,
+		CreatedAt: faker.DateRange(now.Add(-90*24*time.Hour), now).UTC(),
+	}
+}
+
+func product(faker *gofakeit.Faker, id string) store.Product {
+	cents := int64(math.Round(faker.Price(1, 2000) * 100))
+	return store.Product{
+		ID:          id,
+		Name:        faker.ProductName(),
+		Description: faker.ProductDescription(),
+		Price:       money.MustNew(cents, "USD"),
+		Stock:       faker.IntRange(0, 500),
+		Tags:        []string{strings.ToLower(faker.Adjective()), strings.ToLower(faker.Noun())},
+		Categories:  []string{faker.ProductCategory(), faker.ProductCategory()},
+	}
+}
+
+// comment builds one comment on a random post. About a third start a new
+// thread; the rest reply to an earlier comment on the same post, which
+// produces threads of varying depth.
+func comment(faker *gofakeit.Faker, s store.Datasource, id string, users int, now time.Time) store.Comment {
+	postID := strconv.Itoa(faker.IntRange(1, commentPosts))
+	parentID := ""
+	if existing := s.CommentsForPost(postID); len(existing) > 0 && faker.IntN(3) != 0 {
+		parentID = existing[faker.IntN(len(existing))].ID
+	}
+	return store.Comment{
+		ID:        id,
+		PostID:    postID,
+		ParentID:  parentID,
+		AuthorID:  strconv.Itoa(faker.IntRange(1, users)),
+		Body:      faker.Sentence(faker.IntRange(4, 16)),
+		CreatedAt: faker.DateRange(now.Add(-7*24*time.Hour), now).UTC(),
+	}
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+// slug lowercases s and keeps only characters valid in a handle, truncated
+// to max characters
+func slug(s string, max int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || (r == '.' && b.Len() > 0) {
+			b.WriteRune(r)
+		}
+		if b.Len() >= max {
+			break
+		}
+	}
+	return b.String()
+}
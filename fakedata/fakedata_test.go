@@ -0,0 +1,46 @@
+package fakedata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/store"
+)
+
+func seeded(t *testing.T, seed uint64) store.Snapshot {
+	t.Helper()
+	s := store.New()
+	cfg := Config{Users: 20, Products: 10, Comments: 15, Seed: seed}
+	if err := Seed(s, cfg, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	return s.Snapshot()
+}
+
+func TestSameSeedGeneratesSameData(t *testing.T) {
+	first, second := seeded(t, 42), seeded(t, 42)
+
+	if len(first.Users) != 20 || len(first.Products) != 10 {
+		t.Fatalf("generated %d users and %d products, want 20 and 10", len(first.Users), len(first.Products))
+	}
+	if !reflect.DeepEqual(first.Users, second.Users) {
+		t.Error("users differ between runs with the same seed")
+	}
+	if !reflect.DeepEqual(first.Products, second.Products) {
+		t.Error("products differ between runs with the same seed")
+	}
+	if !reflect.DeepEqual(first.Comments, second.Comments) {
+		t.Error("comments differ between runs with the same seed")
+	}
+	if !reflect.DeepEqual(first.Consents, second.Consents) {
+		t.Error("consents differ between runs with the same seed")
+	}
+}
+
+func TestDifferentSeedsGenerateDifferentData(t *testing.T) {
+	first, second := seeded(t, 1), seeded(t, 2)
+	if reflect.DeepEqual(first.Users, second.Users) {
+		t.Error("seeds 1 and 2 generated the same users")
+	}
+}
@@ -0,0 +1,57 @@
+package files
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidBase64 is returned for payloads that are not base64
+var ErrInvalidBase64 = errors.New("invalid base64 payload")
+
+// Base64Reader decodes a base64 payload as it is read. GraphQL has no binary
+// type, so files travel through arguments as base64 strings. Standard and
+// URL-safe alphabets are accepted, with or without padding, as are data
+// URLs ("data:image/png;base64,..."), whose media type is returned.
+// Whitespace such as MIME line breaks is ignored.
+func Base64Reader(payload string) (r io.Reader, mediaType string, err error) {
+	payload = strings.TrimSpace(payload)
+	if rest, ok := strings.CutPrefix(payload, "data:"); ok {
+		header, data, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, "", errors.New("data URL must be base64 encoded")
+		}
+		mediaType, payload = strings.TrimSuffix(header, ";base64"), data
+	}
+
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	if payload == "" {
+		return nil, "", ErrInvalidBase64
+	}
+
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(payload, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(payload, "=") && len(payload)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	return base64.NewDecoder(encoding, strings.NewReader(payload)), mediaType, nil
+}
+
+// Base64Error rewords the decoder's error for malformed base64, which
+// surfaces while a Base64Reader is read; other errors are returned as is
+func Base64Error(err error) error {
+	var corrupt base64.CorruptInputError
+	if errors.As(err, &corrupt) {
+		return fmt.Errorf("%w: bad data at byte %d", ErrInvalidBase64, int64(corrupt))
+	}
+	return err
+}
@@ -0,0 +1,275 @@
+// Package files stores uploaded files such as avatars. Contents are written
+// to a Driver while they are read, so large uploads are never held in
+// memory twice; metadata and checksums are kept alongside.
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrNotFound is returned for unknown file IDs
+var ErrNotFound = errors.New("file not found")
+
+// ErrTooLarge is returned when an upload exceeds its size limit
+var ErrTooLarge = errors.New("file too large")
+
+// Driver persists file contents by ID
+type Driver interface {
+	Put(ctx context.Context, id string, r io.Reader) error
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// File is a stored file's metadata
+type File struct {
+	ID string `json:"id"`
+	// Owner is the ID of the record the file belongs to, e.g. a user
+	Owner       string    `json:"owner,omitempty"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Store keeps file metadata and writes contents through its driver
+type Store struct {
+	driver Driver
+	clock  clock.Clock
+
+	mu    sync.RWMutex
+	files map[string]File
+}
+
+// NewStore creates a store writing contents to driver
+func NewStore(driver Driver) *Store {
+	return &Store{driver: driver, clock: clock.System, files: make(map[string]File)}
+}
+
+// WithClock replaces the system clock that stamps saved files; call it
+// before the store is used
+func (s *Store) WithClock(c clock.Clock) *Store {
+	s.clock = clock.OrSystem(c)
+	return s
+}
+
+// Save streams r to the driver as f.ID, at most maxBytes of it (0 means no
+// limit), and records f with its size and checksum filled in. An upload
+// over the limit fails with ErrTooLarge and leaves nothing behind.
+func (s *Store) Save(ctx context.Context, f File, r io.Reader, maxBytes int64) (File, error) {
+	hash := sha256.New()
+	counted := &countingReader{r: io.TeeReader(r, hash), limit: maxBytes}
+	if err := s.driver.Put(ctx, f.ID, counted); err != nil {
+		s.driver.Delete(ctx, f.ID)
+		if counted.exceeded {
+			return File{}, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, maxBytes)
+		}
+		return File{}, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	f.Size = counted.n
+	f.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	f.CreatedAt = s.clock.Now().UTC()
+
+	s.mu.Lock()
+	s.files[f.ID] = f
+	s.mu.Unlock()
+	return f, nil
+}
+
+// Get returns a file's metadata
+func (s *Store) Get(id string) (File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.files[id]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return f, nil
+}
+
+// Open returns a file's metadata and a reader for its contents
+func (s *Store) Open(ctx context.Context, id string) (File, io.ReadCloser, error) {
+	f, err := s.Get(id)
+	if err != nil {
+		return File{}, nil, err
+	}
+	rc, err := s.driver.Open(ctx, id)
+	if err != nil {
+		return File{}, nil, fmt.Errorf("failed to open file %s: %w", id, err)
+	}
+	return f, rc, nil
+}
+
+// ReadAll returns a file's metadata and its whole contents
+func (s *Store) ReadAll(ctx context.Context, id string) (File, []byte, error) {
+	f, rc, err := s.Open(ctx, id)
+	if err != nil {
+		return File{}, nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return File{}, nil, fmt.Errorf("failed to read file %s: %w", id, err)
+	}
+	return f, data, nil
+}
+
+// Claim hands an unowned file, such as a multipart upload, to owner under
+// name. A non-empty contentType replaces the one the client declared.
+func (s *Store) Claim(id, owner, name, contentType string) (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[id]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if f.Owner != "" {
+		return File{}, fmt.Errorf("file %s is already in use", id)
+	}
+	f.Owner, f.Name = owner, name
+	if contentType != "" {
+		f.ContentType = contentType
+	}
+	s.files[id] = f
+	return f, nil
+}
+
+// Latest returns owner's most recently saved file called name
+func (s *Store) Latest(owner, name string) (File, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var latest File
+	found := false
+	for _, f := range s.files {
+		if f.Owner == owner && f.Name == name && (!found || f.CreatedAt.After(latest.CreatedAt)) {
+			latest, found = f, true
+		}
+	}
+	return latest, found
+}
+
+// Owned returns owner's files, oldest first
+func (s *Store) Owned(owner string) []File {
+	s.mu.RLock()
+	var owned []File
+	for _, f := range s.files {
+		if f.Owner == owner {
+			owned = append(owned, f)
+		}
+	}
+	s.mu.RUnlock()
+	slices.SortFunc(owned, func(a, b File) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return owned
+}
+
+// DeleteOwned removes every file of owner except the given IDs and returns
+// how many were removed
+func (s *Store) DeleteOwned(ctx context.Context, owner string, keep ...string) int {
+	s.mu.Lock()
+	var ids []string
+	for id, f := range s.files {
+		if f.Owner == owner && !slices.Contains(keep, id) {
+			ids = append(ids, id)
+			delete(s.files, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.driver.Delete(ctx, id)
+	}
+	return len(ids)
+}
+
+// DeleteAll removes every file and returns how many there were
+func (s *Store) DeleteAll(ctx context.Context) int {
+	s.mu.Lock()
+	files := s.files
+	s.files = make(map[string]File)
+	s.mu.Unlock()
+
+	for id := range files {
+		s.driver.Delete(ctx, id)
+	}
+	return len(files)
+}
+
+// Delete removes a file; deleting an unknown file is not an error
+func (s *Store) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.files, id)
+	s.mu.Unlock()
+	return s.driver.Delete(ctx, id)
+}
+
+// countingReader counts bytes read and fails once more than limit are read
+type countingReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.limit > 0 && c.n > c.limit {
+		c.exceeded = true
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// MemoryDriver keeps contents in memory; they are lost on restart
+type MemoryDriver struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryDriver creates an empty in-memory driver
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{data: make(map[string][]byte)}
+}
+
+// Put stores everything read from r under id
+func (d *MemoryDriver) Put(ctx context.Context, id string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.data[id] = data
+	d.mu.Unlock()
+	return nil
+}
+
+// Open returns a reader over id's contents
+func (d *MemoryDriver) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	d.mu.RLock()
+	data, ok := d.data[id]
+	d.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes id's contents
+func (d *MemoryDriver) Delete(ctx context.Context, id string) error {
+	d.mu.Lock()
+	delete(d.data, id)
+	d.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package functions implements the custom functions the host calls by name
+// with a JSON payload. typedfn validates every payload and result against
+// the function's spec.
+package functions
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"hc-hello-world-plugin/app"
+	"hc-hello-world-plugin/email"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/typedfn"
+)
+
+// handlers implements the functions on top of the application container
+type handlers struct {
+	*app.App
+}
+
+// Register registers the plugin's custom functions with plugin
+func Register(plugin *registry.Plugin, a *app.App) {
+	h := &handlers{App: a}
+
+	// Register custom functions; typedfn validates payloads against each spec
+	plugin.Group(app.DemoGroup, func() {
+		plugin.RegisterFunction("customFunction", typedfn.Wrap(customFunctionSpec, customFunction))
+	})
+	plugin.RegisterFunction("sendWelcomeEmail", typedfn.Wrap(welcomeEmailSpec, h.sendWelcomeEmail))
+	plugin.RegisterFunction("renderTemplate", typedfn.Wrap(renderTemplateSpec, h.renderTemplate))
+	plugin.Require("network:smtp", "sendWelcomeEmail delivers mail through PLUGIN_SMTP_HOST")
+}
+
+// SendWelcomeEmail queues the welcome email for a new user, as the
+// sendWelcomeEmail function does; mutations creating users call it directly
+func SendWelcomeEmail(ctx context.Context, a *app.App, in WelcomeEmailInput) (WelcomeEmailOutput, error) {
+	return (&handlers{App: a}).sendWelcomeEmail(ctx, in)
+}
+
+// customFunctionInput is the payload of customFunction
+type customFunctionInput struct {
+	Name string `json:"name"`
+}
+
+// customFunctionSpec declares customFunction's payload schemas
+var customFunctionSpec = typedfn.Spec{
+	Name:        "customFunction",
+	Description: "Returns a confirmation message, optionally addressed to name",
+	Input: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "maxLength": 100},
+		},
+	},
+	Output: map[string]interface{}{"type": "string"},
+}
+
+func customFunction(ctx context.Context, in customFunctionInput) (string, error) {
+	if in.Name != "" {
+		return fmt.Sprintf("Custom function executed successfully for %s (SDK Version)", in.Name), nil
+	}
+	return "Custom function executed successfully (SDK Version)", nil
+}
+
+// WelcomeEmailInput is the payload of sendWelcomeEmail
+type WelcomeEmailInput struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// WelcomeEmailOutput is the result of sendWelcomeEmail
+type WelcomeEmailOutput struct {
+	Queued bool   `json:"queued"`
+	JobID  string `json:"jobId"`
+}
+
+// welcomeEmailSpec declares sendWelcomeEmail's payload schemas
+var welcomeEmailSpec = typedfn.Spec{
+	Name:        "sendWelcomeEmail",
+	Description: "Queues the welcome email for a newly created user",
+	Input: map[string]interface{}{
+		"type":     "object",
+		"required": []string{"email"},
+		"properties": map[string]interface{}{
+			"email":    map[string]interface{}{"type": "string", "minLength": 3, "maxLength": 254},
+			"name":     map[string]interface{}{"type": "string", "maxLength": 100},
+			"username": map[string]interface{}{"type": "string", "maxLength": 30},
+		},
+	},
+	Output: map[string]interface{}{
+		"type":     "object",
+		"required": []string{"queued", "jobId"},
+		"properties": map[string]interface{}{
+			"queued": map[string]interface{}{"type": "boolean"},
+			"jobId":  map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+// sendWelcomeEmail renders the welcome template and queues it for delivery
+func (h *handlers) sendWelcomeEmail(ctx context.Context, in WelcomeEmailInput) (WelcomeEmailOutput, error) {
+	if in.Name == "" {
+		in.Name = "there"
+	}
+
+	msg, err := email.Render("welcome", in.Email, map[string]interface{}{
+		"Name":     in.Name,
+		"Username": in.Username,
+	})
+	if err != nil {
+		return WelcomeEmailOutput{}, err
+	}
+
+	jobID, err := h.Jobs.Enqueue("sendWelcomeEmail", func(ctx context.Context) error {
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			log.Printf("📧 [hc-hello-world-plugin] Welcome email to %s failed: %v", logging.MaskEmail(msg.To), err)
+			return err
+		}
+		log.Printf("📧 [hc-hello-world-plugin] Welcome email delivered to %s", logging.MaskEmail(msg.To))
+		return nil
+	})
+	if err != nil {
+		return WelcomeEmailOutput{}, err
+	}
+
+	return WelcomeEmailOutput{Queued: true, JobID: jobID}, nil
+}
+
+// renderTemplateInput is the payload of renderTemplate
+type renderTemplateInput struct {
+	Name string                 `json:"name"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// renderTemplateOutput is the result of renderTemplate
+type renderTemplateOutput struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	HTML        string `json:"html"`
+}
+
+// renderTemplateSpec declares renderTemplate's payload schemas
+var renderTemplateSpec = typedfn.Spec{
+	Name:        "renderTemplate",
+	Description: "Renders one of the plugin's HTML templates with data; every key the template uses must be present",
+	Input: map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 100},
+			"data": map[string]interface{}{"type": "object"},
+		},
+	},
+	Output: map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name", "contentType", "html"},
+		"properties": map[string]interface{}{
+			"name":        map[string]interface{}{"type": "string"},
+			"contentType": map[string]interface{}{"type": "string"},
+			"html":        map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+// renderTemplate renders a template from the embedded set, or from
+// PLUGIN_TEMPLATE_DIR in debug mode
+func (h *handlers) renderTemplate(ctx context.Context, in renderTemplateInput) (renderTemplateOutput, error) {
+	rendered, err := h.Templates.Render(in.Name, in.Data)
+	if err != nil {
+		return renderTemplateOutput{}, err
+	}
+	return renderTemplateOutput{
+		Name:        in.Name,
+		ContentType: "text/html; charset=utf-8",
+		HTML:        rendered,
+	}, nil
+}
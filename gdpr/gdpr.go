@@ -0,0 +1,95 @@
+// Package gdpr signs the receipts the plugin hands out when it erases a
+// user's data, so the requester can later prove what was done and when.
+// A receipt is signed as the exact JSON it is returned as: verifying needs
+// that text and the signature, not a re-encoding that might differ.
+package gdpr
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Erasure outcomes
+const (
+	// OutcomeDeleted users were removed along with their records
+	OutcomeDeleted = "deleted"
+	// OutcomeAnonymized users were kept, stripped of personal data, since
+	// other records such as comments refer to them
+	OutcomeAnonymized = "anonymized"
+)
+
+// ErrInvalidSignature is returned for receipts that were altered or signed
+// with another secret
+var ErrInvalidSignature = errors.New("receipt signature is invalid")
+
+// Receipt records one completed erasure
+type Receipt struct {
+	ID      string `json:"id"`
+	UserID  string `json:"userId"`
+	Outcome string `json:"outcome"`
+	// Erased describes what was removed or overwritten
+	Erased []string `json:"erased"`
+	// Retained describes what was kept and why
+	Retained    []string  `json:"retained"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// Signed is a receipt as handed out: its JSON and the signature of it
+type Signed struct {
+	Receipt   Receipt
+	Payload   string
+	Signature string
+}
+
+// Signer signs and verifies receipts with HMAC-SHA256
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer; an empty secret generates a random one, so
+// receipts signed before a restart no longer verify
+func NewSigner(secret string) *Signer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("gdpr: crypto/rand failed: %v", err))
+		}
+	}
+	return &Signer{secret: key}
+}
+
+// Sign encodes r and signs the encoding
+func (s *Signer) Sign(r Receipt) (Signed, error) {
+	r.CompletedAt = r.CompletedAt.UTC()
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return Signed{}, fmt.Errorf("encoding receipt: %w", err)
+	}
+	return Signed{Receipt: r, Payload: string(payload), Signature: s.sign(payload)}, nil
+}
+
+// Verify checks signature against payload and returns the receipt it holds
+func (s *Signer) Verify(payload, signature string) (Receipt, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign([]byte(payload)))) {
+		return Receipt{}, ErrInvalidSignature
+	}
+	var r Receipt
+	if err := json.Unmarshal([]byte(payload), &r); err != nil {
+		return Receipt{}, fmt.Errorf("decoding receipt: %w", err)
+	}
+	return r, nil
+}
+
+// sign returns the hex HMAC-SHA256 of payload
+func (s *Signer) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,58 @@
+// Package geo validates coordinates and computes great-circle distances
+// for location-based queries.
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadiusKm is the mean Earth radius used for distances
+const EarthRadiusKm = 6371.0088
+
+// Point is a WGS84 coordinate in decimal degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// ValidateLatitude checks that lat is within [-90, 90]
+func ValidateLatitude(lat float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90, got %v", lat)
+	}
+	return nil
+}
+
+// ValidateLongitude checks that lng is within [-180, 180]
+func ValidateLongitude(lng float64) error {
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180, got %v", lng)
+	}
+	return nil
+}
+
+// Validate checks both coordinates of p
+func (p Point) Validate() error {
+	if err := ValidateLatitude(p.Lat); err != nil {
+		return err
+	}
+	return ValidateLongitude(p.Lng)
+}
+
+// DistanceKm returns the haversine great-circle distance between a and b.
+// It treats the Earth as a sphere, which is accurate to about 0.5%.
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	// Rounding can push h fractionally above 1 for antipodal points
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
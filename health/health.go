@@ -0,0 +1,324 @@
+// Package health tracks the plugin's optional dependencies and readiness. A
+// dependency that is unreachable is marked degraded instead of failing
+// startup; the plugin falls back to in-memory behaviour for it while the
+// monitor keeps reconnecting in the background with exponential backoff.
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// Dependency states
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Check probes a dependency; it returns nil when the dependency is usable
+type Check func(ctx context.Context) error
+
+// Config controls how often dependencies are probed
+type Config struct {
+	// Interval is how often healthy dependencies are re-checked
+	Interval time.Duration
+	// Timeout bounds a single check
+	Timeout time.Duration
+	// MaxBackoff caps the delay between checks of a degraded dependency;
+	// the delay starts at a second and doubles after each failure
+	MaxBackoff time.Duration
+	// Clock stamps status changes and schedules checks; nil uses the
+	// system clock
+	Clock clock.Clock
+}
+
+// DependencyStatus is one dependency's state as reported by /health
+type DependencyStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Fallback describes what the plugin does while the dependency is degraded
+	Fallback  string    `json:"fallback"`
+	Error     string    `json:"error,omitempty"`
+	Since     time.Time `json:"since"`
+	LastCheck time.Time `json:"lastCheck"`
+	Failures  int       `json:"failures"`
+}
+
+// Report is the state of every dependency
+type Report struct {
+	// Status is degraded when any dependency is
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+type dependency struct {
+	check Check
+	// wake asks the dependency's loop to re-check now
+	wake chan struct{}
+
+	mu     sync.RWMutex
+	status DependencyStatus
+}
+
+// Monitor probes dependencies in the background
+type Monitor struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	deps  map[string]*dependency
+	order []string
+
+	// changed is closed and replaced after every check
+	changedMu sync.Mutex
+	changed   chan struct{}
+
+	// checked is closed once every dependency has been checked once
+	checked  chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMonitor creates a monitor; add dependencies, then call Start
+func NewMonitor(cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	cfg.Clock = clock.OrSystem(cfg.Clock)
+	return &Monitor{
+		cfg:     cfg,
+		deps:    make(map[string]*dependency),
+		changed: make(chan struct{}),
+		checked: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Add registers a dependency. It counts as healthy until its first check.
+func (m *Monitor) Add(name, fallback string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, dup := m.deps[name]; !dup {
+		m.order = append(m.order, name)
+	}
+	m.deps[name] = &dependency{
+		check:  check,
+		wake:   make(chan struct{}, 1),
+		status: DependencyStatus{Name: name, Status: StatusOK, Fallback: fallback, Since: m.cfg.Clock.Now().UTC()},
+	}
+}
+
+// Start checks every dependency in the background and keeps checking them
+// until Stop; Checked is closed once each has been checked. Startup never
+// fails: unreachable dependencies are logged and marked degraded.
+func (m *Monitor) Start() {
+	m.mu.RLock()
+	deps := make([]*dependency, 0, len(m.order))
+	for _, name := range m.order {
+		deps = append(deps, m.deps[name])
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, d := range deps {
+		wg.Add(1)
+		go func(d *dependency) {
+			m.probe(d)
+			wg.Done()
+			m.loop(d)
+		}(d)
+	}
+	go func() {
+		wg.Wait()
+		close(m.checked)
+	}()
+}
+
+// Checked is closed once every dependency has been checked at least once
+func (m *Monitor) Checked() <-chan struct{} {
+	return m.checked
+}
+
+// Stop ends background checking
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// Healthy reports whether name is usable; unknown dependencies are not
+func (m *Monitor) Healthy(name string) bool {
+	d := m.lookup(name)
+	if d == nil {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status.Status == StatusOK
+}
+
+// MarkFailed records a failure seen while using name, marking it degraded
+// until a background check succeeds again
+func (m *Monitor) MarkFailed(name string, err error) {
+	d := m.lookup(name)
+	if d == nil || err == nil {
+		return
+	}
+	m.record(d, err)
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// WaitHealthy blocks until every named dependency has been checked and is
+// healthy; call it after Start. The monitor keeps retrying degraded ones
+// with backoff meanwhile. When ctx ends first the error lists each
+// unhealthy dependency with its failure count and last error.
+func (m *Monitor) WaitHealthy(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		if m.lookup(name) == nil {
+			return fmt.Errorf("unknown dependency %q (known: %s)", name, strings.Join(m.names(), ", "))
+		}
+	}
+
+	// Dependencies count as healthy until their first check
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("dependencies not checked yet: %w", ctx.Err())
+	case <-m.checked:
+	}
+
+	for {
+		m.changedMu.Lock()
+		changed := m.changed
+		m.changedMu.Unlock()
+
+		var unhealthy []string
+		for _, name := range names {
+			d := m.lookup(name)
+			d.mu.RLock()
+			if d.status.Status != StatusOK {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s after %d failures: %s", name, d.status.Failures, d.status.Error))
+			}
+			d.mu.RUnlock()
+		}
+		if len(unhealthy) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("required dependencies unavailable (%s): %w", strings.Join(unhealthy, "; "), ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// Report returns the state of every dependency in registration order
+func (m *Monitor) Report() Report {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Dependencies: make([]DependencyStatus, 0, len(m.order))}
+	for _, name := range m.order {
+		d := m.deps[name]
+		d.mu.RLock()
+		status := d.status
+		d.mu.RUnlock()
+		if status.Status != StatusOK {
+			report.Status = StatusDegraded
+		}
+		report.Dependencies = append(report.Dependencies, status)
+	}
+	return report
+}
+
+func (m *Monitor) names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.order...)
+}
+
+func (m *Monitor) lookup(name string) *dependency {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.deps[name]
+}
+
+// loop re-checks d every interval while it is healthy and with growing
+// backoff while it is degraded
+func (m *Monitor) loop(d *dependency) {
+	backoff := time.Second
+	for {
+		delay := m.cfg.Interval
+		d.mu.RLock()
+		degraded := d.status.Status != StatusOK
+		d.mu.RUnlock()
+		if degraded {
+			delay = backoff
+			backoff = min(backoff*2, m.cfg.MaxBackoff)
+		} else {
+			backoff = time.Second
+		}
+
+		due := make(chan struct{})
+		timer := m.cfg.Clock.AfterFunc(delay, func() { close(due) })
+		select {
+		case <-m.stop:
+			timer.Stop()
+			return
+		case <-d.wake:
+			timer.Stop()
+		case <-due:
+		}
+		m.probe(d)
+	}
+}
+
+func (m *Monitor) probe(d *dependency) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+	m.record(d, d.check(ctx))
+}
+
+// record updates d after a check or failure, logging state changes
+func (m *Monitor) record(d *dependency, err error) {
+	defer m.notify()
+	now := m.cfg.Clock.Now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.LastCheck = now
+	if err == nil {
+		if d.status.Status != StatusOK {
+			log.Printf("✅ [hc-hello-world-plugin] event=dependency_recovered dependency=%s failures=%d", d.status.Name, d.status.Failures)
+			d.status.Status, d.status.Since = StatusOK, now
+		}
+		d.status.Error, d.status.Failures = "", 0
+		return
+	}
+
+	d.status.Error = err.Error()
+	d.status.Failures++
+	if d.status.Status == StatusOK {
+		log.Printf("⚠️  [hc-hello-world-plugin] event=dependency_degraded dependency=%s error=%q fallback=%q", d.status.Name, err, d.status.Fallback)
+		d.status.Status, d.status.Since = StatusDegraded, now
+	}
+}
+
+func (m *Monitor) notify() {
+	m.changedMu.Lock()
+	close(m.changed)
+	m.changed = make(chan struct{})
+	m.changedMu.Unlock()
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// Heartbeat is the plugin's state as written to the heartbeat file, so a
+// probe in another process, such as --healthcheck run by a Docker
+// HEALTHCHECK, can tell whether the plugin is alive and ready without a
+// connection to it
+type Heartbeat struct {
+	PID       int             `json:"pid"`
+	WrittenAt time.Time       `json:"writtenAt"`
+	Readiness ReadinessReport `json:"readiness"`
+	// Dependencies is the dependency report's status
+	Dependencies string `json:"dependencies"`
+}
+
+// Check returns why hb shows an unhealthy plugin at now, or nil. A heartbeat
+// older than maxAge means the plugin stopped writing it: it hangs or died.
+// Degraded dependencies are healthy, since the plugin falls back.
+func (hb Heartbeat) Check(now time.Time, maxAge time.Duration) error {
+	if age := now.Sub(hb.WrittenAt); age > maxAge {
+		return fmt.Errorf("heartbeat of pid %d is %s old (limit %s)", hb.PID, age.Round(time.Second), maxAge)
+	}
+	if !hb.Readiness.Ready {
+		var pending []string
+		for _, c := range hb.Readiness.Conditions {
+			if !c.Ready {
+				pending = append(pending, c.Name)
+			}
+		}
+		return fmt.Errorf("pid %d is not ready (waiting for %v)", hb.PID, pending)
+	}
+	return nil
+}
+
+// ReadHeartbeat reads the heartbeat file at path
+func ReadHeartbeat(path string) (Heartbeat, error) {
+	var hb Heartbeat
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return hb, err
+	}
+	if err := json.Unmarshal(data, &hb); err != nil {
+		return hb, fmt.Errorf("invalid heartbeat file %s: %w", path, err)
+	}
+	return hb, nil
+}
+
+// WriteHeartbeat replaces the heartbeat file at path atomically, so a probe
+// never reads a partial file
+func WriteHeartbeat(path string, hb Heartbeat) error {
+	data, err := json.Marshal(hb)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Beater writes a heartbeat file every interval while the plugin runs
+type Beater struct {
+	path     string
+	interval time.Duration
+	state    func() Heartbeat
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	timer   clock.Timer
+	stopped bool
+	// failing suppresses repeated warnings while writes keep failing
+	failing bool
+}
+
+// NewBeater writes state to path every interval once started; an empty
+// path disables it
+func NewBeater(path string, interval time.Duration, state func() Heartbeat) *Beater {
+	return &Beater{path: path, interval: interval, state: state, clock: clock.System}
+}
+
+// WithClock replaces the system clock; call it before Start
+func (b *Beater) WithClock(c clock.Clock) *Beater {
+	b.clock = clock.OrSystem(c)
+	return b
+}
+
+// Enabled reports whether a heartbeat file is written
+func (b *Beater) Enabled() bool {
+	return b.path != ""
+}
+
+// Path returns the heartbeat file
+func (b *Beater) Path() string {
+	return b.path
+}
+
+// Start writes the first heartbeat and schedules the next ones
+func (b *Beater) Start() {
+	if !b.Enabled() {
+		return
+	}
+	b.beat()
+}
+
+// Stop stops writing and removes the file, so a probe fails at once
+// rather than once the last heartbeat is stale
+func (b *Beater) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped || !b.Enabled() {
+		return
+	}
+	b.stopped = true
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	os.Remove(b.path)
+}
+
+func (b *Beater) beat() {
+	hb := b.state()
+	hb.PID = os.Getpid()
+	hb.WrittenAt = b.clock.Now().UTC()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	if err := WriteHeartbeat(b.path, hb); err != nil {
+		if !b.failing {
+			log.Printf("⚠️  [hc-hello-world-plugin] event=heartbeat_failed path=%s err=%q", b.path, err)
+		}
+		b.failing = true
+	} else {
+		b.failing = false
+	}
+	b.timer = b.clock.AfterFunc(b.interval, b.beat)
+}
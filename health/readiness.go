@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ConditionStatus is one readiness condition as reported by /health/ready
+type ConditionStatus struct {
+	Name   string    `json:"name"`
+	Ready  bool      `json:"ready"`
+	Detail string    `json:"detail,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// ReadinessReport is the state of every readiness condition
+type ReadinessReport struct {
+	Ready      bool              `json:"ready"`
+	Conditions []ConditionStatus `json:"conditions"`
+}
+
+// Readiness tracks the conditions that must hold before the plugin can
+// serve requests. Liveness only means the process is up; readiness means
+// the plugin's data and dependencies are in place. A condition can become
+// pending again, for example while the store is being replaced.
+type Readiness struct {
+	clock clock.Clock
+
+	mu         sync.Mutex
+	conditions map[string]*ConditionStatus
+	order      []string
+	// changed is closed and replaced whenever a condition changes
+	changed chan struct{}
+}
+
+// NewReadiness creates a tracker with every named condition pending
+func NewReadiness(names ...string) *Readiness {
+	r := &Readiness{clock: clock.System, conditions: make(map[string]*ConditionStatus), changed: make(chan struct{})}
+	now := r.clock.Now().UTC()
+	for _, name := range names {
+		r.conditions[name] = &ConditionStatus{Name: name, Detail: "pending", Since: now}
+		r.order = append(r.order, name)
+	}
+	return r
+}
+
+// WithClock replaces the system clock that stamps condition changes and
+// restamps the pending conditions; call it before the tracker is used
+func (r *Readiness) WithClock(c clock.Clock) *Readiness {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock.OrSystem(c)
+	now := r.clock.Now().UTC()
+	for _, condition := range r.conditions {
+		condition.Since = now
+	}
+	return r
+}
+
+// Set updates a condition; unknown names are ignored
+func (r *Readiness) Set(name string, ready bool, detail string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.conditions[name]
+	if c == nil {
+		return
+	}
+	if c.Ready != ready {
+		c.Since = r.clock.Now().UTC()
+	}
+	c.Ready, c.Detail = ready, detail
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Ready reports whether every condition holds
+func (r *Readiness) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pendingLocked() == nil
+}
+
+// Wait blocks until every condition holds. When ctx ends first the error
+// names the pending conditions.
+func (r *Readiness) Wait(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		pending := r.pendingLocked()
+		changed := r.changed
+		r.mu.Unlock()
+		if pending == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not ready, waiting for %s: %w", strings.Join(pending, ", "), ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// Report returns every condition in the order they were declared
+func (r *Readiness) Report() ReadinessReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := ReadinessReport{Ready: true, Conditions: make([]ConditionStatus, 0, len(r.order))}
+	for _, name := range r.order {
+		c := *r.conditions[name]
+		report.Ready = report.Ready && c.Ready
+		report.Conditions = append(report.Conditions, c)
+	}
+	return report
+}
+
+func (r *Readiness) pendingLocked() []string {
+	var pending []string
+	for _, name := range r.order {
+		if c := r.conditions[name]; !c.Ready {
+			pending = append(pending, fmt.Sprintf("%s (%s)", name, c.Detail))
+		}
+	}
+	return pending
+}
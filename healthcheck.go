@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/config"
+	"hc-hello-world-plugin/health"
+)
+
+// healthcheckMode probes the plugin and exits instead of serving, for use
+// as a container HEALTHCHECK
+var healthcheckMode = flag.Bool("healthcheck", false, "check the running plugin's heartbeat (PLUGIN_HEALTH_FILE), or exercise the core subsystems without one, and exit 0 if healthy or 1 if not")
+
+// heartbeatMisses is how many heartbeats may be missed before the plugin
+// counts as hung
+const heartbeatMisses = 3
+
+// healthcheck returns the --healthcheck exit code
+func healthcheck() int {
+	cfg := config.Load()
+	var err error
+	if cfg.HealthFile != "" {
+		err = checkHeartbeat(cfg)
+	} else {
+		err = selfCheck(cfg)
+	}
+	if err != nil {
+		log.Printf("❌ [hc-hello-world-plugin] event=healthcheck status=unhealthy err=%q", err)
+		return 1
+	}
+	log.Printf("✅ [hc-hello-world-plugin] event=healthcheck status=healthy")
+	return 0
+}
+
+// checkHeartbeat reads the running plugin's heartbeat file
+func checkHeartbeat(cfg config.Config) error {
+	hb, err := health.ReadHeartbeat(cfg.HealthFile)
+	if err != nil {
+		return fmt.Errorf("plugin not running or not writing heartbeats: %w", err)
+	}
+	return hb.Check(time.Now(), heartbeatMisses*cfg.HealthInterval)
+}
+
+// selfCheck builds the plugin in this process without serving: the
+// datasource must open, required dependencies must be reachable and the
+// registrations must be valid. Nothing is written to the datasource.
+func selfCheck(cfg config.Config) error {
+	// newApp exits if the datasource cannot be opened
+	a := newApp(cfg)
+	defer a.Store.Close()
+
+	a.Dependencies.Start()
+	defer a.Dependencies.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DependencyWait)
+	defer cancel()
+	select {
+	case <-a.Dependencies.Checked():
+	case <-ctx.Done():
+		return fmt.Errorf("dependencies not checked within %s", cfg.DependencyWait)
+	}
+	var down []string
+	for _, name := range cfg.RequiredDependencies {
+		if !a.Dependencies.Healthy(name) {
+			down = append(down, name)
+		}
+	}
+	if len(down) > 0 {
+		return fmt.Errorf("required dependencies down: %s", strings.Join(down, ", "))
+	}
+
+	return newPlugin(cfg, a).Validate()
+}
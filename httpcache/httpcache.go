@@ -0,0 +1,100 @@
+// Package httpcache implements ETag generation and If-None-Match handling
+// for the plugin's REST endpoints.
+//
+// REST handlers can only return data, so conditional responses use an
+// envelope the host forwards as-is: {"statusCode", "headers", "body"}.
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// headerKeys are the argument/context keys checked for If-None-Match
+var headerKeys = []string{"If-None-Match", "if-none-match", "if_none_match", "ifNoneMatch"}
+
+// ETag returns a strong ETag for the JSON encoding of body
+func ETag(body interface{}) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// IfNoneMatch extracts the If-None-Match request header from REST args, a
+// nested "headers" map, or the request context
+func IfNoneMatch(ctx context.Context, args map[string]interface{}) string {
+	if value := lookupHeader(args); value != "" {
+		return value
+	}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		if value := lookupHeader(headers); value != "" {
+			return value
+		}
+	}
+	if headers, ok := ctx.Value("headers").(map[string]interface{}); ok {
+		return lookupHeader(headers)
+	}
+	return ""
+}
+
+func lookupHeader(values map[string]interface{}) string {
+	for _, key := range headerKeys {
+		if value, ok := values[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Matches reports whether an If-None-Match header value matches etag, using
+// weak comparison as required for GET/HEAD (RFC 9110 section 13.1.2)
+func Matches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Respond returns a 304 envelope when the request's If-None-Match matches
+// body's ETag, otherwise a 200 envelope carrying body and its ETag
+func Respond(ctx context.Context, args map[string]interface{}, body interface{}) (interface{}, error) {
+	etag, err := ETag(body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]interface{}{
+		"ETag":          etag,
+		"Cache-Control": "no-cache",
+	}
+
+	if Matches(IfNoneMatch(ctx, args), etag) {
+		return map[string]interface{}{
+			"statusCode": 304,
+			"headers":    headers,
+			"body":       nil,
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    headers,
+		"body":       body,
+	}, nil
+}
@@ -0,0 +1,111 @@
+// Package i18n provides localized response messages backed by translation
+// catalogs embedded into the plugin binary.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// DefaultLocale is used when no supported locale is requested
+const DefaultLocale = "en"
+
+// contextKeys are the host context keys checked for a locale, in order
+var contextKeys = []string{"locale", "language", "accept_language"}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs maps a locale to its message key -> format string table
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	result := make(map[string]map[string]string)
+
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		log.Printf("❌ [hc-hello-world-plugin] Failed to read embedded locales: %v", err)
+		return result
+	}
+
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			log.Printf("❌ [hc-hello-world-plugin] Failed to read locale %s: %v", entry.Name(), err)
+			continue
+		}
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Printf("❌ [hc-hello-world-plugin] Failed to parse locale %s: %v", entry.Name(), err)
+			continue
+		}
+
+		result[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+
+	return result
+}
+
+// Supported returns the locales that have an embedded catalog
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// Resolve picks the best supported locale for a requested tag such as
+// "de-DE", "es_MX" or an Accept-Language list like "fr;q=0.9, es;q=0.8".
+func Resolve(requested string) string {
+	for _, part := range strings.Split(requested, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+		if tag == "" {
+			continue
+		}
+		if _, ok := catalogs[tag]; ok {
+			return tag
+		}
+		base := strings.SplitN(tag, "-", 2)[0]
+		if _, ok := catalogs[base]; ok {
+			return base
+		}
+	}
+	return DefaultLocale
+}
+
+// FromContext resolves the locale from an explicit argument first, then from
+// locale values passed by the host in the context
+func FromContext(ctx context.Context, arg string) string {
+	if arg != "" {
+		return Resolve(arg)
+	}
+	for _, key := range contextKeys {
+		if value, ok := ctx.Value(key).(string); ok && value != "" {
+			return Resolve(value)
+		}
+	}
+	return DefaultLocale
+}
+
+// T returns the message for key in locale, formatted with args. Missing keys
+// fall back to the default locale, then to the key itself.
+func T(locale, key string, args ...interface{}) string {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		if format, ok = catalogs[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
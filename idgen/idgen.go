@@ -0,0 +1,180 @@
+// Package idgen generates collision-resistant, time-ordered identifiers for
+// records created by the plugin.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// Supported generator strategies
+const (
+	StrategyUUIDv7 = "uuidv7"
+	StrategyULID   = "ulid"
+)
+
+// Generator produces unique IDs; implementations must be safe for concurrent use
+type Generator interface {
+	NewID() string
+}
+
+// New returns the generator for a strategy name, timestamping IDs with c
+// (the system clock when nil); an empty name selects UUIDv7
+func New(strategy string, c clock.Clock) (Generator, error) {
+	switch strings.ToLower(strategy) {
+	case "", StrategyUUIDv7:
+		return NewUUIDv7().WithClock(c), nil
+	case StrategyULID:
+		return NewULID().WithClock(c), nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q (expected %s or %s)", strategy, StrategyUUIDv7, StrategyULID)
+	}
+}
+
+// UUIDv7 generates RFC 9562 version 7 UUIDs. IDs created within the same
+// millisecond stay ordered through a 12-bit sequence counter.
+type UUIDv7 struct {
+	clock clock.Clock
+
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+// NewUUIDv7 creates a UUIDv7 generator
+func NewUUIDv7() *UUIDv7 {
+	return &UUIDv7{clock: clock.System}
+}
+
+// WithClock replaces the system clock that timestamps IDs; call it before
+// the first NewID
+func (g *UUIDv7) WithClock(c clock.Clock) *UUIDv7 {
+	g.clock = clock.OrSystem(c)
+	return g
+}
+
+// NewID returns a new UUIDv7 string
+func (g *UUIDv7) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("idgen: crypto/rand failed: %v", err))
+	}
+
+	g.mu.Lock()
+	ms := g.clock.Now().UnixMilli()
+	if ms <= g.lastMs {
+		// Same (or earlier) millisecond: bump the sequence, borrowing the next
+		// millisecond if the counter overflows
+		ms = g.lastMs
+		g.seq++
+		if g.seq > 0x0FFF {
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = binary.BigEndian.Uint16(b[6:8]) & 0x07FF
+	}
+	g.lastMs = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8)
+	b[7] = byte(seq)
+	b[8] = (b[8] & 0x3F) | 0x80
+
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+// crockford is the ULID base32 alphabet
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates monotonic ULIDs (26-character, lexicographically sortable)
+type ULID struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	lastMs  int64
+	lastRnd [10]byte
+}
+
+// NewULID creates a ULID generator
+func NewULID() *ULID {
+	return &ULID{clock: clock.System}
+}
+
+// WithClock replaces the system clock that timestamps IDs; call it before
+// the first NewID
+func (g *ULID) WithClock(c clock.Clock) *ULID {
+	g.clock = clock.OrSystem(c)
+	return g
+}
+
+// NewID returns a new ULID string
+func (g *ULID) NewID() string {
+	g.mu.Lock()
+	ms := g.clock.Now().UnixMilli()
+	if ms <= g.lastMs {
+		// Monotonic mode: increment the previous random part
+		ms = g.lastMs
+		for i := len(g.lastRnd) - 1; i >= 0; i-- {
+			g.lastRnd[i]++
+			if g.lastRnd[i] != 0 {
+				break
+			}
+		}
+	} else {
+		if _, err := rand.Read(g.lastRnd[:]); err != nil {
+			g.mu.Unlock()
+			panic(fmt.Sprintf("idgen: crypto/rand failed: %v", err))
+		}
+	}
+	g.lastMs = ms
+
+	var b [16]byte
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	copy(b[6:], g.lastRnd[:])
+	g.mu.Unlock()
+
+	return encodeCrockford(b)
+}
+
+// encodeCrockford encodes 128 bits as 26 base32 characters
+func encodeCrockford(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1F]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(out[:])
+}
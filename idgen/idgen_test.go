@@ -0,0 +1,71 @@
+package idgen
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+var start = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestUUIDv7UsesClock(t *testing.T) {
+	fake := clock.NewFake(start)
+	g := NewUUIDv7().WithClock(fake)
+
+	id := g.NewID()
+	raw, err := hex.DecodeString(strings.ReplaceAll(id, "-", "")[:12])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ms int64
+	for _, b := range raw {
+		ms = ms<<8 | int64(b)
+	}
+	if ms != start.UnixMilli() {
+		t.Errorf("%s carries %d ms, want %d from the clock", id, ms, start.UnixMilli())
+	}
+	if id[14] != '7' {
+		t.Errorf("%s is not version 7", id)
+	}
+}
+
+func TestULIDUsesClock(t *testing.T) {
+	fake := clock.NewFake(start)
+	g := NewULID().WithClock(fake)
+
+	first := g.NewID()
+	fake.Advance(time.Millisecond)
+	second := g.NewID()
+	// The first 10 characters encode the millisecond timestamp
+	if first[:10] == second[:10] {
+		t.Errorf("%s and %s share a timestamp a millisecond apart", first, second)
+	}
+}
+
+// IDs keep sorting in creation order while the clock stands still or runs
+// backwards
+func TestOrderedWithoutClockProgress(t *testing.T) {
+	for _, strategy := range []string{StrategyUUIDv7, StrategyULID} {
+		t.Run(strategy, func(t *testing.T) {
+			fake := clock.NewFake(start)
+			g, err := New(strategy, fake)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prev := g.NewID()
+			for i := range 5000 {
+				if i == 2500 {
+					fake.Set(start.Add(-time.Second))
+				}
+				id := g.NewID()
+				if id <= prev {
+					t.Fatalf("ID %d: %s sorts before %s", i, id, prev)
+				}
+				prev = id
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package imagemeta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Tag is one EXIF tag with its value rendered as text. Lists of numbers
+// are comma separated; rationals are written as decimals, except exposure
+// times, which are fractions of a second such as "1/125".
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// IFD0 tags that point to the Exif and GPS sub-IFDs, and the orientation
+const (
+	exifIFDPointer = 0x8769
+	gpsIFDPointer  = 0x8825
+	orientationTag = 0x0112
+)
+
+// Recognised tags by IFD; anything else, such as maker notes, is skipped
+var (
+	ifd0Tags = map[uint16]string{
+		0x010e: "ImageDescription",
+		0x010f: "Make",
+		0x0110: "Model",
+		0x0112: "Orientation",
+		0x011a: "XResolution",
+		0x011b: "YResolution",
+		0x0128: "ResolutionUnit",
+		0x0131: "Software",
+		0x0132: "DateTime",
+		0x013b: "Artist",
+		0x8298: "Copyright",
+	}
+	exifTags = map[uint16]string{
+		0x829a: "ExposureTime",
+		0x829d: "FNumber",
+		0x8822: "ExposureProgram",
+		0x8827: "ISOSpeedRatings",
+		0x9000: "ExifVersion",
+		0x9003: "DateTimeOriginal",
+		0x9004: "DateTimeDigitized",
+		0x9207: "MeteringMode",
+		0x9209: "Flash",
+		0x920a: "FocalLength",
+		0xa002: "PixelXDimension",
+		0xa003: "PixelYDimension",
+		0xa405: "FocalLengthIn35mmFilm",
+		0xa434: "LensModel",
+	}
+	gpsTags = map[uint16]string{
+		0x0001: "GPSLatitudeRef",
+		0x0002: "GPSLatitude",
+		0x0003: "GPSLongitudeRef",
+		0x0004: "GPSLongitude",
+		0x0005: "GPSAltitudeRef",
+		0x0006: "GPSAltitude",
+		0x001d: "GPSDateStamp",
+	}
+)
+
+// EXIF value types and their sizes in bytes
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+var typeSizes = map[uint16]int{
+	typeByte: 1, typeASCII: 1, typeShort: 2, typeLong: 4, typeRational: 8,
+	typeUndefined: 1, typeSLong: 4, typeSRational: 8,
+}
+
+// maxIFDEntries bounds a single directory; real ones hold a few dozen
+const maxIFDEntries = 1000
+
+// maxListValues is the most numbers rendered for one tag
+const maxListValues = 16
+
+// tiff is an EXIF block: a TIFF header followed by image file directories
+// (IFDs) that refer to each other by offset from the header
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// parseEXIF reads the recognised tags of IFD0 and its Exif and GPS
+// sub-IFDs, and the orientation
+func parseEXIF(data []byte) ([]Tag, int, error) {
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("%w: exif header is truncated", ErrCorrupt)
+	}
+	t := tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("%w: exif byte order %q is invalid", ErrCorrupt, data[:2])
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, 0, fmt.Errorf("%w: exif is not a TIFF structure", ErrCorrupt)
+	}
+
+	var tags []Tag
+	orientation := 0
+	ifds := []directory{{offset: t.order.Uint32(data[4:]), names: ifd0Tags, root: true}}
+	visited := map[uint32]bool{}
+
+	for len(ifds) > 0 {
+		ifd := ifds[0]
+		ifds = ifds[1:]
+		// A directory pointing back at itself would loop forever
+		if visited[ifd.offset] {
+			return nil, 0, fmt.Errorf("%w: exif directory at %d is referenced twice", ErrCorrupt, ifd.offset)
+		}
+		visited[ifd.offset] = true
+
+		entries, err := t.entries(ifd.offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, e := range entries {
+			if ifd.root && (e.tag == exifIFDPointer || e.tag == gpsIFDPointer) {
+				offset, err := t.pointer(e)
+				if err != nil {
+					return nil, 0, err
+				}
+				names := exifTags
+				if e.tag == gpsIFDPointer {
+					names = gpsTags
+				}
+				ifds = append(ifds, directory{offset: offset, names: names})
+				continue
+			}
+
+			name, ok := ifd.names[e.tag]
+			if !ok {
+				continue
+			}
+			value, err := t.render(e)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: exif %s: %v", ErrCorrupt, name, err)
+			}
+			if ifd.root && e.tag == orientationTag {
+				orientation, _ = strconv.Atoi(value)
+			}
+			tags = append(tags, Tag{Name: name, Value: value})
+		}
+	}
+	return tags, orientation, nil
+}
+
+// directory is an IFD still to be read, with the tags it may hold
+type directory struct {
+	offset uint32
+	names  map[uint16]string
+	// root is set for IFD0, which points to the other directories
+	root bool
+}
+
+// entry is one 12-byte IFD entry. Values of up to four bytes are stored in
+// the entry itself, longer ones at an offset.
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	raw   []byte
+}
+
+func (t tiff) entries(offset uint32) ([]entry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("%w: exif directory offset %d is outside the data", ErrCorrupt, offset)
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	start := int(offset) + 2
+	if count > maxIFDEntries || start+count*12 > len(t.data) {
+		return nil, fmt.Errorf("%w: exif directory at %d overruns the data", ErrCorrupt, offset)
+	}
+	entries := make([]entry, count)
+	for i := range entries {
+		raw := t.data[start+i*12 : start+(i+1)*12]
+		entries[i] = entry{
+			tag:   t.order.Uint16(raw),
+			typ:   t.order.Uint16(raw[2:]),
+			count: t.order.Uint32(raw[4:]),
+			raw:   raw[8:12],
+		}
+	}
+	return entries, nil
+}
+
+// value returns the bytes of e's value
+func (t tiff) value(e entry) ([]byte, error) {
+	size, ok := typeSizes[e.typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown value type %d", e.typ)
+	}
+	length := uint64(size) * uint64(e.count)
+	if length <= 4 {
+		return e.raw[:length], nil
+	}
+	offset := uint64(t.order.Uint32(e.raw))
+	if offset+length > uint64(len(t.data)) {
+		return nil, fmt.Errorf("value at %d overruns the data", offset)
+	}
+	return t.data[offset : offset+length], nil
+}
+
+func (t tiff) pointer(e entry) (uint32, error) {
+	if e.typ != typeLong || e.count != 1 {
+		return 0, fmt.Errorf("%w: exif sub-directory pointer has type %d", ErrCorrupt, e.typ)
+	}
+	return t.order.Uint32(e.raw), nil
+}
+
+func (t tiff) render(e entry) (string, error) {
+	b, err := t.value(e)
+	if err != nil {
+		return "", err
+	}
+	switch e.typ {
+	case typeASCII:
+		return strings.TrimSpace(strings.TrimRight(string(b), "\x00")), nil
+	case typeUndefined:
+		// e.g. ExifVersion "0232"; binary blobs are only summarised
+		s := strings.TrimRight(string(b), "\x00")
+		for _, r := range s {
+			if !unicode.IsPrint(r) {
+				return fmt.Sprintf("(%d bytes)", len(b)), nil
+			}
+		}
+		return s, nil
+	}
+
+	size := typeSizes[e.typ]
+	values := make([]string, 0, min(int(e.count), maxListValues))
+	for i := 0; i < int(e.count) && i < maxListValues; i++ {
+		v := b[i*size:]
+		switch e.typ {
+		case typeByte:
+			values = append(values, strconv.Itoa(int(v[0])))
+		case typeShort:
+			values = append(values, strconv.Itoa(int(t.order.Uint16(v))))
+		case typeLong:
+			values = append(values, strconv.FormatUint(uint64(t.order.Uint32(v)), 10))
+		case typeSLong:
+			values = append(values, strconv.Itoa(int(int32(t.order.Uint32(v)))))
+		case typeRational:
+			values = append(values, rational(e.tag, int64(t.order.Uint32(v)), int64(t.order.Uint32(v[4:]))))
+		case typeSRational:
+			values = append(values, rational(e.tag, int64(int32(t.order.Uint32(v))), int64(int32(t.order.Uint32(v[4:])))))
+		}
+	}
+	return strings.Join(values, ", "), nil
+}
+
+func rational(tag uint16, num, den int64) string {
+	switch {
+	case den == 0:
+		return "0"
+	case den == 1:
+		return strconv.FormatInt(num, 10)
+	case tag == 0x829a && num == 1:
+		// ExposureTime
+		return fmt.Sprintf("1/%d", den)
+	}
+	return strconv.FormatFloat(math.Round(float64(num)/float64(den)*1e4)/1e4, 'f', -1, 64)
+}
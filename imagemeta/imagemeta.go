@@ -0,0 +1,200 @@
+// Package imagemeta reads an image's format, dimensions and EXIF data
+// without decoding its pixels. PNG, JPEG and GIF headers are read with the
+// standard library's decoders; WebP and EXIF are parsed by hand.
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ErrUnsupported is returned for data that is not a PNG, JPEG, GIF or WebP
+// image
+var ErrUnsupported = errors.New("unsupported image format")
+
+// ErrCorrupt is returned for images whose headers or EXIF data are broken
+var ErrCorrupt = errors.New("corrupt image")
+
+// Metadata describes an image
+type Metadata struct {
+	// Format is png, jpeg, gif or webp
+	Format string
+	Width  int
+	Height int
+	// Orientation is the EXIF orientation (1-8), or 0 when the image has
+	// none. Values 5-8 mean the image is displayed rotated, with width and
+	// height swapped.
+	Orientation int
+	// EXIF lists the recognised EXIF tags in file order; nil without EXIF
+	EXIF []Tag
+}
+
+// MIMEType returns the image's MIME type
+func (m Metadata) MIMEType() string {
+	return "image/" + m.Format
+}
+
+// Read reads the metadata of an image held in data
+func Read(data []byte) (Metadata, error) {
+	var m Metadata
+	var exif []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		m.Format = "png"
+		exif, err = pngEXIF(data)
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		m.Format = "jpeg"
+		exif, err = jpegEXIF(data)
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		m.Format = "gif"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		m.Format = "webp"
+		m.Width, m.Height, exif, err = webp(data)
+	default:
+		return Metadata{}, ErrUnsupported
+	}
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if m.Format != "webp" {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return Metadata{}, fmt.Errorf("%w: %s header: %v", ErrCorrupt, m.Format, err)
+		}
+		m.Width, m.Height = config.Width, config.Height
+	}
+
+	if exif != nil {
+		if m.EXIF, m.Orientation, err = parseEXIF(exif); err != nil {
+			return Metadata{}, err
+		}
+	}
+	return m, nil
+}
+
+// jpegEXIF walks the JPEG segments before the image data and returns the
+// TIFF structure from the APP1 Exif segment, if any
+func jpegEXIF(data []byte) ([]byte, error) {
+	for i := 2; ; {
+		if i+4 > len(data) {
+			return nil, fmt.Errorf("%w: jpeg ends inside the headers", ErrCorrupt)
+		}
+		if data[i] != 0xff {
+			return nil, fmt.Errorf("%w: jpeg marker expected at byte %d", ErrCorrupt, i)
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0xd8 || marker >= 0xd0 && marker <= 0xd7:
+			// Markers without a payload
+			i += 2
+			continue
+		case marker == 0xda || marker == 0xd9:
+			// Start of scan or end of image: no EXIF before the pixels
+			return nil, nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, fmt.Errorf("%w: jpeg segment at byte %d overruns the file", ErrCorrupt, i)
+		}
+		payload := data[i+4 : i+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return payload[6:], nil
+		}
+		i += 2 + length
+	}
+}
+
+// pngEXIF returns the contents of the eXIf chunk, if any. A PNG that ends
+// before its image data is truncated.
+func pngEXIF(data []byte) ([]byte, error) {
+	for i := 8; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("%w: png chunk header at byte %d is truncated", ErrCorrupt, i)
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		// length, type and CRC surround the chunk data
+		if length < 0 || length > len(data)-i-12 {
+			return nil, fmt.Errorf("%w: png %s chunk at byte %d overruns the file", ErrCorrupt, typ, i)
+		}
+		switch typ {
+		case "eXIf":
+			return data[i+8 : i+8+length], nil
+		case "IDAT", "IEND":
+			// eXIf must come before the image data
+			return nil, nil
+		}
+		i += 12 + length
+	}
+	return nil, fmt.Errorf("%w: png ends before the image data", ErrCorrupt)
+}
+
+// webp reads a WebP file's canvas size and EXIF chunk. The size comes from
+// the VP8X extended header when present, else from the lossy (VP8) or
+// lossless (VP8L) bitstream header.
+func webp(data []byte) (width, height int, exif []byte, err error) {
+	size := int(binary.LittleEndian.Uint32(data[4:]))
+	if size+8 > len(data) {
+		return 0, 0, nil, fmt.Errorf("%w: webp is %d bytes short of its RIFF size", ErrCorrupt, size+8-len(data))
+	}
+	data = data[:size+8]
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return 0, 0, nil, fmt.Errorf("%w: webp chunk header at byte %d is truncated", ErrCorrupt, i)
+		}
+		typ := string(data[i : i+4])
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if length < 0 || length > len(data)-i-8 {
+			return 0, 0, nil, fmt.Errorf("%w: webp %q chunk at byte %d overruns the file", ErrCorrupt, typ, i)
+		}
+		chunk := data[i+8 : i+8+length]
+
+		switch typ {
+		case "VP8X":
+			if len(chunk) < 10 {
+				return 0, 0, nil, fmt.Errorf("%w: webp VP8X chunk is too short", ErrCorrupt)
+			}
+			width = int(uint32(chunk[4])|uint32(chunk[5])<<8|uint32(chunk[6])<<16) + 1
+			height = int(uint32(chunk[7])|uint32(chunk[8])<<8|uint32(chunk[9])<<16) + 1
+		case "VP8 ":
+			if width == 0 {
+				if len(chunk) < 10 || !bytes.Equal(chunk[3:6], []byte{0x9d, 0x01, 0x2a}) {
+					return 0, 0, nil, fmt.Errorf("%w: webp VP8 frame header is invalid", ErrCorrupt)
+				}
+				width = int(binary.LittleEndian.Uint16(chunk[6:]) & 0x3fff)
+				height = int(binary.LittleEndian.Uint16(chunk[8:]) & 0x3fff)
+			}
+		case "VP8L":
+			if width == 0 {
+				if len(chunk) < 5 || chunk[0] != 0x2f {
+					return 0, 0, nil, fmt.Errorf("%w: webp VP8L header is invalid", ErrCorrupt)
+				}
+				bits := binary.LittleEndian.Uint32(chunk[1:])
+				width = int(bits&0x3fff) + 1
+				height = int(bits>>14&0x3fff) + 1
+			}
+		case "EXIF":
+			// Some encoders keep the JPEG-style prefix
+			exif = bytes.TrimPrefix(chunk, []byte("Exif\x00\x00"))
+		}
+		// Chunks are padded to an even length
+		i += 8 + length + length%2
+	}
+	if width == 0 || height == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: webp has no image header", ErrCorrupt)
+	}
+	return width, height, exif, nil
+}
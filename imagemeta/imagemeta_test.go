@@ -0,0 +1,132 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encoded(t *testing.T, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := encode(&b, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// riff wraps WebP chunks, each given as type followed by payload, in a
+// RIFF container
+func riff(chunks ...string) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for i := 0; i < len(chunks); i += 2 {
+		body.WriteString(chunks[i])
+		binary.Write(&body, binary.LittleEndian, uint32(len(chunks[i+1])))
+		body.WriteString(chunks[i+1])
+		if len(chunks[i+1])%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	head := []byte("RIFF\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(head[4:], uint32(body.Len()))
+	return append(head, body.Bytes()...)
+}
+
+// A 3x2 canvas in each WebP header flavour
+var (
+	webpLossy    = riff("VP8 ", "\x00\x00\x00\x9d\x01\x2a\x03\x00\x02\x00")
+	webpLossless = riff("VP8L", "\x2f\x02\x40\x00\x00")
+	webpExtended = riff("VP8X", "\x00\x00\x00\x00\x02\x00\x00\x01\x00\x00", "VP8L", "\x2f\x00\x00\x00\x00")
+)
+
+func validImages(t *testing.T) map[string][]byte {
+	return map[string][]byte{
+		"png":           encoded(t, func(b *bytes.Buffer, m image.Image) error { return png.Encode(b, m) }),
+		"jpeg":          encoded(t, func(b *bytes.Buffer, m image.Image) error { return jpeg.Encode(b, m, nil) }),
+		"gif":           encoded(t, func(b *bytes.Buffer, m image.Image) error { return gif.Encode(b, m, nil) }),
+		"webp lossy":    webpLossy,
+		"webp lossless": webpLossless,
+		"webp extended": webpExtended,
+	}
+}
+
+func TestReadDimensions(t *testing.T) {
+	for name, data := range validImages(t) {
+		t.Run(name, func(t *testing.T) {
+			m, err := Read(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Width != 3 || m.Height != 2 {
+				t.Errorf("got %dx%d, want 3x2", m.Width, m.Height)
+			}
+			if m.EXIF != nil || m.Orientation != 0 {
+				t.Errorf("got EXIF %v, orientation %d from an image without any", m.EXIF, m.Orientation)
+			}
+		})
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	for name, data := range validImages(t) {
+		t.Run(name, func(t *testing.T) {
+			// The first 64 bytes cover the headers of these small images
+			for n := range min(len(data), 64) {
+				_, err := Read(data[:n])
+				if !errors.Is(err, ErrCorrupt) && !errors.Is(err, ErrUnsupported) {
+					t.Errorf("first %d bytes: got %v, want ErrCorrupt or ErrUnsupported", n, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCorrupt(t *testing.T) {
+	// exif builds a JPEG whose APP1 segment holds tiff
+	exif := func(tiff string) []byte {
+		payload := "Exif\x00\x00" + tiff
+		seg := []byte{0xff, 0xe1, 0, 0}
+		binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+		return append(append([]byte{0xff, 0xd8}, seg...), payload...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrUnsupported},
+		{"text", []byte("not an image"), ErrUnsupported},
+		{"bmp", []byte("BM\x00\x00\x00\x00\x00\x00\x00\x00"), ErrUnsupported},
+		{"riff but not webp", []byte("RIFF\x04\x00\x00\x00WAVE"), ErrUnsupported},
+		{"jpeg without marker", []byte("\xff\xd8\x00\x00\x00\x00"), ErrCorrupt},
+		{"jpeg segment overrun", []byte("\xff\xd8\xff\xe0\xff\xff\x00\x00"), ErrCorrupt},
+		{"png chunk overrun", []byte("\x89PNG\r\n\x1a\n\x7f\xff\xff\xffIHDR\x00\x00\x00\x00"), ErrCorrupt},
+		{"png without header", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x00IEND\xae\x42\x60\x82"), ErrCorrupt},
+		{"gif without screen", []byte("GIF89a"), ErrCorrupt},
+		{"webp without image", riff("ICCP", "xx"), ErrCorrupt},
+		{"webp bad vp8 signature", riff("VP8 ", "\x00\x00\x00\x00\x00\x00\x03\x00\x02\x00"), ErrCorrupt},
+		{"webp bad vp8l signature", riff("VP8L", "\x00\x02\x40\x00\x00"), ErrCorrupt},
+		{"webp short vp8x", riff("VP8X", "\x00\x00"), ErrCorrupt},
+		{"exif byte order", exif("XX\x00\x2a\x00\x00\x00\x08"), ErrCorrupt},
+		{"exif not tiff", exif("II\x2b\x00\x08\x00\x00\x00"), ErrCorrupt},
+		{"exif directory outside", exif("II\x2a\x00\xff\x00\x00\x00"), ErrCorrupt},
+		{"exif directory loop", exif("II\x2a\x00\x08\x00\x00\x00" +
+			"\x01\x00" + "\x69\x87\x04\x00\x01\x00\x00\x00\x08\x00\x00\x00"), ErrCorrupt},
+		{"exif value overrun", exif("II\x2a\x00\x08\x00\x00\x00" +
+			"\x01\x00" + "\x0f\x01\x02\x00\x40\x00\x00\x00\x1a\x00\x00\x00"), ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
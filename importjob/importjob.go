@@ -0,0 +1,138 @@
+// Package importjob records the progress of imports so a client can poll a
+// running import by ID, and keeps the outcome of finished ones for a while.
+package importjob
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrNotFound is returned for unknown or forgotten job IDs
+var ErrNotFound = errors.New("import job not found")
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	// StatusFailed jobs stopped early, e.g. on an unreadable file or when
+	// cancelled; rows imported before that stay imported
+	StatusFailed = "failed"
+)
+
+// Job is one import's progress
+type Job struct {
+	ID     string
+	Status string
+	DryRun bool
+	// Total counts the data rows read so far, Imported and Failed those
+	// imported and rejected
+	Total    int
+	Imported int
+	Failed   int
+	// BytesRead is how much of the input has been parsed; BytesTotal is its
+	// size, 0 when unknown
+	BytesRead  int64
+	BytesTotal int64
+	// Errors are the first per-row errors, as importUsers returns them
+	Errors          []interface{}
+	ErrorsTruncated bool
+	// Message describes the outcome once finished
+	Message    string
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Tracker holds the jobs, safe for concurrent use
+type Tracker struct {
+	history int
+	clock   clock.Clock
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	// order holds job IDs, oldest first
+	order []string
+}
+
+// NewTracker creates a tracker that remembers up to history jobs. A nil
+// clock uses the system clock.
+func NewTracker(history int, c clock.Clock) *Tracker {
+	if history < 1 {
+		history = 1
+	}
+	return &Tracker{history: history, clock: clock.OrSystem(c), jobs: make(map[string]*Job)}
+}
+
+// Start records a running job
+func (t *Tracker) Start(id string, dryRun bool, bytesTotal int64) Job {
+	now := t.clock.Now()
+	job := &Job{
+		ID:         id,
+		Status:     StatusRunning,
+		DryRun:     dryRun,
+		BytesTotal: bytesTotal,
+		StartedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.jobs[id] = job
+	t.order = append(t.order, id)
+	t.trim()
+	return *job
+}
+
+// Update changes a running job through fn; unknown jobs are ignored
+func (t *Tracker) Update(id string, fn func(*Job)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = t.clock.Now()
+	}
+}
+
+// Finish marks a job completed, or failed with err's message
+func (t *Tracker) Finish(id, message string, err error) {
+	t.Update(id, func(job *Job) {
+		job.Status = StatusCompleted
+		job.Message = message
+		if err != nil {
+			job.Status = StatusFailed
+			job.Message = err.Error()
+		}
+		job.FinishedAt = t.clock.Now()
+	})
+}
+
+// Get returns a job
+func (t *Tracker) Get(id string) (Job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return *job, nil
+}
+
+// trim forgets the oldest jobs beyond history, preferring finished ones so
+// a running job is only forgotten when every other job is running too
+func (t *Tracker) trim() {
+	for len(t.order) > t.history {
+		drop := 0
+		for i, id := range t.order {
+			if t.jobs[id].Status != StatusRunning {
+				drop = i
+				break
+			}
+		}
+		delete(t.jobs, t.order[drop])
+		t.order = append(t.order[:drop], t.order[drop+1:]...)
+	}
+}
@@ -0,0 +1,84 @@
+// Package inflight coalesces identical concurrent calls: while a call for a
+// key is running, further calls with the same key wait for it and share its
+// result instead of repeating the work.
+package inflight
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of coalescing counters
+type Stats struct {
+	InFlight  int   `json:"inFlight"`
+	Executed  int64 `json:"executed"`
+	Coalesced int64 `json:"coalesced"`
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Group is safe for concurrent use; the zero value is ready to use
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+
+	executed  atomic.Int64
+	coalesced atomic.Int64
+}
+
+// Do runs fn unless a call for key is already running, in which case it
+// waits for that call and returns its result. shared reports whether the
+// result came from another caller's call. All callers receive the same
+// value, so it must be treated as read-only.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (value interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		g.coalesced.Add(1)
+		<-c.done
+		return c.value, c.err, true
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.executed.Add(1)
+	defer func() {
+		// A panic in fn still releases the waiters, with an error
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("coalesced call panicked: %v", r)
+			g.finish(key, c)
+			panic(r)
+		}
+		g.finish(key, c)
+	}()
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
+
+func (g *Group) finish(key string, c *call) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// Stats returns the current counters
+func (g *Group) Stats() Stats {
+	g.mu.Lock()
+	inFlight := len(g.calls)
+	g.mu.Unlock()
+	return Stats{
+		InFlight:  inFlight,
+		Executed:  g.executed.Load(),
+		Coalesced: g.coalesced.Load(),
+	}
+}
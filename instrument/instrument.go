@@ -0,0 +1,130 @@
+// Package instrument detects slow resolvers and repeated identical lookups
+// (the N+1 pattern) within a single request, logging structured warnings.
+package instrument
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// Config holds the detection thresholds
+type Config struct {
+	// SlowThreshold is the resolver duration above which a warning is logged
+	SlowThreshold time.Duration
+	// RepeatThreshold is how many identical lookups in one request trigger a warning
+	RepeatThreshold int
+	// OnCall, when set, is told about every completed resolver call
+	OnCall func(Call)
+}
+
+// Call describes one completed resolver call
+type Call struct {
+	Resolver string
+	// Fingerprint is the arguments' Fingerprint
+	Fingerprint string
+	Duration    time.Duration
+	Err         error
+}
+
+var (
+	configMu sync.RWMutex
+	config   = Config{SlowThreshold: 500 * time.Millisecond, RepeatThreshold: 2}
+)
+
+// Configure replaces the detection thresholds
+func Configure(cfg Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	config = cfg
+}
+
+func currentConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+type trackerKey struct{}
+
+// tracker counts lookups made while serving one resolver call
+type tracker struct {
+	resolver    string
+	fingerprint string
+
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+// Fingerprint returns a short stable hash of a resolver's arguments,
+// ignoring host context values, so repeated calls can be correlated in logs
+// without printing the arguments themselves
+func Fingerprint(args map[string]interface{}) string {
+	filtered := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if !strings.HasPrefix(k, "context_") {
+			filtered[k] = v
+		}
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return "unhashable"
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Resolver wraps a resolver with slow-call detection, a per-request lookup
+// tracker used by RecordLookup and the OnCall hook
+func Resolver(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		t := &tracker{
+			resolver:    name,
+			fingerprint: Fingerprint(args),
+			lookups:     make(map[string]int),
+		}
+		ctx = context.WithValue(ctx, trackerKey{}, t)
+
+		started := time.Now()
+		result, err := resolver(ctx, args)
+		elapsed := time.Since(started)
+
+		cfg := currentConfig()
+		if threshold := cfg.SlowThreshold; threshold > 0 && elapsed > threshold {
+			log.Printf("🐢 [hc-hello-world-plugin] event=slow_resolver resolver=%s duration=%s threshold=%s args_fingerprint=%s error=%t",
+				name, elapsed.Round(time.Millisecond), threshold, t.fingerprint, err != nil)
+		}
+		if cfg.OnCall != nil {
+			cfg.OnCall(Call{Resolver: name, Fingerprint: t.fingerprint, Duration: elapsed, Err: err})
+		}
+		return result, err
+	}
+}
+
+// RecordLookup notes a sub-lookup (e.g. kind "User", key "42") made while
+// serving the current request and warns once when the same lookup repeats
+// RepeatThreshold times - a sign the data should be batched or memoized
+func RecordLookup(ctx context.Context, kind, key string) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+
+	lookup := kind + ":" + key
+	t.mu.Lock()
+	t.lookups[lookup]++
+	count := t.lookups[lookup]
+	t.mu.Unlock()
+
+	if threshold := currentConfig().RepeatThreshold; threshold > 0 && count == threshold {
+		log.Printf("🔁 [hc-hello-world-plugin] event=repeated_lookup resolver=%s lookup=%s count=%d args_fingerprint=%s hint=\"batch or memoize this lookup\"",
+			t.resolver, lookup, count, t.fingerprint)
+	}
+}
@@ -0,0 +1,183 @@
+// Package jobs runs background work on a bounded in-process queue so
+// resolvers can return before slow side effects (emails, webhooks) finish,
+// now or after a delay.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrQueueFull is returned when the queue cannot accept more jobs
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrQueueClosed is returned when enqueueing after Close
+var ErrQueueClosed = errors.New("job queue is closed")
+
+// Func is the work performed by a job
+type Func func(ctx context.Context) error
+
+// job is a queued unit of work
+type job struct {
+	id   uint64
+	name string
+	run  Func
+}
+
+// Queue executes jobs on a fixed pool of workers
+type Queue struct {
+	jobs    chan job
+	timeout time.Duration
+	nextID  atomic.Uint64
+	wg      sync.WaitGroup
+
+	clock clock.Clock
+
+	mu     sync.RWMutex
+	closed bool
+	// scheduled holds the timers of jobs waiting for their delay, by ID
+	scheduled map[uint64]clock.Timer
+}
+
+// NewQueue starts a queue with the given number of workers and buffer size.
+// Every job runs with its own context limited to timeout.
+func NewQueue(workers, size int, timeout time.Duration) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &Queue{
+		jobs:      make(chan job, size),
+		timeout:   timeout,
+		clock:     clock.System,
+		scheduled: make(map[uint64]clock.Timer),
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// WithClock replaces the system clock that times scheduled jobs; call it
+// before the queue is used
+func (q *Queue) WithClock(c clock.Clock) *Queue {
+	q.clock = clock.OrSystem(c)
+	return q
+}
+
+// Enqueue schedules fn to run in the background and returns its job ID
+func (q *Queue) Enqueue(name string, fn Func) (string, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return "", ErrQueueClosed
+	}
+
+	j := job{id: q.nextID.Add(1), name: name, run: fn}
+	if err := q.pushLocked(j); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s#%d", j.name, j.id), nil
+}
+
+// Schedule queues fn once delay has passed and returns its job ID. The
+// timer lives in memory: jobs still waiting when the queue is closed or the
+// plugin restarts never run. A job that comes due while the queue is full
+// is dropped and logged.
+func (q *Queue) Schedule(name string, delay time.Duration, fn Func) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return "", ErrQueueClosed
+	}
+
+	j := job{id: q.nextID.Add(1), name: name, run: fn}
+	q.scheduled[j.id] = q.clock.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if _, pending := q.scheduled[j.id]; !pending {
+			return
+		}
+		delete(q.scheduled, j.id)
+		_ = q.pushLocked(j)
+	})
+	log.Printf("⏲️  [hc-hello-world-plugin] Job %s#%d scheduled in %s", j.name, j.id, delay)
+	return fmt.Sprintf("%s#%d", j.name, j.id), nil
+}
+
+// pushLocked hands j to the workers without blocking; the caller holds q.mu
+// and has checked the queue is open
+func (q *Queue) pushLocked(j job) error {
+	select {
+	case q.jobs <- j:
+		log.Printf("📥 [hc-hello-world-plugin] Job %s#%d queued", j.name, j.id)
+		return nil
+	default:
+		log.Printf("⚠️  [hc-hello-world-plugin] Job %s rejected: %v", j.name, ErrQueueFull)
+		return ErrQueueFull
+	}
+}
+
+// Pending returns how many scheduled jobs are still waiting for their delay
+func (q *Queue) Pending() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.scheduled)
+}
+
+// Close stops accepting jobs, drops scheduled jobs that are not due yet and
+// waits for queued jobs to finish
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	for id, timer := range q.scheduled {
+		timer.Stop()
+		delete(q.scheduled, id)
+	}
+	close(q.jobs)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for j := range q.jobs {
+		q.execute(j)
+	}
+}
+
+// execute runs a single job, recovering from panics so one bad job cannot
+// take down the worker
+func (q *Queue) execute(j job) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		return j.run(ctx)
+	}()
+
+	elapsed := time.Since(started).Round(time.Millisecond)
+	if err != nil {
+		log.Printf("❌ [hc-hello-world-plugin] Job %s#%d failed after %s: %v", j.name, j.id, elapsed, err)
+		return
+	}
+	log.Printf("✅ [hc-hello-world-plugin] Job %s#%d completed in %s", j.name, j.id, elapsed)
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+func TestScheduleRunsWhenClockReachesDelay(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQueue(1, 10, time.Second).WithClock(clk)
+	defer q.Close()
+
+	ran := make(chan string, 2)
+	schedule := func(name string, delay time.Duration) {
+		t.Helper()
+		if _, err := q.Schedule(name, delay, func(context.Context) error {
+			ran <- name
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schedule("later", time.Hour)
+	schedule("sooner", time.Minute)
+	if q.Pending() != 2 {
+		t.Fatalf("Pending = %d, want 2", q.Pending())
+	}
+
+	clk.Advance(59 * time.Second)
+	select {
+	case name := <-ran:
+		t.Fatalf("%s ran before its delay", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+	if name := <-ran; name != "sooner" {
+		t.Fatalf("ran %s, want sooner", name)
+	}
+	if q.Pending() != 1 {
+		t.Fatalf("Pending = %d, want 1", q.Pending())
+	}
+
+	clk.Advance(time.Hour)
+	if name := <-ran; name != "later" {
+		t.Fatalf("ran %s, want later", name)
+	}
+}
+
+func TestCloseDropsJobsNotYetDue(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQueue(1, 10, time.Second).WithClock(clk)
+
+	ran := make(chan struct{}, 1)
+	if _, err := q.Schedule("dropped", time.Minute, func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	if q.Pending() != 0 || clk.Pending() != 0 {
+		t.Fatalf("after Close: queue has %d and clock %d pending, want none", q.Pending(), clk.Pending())
+	}
+
+	clk.Advance(time.Hour)
+	select {
+	case <-ran:
+		t.Fatal("job ran after Close")
+	default:
+	}
+	if _, err := q.Schedule("late", 0, func(context.Context) error { return nil }); err != ErrQueueClosed {
+		t.Errorf("Schedule after Close: %v, want ErrQueueClosed", err)
+	}
+}
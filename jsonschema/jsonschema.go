@@ -0,0 +1,236 @@
+// Package jsonschema validates decoded JSON values against the subset of
+// JSON Schema used by the plugin's REST endpoints: type, properties,
+// required, additionalProperties, items, enum, minLength, maxLength,
+// pattern, minimum, maximum, minItems and maxItems.
+package jsonschema
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// Violation describes one place where a value does not match its schema.
+// Path is a JSON pointer such as "/name" ("" is the document root).
+type Violation struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// ToMap converts the violation into the structure returned in responses
+func (v Violation) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"path":    v.Path,
+		"keyword": v.Keyword,
+		"message": v.Message,
+	}
+}
+
+// IsSchema reports whether schema is a real JSON Schema rather than the
+// informal {"field": "type"} maps the SDK examples use
+func IsSchema(schema map[string]interface{}) bool {
+	_, hasType := schema["type"]
+	_, hasProperties := schema["properties"]
+	return hasType || hasProperties
+}
+
+// Validate checks value against schema and returns every violation found,
+// sorted by path. It returns nil when value is valid.
+func Validate(schema map[string]interface{}, value interface{}) []Violation {
+	var violations []Violation
+	validate(schema, value, "", &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string, out *[]Violation) {
+	add := func(keyword, format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if want, ok := schema["type"].(string); ok && !hasType(value, want) {
+		add("type", "expected %s, got %s", want, typeName(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(enum, value) {
+		add("enum", "must be one of %v", enum)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := number(schema["minLength"]); ok && float64(length) < min {
+			add("minLength", "must be at least %v characters", min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && float64(length) > max {
+			add("maxLength", "must be at most %v characters", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				add("pattern", "schema pattern %q is invalid", pattern)
+			} else if !re.MatchString(v) {
+				add("pattern", "must match pattern %q", pattern)
+			}
+		}
+
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range requiredFields(schema) {
+			if _, present := v[name]; !present {
+				*out = append(*out, Violation{Path: path + "/" + name, Keyword: "required", Message: "is required"})
+			}
+		}
+		for name, propValue := range v {
+			propSchema, known := properties[name].(map[string]interface{})
+			if !known {
+				if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+					*out = append(*out, Violation{Path: path + "/" + name, Keyword: "additionalProperties", Message: "is not allowed"})
+				}
+				continue
+			}
+			validate(propSchema, propValue, path+"/"+name, out)
+		}
+
+	case []interface{}:
+		if min, ok := number(schema["minItems"]); ok && float64(len(v)) < min {
+			add("minItems", "must contain at least %v items", min)
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(v)) > max {
+			add("maxItems", "must contain at most %v items", max)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(items, item, fmt.Sprintf("%s/%d", path, i), out)
+			}
+		}
+
+	default:
+		if n, isNumber := number(value); isNumber {
+			if min, ok := number(schema["minimum"]); ok && n < min {
+				add("minimum", "must be >= %v", min)
+			}
+			if max, ok := number(schema["maximum"]); ok && n > max {
+				add("maximum", "must be <= %v", max)
+			}
+		}
+	}
+}
+
+// requiredFields accepts "required" as either []string or []interface{}
+func requiredFields(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		fields := make([]string, 0, len(required))
+		for _, field := range required {
+			if name, ok := field.(string); ok {
+				fields = append(fields, name)
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+func hasType(value interface{}, want string) bool {
+	switch want {
+	case "integer":
+		n, ok := number(value)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := number(value)
+		return ok
+	default:
+		return typeName(value) == want
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := number(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number converts any Go numeric type to float64
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateBody wraps handler so the request body is validated against schema
+// first. Host-provided context_ args are not part of the body and are
+// ignored. Invalid bodies get a 400 response envelope listing every
+// violation; the handler is not called.
+func ValidateBody(schema map[string]interface{}, handler sdk.RESTHandlerFunc) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		body := make(map[string]interface{}, len(args))
+		for key, value := range args {
+			if !strings.HasPrefix(key, "context_") {
+				body[key] = value
+			}
+		}
+
+		violations := Validate(schema, body)
+		if len(violations) == 0 {
+			return handler(ctx, args)
+		}
+
+		details := make([]interface{}, 0, len(violations))
+		for _, violation := range violations {
+			details = append(details, violation.ToMap())
+		}
+		return map[string]interface{}{
+			"statusCode": 400,
+			"headers":    map[string]interface{}{"Content-Type": "application/json"},
+			"body": map[string]interface{}{
+				"error":      "invalid request body",
+				"code":       "VALIDATION_FAILED",
+				"violations": details,
+			},
+		}, nil
+	}
+}
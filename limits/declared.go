@@ -0,0 +1,130 @@
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// defaultPageSizeArgs are the arguments MaxPageSize applies to when
+// PageSizeArgs is empty
+var defaultPageSizeArgs = []string{"limit", "pageSize", "first", "chunkSize"}
+
+// Declared are one resolver's limits, declared with its registration (see
+// registry.Plugin.Limit) and checked around every call. Zero fields are not
+// checked. Unlike Config.PageSize, an oversized page is rejected rather
+// than clamped, so a client never mistakes a short page for the end.
+type Declared struct {
+	// MaxPageSize bounds the PageSizeArgs arguments
+	MaxPageSize int `json:"maxPageSize,omitempty"`
+	// PageSizeArgs are the arguments holding a page size; empty means
+	// limit, pageSize, first and chunkSize
+	PageSizeArgs []string `json:"pageSizeArgs,omitempty"`
+	// MaxArrayLength bounds every list argument
+	MaxArrayLength int `json:"maxArrayLength,omitempty"`
+	// MaxResponseBytes bounds the JSON encoding of the result
+	MaxResponseBytes int `json:"maxResponseBytes,omitempty"`
+}
+
+// IsZero reports whether no limit is declared
+func (d Declared) IsZero() bool {
+	return d.MaxPageSize <= 0 && d.MaxArrayLength <= 0 && d.MaxResponseBytes <= 0
+}
+
+// Wrap enforces the limits around the resolver registered as name. parse
+// turns the raw arguments into typed ones, as the resolver itself would,
+// so lists sent as JSON strings are counted as lists. Violations are
+// returned as *Error.
+func (d Declared) Wrap(name string, parse func(map[string]interface{}) map[string]interface{}, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	if d.IsZero() {
+		return resolver
+	}
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		if err := d.checkArgs(parse(rawArgs)); err != nil {
+			log.Printf("✂️  [hc-hello-world-plugin] event=limit_exceeded resolver=%s code=%s field=%s limit=%d actual=%d", name, err.Code, err.Field, err.Limit, err.Actual)
+			return nil, err
+		}
+
+		result, err := resolver(ctx, rawArgs)
+		if err != nil || d.MaxResponseBytes <= 0 {
+			return result, err
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("%s: encoding response: %w", name, err)
+		}
+		if len(encoded) > d.MaxResponseBytes {
+			limitErr := &Error{
+				Code:    CodeResponseTooLarge,
+				Message: fmt.Sprintf("%s returned %d bytes as JSON, the maximum is %d - request fewer items or fields", name, len(encoded), d.MaxResponseBytes),
+				Limit:   d.MaxResponseBytes,
+				Actual:  len(encoded),
+			}
+			log.Printf("✂️  [hc-hello-world-plugin] event=limit_exceeded resolver=%s code=%s limit=%d actual=%d", name, limitErr.Code, limitErr.Limit, limitErr.Actual)
+			return nil, limitErr
+		}
+		return result, nil
+	}
+}
+
+// checkArgs returns the first violation among args, checking arguments in
+// name order so the same request always reports the same one
+func (d Declared) checkArgs(args map[string]interface{}) *Error {
+	if d.MaxPageSize > 0 {
+		names := d.PageSizeArgs
+		if len(names) == 0 {
+			names = defaultPageSizeArgs
+		}
+		for _, name := range names {
+			size, ok := intArg(args[name])
+			if ok && size > d.MaxPageSize {
+				return &Error{
+					Code:    CodePageTooLarge,
+					Message: fmt.Sprintf("%s is %d, the maximum is %d", name, size, d.MaxPageSize),
+					Field:   name,
+					Limit:   d.MaxPageSize,
+					Actual:  size,
+				}
+			}
+		}
+	}
+
+	if d.MaxArrayLength > 0 {
+		names := make([]string, 0, len(args))
+		for name := range args {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			list, ok := args[name].([]interface{})
+			if ok && len(list) > d.MaxArrayLength {
+				return &Error{
+					Code:    CodeTooManyItems,
+					Message: fmt.Sprintf("%s has %d items, the maximum is %d", name, len(list), d.MaxArrayLength),
+					Field:   name,
+					Limit:   d.MaxArrayLength,
+					Actual:  len(list),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// intArg reads an integer argument, which JSON decoding may have made a
+// float64
+func intArg(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,176 @@
+// Package limits guards resolvers against oversized requests: it caps page
+// sizes, bounds list arguments and free-form JSON values, and rejects
+// queries whose estimated complexity is too high. Config holds the limits
+// every resolver checks for itself; Declared holds one resolver's limits,
+// declared when it is registered and enforced around it.
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Error codes reported by the guards
+const (
+	CodeTooManyItems    = "TOO_MANY_ITEMS"
+	CodeQueryTooComplex = "QUERY_TOO_COMPLEX"
+	CodeJSONTooDeep     = "JSON_TOO_DEEP"
+	CodeJSONTooLarge    = "JSON_TOO_LARGE"
+	// CodeResourceExhausted is used when a request is shed under memory pressure
+	CodeResourceExhausted = "RESOURCE_EXHAUSTED"
+	CodePageTooLarge      = "PAGE_TOO_LARGE"
+	CodeResponseTooLarge  = "RESPONSE_TOO_LARGE"
+)
+
+// Config holds the configured limits
+type Config struct {
+	// MaxPageSize caps limit/pageSize arguments
+	MaxPageSize int
+	// MaxItems bounds list arguments and the number of items a resolver generates
+	MaxItems int
+	// MaxComplexity is the highest accepted complexity score
+	MaxComplexity int
+	// MaxJSONDepth bounds the nesting of free-form JSON arguments; a flat
+	// object has depth 1
+	MaxJSONDepth int
+	// MaxJSONBytes bounds the encoded size of free-form JSON arguments
+	MaxJSONBytes int
+}
+
+// Default returns conservative limits for the sample resolvers
+func Default() Config {
+	return Config{
+		MaxPageSize:   100,
+		MaxItems:      1000,
+		MaxComplexity: 1000,
+		MaxJSONDepth:  10,
+		MaxJSONBytes:  16 << 10,
+	}
+}
+
+// Error is a limit violation with a machine-readable code
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Field is the argument over the limit; empty when the limit applies to
+	// the whole request or response
+	Field string `json:"field,omitempty"`
+	// Limit and Actual are the allowed and the requested amount, for
+	// numeric limits
+	Limit  int `json:"limit,omitempty"`
+	Actual int `json:"actual,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ToMap converts the error into the structure returned in GraphQL responses
+func (e *Error) ToMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"code":    e.Code,
+		"message": e.Message,
+	}
+	if e.Field != "" {
+		result["field"] = e.Field
+	}
+	if e.Limit > 0 {
+		result["limit"] = e.Limit
+		result["actual"] = e.Actual
+	}
+	return result
+}
+
+// PageSize clamps a requested page size into [1, MaxPageSize], falling back
+// to def when the request is not positive
+func (c Config) PageSize(requested, def int) int {
+	if requested <= 0 {
+		requested = def
+	}
+	if c.MaxPageSize > 0 && requested > c.MaxPageSize {
+		log.Printf("✂️  [hc-hello-world-plugin] Page size %d capped to %d", requested, c.MaxPageSize)
+		return c.MaxPageSize
+	}
+	return requested
+}
+
+// CheckItems fails when a list argument or generated result has more than
+// MaxItems entries
+func (c Config) CheckItems(field string, count int) error {
+	if c.MaxItems > 0 && count > c.MaxItems {
+		return &Error{
+			Code:    CodeTooManyItems,
+			Message: fmt.Sprintf("%s has %d items, the maximum is %d", field, count, c.MaxItems),
+			Field:   field,
+			Limit:   c.MaxItems,
+			Actual:  count,
+		}
+	}
+	return nil
+}
+
+// CheckComplexity estimates a list query's cost as items * costPerItem and
+// fails when it exceeds MaxComplexity
+func (c Config) CheckComplexity(resolver string, items, costPerItem int) error {
+	score := items * costPerItem
+	if c.MaxComplexity > 0 && score > c.MaxComplexity {
+		return &Error{
+			Code: CodeQueryTooComplex,
+			Message: fmt.Sprintf("%s has complexity %d (%d items x cost %d), the maximum is %d - request fewer items",
+				resolver, score, items, costPerItem, c.MaxComplexity),
+		}
+	}
+	return nil
+}
+
+// CheckJSON fails when a free-form JSON argument nests deeper than
+// MaxJSONDepth or encodes to more than MaxJSONBytes
+func (c Config) CheckJSON(field string, value interface{}) error {
+	if depth := jsonDepth(value); c.MaxJSONDepth > 0 && depth > c.MaxJSONDepth {
+		return &Error{
+			Code:    CodeJSONTooDeep,
+			Message: fmt.Sprintf("%s is nested %d levels deep, the maximum is %d", field, depth, c.MaxJSONDepth),
+			Field:   field,
+			Limit:   c.MaxJSONDepth,
+			Actual:  depth,
+		}
+	}
+	if c.MaxJSONBytes > 0 {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if len(encoded) > c.MaxJSONBytes {
+			return &Error{
+				Code:    CodeJSONTooLarge,
+				Message: fmt.Sprintf("%s is %d bytes as JSON, the maximum is %d", field, len(encoded), c.MaxJSONBytes),
+				Field:   field,
+				Limit:   c.MaxJSONBytes,
+				Actual:  len(encoded),
+			}
+		}
+	}
+	return nil
+}
+
+// jsonDepth counts the objects and arrays nested in value, outermost
+// included; scalars have depth 0
+func jsonDepth(value interface{}) int {
+	var children []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			children = append(children, child)
+		}
+	case []interface{}:
+		children = v
+	default:
+		return 0
+	}
+	deepest := 0
+	for _, child := range children {
+		deepest = max(deepest, jsonDepth(child))
+	}
+	return deepest + 1
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// tag prefixes the plugin's own log lines; forwarded records carry the
+// plugin name as their module instead
+const tag = "[hc-hello-world-plugin] "
+
+// fieldPattern matches key=value and key="quoted value" pairs such as the
+// event=... fields of structured log lines
+var fieldPattern = regexp.MustCompile(`\b([a-z][a-z_]*)=("(?:[^"\\]|\\.)*"|\S+)`)
+
+// forwarder is the host logger once Forward has been called
+var forwarder atomic.Pointer[hclog.Logger]
+
+// Forward sends every log line to the host as a structured record. The
+// go-plugin host reads the plugin's stderr and re-logs JSON records under
+// the plugin's name at their own level, so they show up in the engine's
+// console properly attributed; plain lines are only logged at debug level.
+// Levels are inferred from the line's marker emoji and key=value pairs
+// become record fields.
+func Forward(name string) {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       name,
+		Output:     os.Stderr,
+		JSONFormat: true,
+		Level:      hclog.Trace,
+	})
+	forwarder.Store(&logger)
+	// The record carries its own timestamp
+	log.SetFlags(0)
+	log.SetOutput(hostWriter{logger: logger})
+}
+
+// Forwarding reports whether logs are being forwarded to the host
+func Forwarding() bool {
+	return forwarder.Load() != nil
+}
+
+// debugf writes a debug record. Forwarded records keep the debug level
+// instead of having it inferred from the line.
+func debugf(format string, args ...interface{}) {
+	if logger := forwarder.Load(); logger != nil {
+		hostWriter{logger: *logger}.emit(hclog.Debug, fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// hostWriter turns standard log lines into host log records
+type hostWriter struct {
+	logger hclog.Logger
+}
+
+// Write handles one log entry; the log package calls it once per entry, so
+// multi-line entries stay a single record
+func (w hostWriter) Write(p []byte) (int, error) {
+	if entry := string(bytes.TrimRight(p, "\n")); strings.TrimSpace(entry) != "" {
+		w.emit(inferLevel(entry), entry)
+	}
+	return len(p), nil
+}
+
+func (w hostWriter) emit(level hclog.Level, line string) {
+	message := strings.Replace(line, tag, "", 1)
+	var fields []interface{}
+	for _, match := range fieldPattern.FindAllStringSubmatch(message, -1) {
+		fields = append(fields, match[1], strings.Trim(match[2], `"`))
+	}
+	w.logger.Log(level, message, fields...)
+}
+
+// inferLevel maps the plugin's log markers onto host levels
+func inferLevel(line string) hclog.Level {
+	switch {
+	case strings.Contains(line, "❌"), strings.Contains(line, "[ERROR]"):
+		return hclog.Error
+	case strings.Contains(line, "⚠️"), strings.Contains(line, "🐢"), strings.Contains(line, "🔁"), strings.Contains(line, "[WARN]"):
+		return hclog.Warn
+	case strings.Contains(line, "🔍"), strings.Contains(line, "[DEBUG]"):
+		return hclog.Debug
+	default:
+		return hclog.Info
+	}
+}
@@ -0,0 +1,195 @@
+// Package logging adds a debug level to the plugin's logs, redacts
+// sensitive fields before arguments or responses are written out and can
+// forward logs to the host as structured records.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// Level is a log verbosity
+type Level int32
+
+// Levels from most to least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+)
+
+// Redacted replaces the value of every sensitive field
+const Redacted = "[REDACTED]"
+
+// DefaultPatterns are the field name fragments redacted when none are
+// configured
+var DefaultPatterns = []string{"email", "password", "token", "secret", "authorization", "apikey", "api_key", "api-key"}
+
+var (
+	level atomic.Int32
+
+	patternsMu sync.RWMutex
+	patterns   = DefaultPatterns
+)
+
+func init() {
+	level.Store(int32(LevelInfo))
+}
+
+// ParseLevel parses "debug" or "info"
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q (expected debug or info)", s)
+	}
+}
+
+func (l Level) String() string {
+	if l == LevelDebug {
+		return "debug"
+	}
+	return "info"
+}
+
+// SetLevel changes the verbosity; it is safe to call at any time
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// CurrentLevel returns the verbosity
+func CurrentLevel() Level {
+	return Level(level.Load())
+}
+
+// DebugEnabled reports whether debug logs are written
+func DebugEnabled() bool {
+	return CurrentLevel() <= LevelDebug
+}
+
+// SetPatterns replaces the redaction patterns. A field is redacted when its
+// name contains any pattern, ignoring case; an empty list restores the
+// defaults.
+func SetPatterns(p []string) {
+	var cleaned []string
+	for _, pattern := range p {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			cleaned = append(cleaned, pattern)
+		}
+	}
+	if len(cleaned) == 0 {
+		cleaned = DefaultPatterns
+	}
+	patternsMu.Lock()
+	patterns = cleaned
+	patternsMu.Unlock()
+}
+
+// Debugf logs only at debug level. Arguments that may hold user data should
+// be passed through Redact.
+func Debugf(format string, args ...interface{}) {
+	if DebugEnabled() {
+		debugf(format, args...)
+	}
+}
+
+// sensitive reports whether a field name matches a redaction pattern
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	patternsMu.RLock()
+	defer patternsMu.RUnlock()
+	for _, pattern := range patterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact renders v as JSON with every sensitive field's value replaced, at
+// any depth. Values that are not plain maps and slices are converted through
+// JSON first so struct fields are redacted by their JSON names.
+func Redact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<unencodable %T>", v)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Sprintf("<unencodable %T>", v)
+	}
+	redacted, _ := json.Marshal(redact(generic))
+	return string(redacted)
+}
+
+func redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, inner := range value {
+			if sensitive(key) {
+				result[key] = Redacted
+			} else {
+				result[key] = redact(inner)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, inner := range value {
+			result[i] = redact(inner)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// MaskEmail keeps enough of an address to correlate log lines without
+// revealing it: "alice@example.com" becomes "a***@example.com"
+func MaskEmail(address string) string {
+	local, domain, ok := strings.Cut(address, "@")
+	if !ok || local == "" {
+		return Redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+// Resolver logs each call's arguments and response at debug level with
+// sensitive fields redacted. Host context values are left out of the
+// arguments; they are logged by the context debug helpers instead.
+func Resolver(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		if !DebugEnabled() {
+			return resolver(ctx, args)
+		}
+
+		callArgs := make(map[string]interface{}, len(args))
+		for key, value := range args {
+			if !strings.HasPrefix(key, "context_") {
+				callArgs[key] = value
+			}
+		}
+		debugf("➡️  [hc-hello-world-plugin] event=resolver_request resolver=%s args=%s", name, Redact(callArgs))
+
+		started := time.Now()
+		result, err := resolver(ctx, args)
+		if err != nil {
+			debugf("⬅️  [hc-hello-world-plugin] event=resolver_response resolver=%s duration=%s error=%q",
+				name, time.Since(started).Round(time.Microsecond), err)
+		} else {
+			debugf("⬅️  [hc-hello-world-plugin] event=resolver_response resolver=%s duration=%s response=%s",
+				name, time.Since(started).Round(time.Microsecond), Redact(result))
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,417 @@
+// Package markdown converts a practical subset of Markdown to HTML:
+// ATX headings, paragraphs, hard line breaks, emphasis, code spans, fenced
+// code blocks, block quotes, flat and nested lists, horizontal rules, links,
+// images, autolinks and raw HTML.
+//
+// Raw HTML is passed through as written, so the output must be cleaned with
+// sanitize.HTML before it reaches a browser.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ToHTML renders src as HTML
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	var b strings.Builder
+	blocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+var (
+	headingLine = regexp.MustCompile(`^(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	ruleLine    = regexp.MustCompile(`^(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	fenceLine   = regexp.MustCompile("^(`{3,}|~{3,})[ ]*([^`]*)$")
+	listLine    = regexp.MustCompile(`^([ ]{0,3})([-*+]|\d{1,9}[.)])(?:[ ]+(.*))?$`)
+	htmlLine    = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(?:\s|/?>|$)`)
+	langName    = regexp.MustCompile(`^[A-Za-z0-9_+-]+`)
+)
+
+// blocks renders lines as a sequence of block elements
+func blocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case fenceLine.MatchString(trimmed):
+			m := fenceLine.FindStringSubmatch(trimmed)
+			fence := m[1]
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), fence) {
+				j++
+			}
+			b.WriteString("<pre><code")
+			if lang := langName.FindString(m[2]); lang != "" {
+				b.WriteString(` class="language-` + lang + `"`)
+			}
+			b.WriteString(">")
+			for _, code := range lines[i+1 : j] {
+				b.WriteString(html.EscapeString(code) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+			// An unclosed fence runs to the end of the document
+			i = j + 1
+
+		case headingLine.MatchString(trimmed):
+			m := headingLine.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+			i++
+
+		case ruleLine.MatchString(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			blocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case listLine.MatchString(line):
+			i = list(b, lines, i)
+
+		case htmlLine.MatchString(trimmed):
+			// Raw HTML blocks run to the next blank line
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				b.WriteString(lines[i] + "\n")
+			}
+
+		default:
+			var para []string
+			for ; i < len(lines) && !interrupts(lines[i], len(para) > 0); i++ {
+				para = append(para, lines[i])
+			}
+			b.WriteString("<p>" + paragraph(para) + "</p>\n")
+		}
+	}
+}
+
+// interrupts reports whether line ends the paragraph before it
+func interrupts(line string, inParagraph bool) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return true
+	}
+	if !inParagraph {
+		return false
+	}
+	return fenceLine.MatchString(trimmed) || headingLine.MatchString(trimmed) ||
+		ruleLine.MatchString(trimmed) || strings.HasPrefix(trimmed, ">") ||
+		listLine.MatchString(line)
+}
+
+// paragraph renders a paragraph's lines. A line ending in two spaces or a
+// backslash is followed by a hard break.
+func paragraph(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`)
+		line = strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " "), `\`), " ")
+		b.WriteString(inline(line))
+		if i < len(lines)-1 {
+			if hardBreak {
+				b.WriteString("<br>")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// list renders the list starting at lines[start] and returns the index of
+// the first line after it. Items continue on lines indented past the
+// marker; a list with no blank lines between items is tight and its items'
+// single paragraphs are not wrapped in <p>.
+func list(b *strings.Builder, lines []string, start int) int {
+	first := listLine.FindStringSubmatch(lines[start])
+	indent := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	bullet := first[2][len(first[2])-1:]
+
+	var items [][]string
+	tight := true
+	i := start
+	for i < len(lines) {
+		m := listLine.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || m[2][len(m[2])-1:] != bullet {
+			break
+		}
+		item := []string{m[3]}
+		// Content lines align with the text after the marker
+		contentIndent := len(m[1]) + len(m[2]) + 1
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item only if indented content follows
+				j := i + 1
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j < len(lines) && leadingSpaces(lines[j]) >= contentIndent {
+					item = append(item, "")
+					i++
+					tight = false
+					continue
+				}
+				if j < len(lines) && isSibling(lines[j], indent, bullet) {
+					tight = false
+				}
+				i = j
+				break
+			}
+			if leadingSpaces(line) >= contentIndent {
+				item = append(item, line[contentIndent:])
+			} else if listLine.MatchString(line) || interrupts(line, true) {
+				break
+			} else {
+				// Lazy continuation of the item's paragraph
+				item = append(item, strings.TrimSpace(line))
+			}
+			i++
+		}
+		items = append(items, item)
+		if i < len(lines) && !isSibling(lines[i], indent, bullet) {
+			break
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if n, _ := strconv.Atoi(strings.TrimRight(first[2], ".)")); ordered && n != 1 {
+		b.WriteString(` start="` + strconv.Itoa(n) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		var content strings.Builder
+		blocks(&content, item)
+		rendered := content.String()
+		if tight {
+			rendered = unwrapParagraphs(rendered)
+		}
+		b.WriteString("<li>" + strings.TrimSuffix(rendered, "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isSibling(line string, indent int, bullet string) bool {
+	m := listLine.FindStringSubmatch(line)
+	return m != nil && len(m[1]) == indent && m[2][len(m[2])-1:] == bullet
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// unwrapParagraphs drops the <p> tags of a tight list item
+func unwrapParagraphs(s string) string {
+	s = strings.ReplaceAll(s, "<p>", "")
+	return strings.ReplaceAll(s, "</p>", "")
+}
+
+var (
+	inlineTag = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][-A-Za-z0-9_:.]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*\s*/?>`)
+	autolink  = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]+)>`)
+)
+
+// inline renders emphasis, code spans, links, images, autolinks and inline
+// HTML within a block's text
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!<>|~\"'", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			run := i
+			for run < len(s) && s[run] == '`' {
+				run++
+			}
+			fence := s[i:run]
+			if end := strings.Index(s[run:], fence); end >= 0 {
+				code := s[run : run+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = run + end + len(fence)
+				continue
+			}
+			b.WriteString(fence)
+			i = run
+			continue
+
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if text, dest, title, n := link(s[i+1:]); n > 0 {
+				b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(text) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if text, dest, title, n := link(s[i:]); n > 0 {
+				b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + inline(text) + "</a>")
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if m := autolink.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + html.EscapeString(m[1]) + `">` + html.EscapeString(strings.TrimPrefix(m[1], "mailto:")) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if tag := inlineTag.FindString(s[i:]); tag != "" {
+				b.WriteString(tag)
+				i += len(tag)
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~' && strings.HasPrefix(s[i:], "~~"):
+			if rendered, n := emphasis(s[i:]); n > 0 {
+				b.WriteString(rendered)
+				i += n
+				continue
+			}
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// emphasis renders "**strong**", "*em*", "__strong__", "_em_",
+// "***both***" or "~~deleted~~" at the start of s. The delimiters must hug
+// the text, and underscores must not sit inside a word, so snake_case stays
+// as written.
+func emphasis(s string) (string, int) {
+	delim := s[:1]
+	if strings.HasPrefix(s, delim+delim) {
+		delim += delim
+	}
+	if delim == "~" {
+		return "", 0
+	}
+	if len(delim) == 2 && delim != "~~" && strings.HasPrefix(s, delim+delim[:1]) {
+		if rendered, n := emphasisRun(s, delim+delim[:1]); n > 0 {
+			return rendered, n
+		}
+	}
+	return emphasisRun(s, delim)
+}
+
+// emphasisRun renders the emphasis opened by delim at the start of s
+func emphasisRun(s, delim string) (string, int) {
+	rest := s[len(delim):]
+	if rest == "" || rest[0] == ' ' {
+		return "", 0
+	}
+
+	for from := 0; ; {
+		end := strings.Index(rest[from:], delim)
+		if end < 0 {
+			return "", 0
+		}
+		end += from
+		after := rest[end+len(delim):]
+		// A single delimiter must not be half of a double one
+		doubled := len(delim) == 1 && strings.HasPrefix(after, delim)
+		wordAfter := delim[0] == '_' && after != "" && isWordByte(after[0])
+		if end > 0 && rest[end-1] != ' ' && !doubled && !wordAfter {
+			open, close := "<em>", "</em>"
+			switch {
+			case delim == "~~":
+				open, close = "<del>", "</del>"
+			case len(delim) == 2:
+				open, close = "<strong>", "</strong>"
+			case len(delim) == 3:
+				open, close = "<em><strong>", "</strong></em>"
+			}
+			return open + inline(rest[:end]) + close, len(delim) + end + len(delim)
+		}
+		from = end + len(delim)
+		if doubled {
+			from++
+		}
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// link parses "[text](destination "title")" at the start of s and returns
+// its parts and length, or n == 0 when s does not start with a link
+func link(s string) (text, dest, title string, n int) {
+	depth := 0
+	closeText := -1
+	for i := 0; i < len(s) && closeText < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				closeText = i
+			}
+		}
+	}
+	if closeText < 0 || !strings.HasPrefix(s[closeText+1:], "(") {
+		return "", "", "", 0
+	}
+
+	depth = 0
+	for i := closeText + 1; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				inside := strings.TrimSpace(s[closeText+2 : i])
+				dest, title, _ = strings.Cut(inside, " ")
+				title = strings.TrimSpace(title)
+				if len(title) >= 2 && (title[0] == '"' || title[0] == '\'') && title[len(title)-1] == title[0] {
+					title = title[1 : len(title)-1]
+				} else if title != "" {
+					return "", "", "", 0
+				}
+				dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+				return s[1:closeText], dest, title, i + 1
+			}
+		}
+	}
+	return "", "", "", 0
+}
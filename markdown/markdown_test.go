@@ -0,0 +1,75 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"hc-hello-world-plugin/sanitize"
+)
+
+func TestEmphasis(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"triple asterisks", "***both***", "<p><em><strong>both</strong></em></p>\n"},
+		{"triple underscores", "___both___", "<p><em><strong>both</strong></em></p>\n"},
+		{"em inside strong", "**bold *em* bold**", "<p><strong>bold <em>em</em> bold</strong></p>\n"},
+		{"strong inside em", "*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>\n"},
+		{"mixed delimiters", "_a __b__ c_", "<p><em>a <strong>b</strong> c</em></p>\n"},
+		{"em inside del", "~~del *em*~~", "<p><del>del <em>em</em></del></p>\n"},
+		{"emphasis in link text", "[**b**](http://x.example)", `<p><a href="http://x.example"><strong>b</strong></a></p>` + "\n"},
+		{"snake_case", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"unclosed", "**unclosed", "<p>**unclosed</p>\n"},
+		{"spaced delimiters", "** no **", "<p>** no **</p>\n"},
+		{"first closer wins", "*a*b*", "<p><em>a</em>b*</p>\n"},
+		{"raw html in emphasis is left for sanitize", "*<b>*", "<p><em><b></em></p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.src); got != tt.want {
+				t.Errorf("ToHTML(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// Rendered Markdown is only safe once sanitized, as the resolver serves it
+func TestRenderedThenSanitized(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"javascript link", "[x](javascript:alert(1))",
+			`<p><a rel="nofollow noopener">x</a></p>` + "\n"},
+		{"mixed-case scheme", "[x](JaVaScRiPt:alert(1))",
+			`<p><a rel="nofollow noopener">x</a></p>` + "\n"},
+		{"data image", "![x](data:image/png;base64,AAA)",
+			`<p><img alt="x"></p>` + "\n"},
+		{"data link", "[x](data:text/html,<script>alert(1)</script>)",
+			`<p><a rel="nofollow noopener">x</a></p>` + "\n"},
+		{"alt breakout", `![a" onerror="alert(1)](x.png)`,
+			`<p><img src="x.png" alt="a&#34; onerror=&#34;alert(1)"></p>` + "\n"},
+		{"title breakout", `[x](/ok 'a" onclick="alert(1)')`,
+			`<p><a href="/ok" title="a&#34; onclick=&#34;alert(1)" rel="nofollow noopener">x</a></p>` + "\n"},
+		{"destination breakout is not a link", `[x](http://a.example/" onmouseover="alert(1))`,
+			"<p>[x](http://a.example/&#34; onmouseover=&#34;alert(1))</p>\n"},
+		{"entity in destination stays literal", "[x](&#106;avascript:alert(1))",
+			`<p><a href="&amp;#106;avascript:alert(1)" rel="nofollow noopener">x</a></p>` + "\n"},
+		{"script block", "<script>alert(1)</script>", "\n"},
+		{"inline img onerror", "hi <img src=x onerror=alert(1)> there",
+			`<p>hi <img src="x"> there</p>` + "\n"},
+		{"raw link with encoded scheme", `<a href="&#x6A;avascript&colon;alert(1)">x</a>`,
+			`<a rel="nofollow noopener">x</a>` + "\n"},
+		{"code span", "`<script>`", "<p><code>&lt;script&gt;</code></p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitize.HTML(ToHTML(tt.src))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if strings.Contains(strings.ToLower(got), "javascript:") || strings.Contains(got, "<script") {
+				t.Errorf("active content survived: %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package metrics counts handler calls since startup for /metrics. Calls is
+// the metrics sink the plugin is initialized with, so every resolver, REST
+// handler and function is counted, including calls middleware rejects.
+//
+// Each handler's durations also go into a latency histogram with fixed
+// buckets, from which the p50, p95 and p99 are estimated. Memory per
+// handler stays constant however many calls it serves.
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bounds are the upper bounds of the latency histogram buckets; slower
+// calls land in an overflow bucket
+var Bounds = []time.Duration{
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// Handler is one handler's counters
+type Handler struct {
+	Name   string  `json:"name"`
+	Calls  int64   `json:"calls"`
+	Errors int64   `json:"errors"`
+	AvgMs  float64 `json:"avgMs"`
+	MaxMs  float64 `json:"maxMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+	// Histogram holds the cumulative count of calls at or below each of
+	// Bounds; the rest took longer
+	Histogram []Bucket `json:"histogram"`
+}
+
+// Bucket is the number of calls that took at most LeMs milliseconds
+type Bucket struct {
+	LeMs  float64 `json:"leMs"`
+	Count int64   `json:"count"`
+}
+
+type counters struct {
+	calls, errors int64
+	total, max    time.Duration
+	// buckets counts calls per bucket of Bounds, plus the overflow bucket
+	buckets []int64
+}
+
+// Calls is safe for concurrent use
+type Calls struct {
+	mu        sync.Mutex
+	byHandler map[string]*counters
+}
+
+// NewCalls creates empty counters
+func NewCalls() *Calls {
+	return &Calls{byHandler: make(map[string]*counters)}
+}
+
+// ObserveCall counts a completed call of the handler registered as name
+func (c *Calls) ObserveCall(name string, elapsed time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.byHandler[name]
+	if !ok {
+		h = &counters{buckets: make([]int64, len(Bounds)+1)}
+		c.byHandler[name] = h
+	}
+	h.calls++
+	if err != nil {
+		h.errors++
+	}
+	h.total += elapsed
+	h.max = max(h.max, elapsed)
+	h.buckets[sort.Search(len(Bounds), func(i int) bool { return elapsed <= Bounds[i] })]++
+}
+
+// Handlers returns the counters of every handler called so far, sorted by
+// name
+func (c *Calls) Handlers() []Handler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]Handler, 0, len(c.byHandler))
+	for name, h := range c.byHandler {
+		histogram := make([]Bucket, len(Bounds))
+		var cumulative int64
+		for i, bound := range Bounds {
+			cumulative += h.buckets[i]
+			histogram[i] = Bucket{LeMs: milliseconds(bound), Count: cumulative}
+		}
+		result = append(result, Handler{
+			Name:      name,
+			Calls:     h.calls,
+			Errors:    h.errors,
+			AvgMs:     milliseconds(h.total) / float64(h.calls),
+			MaxMs:     milliseconds(h.max),
+			P50Ms:     milliseconds(h.quantile(0.50)),
+			P95Ms:     milliseconds(h.quantile(0.95)),
+			P99Ms:     milliseconds(h.quantile(0.99)),
+			Histogram: histogram,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// quantile estimates the duration q of the calls took at most, by linear
+// interpolation within the bucket it falls in. The estimate never exceeds
+// the slowest call, which also bounds the overflow bucket.
+func (h *counters) quantile(q float64) time.Duration {
+	if h.calls == 0 {
+		return 0
+	}
+	rank := q * float64(h.calls)
+	var below int64
+	for i, count := range h.buckets {
+		if count == 0 || float64(below+count) < rank {
+			below += count
+			continue
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = Bounds[i-1]
+		}
+		upper := h.max
+		if i < len(Bounds) {
+			upper = min(Bounds[i], h.max)
+		}
+		fraction := (rank - float64(below)) / float64(count)
+		return lower + time.Duration(fraction*float64(upper-lower))
+	}
+	return h.max
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
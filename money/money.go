@@ -0,0 +1,197 @@
+// Package money represents monetary amounts as integer minor units plus an
+// ISO 4217 currency code, avoiding float64 rounding errors in prices.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents lists the supported ISO 4217 currencies and their number
+// of minor-unit digits
+var currencyExponents = map[string]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"CHF": 2,
+	"INR": 2,
+	"BDT": 2,
+	"CNY": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// ErrOverflow is returned when a result does not fit in int64 minor units
+var ErrOverflow = errors.New("amount out of range")
+
+// Money is an amount expressed in the minor units of its currency
+// (e.g. cents for USD)
+type Money struct {
+	Minor    int64  `json:"minorUnits"`
+	Currency string `json:"currency"`
+}
+
+// ValidateCurrency checks that code is a supported ISO 4217 currency code
+func ValidateCurrency(code string) error {
+	if _, ok := currencyExponents[code]; !ok {
+		return fmt.Errorf("unsupported currency %q", code)
+	}
+	return nil
+}
+
+// New creates an amount from minor units
+func New(minor int64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// MustNew is like New but panics on an invalid currency; intended for
+// package-level sample data
+func MustNew(minor int64, currency string) Money {
+	m, err := New(minor, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Parse reads a decimal string such as "29.99" or "-5" in the given
+// currency. It takes at most one leading sign; more fractional digits than
+// the currency allows is an error.
+func Parse(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	exp := currencyExponents[currency]
+
+	amount = strings.TrimSpace(amount)
+	digits := amount
+	negative := strings.HasPrefix(digits, "-")
+	if negative || strings.HasPrefix(digits, "+") {
+		digits = digits[1:]
+	}
+
+	// A point needs digits after it: "1." is as likely a typo as "1.50"
+	whole, frac, point := strings.Cut(digits, ".")
+	if !isDigits(whole) || !isDigits(frac) || whole+frac == "" || point && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", amount, exp, currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	// Parsing with the sign reaches MinInt64, which has no positive twin
+	if negative {
+		whole = "-" + whole
+	}
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// isDigits reports whether s holds only ASCII digits; "" does
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns m + other; both must share a currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Minor + other.Minor
+	// Overflow wraps around, flipping the sign away from the operands'
+	if (other.Minor > 0 && sum < m.Minor) || (other.Minor < 0 && sum > m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other; both must share a currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	diff := m.Minor - other.Minor
+	if (other.Minor > 0 && diff > m.Minor) || (other.Minor < 0 && diff < m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: diff, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by a whole quantity
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := m.Minor * quantity
+	// Dividing back finds a wrapped product, except for -1 × MinInt64,
+	// which wraps to itself
+	if m.Minor != 0 && (product/m.Minor != quantity || (m.Minor == -1 && quantity == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, quantity)
+	}
+	return Money{Minor: product, Currency: m.Currency}, nil
+}
+
+// Decimal formats the amount without a currency, e.g. "29.99"
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	// The magnitude is unsigned so that MinInt64 does not overflow when
+	// negated
+	minor := uint64(m.Minor)
+	sign := ""
+	if m.Minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	if exp == 0 {
+		return sign + strconv.FormatUint(minor, 10)
+	}
+
+	digits := fmt.Sprintf("%0*d", exp+1, minor)
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Float returns an approximate float64 value for legacy Float fields only;
+// never use it for arithmetic
+func (m Money) Float() float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// String formats the amount with its currency, e.g. "29.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// ToMap converts the amount into the Money GraphQL object structure
+func (m Money) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"amount":     m.Decimal(),
+		"currency":   m.Currency,
+		"minorUnits": int(m.Minor),
+	}
+}
@@ -0,0 +1,86 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]int64{
+		"29.99":                 2999,
+		"-5":                    -500,
+		"+5":                    500,
+		"0.5":                   50,
+		".5":                    50,
+		" 7 ":                   700,
+		"-92233720368547758.08": math.MinInt64,
+	}
+	for amount, want := range valid {
+		m, err := Parse(amount, "usd")
+		if err != nil || m.Minor != want || m.Currency != "USD" {
+			t.Errorf("Parse(%q) = %+v, %v; want %d USD", amount, m, err, want)
+		}
+	}
+
+	for _, amount := range []string{"--5", "+-5", "-+5", "++5", "5-", "1.2.3", "1.999", "", ".", "-", "1.", "-1.", "+.", "1e3", "92233720368547758.08", "-92233720368547758.09"} {
+		if m, err := Parse(amount, "USD"); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", amount, m)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{2999, "USD", "29.99"},
+		{-5, "USD", "-0.05"},
+		{0, "USD", "0.00"},
+		{-5, "JPY", "-5"},
+		{math.MaxInt64, "USD", "92233720368547758.07"},
+		{math.MinInt64, "USD", "-92233720368547758.08"},
+		{math.MinInt64, "JPY", "-9223372036854775808"},
+	}
+	for _, tt := range tests {
+		m := MustNew(tt.minor, tt.currency)
+		if got := m.Decimal(); got != tt.want {
+			t.Errorf("%d %s: Decimal() = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+		// Every amount survives a round trip
+		if back, err := Parse(m.Decimal(), m.Currency); err != nil || back != m {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", m.Decimal(), back, err, m)
+		}
+	}
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	big := MustNew(math.MaxInt64, "USD")
+	small := MustNew(math.MinInt64, "USD")
+	one := MustNew(1, "USD")
+
+	if _, err := big.Add(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 + 1: %v, want ErrOverflow", err)
+	}
+	if _, err := small.Sub(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt64 - 1: %v, want ErrOverflow", err)
+	}
+	if _, err := big.Mul(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 * 2: %v, want ErrOverflow", err)
+	}
+	if _, err := MustNew(-1, "USD").Mul(math.MinInt64); !errors.Is(err, ErrOverflow) {
+		t.Errorf("-1 * MinInt64: %v, want ErrOverflow", err)
+	}
+
+	if sum, err := big.Add(MustNew(-1, "USD")); err != nil || sum.Minor != math.MaxInt64-1 {
+		t.Errorf("MaxInt64 + -1 = %+v, %v", sum, err)
+	}
+	if product, err := MustNew(2999, "USD").Mul(3); err != nil || product.Minor != 8997 {
+		t.Errorf("29.99 * 3 = %+v, %v", product, err)
+	}
+	if _, err := one.Add(MustNew(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR: %v, want ErrCurrencyMismatch", err)
+	}
+}
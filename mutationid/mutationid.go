@@ -0,0 +1,224 @@
+// Package mutationid lets clients tag a mutation with a clientMutationId
+// of their choosing. The ID is echoed in the mutation's result, so a
+// front-end that applied the change optimistically can match the result to
+// it, and a mutation repeated with the same ID within a window, e.g. a
+// retry after a dropped response, returns the first result instead of
+// running again.
+package mutationid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+)
+
+// Arg is the argument and result field carrying the ID
+const Arg = "clientMutationId"
+
+var errPanicked = errors.New("mutation panicked")
+
+// Config configures a Cache
+type Config struct {
+	// Window is how long a result is replayed; 0 disables replays, while
+	// IDs are still echoed
+	Window time.Duration
+	// MaxEntries caps the results kept, dropping the oldest first
+	MaxEntries int
+	// Clock ages results; nil uses the system clock
+	Clock clock.Clock
+}
+
+// entry is one mutation's result, or the mutation still running
+type entry struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+	at    time.Time
+}
+
+// finished reports whether the call for e has returned
+func (e *entry) finished() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Cache holds recent mutation results by key, safe for concurrent use
+type Cache struct {
+	cfg Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// order holds keys, oldest first
+	order []string
+}
+
+// New creates a cache
+func New(cfg Config) *Cache {
+	cfg.Clock = clock.OrSystem(cfg.Clock)
+	if cfg.MaxEntries < 1 {
+		cfg.MaxEntries = 1
+	}
+	return &Cache{cfg: cfg, entries: make(map[string]*entry)}
+}
+
+// Key builds the key a mutation call is replayed under: its tenant, the
+// caller, the mutation's name, the ID and a hash of the other args, so a
+// repeat only matches when the same caller sends the same request. The ID
+// and per-request context args are left out of the hash. The second result
+// is false when the args cannot be encoded and the call must not be
+// replayed.
+func Key(tenant, caller, name, id string, args map[string]interface{}) (string, bool) {
+	hashed := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k != Arg && !strings.HasPrefix(k, "context_") {
+			hashed[k] = v
+		}
+	}
+	data, err := json.Marshal(hashed)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return strings.Join([]string{tenant, caller, name, id, hex.EncodeToString(sum[:])}, "\x00"), true
+}
+
+// Do runs fn unless a call for key succeeded within the window, in which
+// case it returns that call's result with replayed set. A call for key
+// still running is waited for. Failed calls, those returning an error or
+// an envelope with success false, are not kept, so a client can retry
+// them with the same ID.
+func (c *Cache) Do(key string, fn func() (interface{}, error)) (value interface{}, err error, replayed bool) {
+	if c.cfg.Window <= 0 {
+		value, err = fn()
+		return value, err, false
+	}
+
+	now := c.cfg.Clock.Now()
+	c.mu.Lock()
+	c.expire(now)
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-e.done
+		if succeeded(e.value, e.err) {
+			return e.value, nil, true
+		}
+		// The call we waited for failed; this one runs in its own right
+		return c.Do(key, fn)
+	}
+	e := &entry{done: make(chan struct{}), at: now}
+	c.entries[key] = e
+	c.order = append(c.order, key)
+	c.trim()
+	c.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			e.err = errPanicked
+			c.finish(key, e)
+			panic(r)
+		}
+		c.finish(key, e)
+	}()
+	e.value, e.err = fn()
+	return e.value, e.err, false
+}
+
+// succeeded reports whether a call's result may be replayed: it returned
+// no error and, when it is an envelope, one with success true
+func succeeded(value interface{}, err error) bool {
+	if err != nil {
+		return false
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	success, ok := object["success"].(bool)
+	return !ok || success
+}
+
+// finish releases the waiters for e and forgets it if it failed
+func (c *Cache) finish(key string, e *entry) {
+	c.mu.Lock()
+	if !succeeded(e.value, e.err) && c.entries[key] == e {
+		c.forget(key)
+	}
+	c.mu.Unlock()
+	close(e.done)
+}
+
+// expire forgets the results older than the window, stopping at a call
+// still running so it is never repeated alongside; the caller holds mu
+func (c *Cache) expire(now time.Time) {
+	for len(c.order) > 0 {
+		e := c.entries[c.order[0]]
+		if now.Sub(e.at) < c.cfg.Window {
+			return
+		}
+		if !e.finished() {
+			return
+		}
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// trim forgets the oldest results beyond MaxEntries. Calls still running
+// are skipped, as in expire, so the cache may hold more until they finish;
+// the caller holds mu
+func (c *Cache) trim() {
+	excess := len(c.order) - c.cfg.MaxEntries
+	if excess <= 0 {
+		return
+	}
+	kept := c.order[:0]
+	for _, key := range c.order {
+		if excess > 0 && c.entries[key].finished() {
+			delete(c.entries, key)
+			excess--
+			continue
+		}
+		kept = append(kept, key)
+	}
+	c.order = kept
+}
+
+// forget removes key; the caller holds mu
+func (c *Cache) forget(key string) {
+	delete(c.entries, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// Echo returns result with the ID set, when result is an object. The
+// result is copied, since a replayed one is shared between callers.
+func Echo(result interface{}, id string) interface{} {
+	object, ok := result.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	echoed := make(map[string]interface{}, len(object)+1)
+	for key, value := range object {
+		echoed[key] = value
+	}
+	if id == "" {
+		echoed[Arg] = nil
+	} else {
+		echoed[Arg] = id
+	}
+	return echoed
+}
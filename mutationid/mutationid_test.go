@@ -0,0 +1,76 @@
+package mutationid
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRepeatWithinWindowIsReplayed(t *testing.T) {
+	c := New(Config{Window: time.Minute, MaxEntries: 10})
+	runs := 0
+	fn := func() (interface{}, error) {
+		runs++
+		return map[string]interface{}{"success": true, "run": runs}, nil
+	}
+
+	first, _, replayed := c.Do("k", fn)
+	if replayed {
+		t.Fatal("first call was replayed")
+	}
+	second, _, replayed := c.Do("k", fn)
+	if !replayed || runs != 1 {
+		t.Fatalf("repeat: replayed=%v after %d runs, want a replay of the only run", replayed, runs)
+	}
+	if second.(map[string]interface{})["run"] != first.(map[string]interface{})["run"] {
+		t.Errorf("replayed %v, want %v", second, first)
+	}
+}
+
+func TestFullCacheKeepsRunningCalls(t *testing.T) {
+	const repeats = 8
+	c := New(Config{Window: time.Minute, MaxEntries: 1})
+
+	var runs atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	slow := func() (interface{}, error) {
+		if runs.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return map[string]interface{}{"success": true}, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Do("slow", slow)
+	}()
+	<-started
+
+	// Another mutation fills the cache past MaxEntries while the first is
+	// still running
+	c.Do("other", func() (interface{}, error) { return "done", nil })
+
+	replays := make([]bool, repeats)
+	for i := range repeats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, replays[i] = c.Do("slow", slow)
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("the mutation ran %d times, want 1", n)
+	}
+	for i, replayed := range replays {
+		if !replayed {
+			t.Errorf("repeat %d was not replayed", i)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package oauth obtains access tokens with the OAuth2 client credentials
+// grant (RFC 6749, section 4.4) and attaches them to outbound requests.
+// Tokens are cached until shortly before they expire, and a request the
+// API rejects with 401 is retried once with a fresh token.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/retry"
+)
+
+// ErrNotConfigured is returned by Token when no token URL is configured
+var ErrNotConfigured = errors.New("OAuth2 client credentials are not configured")
+
+// defaultLifetime is how long a token is cached when the server does not
+// say when it expires
+const defaultLifetime = 5 * time.Minute
+
+// maxErrorBody is how much of a failed token response is read for its
+// error description
+const maxErrorBody = 4 << 10
+
+// Config configures a TokenSource
+type Config struct {
+	// TokenURL is the authorization server's token endpoint; tokens are
+	// disabled when it is empty
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested space-separated; none requests the default scope
+	Scopes []string
+	// Audience is sent for servers that require it, such as Auth0
+	Audience string
+	// Leeway refreshes a token this long before it expires, so it does not
+	// expire in flight
+	Leeway time.Duration
+	// Policy retries token requests that fail with a network error or 5xx
+	Policy retry.Policy
+	// Doer sends the token requests; nil uses a client with a 10s timeout
+	Doer outbound.Doer
+	// Clock times token expiry; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Token is an access token and when it stops being usable
+type Token struct {
+	AccessToken string
+	TokenType   string
+	ExpiresAt   time.Time
+}
+
+// TokenSource hands out a cached token, fetching a new one when it is about
+// to expire. Concurrent callers share one token request.
+type TokenSource struct {
+	cfg   Config
+	clock clock.Clock
+
+	mu    sync.Mutex
+	token Token
+}
+
+// New creates a token source
+func New(cfg Config) *TokenSource {
+	if cfg.Doer == nil {
+		cfg.Doer = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &TokenSource{cfg: cfg, clock: clock.OrSystem(cfg.Clock)}
+}
+
+// Enabled reports whether a token URL is configured
+func (s *TokenSource) Enabled() bool {
+	return s.cfg.TokenURL != ""
+}
+
+// Token returns the cached token, or fetches one when there is none or it
+// expires within the leeway
+func (s *TokenSource) Token(ctx context.Context) (Token, error) {
+	if !s.Enabled() {
+		return Token{}, ErrNotConfigured
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.AccessToken != "" && s.clock.Now().Add(s.cfg.Leeway).Before(s.token.ExpiresAt) {
+		return s.token, nil
+	}
+
+	var token Token
+	err := retry.Do(ctx, s.cfg.Policy, func(ctx context.Context) error {
+		var err error
+		token, err = s.fetch(ctx)
+		return err
+	})
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	s.token = token
+	log.Printf("🔑 [hc-hello-world-plugin] event=oauth_token_fetched expires_at=%s", token.ExpiresAt.UTC().Format(time.RFC3339))
+	return token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the API rejected it
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = Token{}
+}
+
+// tokenResponse is the token endpoint's success or error response
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetch makes one token request. The client authenticates with HTTP Basic,
+// which every server must support. Rejections other than 429 are
+// permanent: wrong credentials stay wrong.
+func (s *TokenSource) fetch(ctx context.Context) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+
+	resp, err := s.cfg.Doer.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	var body tokenResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&body)
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		if decodeErr != nil || body.AccessToken == "" {
+			return Token{}, retry.Permanent(errors.New("token endpoint returned no access token"))
+		}
+	case code >= 400 && code < 500 && code != http.StatusTooManyRequests:
+		return Token{}, retry.Permanent(fmt.Errorf("token endpoint rejected the client with status %d: %s", code, describe(body)))
+	default:
+		return Token{}, fmt.Errorf("token endpoint responded with status %d", code)
+	}
+
+	lifetime := defaultLifetime
+	if body.ExpiresIn > 0 {
+		lifetime = time.Duration(body.ExpiresIn) * time.Second
+	}
+	return Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+		ExpiresAt:   s.clock.Now().Add(lifetime),
+	}, nil
+}
+
+func describe(body tokenResponse) string {
+	switch {
+	case body.Error != "" && body.ErrorDescription != "":
+		return body.Error + " (" + body.ErrorDescription + ")"
+	case body.Error != "":
+		return body.Error
+	default:
+		return "no error given"
+	}
+}
+
+// Doer wraps next so every request carries the source's token as a
+// bearer token
+func (s *TokenSource) Doer(next outbound.Doer) outbound.Doer {
+	return bearer{source: s, next: next}
+}
+
+type bearer struct {
+	source *TokenSource
+	next   outbound.Doer
+}
+
+func (b bearer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := b.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// The token may have been revoked before it expired; a request whose
+	// body cannot be replayed is not retried
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	b.source.Invalidate()
+	retried := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retried.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+	return b.send(retried)
+}
+
+// send makes the request with the current token, set on a copy so the
+// caller's request is left as it was
+func (b bearer) send(req *http.Request) (*http.Response, error) {
+	token, err := b.source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return b.next.Do(req)
+}
@@ -0,0 +1,59 @@
+// Package optarg reads optional resolver arguments as pointers that are nil
+// when the argument was not given. sdk.GetStringArg and its siblings return
+// the zero value for a missing argument, so a resolver cannot tell
+// active: false from no active argument at all; these can, which is what
+// partial updates need. Parsed args omit both missing and null arguments,
+// so both read as nil.
+package optarg
+
+// String returns the string argument name, or nil if it is absent or not a
+// string
+func String(args map[string]interface{}, name string) *string {
+	if v, ok := args[name].(string); ok {
+		return &v
+	}
+	return nil
+}
+
+// Int returns the integer argument name, or nil if it is absent or not a
+// number. JSON numbers arrive as float64 in unparsed args.
+func Int(args map[string]interface{}, name string) *int {
+	var n int
+	switch v := args[name].(type) {
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	default:
+		return nil
+	}
+	return &n
+}
+
+// Float returns the float argument name, or nil if it is absent or not a
+// number
+func Float(args map[string]interface{}, name string) *float64 {
+	var f float64
+	switch v := args[name].(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case int:
+		f = float64(v)
+	default:
+		return nil
+	}
+	return &f
+}
+
+// Bool returns the boolean argument name, or nil if it is absent or not a
+// boolean
+func Bool(args map[string]interface{}, name string) *bool {
+	if v, ok := args[name].(bool); ok {
+		return &v
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package outbound
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Fixtures holds the fixtures mode and directory shared by every Doer it
+// wraps. Set switches them while requests are in flight, so fixtures can be
+// reloaded without rebuilding the clients that send through them.
+type Fixtures struct {
+	current atomic.Pointer[fixtureSettings]
+}
+
+type fixtureSettings struct {
+	mode Mode
+	dir  string
+}
+
+// NewFixtures starts in mode, reading or writing fixtures in dir
+func NewFixtures(mode Mode, dir string) *Fixtures {
+	f := &Fixtures{}
+	f.Set(mode, dir)
+	return f
+}
+
+// Set changes the mode and directory for requests sent from now on
+func (f *Fixtures) Set(mode Mode, dir string) {
+	f.current.Store(&fixtureSettings{mode: mode, dir: dir})
+}
+
+// Settings returns the current mode and directory
+func (f *Fixtures) Settings() (Mode, string) {
+	settings := f.current.Load()
+	return settings.mode, settings.dir
+}
+
+// Wrap returns a Doer that sends through d as the current settings require,
+// like Wrap(d, mode, dir)
+func (f *Fixtures) Wrap(d Doer) Doer {
+	// The wrapped Doer is rebuilt only when the settings change, so a
+	// Recorder's lock is shared by the requests it records
+	type wrapped struct {
+		settings *fixtureSettings
+		doer     Doer
+	}
+	var cached atomic.Pointer[wrapped]
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		settings := f.current.Load()
+		w := cached.Load()
+		if w == nil || w.settings != settings {
+			w = &wrapped{settings: settings, doer: Wrap(d, settings.mode, settings.dir)}
+			cached.Store(w)
+		}
+		return w.doer.Do(req)
+	})
+}
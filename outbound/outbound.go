@@ -0,0 +1,213 @@
+// Package outbound defines the Doer every outbound HTTP call goes through,
+// so callers can inject a different one, and a recorder and replayer that
+// save real responses as fixtures and serve them back offline.
+//
+// Fixtures are matched by method and URL; request bodies and headers are
+// ignored, so a recorded response is replayed for any body sent to the
+// same URL, and credentials never end up in a fixture.
+package outbound
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrNoFixture is returned by a Replayer for requests nothing was recorded for
+var ErrNoFixture = errors.New("no recorded fixture")
+
+// maxFixtureBody bounds the response bodies a Recorder saves
+const maxFixtureBody = 10 << 20
+
+// Doer sends an HTTP request; *http.Client is one
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req)
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Fixture is a recorded response to a request
+type Fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Key identifies the requests a fixture answers: the method and the URL
+// with its query parameters sorted
+func Key(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	return req.Method + " " + u.String()
+}
+
+// unsafeChars are replaced in fixture file names
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// FileName returns the file a request's fixture is stored in, readable
+// enough to find by hand: "get_api.example.com_v1_forecast-1a2b3c4d.json"
+func FileName(req *http.Request) string {
+	sum := sha256.Sum256([]byte(Key(req)))
+	readable := unsafeChars.ReplaceAllString(strings.ToLower(req.Method)+"_"+req.URL.Host+req.URL.Path, "_")
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	return strings.Trim(readable, "_") + "-" + hex.EncodeToString(sum[:4]) + ".json"
+}
+
+// Recorder sends requests through next and saves every response it gets
+// as a fixture in dir, replacing any earlier one for the same request
+type Recorder struct {
+	next Doer
+	dir  string
+	mu   sync.Mutex
+}
+
+// NewRecorder creates a recorder saving to dir, which is created on the
+// first response
+func NewRecorder(next Doer, dir string) *Recorder {
+	return &Recorder{next: next, dir: dir}
+}
+
+// Do sends req and records the response. Set-Cookie headers are not saved.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFixtureBody))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to record: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	fixture := Fixture{Method: req.Method, URL: Key(req)[len(req.Method)+1:], Status: resp.StatusCode, Header: header, Body: string(body)}
+	if err := r.save(FileName(req), fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(name string, fixture Fixture) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, name), data.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Replayer answers requests from the fixtures in dir without sending them
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer creates a replayer reading from dir
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// Do returns the recorded response to req, or ErrNoFixture
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	name := FileName(req)
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s (expected %s)", ErrNoFixture, Key(req), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	header := fixture.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Mode is how outbound calls are handled, see Wrap
+type Mode string
+
+// Modes
+const (
+	// Live sends requests as they are
+	Live Mode = ""
+	// Record sends requests and saves the responses as fixtures
+	Record Mode = "record"
+	// Replay answers requests from fixtures and sends nothing
+	Replay Mode = "replay"
+)
+
+// ParseMode validates a mode name
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case Live, Record, Replay:
+		return mode, nil
+	default:
+		return Live, fmt.Errorf("invalid HTTP fixtures mode %q: expected record or replay", s)
+	}
+}
+
+// Wrap returns d as mode requires: unchanged, recording to dir, or
+// replaced by a replayer reading from dir
+func Wrap(d Doer, mode Mode, dir string) Doer {
+	switch mode {
+	case Record:
+		return NewRecorder(d, dir)
+	case Replay:
+		return NewReplayer(dir)
+	default:
+		return d
+	}
+}
@@ -0,0 +1,207 @@
+// Package pii protects personal user fields, emails and phone numbers. A
+// Protector shows them in clear only to callers whose role may see them;
+// everyone else gets them masked, e.g. "j***@example.com". With a key it
+// also seals them before they reach the store, so they are encrypted in
+// memory, in snapshots and in the SQL datasources.
+//
+// Sealing is deterministic: equal values seal to equal ciphertexts, which
+// lets the store keep emails unique without seeing them, at the cost of
+// revealing which users share a value. The nonce is an HMAC of the value,
+// as in AES-SIV, so the same nonce is only ever reused for the same value.
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+	"golang.org/x/crypto/hkdf"
+
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/store"
+)
+
+// MinSecretLength is the shortest secret New accepts; like atrest, the key
+// is derived without stretching, so the secret should be random
+const MinSecretLength = 32
+
+// RoleKey is the host context value naming the caller's role. Only the
+// context is trusted; a context_role argument is ignored, since args can
+// come from the client.
+const RoleKey = "role"
+
+// prefix starts sealed values; the number is the format version
+const prefix = "pii:v1:"
+
+// ErrNoKey is returned when opening a sealed value without a key
+var ErrNoKey = errors.New("field is encrypted but no field encryption key is configured")
+
+// ErrDecrypt is returned for sealed values that are corrupt or were sealed
+// with another key
+var ErrDecrypt = errors.New("cannot decrypt field: wrong key or corrupted value")
+
+// Config configures a Protector
+type Config struct {
+	// Secret derives the encryption keys; empty turns encryption off, while
+	// masking still applies
+	Secret string
+	// Roles may see protected fields in clear
+	Roles []string
+}
+
+// Protector masks protected fields by role and, when keyed, seals and
+// opens them. A nil *Protector stores and shows values as given.
+type Protector struct {
+	// aead and nonceKey are nil without a key
+	aead     cipher.AEAD
+	nonceKey []byte
+	roles    map[string]bool
+}
+
+// New returns a Protector masking fields for roles not in cfg.Roles,
+// encrypting them too when cfg.Secret is set
+func New(cfg Config) (*Protector, error) {
+	p := &Protector{roles: make(map[string]bool, len(cfg.Roles))}
+	for _, role := range cfg.Roles {
+		p.roles[role] = true
+	}
+	if cfg.Secret == "" {
+		return p, nil
+	}
+	if len(cfg.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("field encryption secret must be at least %d bytes", MinSecretLength)
+	}
+	keys := hkdf.New(sha256.New, []byte(cfg.Secret), nil, []byte("hc-hello-world-plugin field encryption v1"))
+	key, nonceKey := make([]byte, 32), make([]byte, 32)
+	if _, err := io.ReadFull(keys, key); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(keys, nonceKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	p.aead, p.nonceKey = aead, nonceKey
+	return p, nil
+}
+
+// Enabled reports whether p encrypts fields
+func (p *Protector) Enabled() bool {
+	return p != nil && p.aead != nil
+}
+
+// SealField encrypts value. Empty and already sealed values are returned
+// unchanged, as is everything when p has no key. It implements
+// store.FieldSealer.
+func (p *Protector) SealField(value string) string {
+	if !p.Enabled() || value == "" || IsSealed(value) {
+		return value
+	}
+	mac := hmac.New(sha256.New, p.nonceKey)
+	mac.Write([]byte(value))
+	nonce := mac.Sum(nil)[:p.aead.NonceSize()]
+	return prefix + base64.RawURLEncoding.EncodeToString(p.aead.Seal(nonce, nonce, []byte(value), []byte(prefix)))
+}
+
+// Open decrypts a sealed value; others are returned unchanged
+func (p *Protector) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if !p.Enabled() {
+		return "", ErrNoKey
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value[len(prefix):])
+	if err != nil || len(raw) < p.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := raw[:p.aead.NonceSize()], raw[p.aead.NonceSize():]
+	plain, err := p.aead.Open(nil, nonce, ciphertext, []byte(prefix))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// IsSealed reports whether value was sealed by a Protector
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Show returns a stored value of field, "email" or "phone", as the caller
+// may see it: in clear when the caller's role allows, masked otherwise.
+// Other fields are returned unchanged.
+func (p *Protector) Show(ctx context.Context, field, value string) string {
+	mask, protected := masks[field]
+	if p == nil || !protected || value == "" {
+		return value
+	}
+	plain, err := p.Open(value)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] event=field_decrypt_failed field=%s err=%q", field, err)
+		return logging.Redacted
+	}
+	if Revealed(ctx) {
+		return plain
+	}
+	return mask(plain)
+}
+
+// ShowUser returns u with its protected fields as the caller may see them
+func (p *Protector) ShowUser(ctx context.Context, u store.User) store.User {
+	u.Email = p.Show(ctx, "email", u.Email)
+	u.Phone = p.Show(ctx, "phone", u.Phone)
+	return u
+}
+
+// masks hide the protected fields, by field name
+var masks = map[string]func(string) string{
+	"email": logging.MaskEmail,
+	"phone": MaskPhone,
+}
+
+// MaskPhone keeps a number's last two digits: "+14155550123" becomes
+// "**********23"
+func MaskPhone(number string) string {
+	if len(number) <= 2 {
+		return logging.Redacted
+	}
+	return strings.Repeat("*", len(number)-2) + number[len(number)-2:]
+}
+
+type revealKey struct{}
+
+// Revealed reports whether the call may see protected fields in clear,
+// as decided by Resolver; calls it did not see may not
+func Revealed(ctx context.Context) bool {
+	reveal, _ := ctx.Value(revealKey{}).(bool)
+	return reveal
+}
+
+// Resolver decides whether each call may see protected fields in clear,
+// from the role in the host context, for Show to look up. It has the
+// registry.Middleware signature.
+func (p *Protector) Resolver(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	if p == nil {
+		return handler
+	}
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		role := sdk.GetContextFromContext(ctx, RoleKey)
+		return handler(context.WithValue(ctx, revealKey{}, p.roles[role]), args)
+	}
+}
@@ -0,0 +1,77 @@
+// Package playground serves the plugin's GraphQL operations over HTTP
+// without a host, with a GraphiQL page to explore them. It is for local
+// development: operations run through the registered resolvers and their
+// middleware, but nothing the host adds, such as authentication or tenant
+// context, is there.
+package playground
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/schema"
+	"hc-hello-world-plugin/upload"
+)
+
+// Endpoint is the path GraphQL requests are posted to
+const Endpoint = "/graphql"
+
+// maxRequestBytes caps a GraphQL request body
+const maxRequestBytes = 1 << 20
+
+//go:embed playground.html.tmpl
+var pageSource string
+
+var pageTemplate = template.Must(template.New("playground").Parse(pageSource))
+
+// Handler serves the GraphiQL page at /, GraphQL requests at Endpoint and
+// the SDL at /schema.graphql. Call it once every registration is made: the
+// SDL is rendered here.
+func Handler(name string, plugin *registry.Plugin) http.Handler {
+	sdl := schema.PrintSDL(plugin)
+
+	var page bytes.Buffer
+	err := pageTemplate.Execute(&page, map[string]interface{}{
+		"Name":         name,
+		"SDL":          sdl,
+		"Endpoint":     Endpoint,
+		"DefaultQuery": "{\n  " + plugin.ExposedName("pluginInfo") + " {\n    name\n    version\n  }\n}\n",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page.Bytes())
+	})
+	mux.HandleFunc("GET /schema.graphql", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(sdl))
+	})
+	mux.HandleFunc("POST "+Endpoint, func(w http.ResponseWriter, r *http.Request) {
+		var req upload.Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"message": "invalid GraphQL request: " + err.Error()}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, upload.Execute(r.Context(), plugin, req, nil))
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] event=playground_write_failed err=%q", err)
+	}
+}
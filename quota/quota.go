@@ -0,0 +1,272 @@
+// Package quota limits how much each tenant may call the plugin: a token
+// bucket caps the request rate, and a monthly call budget, counted in the
+// store, caps total consumption.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/store"
+)
+
+// ErrRateLimited is returned when a tenant calls faster than its rate limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// ErrQuotaExceeded is returned when a tenant has used its monthly budget
+var ErrQuotaExceeded = errors.New("monthly quota exceeded")
+
+// DefaultTenant counts calls that carry no tenant ID
+const DefaultTenant = "default"
+
+// maxIdleBuckets is how many rate limit buckets are kept before full,
+// idle ones are dropped
+const maxIdleBuckets = 10000
+
+// Config configures a Limiter
+type Config struct {
+	// RatePerSecond is how many calls per second each tenant may make on
+	// average, and Burst how many it may make at once; 0 disables rate
+	// limiting
+	RatePerSecond float64
+	Burst         int
+	// MonthlyCalls is each tenant's budget per calendar month (UTC); 0 is
+	// unlimited, but calls are still counted
+	MonthlyCalls int
+	// Overrides replaces MonthlyCalls for individual tenants
+	Overrides map[string]int
+	// Exempt names handlers that are neither limited nor counted, such as
+	// health checks and the usage query itself
+	Exempt []string
+	// Clock refills buckets and decides the month; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Usage is a tenant's consumption in the current month
+type Usage struct {
+	Tenant string
+	Month  string
+	Calls  int
+	// Limit is the monthly budget; 0 is unlimited
+	Limit int
+	// ResetsAt is the start of the next month
+	ResetsAt time.Time
+}
+
+// Remaining is how many calls are left this month, or -1 when unlimited
+func (u Usage) Remaining() int {
+	if u.Limit <= 0 {
+		return -1
+	}
+	return max(u.Limit-u.Calls, 0)
+}
+
+// Limiter enforces the rate limit and monthly budget per tenant
+type Limiter struct {
+	cfg    Config
+	store  store.Datasource
+	clock  clock.Clock
+	exempt map[string]bool
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket is one tenant's token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter counting monthly calls in s
+func New(cfg Config, s store.Datasource) *Limiter {
+	if cfg.Burst < 1 {
+		cfg.Burst = max(int(math.Ceil(cfg.RatePerSecond)), 1)
+	}
+	exempt := make(map[string]bool, len(cfg.Exempt))
+	for _, name := range cfg.Exempt {
+		exempt[name] = true
+	}
+	return &Limiter{
+		cfg:     cfg,
+		store:   s,
+		clock:   clock.OrSystem(cfg.Clock),
+		exempt:  exempt,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Limit returns tenant's monthly budget; 0 is unlimited
+func (l *Limiter) Limit(tenant string) int {
+	if limit, ok := l.cfg.Overrides[tenant]; ok {
+		return limit
+	}
+	return l.cfg.MonthlyCalls
+}
+
+// Allow counts one call by tenant, or returns ErrRateLimited or
+// ErrQuotaExceeded. A call rejected by the rate limit does not use up the
+// monthly budget.
+func (l *Limiter) Allow(tenant string) error {
+	now := l.clock.Now()
+	if wait, ok := l.take(tenant, now); !ok {
+		return &LimitError{Err: ErrRateLimited, Tenant: tenant, RetryAfter: wait}
+	}
+	limit := l.Limit(tenant)
+	if usage, ok := l.store.ConsumeQuota(tenant, now, limit); !ok {
+		return &LimitError{Err: ErrQuotaExceeded, Tenant: tenant, Limit: limit, Month: usage.Month, RetryAfter: nextMonth(now).Sub(now)}
+	}
+	return nil
+}
+
+// take removes a token from tenant's bucket, or returns how long until one
+// is available
+func (l *Limiter) take(tenant string, now time.Time) (time.Duration, bool) {
+	if l.cfg.RatePerSecond <= 0 {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[tenant]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.pruneLocked(now)
+		}
+		b = &bucket{tokens: float64(l.cfg.Burst), last: now}
+		l.buckets[tenant] = b
+	}
+	b.tokens = math.Min(float64(l.cfg.Burst), b.tokens+now.Sub(b.last).Seconds()*l.cfg.RatePerSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.cfg.RatePerSecond * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// pruneLocked drops buckets that have refilled completely, which behave
+// exactly like new ones
+func (l *Limiter) pruneLocked(now time.Time) {
+	full := time.Duration(float64(l.cfg.Burst) / l.cfg.RatePerSecond * float64(time.Second))
+	for tenant, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, tenant)
+		}
+	}
+}
+
+// Usage returns tenant's consumption in the current month
+func (l *Limiter) Usage(tenant string) Usage {
+	now := l.clock.Now()
+	counted := l.store.QuotaUsage(tenant, now)
+	return Usage{
+		Tenant:   tenant,
+		Month:    counted.Month,
+		Calls:    counted.Calls,
+		Limit:    l.Limit(tenant),
+		ResetsAt: nextMonth(now),
+	}
+}
+
+// RatePerSecond returns the configured rate limit; 0 when disabled
+func (l *Limiter) RatePerSecond() float64 {
+	return l.cfg.RatePerSecond
+}
+
+// Burst returns how many calls a tenant may make at once
+func (l *Limiter) Burst() int {
+	return l.cfg.Burst
+}
+
+func nextMonth(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// LimitError describes a rejected call
+type LimitError struct {
+	// Err is ErrRateLimited or ErrQuotaExceeded
+	Err    error
+	Tenant string
+	// Limit and Month are set for ErrQuotaExceeded
+	Limit int
+	Month string
+	// RetryAfter is how long until a call can succeed
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	if errors.Is(e.Err, ErrQuotaExceeded) {
+		return fmt.Sprintf("%v: tenant %s has used all %d calls for %s", e.Err, e.Tenant, e.Limit, e.Month)
+	}
+	return fmt.Sprintf("%v: retry in %s", e.Err, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Tenant returns the tenant a call is made for, DefaultTenant when the
+// host sent none
+func Tenant(ctx context.Context, args map[string]interface{}) string {
+	if tenant := sdk.GetTenantID(args); tenant != "" {
+		return tenant
+	}
+	if tenant := sdk.GetTenantIDFromContext(ctx); tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// Resolver enforces the limits on handler. It has the registry.Middleware
+// signature, so it covers resolvers, REST handlers and functions alike.
+// REST handlers, named "METHOD /path", answer a rejected call with a 429
+// envelope and a Retry-After header; the others return the LimitError.
+func (l *Limiter) Resolver(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	if l.exempt[name] {
+		return handler
+	}
+	rest := strings.Contains(name, " /")
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		err := l.Allow(Tenant(ctx, args))
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) && rest {
+			return map[string]interface{}{
+				"statusCode": 429,
+				"headers": map[string]interface{}{
+					"Content-Type": "application/json",
+					"Retry-After":  strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))),
+				},
+				"body": map[string]interface{}{"error": limitErr.Error()},
+			}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, args)
+	}
+}
+
+// ParseOverrides reads per-tenant budgets written as "tenant=calls"
+func ParseOverrides(items []string) (map[string]int, error) {
+	overrides := make(map[string]int, len(items))
+	for _, item := range items {
+		tenant, calls, ok := strings.Cut(item, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(calls))
+		if !ok || strings.TrimSpace(tenant) == "" || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid quota override %q, expected tenant=calls", item)
+		}
+		overrides[strings.TrimSpace(tenant)] = n
+	}
+	return overrides, nil
+}
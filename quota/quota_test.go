@@ -0,0 +1,72 @@
+package quota
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/store"
+)
+
+func TestRateLimitRefillsWithClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	l := New(Config{RatePerSecond: 2, Burst: 2, Clock: clk}, store.New())
+
+	for i := range 2 {
+		if err := l.Allow("t1"); err != nil {
+			t.Fatalf("call %d within the burst: %v", i+1, err)
+		}
+	}
+	err := l.Allow("t1")
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("call beyond the burst: %v, want ErrRateLimited", err)
+	}
+	if limitErr.RetryAfter != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %s, want 500ms", limitErr.RetryAfter)
+	}
+	// Other tenants have their own bucket
+	if err := l.Allow("t2"); err != nil {
+		t.Errorf("other tenant: %v", err)
+	}
+
+	clk.Advance(500 * time.Millisecond)
+	if err := l.Allow("t1"); err != nil {
+		t.Fatalf("call after one token refilled: %v", err)
+	}
+	if err := l.Allow("t1"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second call after one token refilled: %v, want ErrRateLimited", err)
+	}
+}
+
+func TestMonthlyBudgetResetsWithMonth(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 31, 23, 59, 0, 0, time.UTC))
+	l := New(Config{MonthlyCalls: 2, Overrides: map[string]int{"big": 3}, Clock: clk}, store.New())
+
+	for i := range 2 {
+		if err := l.Allow("t1"); err != nil {
+			t.Fatalf("call %d within the budget: %v", i+1, err)
+		}
+	}
+	err := l.Allow("t1")
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("call beyond the budget: %v, want ErrQuotaExceeded", err)
+	}
+	if limitErr.RetryAfter != time.Minute {
+		t.Errorf("RetryAfter = %s, want 1m", limitErr.RetryAfter)
+	}
+	if got := l.Usage("big").Remaining(); got != 3 {
+		t.Errorf("overridden tenant has %d calls left, want 3", got)
+	}
+
+	clk.Advance(time.Minute)
+	if err := l.Allow("t1"); err != nil {
+		t.Fatalf("first call of the new month: %v", err)
+	}
+	usage := l.Usage("t1")
+	if usage.Month != "2026-02" || usage.Calls != 1 || usage.Remaining() != 1 {
+		t.Errorf("usage in the new month = %+v, want 1 call of 2 in 2026-02", usage)
+	}
+}
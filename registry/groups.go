@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Group is a set of registrations, such as the demo resolvers or the debug
+// routes, that is only made in some environments
+type Group struct {
+	Name        string
+	Description string
+	// Environments the group is registered in unless overridden; empty
+	// means every environment
+	Environments []string
+}
+
+// GroupStatus reports whether a group was registered and why
+type GroupStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Reason      string `json:"reason"`
+}
+
+// WithEnvironment names the environment groups are enabled for (default
+// development)
+func WithEnvironment(env string) Option {
+	return func(o *options) { o.environment = env }
+}
+
+// WithGroupOverrides enables or disables the named groups whatever the
+// environment; a group in both lists is disabled
+func WithGroupOverrides(enabled, disabled []string) Option {
+	return func(o *options) {
+		o.enabledGroups = append(o.enabledGroups, enabled...)
+		o.disabledGroups = append(o.disabledGroups, disabled...)
+	}
+}
+
+// Group calls register, which makes the group's registrations, if the group
+// is enabled in the environment, and reports whether it did. Skipped groups
+// are never called, so building their types costs nothing; Serve logs a
+// summary of them. A group may be declared by several packages.
+func (p *Plugin) Group(g Group, register func()) bool {
+	status := GroupStatus{Name: g.Name, Description: g.Description}
+	status.Enabled, status.Reason = p.opts.groupEnabled(g)
+
+	p.mu.Lock()
+	if !slices.ContainsFunc(p.groups, func(s GroupStatus) bool { return s.Name == g.Name }) {
+		p.groups = append(p.groups, status)
+	}
+	p.mu.Unlock()
+
+	if status.Enabled {
+		register()
+	}
+	return status.Enabled
+}
+
+// Groups returns every group declared, in declaration order
+func (p *Plugin) Groups() []GroupStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]GroupStatus{}, p.groups...)
+}
+
+func (o options) groupEnabled(g Group) (bool, string) {
+	switch {
+	case slices.Contains(o.disabledGroups, g.Name):
+		return false, "disabled by override"
+	case slices.Contains(o.enabledGroups, g.Name):
+		return true, "enabled by override"
+	case len(g.Environments) == 0:
+		return true, "registered in every environment"
+	case slices.Contains(g.Environments, o.environment):
+		return true, "registered in " + o.environment
+	default:
+		return false, "only registered in " + strings.Join(g.Environments, ", ")
+	}
+}
+
+// logGroups logs which groups were registered and which skipped, and warns
+// about overrides that name no group, which are usually typos
+func (p *Plugin) logGroups() {
+	groups := p.Groups()
+	if len(groups) == 0 {
+		return
+	}
+
+	var enabled, skipped []string
+	declared := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		declared[g.Name] = true
+		if g.Enabled {
+			enabled = append(enabled, g.Name)
+		} else {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", g.Name, g.Reason))
+		}
+	}
+	p.opts.logger.Printf("🧩 [hc-hello-world-plugin] event=registration_groups env=%s enabled=%q skipped=%q",
+		p.opts.environment, strings.Join(enabled, ","), strings.Join(skipped, ", "))
+
+	for _, name := range append(append([]string{}, p.opts.enabledGroups...), p.opts.disabledGroups...) {
+		if !declared[name] {
+			p.opts.logger.Printf("⚠️  [hc-hello-world-plugin] event=unknown_group name=%q", name)
+		}
+	}
+}
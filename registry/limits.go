@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"fmt"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/limits"
+)
+
+// Limit declares the limits of the query or mutation registered as name.
+// Call it before registering name: the limits are checked around the
+// resolver, inside the middleware, and listed with the operation in the
+// manifest.
+func (p *Plugin) Limit(name string, declared limits.Declared) {
+	exposed := p.ExposedName(name)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, isQuery := p.queries[exposed]
+	_, isMutation := p.mutations[exposed]
+	if isQuery || isMutation {
+		p.conflicts = append(p.conflicts, fmt.Sprintf("limits for %s are declared after it was registered, so they are not enforced", exposed))
+		return
+	}
+	p.limits[name] = declared
+}
+
+// limit wraps the resolver registered as name with its declared limits and
+// returns them, or nil when none are declared
+func (p *Plugin) limit(name string, resolver sdk.ResolverFunc) (sdk.ResolverFunc, *limits.Declared) {
+	p.mu.RLock()
+	declared, ok := p.limits[name]
+	p.mu.RUnlock()
+	if !ok || declared.IsZero() {
+		return resolver, nil
+	}
+	exposed := p.ExposedName(name)
+	parse := func(rawArgs map[string]interface{}) map[string]interface{} {
+		return sdk.ParseArgsForResolver(exposed, rawArgs)
+	}
+	return declared.Wrap(name, parse, resolver), &declared
+}
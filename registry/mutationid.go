@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/mutationid"
+)
+
+// withMutationID adds the clientMutationId arg to a mutation and, when it
+// returns an object, the field echoing it to that object type
+func (p *Plugin) withMutationID(field sdk.GraphQLField) sdk.GraphQLField {
+	args := make(map[string]interface{}, len(field.Args)+1)
+	for key, value := range field.Args {
+		args[key] = value
+	}
+	if _, declared := args[mutationid.Arg]; !declared {
+		args[mutationid.Arg] = sdk.StringArg("Any ID the client chooses; echoed in the result, and a repeat within PLUGIN_MUTATION_ID_WINDOW returns the first result")
+	}
+	field.Args = args
+
+	typeDef, ok := args[objectTypeKey].(map[string]interface{})
+	if !ok {
+		return field
+	}
+	typeName, _ := typeDef["typeName"].(string)
+	def, ok := p.GetObjectType(typeName)
+	if !ok {
+		return field
+	}
+	if _, declared := def.Fields[mutationid.Arg]; !declared {
+		def.Fields[mutationid.Arg] = sdk.ObjectFieldDef{
+			Type:        "String",
+			Description: "The clientMutationId the mutation was called with",
+			Nullable:    true,
+		}
+		p.RegisterObjectType(def)
+	}
+	rebuilt := sdk.ComplexObjectField(field.Description, def)
+	field.Type = rebuilt.Type
+	args[objectTypeKey] = rebuilt.Args[objectTypeKey]
+	return field
+}
+
+// mutationID wraps the mutation registered as name so it echoes its
+// clientMutationId and replays the result of a repeat. Repeats are matched
+// per tenant, caller, mutation and args; mutations exempted with
+// WithMutationIDs only echo.
+func (p *Plugin) mutationID(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	cache := p.opts.mutationIDs
+	if slices.Contains(p.opts.noReplay, name) {
+		cache = nil
+	}
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		args := ParseArgs(name, rawArgs)
+		id := sdk.GetStringArg(args, mutationid.Arg, "")
+		if id == "" || cache == nil {
+			result, err := resolver(ctx, rawArgs)
+			return mutationid.Echo(result, id), err
+		}
+
+		tenant := sdk.GetTenantID(rawArgs)
+		if tenant == "" {
+			tenant = sdk.GetTenantIDFromContext(ctx)
+		}
+		caller := sdk.GetUserID(rawArgs)
+		if caller == "" {
+			caller = sdk.GetUserIDFromContext(ctx)
+		}
+		key, ok := mutationid.Key(tenant, caller, name, id, args)
+		if !ok {
+			result, err := resolver(ctx, rawArgs)
+			return mutationid.Echo(result, id), err
+		}
+		result, err, replayed := cache.Do(key, func() (interface{}, error) {
+			return resolver(ctx, rawArgs)
+		})
+		if replayed {
+			log.Printf("🔁 [hc-hello-world-plugin] event=mutation_replayed name=%s clientMutationId=%q", name, id)
+		}
+		return mutationid.Echo(result, id), err
+	}
+}
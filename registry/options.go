@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/mutationid"
+)
+
+// HealthChecker reports when the plugin can answer requests; Serve waits
+// for it. *health.Readiness is one.
+type HealthChecker interface {
+	// Wait blocks until the plugin is ready or ctx ends
+	Wait(ctx context.Context) error
+}
+
+// MetricsSink is told about every completed resolver, REST handler and
+// function call
+type MetricsSink interface {
+	ObserveCall(name string, elapsed time.Duration, err error)
+}
+
+// Option configures a plugin created with New
+type Option func(*options)
+
+type options struct {
+	logger         *log.Logger
+	handlerTimeout time.Duration
+	readyTimeout   time.Duration
+	health         HealthChecker
+	middleware     []Middleware
+	metrics        MetricsSink
+	environment    string
+	enabledGroups  []string
+	disabledGroups []string
+	namePrefix     string
+	mutationIDs    *mutationid.Cache
+	noReplay       []string
+}
+
+func defaultOptions() options {
+	return options{logger: log.Default(), readyTimeout: 30 * time.Second, environment: "development"}
+}
+
+// WithLogger logs the plugin's startup and timed out calls to logger
+// instead of the standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithHandlerTimeout gives every call a deadline d after it starts; 0
+// leaves calls unbounded. Handlers see it through their context.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(o *options) { o.handlerTimeout = d }
+}
+
+// WithReadyTimeout bounds how long Serve waits for the health checker
+// (default 30s)
+func WithReadyTimeout(d time.Duration) Option {
+	return func(o *options) { o.readyTimeout = d }
+}
+
+// WithHealthChecker makes Serve wait until checker reports ready, so the
+// host only learns about handlers that can answer
+func WithHealthChecker(checker HealthChecker) Option {
+	return func(o *options) { o.health = checker }
+}
+
+// WithMiddleware adds middleware around every handler, as Use does
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) { o.middleware = append(o.middleware, middleware...) }
+}
+
+// WithMutationIDs replays a mutation repeated with the same
+// clientMutationId from cache; without it IDs are only echoed. The
+// mutations named in exempt, such as those issuing credentials, are never
+// replayed, so a repeat cannot hand out a credential a second time.
+func WithMutationIDs(cache *mutationid.Cache, exempt ...string) Option {
+	return func(o *options) {
+		o.mutationIDs = cache
+		o.noReplay = append(o.noReplay, exempt...)
+	}
+}
+
+// WithMetricsSink reports every call to sink, including calls rejected by
+// middleware
+func WithMetricsSink(sink MetricsSink) Option {
+	return func(o *options) { o.metrics = sink }
+}
+
+// New initializes the SDK plugin and wraps it. The metrics sink sees each
+// call first, then the handler timeout applies, then the middleware in the
+// order given.
+func New(name, version, apiKey string, opts ...Option) *Plugin {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := Wrap(sdk.Init(name, version, apiKey))
+	p.opts = o
+	if o.metrics != nil {
+		p.Use(observe(o.metrics))
+	}
+	if o.handlerTimeout > 0 {
+		p.Use(timeout(o.handlerTimeout, o.logger))
+	}
+	p.Use(o.middleware...)
+	return p
+}
+
+// Serve validates the registrations, waits for the health checker, if any,
+// and hands the plugin to the host. The host learns about the handlers
+// during that handshake, so they are only advertised once the plugin can
+// answer them; a plugin with invalid registrations, or that does not become
+// ready within the ready timeout, exits.
+func (p *Plugin) Serve() {
+	if err := p.Validate(); err != nil {
+		p.opts.logger.Fatalf("❌ [hc-hello-world-plugin] Invalid registrations, not serving: %v", err)
+	}
+	p.logGroups()
+	if p.opts.health != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), p.opts.readyTimeout)
+		err := p.opts.health.Wait(ctx)
+		cancel()
+		if err != nil {
+			p.opts.logger.Fatalf("❌ [hc-hello-world-plugin] Plugin did not become ready within %s: %v", p.opts.readyTimeout, err)
+		}
+	}
+
+	p.opts.logger.Printf("🚀 [hc-hello-world-plugin] Plugin registration complete, starting server...")
+	p.Plugin.Serve()
+}
+
+// observe reports each call's duration and error to sink
+func observe(sink MetricsSink) Middleware {
+	return func(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+		return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			start := time.Now()
+			result, err := handler(ctx, args)
+			sink.ObserveCall(name, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// timeout cancels each call's context d after it starts
+func timeout(d time.Duration, logger *log.Logger) Middleware {
+	return func(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+		return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			result, err := handler(ctx, args)
+			if ctx.Err() == context.DeadlineExceeded {
+				logger.Printf("⏱️  [hc-hello-world-plugin] event=handler_timeout name=%q timeout=%s", name, d)
+			}
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"strings"
+	"sync/atomic"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// current is the most recently wrapped plugin, which ParseArgs resolves
+// names against, the way the SDK keeps its own current plugin
+var current atomic.Pointer[Plugin]
+
+// WithNamePrefix prefixes every query and mutation name the host sees, e.g.
+// "hw_" exposes getUsers as hw_getUsers, so plugins installed side by side
+// do not collide. Handlers, middleware and ParseArgs keep using the names
+// they were registered under.
+func WithNamePrefix(prefix string) Option {
+	return func(o *options) { o.namePrefix = prefix }
+}
+
+// ExposedName is the name the host sees for an operation registered as
+// name. Names starting with _, such as federation's _service and _entities,
+// are fixed by their specifications and never prefixed.
+func (p *Plugin) ExposedName(name string) string {
+	if strings.HasPrefix(name, "_") {
+		return name
+	}
+	return p.opts.namePrefix + name
+}
+
+// ParseArgs is sdk.ParseArgsForResolver for operations registered through
+// this package: name is the name the operation was registered under, which
+// is looked up with the name prefix applied
+func ParseArgs(name string, rawArgs map[string]interface{}) map[string]interface{} {
+	if p := current.Load(); p != nil {
+		name = p.ExposedName(name)
+	}
+	return sdk.ParseArgsForResolver(name, rawArgs)
+}
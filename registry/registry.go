@@ -0,0 +1,324 @@
+// Package registry wraps the SDK plugin and records everything registered
+// with it. The SDK keeps its registrations private, so this is how a running
+// plugin can describe its own queries, mutations, REST routes and functions.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/limits"
+)
+
+// Operation describes a registered GraphQL query or mutation
+type Operation struct {
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Args        map[string]interface{} `json:"args,omitempty"`
+	// Limits are the limits declared with Limit
+	Limits *limits.Declared `json:"limits,omitempty"`
+}
+
+// Route describes a registered REST endpoint
+type Route struct {
+	Method      string                 `json:"method"`
+	Path        string                 `json:"path"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+}
+
+// Permission is a host capability the plugin needs, e.g. outbound SMTP
+type Permission struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Manifest describes the plugin for installers: who it is, what it needs
+// and what it exposes
+type Manifest struct {
+	Name        string       `json:"name"`
+	Version     string       `json:"version"`
+	SDKVersion  string       `json:"sdkVersion"`
+	Permissions []Permission `json:"permissions"`
+	Queries     []Operation  `json:"queries"`
+	Mutations   []Operation  `json:"mutations"`
+	REST        []Route      `json:"rest"`
+	Functions   []string     `json:"functions"`
+}
+
+// Middleware wraps a handler registered under name. Resolvers, REST
+// handlers and functions share one signature, so one middleware covers all
+// three; REST handlers are named "METHOD /path".
+type Middleware func(name string, handler sdk.ResolverFunc) sdk.ResolverFunc
+
+// Plugin is an sdk.Plugin that remembers its registrations. It is a drop-in
+// replacement: methods it does not override go straight to the SDK.
+type Plugin struct {
+	*sdk.Plugin
+
+	mu          sync.RWMutex
+	queries     map[string]Operation
+	mutations   map[string]Operation
+	resolvers   map[string]sdk.ResolverFunc
+	routes      []Route
+	functions   map[string]bool
+	permissions map[string]string
+	// limits are the declared limits by registered name
+	limits     map[string]limits.Declared
+	middleware []Middleware
+	opts       options
+
+	// conflicts are problems found while registering; see Problems
+	conflicts []string
+	// paginated maps paginated type names to the item types they were
+	// built for
+	paginated map[string][]string
+	// groups are the registration groups declared, enabled or not
+	groups []GroupStatus
+}
+
+// Wrap starts recording registrations made through the returned plugin,
+// which has the default options; use New to set them
+func Wrap(plugin *sdk.Plugin) *Plugin {
+	p := &Plugin{
+		Plugin:      plugin,
+		queries:     make(map[string]Operation),
+		mutations:   make(map[string]Operation),
+		resolvers:   make(map[string]sdk.ResolverFunc),
+		functions:   make(map[string]bool),
+		permissions: make(map[string]string),
+		limits:      make(map[string]limits.Declared),
+		paginated:   make(map[string][]string),
+		opts:        defaultOptions(),
+	}
+	current.Store(p)
+	return p
+}
+
+// Use adds middleware around everything registered afterwards; the first
+// middleware added is the outermost
+func (p *Plugin) Use(middleware ...Middleware) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.middleware = append(p.middleware, middleware...)
+}
+
+func (p *Plugin) wrap(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i := len(p.middleware) - 1; i >= 0; i-- {
+		handler = p.middleware[i](name, handler)
+	}
+	return handler
+}
+
+// RegisterQuery registers and records a GraphQL query. The host sees it
+// under ExposedName(name); middleware still sees name.
+func (p *Plugin) RegisterQuery(name string, field sdk.GraphQLField, resolver sdk.ResolverFunc) {
+	resolver, declared := p.limit(name, resolver)
+	resolver = p.wrap(name, resolver)
+	name = p.ExposedName(name)
+	p.Plugin.RegisterQuery(name, field, resolver)
+	p.mu.Lock()
+	_, duplicate := p.queries[name]
+	p.checkOperation("query", name, field, duplicate)
+	p.queries[name] = operation(name, field, declared)
+	p.resolvers["query "+name] = resolver
+	p.mu.Unlock()
+}
+
+// RegisterMutation registers and records a GraphQL mutation, under
+// ExposedName(name) like RegisterQuery. Every mutation takes a
+// clientMutationId and echoes it in its result; see package mutationid.
+func (p *Plugin) RegisterMutation(name string, field sdk.GraphQLField, resolver sdk.ResolverFunc) {
+	field = p.withMutationID(field)
+	resolver, declared := p.limit(name, resolver)
+	resolver = p.mutationID(name, resolver)
+	resolver = p.wrap(name, resolver)
+	name = p.ExposedName(name)
+	p.Plugin.RegisterMutation(name, field, resolver)
+	p.mu.Lock()
+	_, duplicate := p.mutations[name]
+	p.checkOperation("mutation", name, field, duplicate)
+	p.mutations[name] = operation(name, field, declared)
+	p.resolvers["mutation "+name] = resolver
+	p.mu.Unlock()
+}
+
+// Resolver returns the resolver registered for a query or mutation, with
+// its middleware, so operations the host does not route - such as
+// multipart uploads - can be executed by the plugin itself.
+// operationType is "query" or "mutation"; name is the exposed name.
+func (p *Plugin) Resolver(operationType, name string) (sdk.ResolverFunc, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	resolver, ok := p.resolvers[operationType+" "+name]
+	return resolver, ok
+}
+
+// RegisterRESTAPI registers and records a REST endpoint
+func (p *Plugin) RegisterRESTAPI(endpoint sdk.RESTEndpoint, handler sdk.RESTHandlerFunc) {
+	p.Plugin.RegisterRESTAPI(endpoint, sdk.RESTHandlerFunc(p.wrap(endpoint.Method+" "+endpoint.Path, sdk.ResolverFunc(handler))))
+	p.mu.Lock()
+	for _, route := range p.routes {
+		if route.Method == endpoint.Method && route.Path == endpoint.Path {
+			p.conflicts = append(p.conflicts, fmt.Sprintf("REST endpoint %s %s is registered more than once", endpoint.Method, endpoint.Path))
+			break
+		}
+	}
+	p.routes = append(p.routes, Route{
+		Method:      endpoint.Method,
+		Path:        endpoint.Path,
+		Description: endpoint.Description,
+		Schema:      endpoint.Schema,
+	})
+	p.mu.Unlock()
+}
+
+// RegisterFunction registers and records a custom function
+func (p *Plugin) RegisterFunction(name string, function sdk.FunctionHandlerFunc) {
+	p.Plugin.RegisterFunction(name, sdk.FunctionHandlerFunc(p.wrap(name, sdk.ResolverFunc(function))))
+	p.mu.Lock()
+	if p.functions[name] {
+		p.conflicts = append(p.conflicts, fmt.Sprintf("function %s is registered more than once", name))
+	}
+	p.functions[name] = true
+	p.mu.Unlock()
+}
+
+// Require declares that the plugin needs permission; the first reason given
+// for a permission is kept
+func (p *Plugin) Require(permission, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.permissions[permission]; !ok {
+		p.permissions[permission] = reason
+	}
+}
+
+// Permissions returns the declared permissions sorted by name
+func (p *Plugin) Permissions() []Permission {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	result := make([]Permission, 0, len(p.permissions))
+	for name, reason := range p.permissions {
+		result = append(result, Permission{Name: name, Reason: reason})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Manifest describes everything registered so far
+func (p *Plugin) Manifest(name, version string) Manifest {
+	return Manifest{
+		Name:        name,
+		Version:     version,
+		SDKVersion:  sdk.Version,
+		Permissions: p.Permissions(),
+		Queries:     p.Queries(),
+		Mutations:   p.Mutations(),
+		REST:        p.Routes(),
+		Functions:   p.Functions(),
+	}
+}
+
+// Queries returns the registered queries sorted by name, as the host sees
+// them
+func (p *Plugin) Queries() []Operation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return sortedOperations(p.queries)
+}
+
+// Mutations returns the registered mutations sorted by name, as the host
+// sees them
+func (p *Plugin) Mutations() []Operation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return sortedOperations(p.mutations)
+}
+
+// Routes returns the registered REST endpoints in registration order
+func (p *Plugin) Routes() []Route {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Route{}, p.routes...)
+}
+
+// Functions returns the registered function names sorted
+func (p *Plugin) Functions() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.functions))
+	for name := range p.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// objectTypeKey is where the SDK's object field helpers stash the return
+// type's definition among the arguments; it is not an argument
+const objectTypeKey = "objectType"
+
+func operation(name string, field sdk.GraphQLField, declared *limits.Declared) Operation {
+	var args map[string]interface{}
+	for argName, arg := range field.Args {
+		if argName == objectTypeKey {
+			continue
+		}
+		if args == nil {
+			args = make(map[string]interface{}, len(field.Args))
+		}
+		args[argName] = arg
+	}
+	return Operation{
+		Name:        name,
+		Type:        TypeName(field.Type),
+		Description: field.Description,
+		Args:        args,
+		Limits:      declared,
+	}
+}
+
+func sortedOperations(byName map[string]Operation) []Operation {
+	result := make([]Operation, 0, len(byName))
+	for _, op := range byName {
+		result = append(result, op)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// TypeName renders a field type in GraphQL notation, e.g. "[User!]!". Field
+// types are either plain strings or sdk.GraphQLTypeDefinition values.
+func TypeName(t interface{}) string {
+	switch typ := t.(type) {
+	case string:
+		return typ
+	case sdk.GraphQLTypeDefinition:
+		return typeDefName(&typ)
+	case *sdk.GraphQLTypeDefinition:
+		return typeDefName(typ)
+	default:
+		return "Unknown"
+	}
+}
+
+func typeDefName(typ *sdk.GraphQLTypeDefinition) string {
+	if typ == nil {
+		return "Unknown"
+	}
+	switch typ.Kind {
+	case "list":
+		return "[" + typeDefName(typ.OfType) + "]"
+	case "non_null":
+		return typeDefName(typ.OfType) + "!"
+	default:
+		return typ.Name
+	}
+}
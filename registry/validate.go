@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// builtinTypes are the type names the host resolves without a registered
+// object type
+var builtinTypes = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true, "Object": true}
+
+// rootTypes are the host's operation types, which plugins extend through
+// RegisterQuery and RegisterMutation rather than define
+var rootTypes = map[string]bool{"Query": true, "Mutation": true, "Subscription": true}
+
+// graphQLName matches the names GraphQL allows
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// baseType strips list and non-null markers: "[User!]!" becomes "User"
+func baseType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// nameProblem describes why name cannot be used in the schema, or returns
+// ""; names starting with __ belong to introspection
+func nameProblem(name string) string {
+	switch {
+	case !graphQLName.MatchString(name):
+		return fmt.Sprintf("%q is not a valid GraphQL name", name)
+	case strings.HasPrefix(name, "__"):
+		return fmt.Sprintf("%q is reserved: names starting with __ belong to introspection", name)
+	}
+	return ""
+}
+
+// ValidationError lists every problem Validate found
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d registration problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks everything registered so far and returns a
+// *ValidationError listing the problems, or nil. Serve calls it before
+// anything reaches the host, so mistakes stop the plugin at startup rather
+// than surfacing as host errors on the first request.
+func (p *Plugin) Validate() error {
+	if problems := p.Problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// PaginatedType builds the SDK's paginated response type for items of
+// itemType, and remembers itemType so Validate can check it is registered.
+// The SDK names every paginated type PaginatedResponse, so building two for
+// different item types is reported as a conflict.
+func (p *Plugin) PaginatedType(itemType string) sdk.ObjectTypeDefinition {
+	def := sdk.PaginatedResponseType(itemType)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paginated[def.TypeName] = append(p.paginated[def.TypeName], itemType)
+	return def
+}
+
+// checkOperation records problems with an operation as it is registered:
+// duplicates and arguments that would clobber the SDK's objectType entry.
+// The caller holds p.mu.
+func (p *Plugin) checkOperation(kind, name string, field sdk.GraphQLField, duplicate bool) {
+	if duplicate {
+		p.conflicts = append(p.conflicts, fmt.Sprintf("%s %s is registered more than once; the last registration wins", kind, name))
+	}
+	if arg, ok := field.Args[objectTypeKey].(map[string]interface{}); ok {
+		if _, isTypeDef := arg["typeName"]; !isTypeDef {
+			p.conflicts = append(p.conflicts, fmt.Sprintf("%s %s: argument %q is reserved for the SDK's return type definition", kind, name, objectTypeKey))
+		}
+	}
+}
+
+// Problems lists everything the host would reject or resolve wrongly:
+// operations, REST endpoints and functions registered twice, invalid or
+// reserved names, and operation results, arguments, object fields and
+// paginated items naming a type that was never registered
+func (p *Plugin) Problems() []string {
+	types := p.GetAllObjectTypes()
+	known := func(typ string) bool {
+		name := baseType(typ)
+		_, registered := types[name]
+		return builtinTypes[name] || registered
+	}
+
+	p.mu.RLock()
+	problems := append([]string(nil), p.conflicts...)
+	paginated := make(map[string][]string, len(p.paginated))
+	for typeName, items := range p.paginated {
+		paginated[typeName] = append([]string(nil), items...)
+	}
+	p.mu.RUnlock()
+
+	for kind, ops := range map[string][]Operation{"query": p.Queries(), "mutation": p.Mutations()} {
+		for _, op := range ops {
+			if problem := nameProblem(op.Name); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s %s: %s", kind, op.Name, problem))
+			}
+			if !known(op.Type) {
+				problems = append(problems, fmt.Sprintf("%s %s returns unregistered type %s", kind, op.Name, op.Type))
+			}
+			for argName, raw := range op.Args {
+				if problem := nameProblem(argName); problem != "" {
+					problems = append(problems, fmt.Sprintf("%s %s: argument %s", kind, op.Name, problem))
+				}
+				arg, _ := raw.(map[string]interface{})
+				if typ, ok := arg["type"].(string); ok && !known(typ) {
+					problems = append(problems, fmt.Sprintf("%s %s: argument %s has unregistered type %s", kind, op.Name, argName, typ))
+				}
+			}
+		}
+	}
+
+	for typeName, def := range types {
+		switch {
+		case builtinTypes[typeName]:
+			problems = append(problems, fmt.Sprintf("type %s: %q is reserved for a built-in scalar", typeName, typeName))
+		case rootTypes[typeName]:
+			problems = append(problems, fmt.Sprintf("type %s: %q is reserved for the host's root type; use RegisterQuery or RegisterMutation", typeName, typeName))
+		default:
+			if problem := nameProblem(typeName); problem != "" {
+				problems = append(problems, fmt.Sprintf("type %s: %s", typeName, problem))
+			}
+		}
+		for fieldName, field := range def.Fields {
+			if problem := nameProblem(fieldName); problem != "" {
+				problems = append(problems, fmt.Sprintf("field %s.%s: %s", typeName, fieldName, problem))
+			}
+			if !known(field.Type) {
+				problems = append(problems, fmt.Sprintf("field %s.%s has unregistered type %s", typeName, fieldName, field.Type))
+			}
+		}
+	}
+
+	for typeName, items := range paginated {
+		distinct := make(map[string]bool)
+		for _, item := range items {
+			if !distinct[item] && !known(item) {
+				problems = append(problems, fmt.Sprintf("paginated type %s lists items of unregistered type %s", typeName, item))
+			}
+			distinct[item] = true
+		}
+		if len(distinct) > 1 {
+			names := make([]string, 0, len(distinct))
+			for item := range distinct {
+				names = append(names, item)
+			}
+			sort.Strings(names)
+			problems = append(problems, fmt.Sprintf("paginated type %s is built for items of %s; the SDK gives them one name, so only the last definition is kept", typeName, strings.Join(names, ", ")))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
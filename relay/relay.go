@@ -0,0 +1,88 @@
+// Package relay implements Relay global object identification: opaque
+// base64 IDs such as base64("User:1") resolved through a type registry.
+package relay
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fetcher loads the object of a registered type by its local ID. It returns
+// nil, nil when the object does not exist.
+type Fetcher func(ctx context.Context, id string) (map[string]interface{}, error)
+
+// ToGlobalID encodes a type name and local ID into an opaque global ID
+func ToGlobalID(typeName, id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(typeName + ":" + id))
+}
+
+// FromGlobalID decodes a global ID into its type name and local ID
+func FromGlobalID(globalID string) (string, string, error) {
+	raw, err := base64.StdEncoding.DecodeString(globalID)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid global ID %q: not base64", globalID)
+	}
+	typeName, id, ok := strings.Cut(string(raw), ":")
+	if !ok || typeName == "" || id == "" {
+		return "", "", fmt.Errorf("invalid global ID %q: expected base64(\"Type:id\")", globalID)
+	}
+	return typeName, id, nil
+}
+
+// Registry maps type names to fetchers
+type Registry struct {
+	mu       sync.RWMutex
+	fetchers map[string]Fetcher
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{fetchers: make(map[string]Fetcher)}
+}
+
+// Register makes typeName resolvable through Resolve
+func (r *Registry) Register(typeName string, fetcher Fetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetchers[typeName] = fetcher
+	log.Printf("🌐 [hc-hello-world-plugin] Registered node type '%s'", typeName)
+}
+
+// Types returns the registered type names in sorted order
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.fetchers))
+	for typeName := range r.fetchers {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Resolve decodes globalID and loads the object through its type's fetcher.
+// It returns the type name alongside the object.
+func (r *Registry) Resolve(ctx context.Context, globalID string) (string, map[string]interface{}, error) {
+	typeName, id, err := FromGlobalID(globalID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r.mu.RLock()
+	fetcher, ok := r.fetchers[typeName]
+	r.mu.RUnlock()
+	if !ok {
+		return "", nil, fmt.Errorf("unknown node type %q", typeName)
+	}
+
+	obj, err := fetcher(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	return typeName, obj, nil
+}
@@ -0,0 +1,134 @@
+// Package render executes the plugin's HTML templates for pages, emails and
+// documents. Templates use html/template, so data is escaped for the
+// context it lands in, and run with missingkey=error, so a typo in a key
+// fails loudly instead of rendering "<no value>".
+//
+// Every templates/<name>.html.tmpl file can be rendered by name. Files
+// starting with "_" only define partials, such as "footer", that the other
+// templates include.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed templates/*.html.tmpl
+var embedded embed.FS
+
+// ErrUnknownTemplate is returned when rendering a template that does not exist
+var ErrUnknownTemplate = errors.New("unknown template")
+
+const (
+	pattern = "*.html.tmpl"
+	suffix  = ".html.tmpl"
+)
+
+// Set is a parsed set of templates
+type Set struct {
+	fsys fs.FS
+	// reload re-reads the templates before every render
+	reload bool
+
+	mu   sync.RWMutex
+	tmpl *template.Template
+}
+
+// Embedded returns the templates built into the plugin
+func Embedded() *Set {
+	fsys, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		panic(err)
+	}
+	set := &Set{fsys: fsys}
+	if set.tmpl, err = parse(fsys); err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// FromDir reads the templates in dir and reads them again before every
+// render, so edits show up without rebuilding the plugin. Meant for debug
+// mode: re-parsing on every call is slow and a broken file only shows up
+// when something is rendered.
+func FromDir(dir string) (*Set, error) {
+	fsys := os.DirFS(dir)
+	tmpl, err := parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Set{fsys: fsys, reload: true, tmpl: tmpl}, nil
+}
+
+func parse(fsys fs.FS) (*template.Template, error) {
+	tmpl, err := template.New("render").Option("missingkey=error").ParseFS(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render executes the template called name, e.g. "greeting" for
+// greeting.html.tmpl
+func (s *Set) Render(name string, data interface{}) (string, error) {
+	tmpl, err := s.current()
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(name, "_") || tmpl.Lookup(name+suffix) == nil {
+		return "", fmt.Errorf("%w %q (available: %s)", ErrUnknownTemplate, name, strings.Join(names(tmpl), ", "))
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&out, name+suffix, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// Names lists the templates that can be rendered, sorted
+func (s *Set) Names() ([]string, error) {
+	tmpl, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return names(tmpl), nil
+}
+
+// current returns the parsed templates, re-reading them first when the set
+// reloads. A template that no longer parses is reported rather than
+// silently replaced by the last good version.
+func (s *Set) current() (*template.Template, error) {
+	if !s.reload {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.tmpl, nil
+	}
+	tmpl, err := parse(s.fsys)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.tmpl = tmpl
+	s.mu.Unlock()
+	return tmpl, nil
+}
+
+func names(tmpl *template.Template) []string {
+	var result []string
+	for _, t := range tmpl.Templates() {
+		if name, ok := strings.CutSuffix(t.Name(), suffix); ok && !strings.HasPrefix(name, "_") {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
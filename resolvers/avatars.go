@@ -0,0 +1,211 @@
+package resolvers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/files"
+	"hc-hello-world-plugin/imagemeta"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// getImageMetadataResolver reads the format, dimensions and EXIF tags of an
+// uploaded image, such as an avatar. Only the headers are parsed, never the
+// pixels; truncated or malformed headers and EXIF blocks are reported as
+// corrupt rather than guessed around.
+func (h *handlers) getImageMetadataResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getImageMetadataResolver called")
+
+	args := registry.ParseArgs("getImageMetadata", rawArgs)
+	fileID := sdk.GetStringArg(args, "fileId", "")
+
+	f, data, err := h.Uploads.ReadAll(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := imagemeta.Read(data)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Cannot read image metadata of file %s: %v", fileID, err)
+		return nil, fmt.Errorf("file %s: %w", fileID, err)
+	}
+
+	exif := make([]interface{}, 0, len(meta.EXIF))
+	for _, tag := range meta.EXIF {
+		exif = append(exif, map[string]interface{}{"name": tag.Name, "value": tag.Value})
+	}
+	result := map[string]interface{}{
+		"fileId":   f.ID,
+		"format":   meta.Format,
+		"mimeType": meta.MIMEType(),
+		"width":    meta.Width,
+		"height":   meta.Height,
+		"size":     f.Size,
+		"exif":     exif,
+	}
+	if meta.Orientation != 0 {
+		result["orientation"] = meta.Orientation
+	}
+	return result, nil
+}
+
+// avatarContentTypes are the image formats accepted by uploadAvatar,
+// detected from the decoded bytes rather than trusted from the client
+var avatarContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// avatarToMap renders an avatar file. The base64 data is only encoded when
+// data or dataUrl is selected, since it is by far the largest field.
+func (h *handlers) avatarToMap(ctx context.Context, userID string, f files.File, sel selection.Set) (map[string]interface{}, error) {
+	avatar := map[string]interface{}{
+		"userId":    userID,
+		"fileId":    f.ID,
+		"mimeType":  f.ContentType,
+		"size":      f.Size,
+		"sha256":    f.SHA256,
+		"updatedAt": timeutil.FormatUTC(f.CreatedAt),
+	}
+	if sel.Has("data") || sel.Has("dataUrl") {
+		_, data, err := h.Uploads.ReadAll(ctx, f.ID)
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(data)
+		avatar["data"] = encoded
+		avatar["dataUrl"] = "data:" + f.ContentType + ";base64," + encoded
+	}
+	return avatar, nil
+}
+
+// getUserAvatarResolver returns a user's avatar as base64 with its MIME
+// type, or null when they have none
+func (h *handlers) getUserAvatarResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUserAvatarResolver called")
+
+	args := registry.ParseArgs("getUserAvatar", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "")
+	if _, err := h.Store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	f, ok := h.Uploads.Latest(userID, "avatar")
+	if !ok {
+		return nil, nil
+	}
+	return h.avatarToMap(ctx, userID, f, selection.FromContext(ctx))
+}
+
+// uploadAvatarResolver sets a user's avatar, replacing the previous one,
+// from either a base64 payload or a file sent through POST /graphql/upload.
+// A base64 payload is decoded while it is stored and rejected once it
+// exceeds the size limit.
+func (h *handlers) uploadAvatarResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] uploadAvatarResolver called")
+
+	args := registry.ParseArgs("uploadAvatar", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "")
+	data := sdk.GetStringArg(args, "data", "")
+	fileID := sdk.GetStringArg(args, "fileId", "")
+	declared := sdk.GetStringArg(args, "mimeType", "")
+	if _, err := h.Store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	var f files.File
+	var err error
+	switch {
+	case data == "" && fileID == "":
+		return nil, errors.New("data or fileId is required")
+	case data != "" && fileID != "":
+		return nil, errors.New("pass either data or fileId, not both")
+	case fileID != "":
+		f, err = h.claimAvatar(ctx, userID, fileID, declared)
+	default:
+		f, err = h.saveAvatar(ctx, userID, data, declared)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Only the newest avatar is kept
+	h.Uploads.DeleteOwned(ctx, userID, f.ID)
+
+	log.Printf("🖼️  [hc-hello-world-plugin] Stored %d byte %s avatar for user %s", f.Size, f.ContentType, userID)
+	return h.avatarToMap(ctx, userID, f, selection.FromContext(ctx))
+}
+
+// sniffAvatar detects the image format from the first bytes of r, checking
+// it against the allowed types and the type the client declared, if any.
+// The returned reader still yields every byte of r.
+func sniffAvatar(r io.Reader, declared string) (*bufio.Reader, string, error) {
+	body := bufio.NewReaderSize(r, 512)
+	head, err := body.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	if len(head) == 0 {
+		return nil, "", errors.New("avatar is empty")
+	}
+	contentType := http.DetectContentType(head)
+	if !slices.Contains(avatarContentTypes, contentType) {
+		return nil, "", fmt.Errorf("avatar must be one of %s, got %s", strings.Join(avatarContentTypes, ", "), contentType)
+	}
+	if declared != "" && declared != contentType {
+		return nil, "", fmt.Errorf("mimeType %s does not match the uploaded %s data", declared, contentType)
+	}
+	return body, contentType, nil
+}
+
+// saveAvatar stores a base64 payload as userID's avatar. A mislabelled or
+// non-image payload is rejected before anything is stored.
+func (h *handlers) saveAvatar(ctx context.Context, userID, data, declared string) (files.File, error) {
+	decoded, dataURLType, err := files.Base64Reader(data)
+	if err != nil {
+		return files.File{}, err
+	}
+	if declared == "" {
+		declared = dataURLType
+	}
+
+	body, contentType, err := sniffAvatar(decoded, declared)
+	if err != nil {
+		return files.File{}, files.Base64Error(err)
+	}
+	f, err := h.Uploads.Save(ctx, files.File{
+		ID:          h.IDs.NewID(),
+		Owner:       userID,
+		Name:        "avatar",
+		ContentType: contentType,
+	}, body, int64(h.Config.MaxAvatarBytes))
+	if err != nil {
+		return files.File{}, files.Base64Error(err)
+	}
+	return f, nil
+}
+
+// claimAvatar makes an uploaded file userID's avatar once it passes the
+// same size and format checks as a base64 payload
+func (h *handlers) claimAvatar(ctx context.Context, userID, fileID, declared string) (files.File, error) {
+	f, rc, err := h.Uploads.Open(ctx, fileID)
+	if err != nil {
+		return files.File{}, err
+	}
+	defer rc.Close()
+	if f.Size > int64(h.Config.MaxAvatarBytes) {
+		return files.File{}, fmt.Errorf("%w: more than %d bytes", files.ErrTooLarge, h.Config.MaxAvatarBytes)
+	}
+	_, contentType, err := sniffAvatar(rc, declared)
+	if err != nil {
+		return files.File{}, err
+	}
+	return h.Uploads.Claim(fileID, userID, "avatar", contentType)
+}
@@ -0,0 +1,143 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/buildinfo"
+	"hc-hello-world-plugin/compat"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// pluginInfoResolver reports which build of the plugin is running
+func (h *handlers) pluginInfoResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	info := buildinfo.Get()
+	return map[string]interface{}{
+		"name":       h.Name,
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"buildTime":  info.BuildTime,
+		"modified":   info.Modified,
+		"goVersion":  info.GoVersion,
+		"sdkVersion": info.SDKVersion,
+	}, nil
+}
+
+// runtimeInfoResolver reports the process behind pluginInfo's build: its
+// platform, scheduler and memory state and the build tags it was compiled
+// with. Byte counts are Floats, since they outgrow GraphQL's 32-bit Int.
+func (h *handlers) runtimeInfoResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] runtimeInfoResolver called")
+
+	rt := buildinfo.GetRuntime()
+	var lastGC interface{}
+	if !rt.Memory.LastGC.IsZero() {
+		lastGC = timeutil.FormatUTC(rt.Memory.LastGC)
+	}
+	return map[string]interface{}{
+		"os":           rt.OS,
+		"arch":         rt.Arch,
+		"numCPU":       rt.NumCPU,
+		"gomaxprocs":   rt.GOMAXPROCS,
+		"numGoroutine": rt.NumGoroutine,
+		"buildTags":    rt.BuildTags,
+		"cgoEnabled":   rt.CgoEnabled,
+		"memory": map[string]interface{}{
+			"heapAlloc":    float64(rt.Memory.HeapAlloc),
+			"heapInuse":    float64(rt.Memory.HeapInuse),
+			"sys":          float64(rt.Memory.Sys),
+			"numGC":        int(rt.Memory.NumGC),
+			"pauseTotalMs": float64(rt.Memory.PauseTotal) / float64(time.Millisecond),
+			"lastGC":       lastGC,
+		},
+	}, nil
+}
+
+// pluginCapabilitiesResolver is the GraphQL form of /capabilities. The SDK
+// has no JSON scalar, so schemas are returned as JSON strings.
+func (h *handlers) pluginCapabilitiesResolver(plugin *registry.Plugin) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		log.Printf("🚀 [hc-hello-world-plugin] pluginCapabilitiesResolver called")
+
+		operations := func(ops []registry.Operation) ([]interface{}, error) {
+			result := make([]interface{}, 0, len(ops))
+			for _, op := range ops {
+				args, err := jsonOrNil(op.Args)
+				if err != nil {
+					return nil, fmt.Errorf("%s arguments: %w", op.Name, err)
+				}
+				result = append(result, map[string]interface{}{
+					"name":        op.Name,
+					"type":        op.Type,
+					"description": op.Description,
+					"args":        args,
+				})
+			}
+			return result, nil
+		}
+		queries, err := operations(plugin.Queries())
+		if err != nil {
+			return nil, err
+		}
+		mutations, err := operations(plugin.Mutations())
+		if err != nil {
+			return nil, err
+		}
+
+		routes := plugin.Routes()
+		rest := make([]interface{}, 0, len(routes))
+		for _, route := range routes {
+			schema, err := jsonOrNil(route.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s schema: %w", route.Method, route.Path, err)
+			}
+			rest = append(rest, map[string]interface{}{
+				"method":      route.Method,
+				"path":        route.Path,
+				"description": route.Description,
+				"schema":      schema,
+			})
+		}
+
+		features := make([]interface{}, 0, len(compat.Known))
+		for _, f := range h.Host.Features() {
+			features = append(features, map[string]interface{}{
+				"name":    f.Name,
+				"enabled": f.Enabled,
+				"since":   f.Since,
+				"reason":  f.Reason,
+			})
+		}
+		functions := make([]interface{}, 0)
+		for _, name := range plugin.Functions() {
+			functions = append(functions, name)
+		}
+
+		return map[string]interface{}{
+			"hostSdkVersion": h.Host.Version,
+			"queries":        queries,
+			"mutations":      mutations,
+			"rest":           rest,
+			"functions":      functions,
+			"features":       features,
+		}, nil
+	}
+}
+
+// jsonOrNil encodes a non-empty map as JSON, returning nil for empty ones
+func jsonOrNil(m map[string]interface{}) (interface{}, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
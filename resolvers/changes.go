@@ -0,0 +1,71 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// getChangesSinceResolver returns the store's changes after a cursor so
+// external systems can sync incrementally. Each change carries the record's
+// current state when it still exists; deletions carry only the ID.
+func (h *handlers) getChangesSinceResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getChangesSinceResolver called")
+
+	args := registry.ParseArgs("getChangesSince", rawArgs)
+	limit := h.Limits.PageSize(sdk.GetIntArg(args, "limit", 100), 100)
+	seq, err := h.Store.ParseChangeCursor(sdk.GetStringArg(args, "cursor", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	changes, next, more, err := h.Store.ChangesSince(seq, limit)
+	if errors.Is(err, store.ErrCursorExpired) {
+		return nil, fmt.Errorf("%w: resync from the beginning by omitting the cursor", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sel := selection.FromContext(ctx).Sub("changes")
+	result := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		item := map[string]interface{}{
+			"seq":       int(change.Seq),
+			"entity":    change.Entity,
+			"id":        change.ID,
+			"op":        change.Op,
+			"changedAt": timeutil.FormatUTC(change.At),
+			"user":      nil,
+			"product":   nil,
+		}
+		// Records are looked up now, so several changes to one record all
+		// carry its latest state
+		switch change.Entity {
+		case store.EntityUser:
+			if user, err := h.Store.GetUser(change.ID); err == nil && sel.Has("user") {
+				item["user"] = h.userToMap(ctx, user, sel.Sub("user"), time.UTC)
+			}
+		case store.EntityProduct:
+			if product, err := h.Store.GetProduct(change.ID); err == nil {
+				item["product"] = productToMap(product)
+			}
+		}
+		result = append(result, item)
+	}
+
+	return map[string]interface{}{
+		"changes":    result,
+		"nextCursor": h.Store.ChangeCursor(next),
+		"hasMore":    more,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+const (
+	// defaultCommentDepth and maxCommentDepth bound how many levels of
+	// replies getCommentTree nests
+	defaultCommentDepth = 3
+	maxCommentDepth     = 10
+)
+
+// getCommentTreeResolver returns a post's comment threads as nested
+// Comment objects. Comment.replies refers to Comment itself, so the depth
+// argument is what keeps the response finite.
+func (h *handlers) getCommentTreeResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getCommentTreeResolver called")
+
+	args := registry.ParseArgs("getCommentTree", rawArgs)
+	postID := sdk.GetStringArg(args, "postId", "")
+	parentID := sdk.GetStringArg(args, "parentId", "")
+	depth := sdk.GetIntArg(args, "depth", defaultCommentDepth)
+	if depth < 1 || depth > maxCommentDepth {
+		return nil, fmt.Errorf("depth must be between 1 and %d", maxCommentDepth)
+	}
+
+	// Continuing a truncated thread: the parent must belong to this post
+	if parentID != "" {
+		parent, err := h.Store.GetComment(parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, fmt.Errorf("comment %s is not on post %s", parentID, postID)
+		}
+	}
+
+	// Index replies by parent once so building the tree is linear
+	children := make(map[string][]store.Comment)
+	for _, c := range h.Store.CommentsForPost(postID) {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+
+	return h.commentTreeToMaps(ctx, children, parentID, depth, selection.FromContext(ctx)), nil
+}
+
+// commentTreeToMaps builds the Comment responses for the replies to
+// parentID, nesting at most depth levels. At the last level replies is null
+// while replyCount still reports how many exist, so clients can fetch the
+// rest with parentId.
+func (h *handlers) commentTreeToMaps(ctx context.Context, children map[string][]store.Comment, parentID string, depth int, sel selection.Set) []interface{} {
+	result := make([]interface{}, 0, len(children[parentID]))
+	for _, c := range children[parentID] {
+		comment := map[string]interface{}{
+			"id":         c.ID,
+			"postId":     c.PostID,
+			"body":       c.Body,
+			"createdAt":  timeutil.FormatUTC(c.CreatedAt),
+			"replyCount": len(children[c.ID]),
+			"replies":    nil,
+		}
+		if c.ParentID != "" {
+			comment["parentId"] = c.ParentID
+		}
+		if sel.Has("author") {
+			if author, err := h.Store.GetUser(c.AuthorID); err == nil {
+				comment["author"] = h.userToMap(ctx, author, sel.Sub("author"), time.UTC)
+			}
+		}
+		if depth > 1 && sel.Has("replies") {
+			comment["replies"] = h.commentTreeToMaps(ctx, children, c.ID, depth-1, sel.Sub("replies"))
+		}
+		result = append(result, comment)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package resolvers
+
+import (
+	"strconv"
+
+	"hc-hello-world-plugin/store"
+)
+
+// What updateUserPartial does when expectedVersion is stale, i.e. the user
+// changed after the client read it
+const (
+	// conflictReject fails the update and returns the current user
+	conflictReject = "reject"
+	// conflictLastWriteWins applies every field given, overwriting
+	// concurrent changes to them
+	conflictLastWriteWins = "lastWriteWins"
+	// conflictMerge applies the fields nobody changed in the meantime and
+	// keeps the stored value of those that were
+	conflictMerge = "merge"
+)
+
+var conflictStrategies = []string{conflictReject, conflictLastWriteWins, conflictMerge}
+
+// fieldChange is one field an update sets. Values are compared and
+// reported as strings.
+type fieldChange struct {
+	// arg is the updateUserPartial arg; field is the User field's JSON name,
+	// as in store.User.FieldVersions
+	arg    string
+	field  string
+	value  string
+	stored func(store.User) string
+	apply  func(*store.User)
+}
+
+// versionedUpdate applies changes to a user the client read at expected
+// (0: not given, so nothing is checked), resolving conflicts by strategy.
+// Its apply method is a store.TryUpdateUser callback.
+type versionedUpdate struct {
+	changes  []fieldChange
+	expected int
+	strategy string
+	// show formats a stored value of a User field for the response; nil
+	// shows it as is
+	show func(field, value string) string
+
+	// conflicts lists the fields given that were also changed concurrently,
+	// as UpdateConflict objects
+	conflicts []interface{}
+}
+
+// apply changes u, or refuses with a *store.VersionConflictError
+func (up *versionedUpdate) apply(u *store.User) error {
+	up.conflicts = []interface{}{}
+	if up.expected == 0 {
+		for _, change := range up.changes {
+			change.apply(u)
+		}
+		return nil
+	}
+	if up.expected > u.Version {
+		// Not a version this user ever had
+		return &store.VersionConflictError{ID: u.ID, Expected: up.expected, Current: u.Version}
+	}
+
+	stale := u.Version != up.expected
+	for _, change := range up.changes {
+		stored := change.stored(*u)
+		// Setting a field to the value it was changed to is no conflict
+		if !stale || u.FieldVersion(change.field) <= up.expected || stored == change.value {
+			change.apply(u)
+			continue
+		}
+		shown := stored
+		if up.show != nil {
+			shown = up.show(change.field, stored)
+		}
+		kept := "request"
+		if up.strategy != conflictLastWriteWins {
+			kept = "stored"
+		}
+		up.conflicts = append(up.conflicts, map[string]interface{}{
+			"field":        change.arg,
+			"requestValue": change.value,
+			"storedValue":  shown,
+			"changedIn":    u.FieldVersion(change.field),
+			"kept":         kept,
+		})
+		if kept == "request" {
+			change.apply(u)
+		}
+	}
+	if stale && up.strategy == conflictReject {
+		return &store.VersionConflictError{ID: u.ID, Expected: up.expected, Current: u.Version}
+	}
+	return nil
+}
+
+// stringChange builds the fieldChange setting a string field
+func stringChange(arg, field, value string, get func(store.User) string, set func(*store.User, string)) fieldChange {
+	return fieldChange{
+		arg:    arg,
+		field:  field,
+		value:  value,
+		stored: get,
+		apply:  func(u *store.User) { set(u, value) },
+	}
+}
+
+// boolChange builds the fieldChange setting a bool field
+func boolChange(arg, field string, value bool, get func(store.User) bool, set func(*store.User, bool)) fieldChange {
+	return fieldChange{
+		arg:    arg,
+		field:  field,
+		value:  strconv.FormatBool(value),
+		stored: func(u store.User) string { return strconv.FormatBool(get(u)) },
+		apply:  func(u *store.User) { set(u, value) },
+	}
+}
@@ -0,0 +1,140 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/consent"
+	"hc-hello-world-plugin/email"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// consentToMap converts a decision to the Consent object; a purpose the
+// user was never asked about has no updatedAt
+func consentToMap(c store.Consent) map[string]interface{} {
+	result := map[string]interface{}{
+		"userId":      c.UserID,
+		"purpose":     c.Purpose,
+		"description": consent.Describe(c.Purpose),
+		"granted":     c.Granted,
+		"source":      nil,
+		"updatedAt":   nil,
+	}
+	if c.Source != "" {
+		result["source"] = c.Source
+	}
+	if !c.UpdatedAt.IsZero() {
+		result["updatedAt"] = timeutil.FormatUTC(c.UpdatedAt)
+	}
+	return result
+}
+
+// getUserConsentsResolver lists a user's decision for every purpose,
+// including those never asked about, which count as not granted
+func (h *handlers) getUserConsentsResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUserConsentsResolver called")
+
+	args := registry.ParseArgs("getUserConsents", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "")
+	if _, err := h.Store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]store.Consent)
+	for _, c := range h.Store.ConsentsOfUser(userID) {
+		recorded[c.Purpose] = c
+	}
+	purposes := consent.Purposes()
+	result := make([]interface{}, 0, len(purposes))
+	for _, purpose := range purposes {
+		c, ok := recorded[purpose]
+		if !ok {
+			c = store.Consent{UserID: userID, Purpose: purpose}
+		}
+		result = append(result, consentToMap(c))
+	}
+	return result, nil
+}
+
+// setConsentResolver returns the resolver of grantConsent or revokeConsent,
+// which record granted as the user's decision for a purpose
+func (h *handlers) setConsentResolver(name string, granted bool) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		log.Printf("🚀 [hc-hello-world-plugin] %sResolver called", name)
+
+		args := registry.ParseArgs(name, rawArgs)
+		purpose := sdk.GetStringArg(args, "purpose", "")
+		if err := consent.Validate(purpose); err != nil {
+			return nil, err
+		}
+		c, err := h.Store.SetConsent(store.Consent{
+			UserID:    sdk.GetStringArg(args, "userId", ""),
+			Purpose:   purpose,
+			Granted:   granted,
+			Source:    h.Sanitizer.Field("source", sdk.GetStringArg(args, "source", "")),
+			UpdatedAt: h.Clock.Now().UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("📝 [hc-hello-world-plugin] event=consent_recorded user=%s purpose=%s granted=%t", c.UserID, c.Purpose, c.Granted)
+		return consentToMap(c), nil
+	}
+}
+
+// requireConsent wraps the resolver registered as name, which processes
+// the user named by its userId argument, so it refuses users who have not
+// granted purpose
+func (h *handlers) requireConsent(name, purpose string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		userID := sdk.GetStringArg(registry.ParseArgs(name, rawArgs), "userId", "")
+		if _, err := h.Store.GetUser(userID); err != nil {
+			return nil, err
+		}
+		if !h.Store.HasConsent(userID, purpose) {
+			log.Printf("🚫 [hc-hello-world-plugin] event=consent_missing name=%s user=%s purpose=%s", name, userID, purpose)
+			return nil, consent.Required(userID, purpose)
+		}
+		return resolver(ctx, rawArgs)
+	}
+}
+
+// sendMarketingEmailResolver queues a promotional email to a user; it is
+// registered behind requireConsent, so only users who granted marketing
+// get one
+func (h *handlers) sendMarketingEmailResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] sendMarketingEmailResolver called")
+
+	args := registry.ParseArgs("sendMarketingEmail", rawArgs)
+	user, err := h.Store.GetUser(sdk.GetStringArg(args, "userId", ""))
+	if err != nil {
+		return nil, err
+	}
+	to, err := h.PII.Open(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("opening email: %w", err)
+	}
+	msg := email.Message{
+		To:      to,
+		Subject: sdk.GetStringArg(args, "subject", ""),
+		Body:    sdk.GetStringArg(args, "body", ""),
+	}
+	jobID, err := h.Jobs.Enqueue("sendMarketingEmail", func(ctx context.Context) error {
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			log.Printf("📧 [hc-hello-world-plugin] Marketing email to %s failed: %v", logging.MaskEmail(msg.To), err)
+			return err
+		}
+		log.Printf("📧 [hc-hello-world-plugin] Marketing email delivered to %s", logging.MaskEmail(msg.To))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"queued": true, "jobId": jobID}, nil
+}
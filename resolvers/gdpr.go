@@ -0,0 +1,279 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/admin"
+	"hc-hello-world-plugin/files"
+	"hc-hello-world-plugin/gdpr"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// userArchive is everything the plugin keeps about one user, as
+// exportUserData returns it
+type userArchive struct {
+	UserID     string    `json:"userId"`
+	ExportedAt time.Time `json:"exportedAt"`
+	// User has its email and phone decrypted
+	User     store.User      `json:"user"`
+	Groups   []store.Group   `json:"groups"`
+	Comments []store.Comment `json:"comments"`
+	Consents []store.Consent `json:"consents"`
+	// Logins are the usernames the user can log in with; password hashes
+	// are never exported
+	Logins        []string     `json:"logins"`
+	Uploads       []files.File `json:"uploads"`
+	StorageBucket string       `json:"storageBucket,omitempty"`
+	// AuditEntries and WebhookDeliveries are the retained log entries that
+	// mention the user's ID
+	AuditEntries      []admin.AuditEntry       `json:"auditEntries"`
+	WebhookDeliveries []map[string]interface{} `json:"webhookDeliveries"`
+}
+
+// exportUserDataResolver returns a JSON archive of everything stored about
+// a user, for data subject access requests
+func (h *handlers) exportUserDataResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] exportUserDataResolver called")
+
+	args := registry.ParseArgs("exportUserData", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "")
+	user, err := h.Store.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email, err = h.PII.Open(user.Email); err != nil {
+		return nil, fmt.Errorf("opening email: %w", err)
+	}
+	if user.Phone, err = h.PII.Open(user.Phone); err != nil {
+		return nil, fmt.Errorf("opening phone: %w", err)
+	}
+
+	archive := userArchive{
+		UserID:            userID,
+		ExportedAt:        h.Clock.Now().UTC(),
+		User:              user,
+		Groups:            append([]store.Group{}, h.Store.GroupsOfUser(userID)...),
+		Comments:          []store.Comment{},
+		Consents:          append([]store.Consent{}, h.Store.ConsentsOfUser(userID)...),
+		Logins:            append([]string{}, h.Credentials.Usernames(userID)...),
+		Uploads:           append([]files.File{}, h.Uploads.Owned(userID)...),
+		AuditEntries:      []admin.AuditEntry{},
+		WebhookDeliveries: []map[string]interface{}{},
+	}
+	for _, c := range h.Store.Snapshot().Comments {
+		if c.AuthorID == userID {
+			archive.Comments = append(archive.Comments, c)
+		}
+	}
+	if bucket, ok := h.provisionedStorage.Load(userID); ok {
+		archive.StorageBucket = bucket.(string)
+	}
+	for _, entry := range h.Audit.Entries(0) {
+		if mentionsUser(entry.Args, userID) {
+			archive.AuditEntries = append(archive.AuditEntries, entry)
+		}
+	}
+	for _, d := range h.Webhooks.Deliveries("", 0) {
+		if mentionsUser(d.Payload, userID) {
+			archive.WebhookDeliveries = append(archive.WebhookDeliveries, webhookDeliveryToMap(d))
+		}
+	}
+
+	// Round-trip through JSON so the archive is returned exactly as it
+	// would be downloaded
+	data, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("encoding archive: %w", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("encoding archive: %w", err)
+	}
+	log.Printf("📦 [hc-hello-world-plugin] event=user_data_exported id=%s bytes=%d", userID, len(data))
+	return map[string]interface{}{
+		"userId":     userID,
+		"exportedAt": timeutil.FormatUTC(archive.ExportedAt),
+		"archive":    object,
+	}, nil
+}
+
+// mentionsUser reports whether the JSON document refers to userID: as a
+// string value, or as the user half of a membership ID "userID:groupID"
+func mentionsUser(document, userID string) bool {
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return false
+	}
+	var walk func(v interface{}) bool
+	walk = func(v interface{}) bool {
+		switch v := v.(type) {
+		case string:
+			return v == userID || strings.HasPrefix(v, userID+":")
+		case map[string]interface{}:
+			for _, inner := range v {
+				if walk(inner) {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, inner := range v {
+				if walk(inner) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return walk(value)
+}
+
+// eraseUserResolver removes a user's personal data and returns a signed
+// receipt. The user is deleted, or anonymized when comments still refer
+// to them; their logins, uploads and storage bucket go either way.
+func (h *handlers) eraseUserResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] eraseUserResolver called")
+
+	args := registry.ParseArgs("eraseUser", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "")
+	if _, err := h.Store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	// The derived data goes first, so a failure below leaves the user in
+	// place for the erasure to be retried
+	var erased []string
+	if n := h.Credentials.DeleteUser(userID); n > 0 {
+		erased = append(erased, countOf(n, "login credential"))
+	}
+	if n := h.Uploads.DeleteOwned(ctx, userID); n > 0 {
+		erased = append(erased, countOf(n, "uploaded file"))
+	}
+	if _, ok := h.provisionedStorage.LoadAndDelete(userID); ok {
+		erased = append(erased, "storage bucket")
+	}
+
+	consents := len(h.Store.ConsentsOfUser(userID))
+	outcome := gdpr.OutcomeDeleted
+	retained := []string{}
+	err := h.Store.DeleteUser(userID)
+	if errors.Is(err, store.ErrConflict) {
+		outcome = gdpr.OutcomeAnonymized
+		var comments int
+		comments, err = h.anonymizeUser(userID)
+		retained = append(retained, countOf(comments, "comment")+", now attributed to the anonymized user")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if outcome == gdpr.OutcomeDeleted {
+		erased = append(erased, "user record", "group memberships")
+		if consents > 0 {
+			erased = append(erased, countOf(consents, "consent"))
+		}
+	} else {
+		erased = append(erased, "name, username, email, phone, address, tags and metadata", "group memberships")
+		if consents > 0 {
+			erased = append(erased, countOf(consents, "consent")+", withdrawn")
+		}
+	}
+	retained = append(retained,
+		"admin audit entries, which hold the user's ID and redacted arguments only",
+		"webhook deliveries and store change log entries, which hold the user's ID only",
+		"snapshots written before the erasure, until they are replaced")
+
+	signed, err := h.Receipts.Sign(gdpr.Receipt{
+		ID:          h.IDs.NewID(),
+		UserID:      userID,
+		Outcome:     outcome,
+		Erased:      erased,
+		Retained:    retained,
+		CompletedAt: h.Clock.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🗑️  [hc-hello-world-plugin] event=user_erased id=%s outcome=%s receipt=%s", userID, outcome, signed.Receipt.ID)
+	return receiptToMap(signed), nil
+}
+
+// anonymizeUser strips userID of personal data, group memberships and
+// consents, keeping the record and the ID comments refer to, and returns
+// how many comments they wrote
+func (h *handlers) anonymizeUser(userID string) (int, error) {
+	for _, g := range h.Store.GroupsOfUser(userID) {
+		if _, err := h.Store.RemoveUserFromGroup(userID, g.ID); err != nil {
+			return 0, err
+		}
+	}
+	// The store keeps a decision per purpose, so the closest to removing
+	// them is withdrawing every one
+	for _, c := range h.Store.ConsentsOfUser(userID) {
+		c.Granted, c.Source, c.UpdatedAt = false, "erasure", h.Clock.Now().UTC()
+		if _, err := h.Store.SetConsent(c); err != nil {
+			return 0, err
+		}
+	}
+	_, err := h.Store.UpdateUser(userID, func(u *store.User) {
+		u.Name = "Deleted user"
+		u.Username = "deleted-" + userID
+		// .invalid is reserved and never resolves (RFC 2606)
+		u.Email = "deleted-" + userID + "@example.invalid"
+		u.Phone = ""
+		u.Address = nil
+		u.Tags = []store.Tag{}
+		u.Metadata = nil
+		u.Active = false
+	})
+	if err != nil {
+		return 0, err
+	}
+	comments := 0
+	for _, c := range h.Store.Snapshot().Comments {
+		if c.AuthorID == userID {
+			comments++
+		}
+	}
+	return comments, nil
+}
+
+// countOf renders n things, e.g. "1 comment" or "3 comments"
+func countOf(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// receiptToMap converts a signed receipt to the ErasureReceipt object
+func receiptToMap(s gdpr.Signed) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          s.Receipt.ID,
+		"userId":      s.Receipt.UserID,
+		"outcome":     s.Receipt.Outcome,
+		"erased":      s.Receipt.Erased,
+		"retained":    s.Receipt.Retained,
+		"completedAt": timeutil.FormatUTC(s.Receipt.CompletedAt),
+		"payload":     s.Payload,
+		"signature":   s.Signature,
+	}
+}
+
+// verifyErasureReceiptResolver checks that a receipt was issued by this
+// plugin and not altered since
+func (h *handlers) verifyErasureReceiptResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] verifyErasureReceiptResolver called")
+
+	args := registry.ParseArgs("verifyErasureReceipt", rawArgs)
+	_, err := h.Receipts.Verify(sdk.GetStringArg(args, "payload", ""), sdk.GetStringArg(args, "signature", ""))
+	return err == nil, nil
+}
@@ -0,0 +1,153 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/validate"
+)
+
+// groupToMap builds the Group response
+func (h *handlers) groupToMap(g store.Group) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          g.ID,
+		"name":        g.Name,
+		"description": g.Description,
+		"createdAt":   timeutil.FormatUTC(g.CreatedAt),
+		"memberCount": h.Store.CountMembers(g.ID),
+	}
+}
+
+// createGroupInput is the validated form of createGroup's arguments
+type createGroupInput struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// createGroupResolver creates an empty group
+func (h *handlers) createGroupResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] createGroupResolver called")
+
+	args := registry.ParseArgs("createGroup", rawArgs)
+	in := createGroupInput{
+		Name:        strings.TrimSpace(sdk.GetStringArg(args, "name", "")),
+		Description: strings.TrimSpace(sdk.GetStringArg(args, "description", "")),
+	}
+	if fieldErrors := validate.Struct(in); len(fieldErrors) > 0 {
+		return map[string]interface{}{
+			"success": false,
+			"message": fieldErrors[0].Message,
+			"group":   nil,
+		}, nil
+	}
+
+	group, err := h.Store.CreateGroup(store.Group{
+		ID:          h.IDs.NewID(),
+		Name:        in.Name,
+		Description: in.Description,
+		CreatedAt:   h.Clock.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrConflict) {
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("A group named %q already exists", in.Name),
+			"group":   nil,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] createGroupResolver created group %s", group.ID)
+	return map[string]interface{}{
+		"success": true,
+		"message": "Group created",
+		"group":   h.groupToMap(group),
+	}, nil
+}
+
+// membershipResolver builds the addUserToGroup and removeUserFromGroup
+// resolvers, which differ only in the store operation they apply
+func (h *handlers) membershipResolver(name string, apply func(userID, groupID string) (bool, error), changedMessage, unchangedMessage string) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		log.Printf("🚀 [hc-hello-world-plugin] %sResolver called with args: %+v", name, rawArgs)
+
+		args := registry.ParseArgs(name, rawArgs)
+		userID := sdk.GetStringArg(args, "userId", "")
+		groupID := sdk.GetStringArg(args, "groupId", "")
+
+		changed, err := apply(userID, groupID)
+		if errors.Is(err, store.ErrNotFound) {
+			return map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+				"changed": false,
+				"user":    nil,
+			}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := h.Store.GetUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		message := unchangedMessage
+		if changed {
+			message = changedMessage
+		}
+		return map[string]interface{}{
+			"success": true,
+			"message": message,
+			"changed": changed,
+			"user":    h.userToMap(ctx, user, selection.FromContext(ctx).Sub("user"), time.UTC),
+		}, nil
+	}
+}
+
+// getGroupMembersResolver lists the users in a group
+func (h *handlers) getGroupMembersResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getGroupMembersResolver called")
+
+	args := registry.ParseArgs("getGroupMembers", rawArgs)
+	groupID := sdk.GetStringArg(args, "groupId", "")
+	limit := h.Limits.PageSize(sdk.GetIntArg(args, "limit", 50), 50)
+	offset := max(sdk.GetIntArg(args, "offset", 0), 0)
+
+	members, err := h.Store.UsersInGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(members) {
+		offset = len(members)
+	}
+	members = members[offset:min(offset+limit, len(members))]
+
+	sel := selection.FromContext(ctx)
+	result := make([]interface{}, 0, len(members))
+	for _, user := range members {
+		result = append(result, h.userToMap(ctx, user, sel, time.UTC))
+	}
+	return result, nil
+}
+
+// getGroupsResolver lists all groups
+func (h *handlers) getGroupsResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	groups := h.Store.ListGroups()
+	result := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, h.groupToMap(g))
+	}
+	return result, nil
+}
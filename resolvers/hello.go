@@ -0,0 +1,363 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/i18n"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/markdown"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/sanitize"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/variables"
+)
+
+// debugContextValues safely prints all known context values without panicking
+func debugContextValues(ctx context.Context) {
+	log.Printf("🔍 [hc-hello-world-plugin] === Context Debug Information ===")
+
+	// List of known context keys to check
+	knownKeys := []string{
+		"project_id",
+		"plugin_id",
+		"cache",
+		"user_id",
+		"tenant_id",
+		"request_id",
+		"session_id",
+		"application_id",
+		"database",
+		"config",
+		selection.ContextKey,
+		variables.ContextKey,
+	}
+
+	for _, key := range knownKeys {
+		val := ctx.Value(key)
+		if val == nil {
+			log.Printf("🔍 [hc-hello-world-plugin] %s: <nil>", key)
+			continue
+		}
+
+		// Print the raw value first
+		log.Printf("🔍 [hc-hello-world-plugin] %s (raw): %s (type: %T)", key, logging.Redact(val), val)
+
+		// Try safe type assertions for common types
+		switch v := val.(type) {
+		case string:
+			log.Printf("🔍 [hc-hello-world-plugin] %s (string): %s", key, v)
+		case int:
+			log.Printf("🔍 [hc-hello-world-plugin] %s (int): %d", key, v)
+		case int64:
+			log.Printf("🔍 [hc-hello-world-plugin] %s (int64): %d", key, v)
+		case bool:
+			log.Printf("🔍 [hc-hello-world-plugin] %s (bool): %t", key, v)
+		case map[string]interface{}:
+			log.Printf("🔍 [hc-hello-world-plugin] %s (map): %s", key, logging.Redact(v))
+		default:
+			// For unknown types, just print the value and type
+			log.Printf("🔍 [hc-hello-world-plugin] %s (unknown type %T): %s", key, v, logging.Redact(v))
+		}
+	}
+
+	log.Printf("🔍 [hc-hello-world-plugin] === End Context Debug ===")
+}
+
+func (h *handlers) helloWorldResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+
+	log.Printf("🚀 [hc-hello-world-plugin] helloWorldResolver called")
+
+	// Safe way to debug and print all context values without panicking
+	debugContextValues(ctx)
+
+	// NEW: Access context data passed from the host using SDK helpers
+	pluginID := sdk.GetPluginID(rawArgs)
+	projectID := sdk.GetProjectID(rawArgs)
+	userID := sdk.GetUserID(rawArgs)
+	tenantID := sdk.GetTenantID(rawArgs)
+
+	// Also demonstrate direct context access
+	pluginIDFromCtx := sdk.GetPluginIDFromContext(ctx)
+
+	log.Printf("🔐 [hc-hello-world-plugin] Context Data from Host:")
+	log.Printf("   - Plugin ID: %s", pluginID)
+	log.Printf("   - Project ID: %s", projectID)
+	log.Printf("   - User ID: %s", userID)
+	log.Printf("   - Tenant ID: %s", tenantID)
+	log.Printf("   - Plugin ID from Context: %s", pluginIDFromCtx)
+
+	// Get all context data for debugging
+	allContextData := sdk.GetAllContextData(rawArgs)
+	log.Printf("🔍 [hc-hello-world-plugin] All Context Data: %s", logging.Redact(allContextData))
+
+	// Use the SDK's automatic argument parsing based on field definition
+	args := registry.ParseArgs("helloWorldQuery", rawArgs)
+
+	logging.Debugf("📝 [hc-hello-world-plugin] Parsed args: %s", logging.Redact(args))
+
+	// Pick the response language from the locale arg or the host context
+	locale := i18n.FromContext(ctx, sdk.GetStringArg(args, "locale", ""))
+	log.Printf("🌐 [hc-hello-world-plugin] Response locale: %s", locale)
+
+	var result strings.Builder
+	result.WriteString(i18n.T(locale, "greeting.header") + "\n")
+
+	// Add context information to the response
+	if pluginID != "" {
+		result.WriteString(fmt.Sprintf("🔐 Plugin ID: %s\n", pluginID))
+	}
+	if projectID != "" {
+		result.WriteString(fmt.Sprintf("🏗️  Project ID: %s\n", projectID))
+	}
+	if userID != "" {
+		result.WriteString(fmt.Sprintf("👤 User ID: %s\n", userID))
+	}
+	if tenantID != "" {
+		result.WriteString(fmt.Sprintf("🏢 Tenant ID: %s\n", tenantID))
+	}
+
+	// Handle name parameter - now type-safe!
+	name := h.Sanitizer.Field("name", sdk.GetStringArg(args, "name", "World"))
+	log.Printf("👋 [hc-hello-world-plugin] Greeting name: %s", name)
+	result.WriteString(i18n.T(locale, "greeting.hello", name) + "\n")
+
+	// Handle object parameter - automatically parsed!
+	if obj := sdk.GetObjectArg(args, "object"); len(obj) > 0 {
+		logging.Debugf("📦 [hc-hello-world-plugin] Object parameter received: %s", logging.Redact(obj))
+		objName := sdk.GetStringArg(obj, "name")
+		objAge := sdk.GetIntArg(obj, "age")
+		result.WriteString(i18n.T(locale, "greeting.object_received", objName, objAge) + "\n")
+	}
+
+	// Handle arrayofObjects parameter - automatically parsed!
+	if arrObjs := sdk.GetArrayArg(args, "arrayofObjects"); len(arrObjs) > 0 {
+		if err := h.Limits.CheckItems("arrayofObjects", len(arrObjs)); err != nil {
+			return nil, err
+		}
+		log.Printf("📊 [hc-hello-world-plugin] Array of objects received: %d items", len(arrObjs))
+		result.WriteString(i18n.T(locale, "greeting.array_received") + "\n")
+		for i, obj := range arrObjs {
+			if objMap, ok := obj.(map[string]interface{}); ok {
+				objName := sdk.GetStringArg(objMap, "name")
+				objAge := sdk.GetIntArg(objMap, "age")
+				result.WriteString(i18n.T(locale, "greeting.array_item", i+1, objName, objAge) + "\n")
+			}
+		}
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] helloWorldResolver completed successfully")
+	return result.String(), nil
+}
+
+// inspectVariablesResolver contrasts the raw GraphQL variables with the
+// resolver's args. Given
+//
+//	query($size: Int, $who: String) { inspectVariables(limit: $size, name: $who) }
+//
+// args holds "limit" and "name" (already substituted and, after
+// ParseArgsForResolver, converted to Int and String) while the variables map
+// holds "size" and "who" exactly as the client sent them: numbers arrive as
+// float64 and unused variables are included.
+func inspectVariablesResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] inspectVariablesResolver called")
+
+	// The same map is also available as rawArgs["context_variables"]
+	vars := variables.FromContext(ctx)
+	rawVariables := make([]interface{}, 0, len(vars))
+	for _, name := range variables.Names(vars) {
+		rawVariables = append(rawVariables, describeValue(name, vars[name]))
+	}
+
+	// Parsed args exclude the context_* entries the SDK merges in
+	args := registry.ParseArgs("inspectVariables", rawArgs)
+	argNames := make([]string, 0, len(args))
+	for name := range args {
+		if !strings.HasPrefix(name, "context_") {
+			argNames = append(argNames, name)
+		}
+	}
+	sort.Strings(argNames)
+	parsedArgs := make([]interface{}, 0, len(argNames))
+	for _, name := range argNames {
+		parsedArgs = append(parsedArgs, describeValue(name, args[name]))
+	}
+
+	return map[string]interface{}{
+		"variables":    rawVariables,
+		"args":         parsedArgs,
+		"hasVariables": vars != nil,
+	}, nil
+}
+
+// describeValue builds a NamedValue: the JSON encoding of value plus its Go
+// type, which is where variables and parsed args visibly differ
+func describeValue(name string, value interface{}) map[string]interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%q", fmt.Sprint(value)))
+	}
+	return map[string]interface{}{
+		"name":   name,
+		"value":  string(encoded),
+		"goType": fmt.Sprintf("%T", value),
+	}
+}
+
+// maxMarkdownBytes caps renderMarkdown's source
+const maxMarkdownBytes = 100 << 10
+
+// renderMarkdownResolver converts untrusted markdown to HTML that is safe to
+// embed in a page. Markdown allows raw HTML, so the rendered output goes
+// through sanitize.HTML, which removes script and style elements and any
+// attribute or URL that could run code; escaping the source instead would
+// also break the HTML markdown users legitimately write.
+func renderMarkdownResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] renderMarkdownResolver called")
+
+	args := registry.ParseArgs("renderMarkdown", rawArgs)
+	source := sdk.GetStringArg(args, "source", "")
+	if len(source) > maxMarkdownBytes {
+		return nil, fmt.Errorf("source is %d bytes, at most %d allowed", len(source), maxMarkdownBytes)
+	}
+	return sanitize.HTML(markdown.ToHTML(source)), nil
+}
+
+func (h *handlers) processComplexDataResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("processComplexData", rawArgs)
+
+	// Bound every list argument before doing any work
+	listSizes := map[string]int{
+		"users":         len(sdk.GetArrayArg(args, "users")),
+		"optionalUsers": len(sdk.GetArrayArg(args, "optionalUsers")),
+	}
+	if tagSlice, ok := args["tags"].([]string); ok {
+		listSizes["tags"] = len(tagSlice)
+	}
+	if numberSlice, ok := args["numbers"].([]int); ok {
+		listSizes["numbers"] = len(numberSlice)
+	}
+	for field, size := range listSizes {
+		if err := h.Limits.CheckItems(field, size); err != nil {
+			return nil, err
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString("Processing complex data (SDK Version with Auto-Parsing):\n")
+
+	// Process single user object - type-safe!
+	if user := sdk.GetObjectArg(args, "user"); len(user) > 0 {
+		result.WriteString("User: ")
+		id := sdk.GetIntArg(user, "id")
+		name := sdk.GetStringArg(user, "name")
+		email := sdk.GetStringArg(user, "email")
+		age := sdk.GetIntArg(user, "age")
+		active := sdk.GetBoolArg(user, "active")
+		result.WriteString(fmt.Sprintf("ID=%d Name=%s Email=%s Age=%d Active=%t\n", id, name, email, age, active))
+	}
+
+	// Process array of strings (tags) - automatically converted!
+	if tagSlice, ok := args["tags"].([]string); ok {
+		result.WriteString("Tags: ")
+		for i, tag := range tagSlice {
+			result.WriteString(tag)
+			if i < len(tagSlice)-1 {
+				result.WriteString(", ")
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	// Process array of integers (numbers) - automatically converted!
+	if numberSlice, ok := args["numbers"].([]int); ok {
+		result.WriteString("Numbers: ")
+		for i, num := range numberSlice {
+			result.WriteString(fmt.Sprintf("%d", num))
+			if i < len(numberSlice)-1 {
+				result.WriteString(", ")
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	// Process array of user objects (users) - automatically parsed!
+	if users := sdk.GetArrayArg(args, "users"); len(users) > 0 {
+		result.WriteString("Users:\n")
+		for i, user := range users {
+			if userMap, ok := user.(map[string]interface{}); ok {
+				id := sdk.GetIntArg(userMap, "id")
+				name := sdk.GetStringArg(userMap, "name")
+				email := sdk.GetStringArg(userMap, "email")
+				result.WriteString(fmt.Sprintf("  User %d: ID=%d Name=%s Email=%s\n", i+1, id, name, email))
+			}
+		}
+	}
+
+	// Process array of optional user objects (optionalUsers)
+	if optionalUsers := sdk.GetArrayArg(args, "optionalUsers"); len(optionalUsers) > 0 {
+		result.WriteString("Optional Users:\n")
+		for i, user := range optionalUsers {
+			if user != nil {
+				if userMap, ok := user.(map[string]interface{}); ok {
+					name := sdk.GetStringArg(userMap, "name")
+					email := sdk.GetStringArg(userMap, "email")
+					result.WriteString(fmt.Sprintf("  Optional User %d: Name=%s Email=%s\n", i+1, name, email))
+				}
+			} else {
+				result.WriteString(fmt.Sprintf("  Optional User %d: null\n", i+1))
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+func (h *handlers) sayHelloResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("sayHelloMutation", rawArgs)
+
+	// Type-safe argument extraction with default value
+	message := h.Sanitizer.Field("message", sdk.GetStringArg(args, "message", "Hello!"))
+
+	return fmt.Sprintf("Plugin says: %s (from hc-hello-world-plugin using SDK with Auto-Parsing)", message), nil
+}
+
+// slowOperationResolver deliberately takes a long time so cancellation from the
+// host can be observed propagating through the SDK into plugin code
+func (h *handlers) slowOperationResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] slowOperationResolver called")
+
+	args := registry.ParseArgs("slowOperation", rawArgs)
+	steps := sdk.GetIntArg(args, "steps", 10)
+	delayMs := sdk.GetIntArg(args, "delayMs", 1000)
+	if err := h.Limits.CheckItems("steps", steps); err != nil {
+		return nil, err
+	}
+
+	started := h.Clock.Now()
+	for step := 1; step <= steps; step++ {
+		done := make(chan struct{})
+		timer := h.Clock.AfterFunc(time.Duration(delayMs)*time.Millisecond, func() { close(done) })
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("⛔ [hc-hello-world-plugin] slowOperationResolver cancelled at step %d/%d after %s: %v",
+				step, steps, h.Clock.Since(started).Round(time.Millisecond), ctx.Err())
+			return nil, fmt.Errorf("slow operation cancelled at step %d of %d: %w", step, steps, ctx.Err())
+		case <-done:
+			log.Printf("⏳ [hc-hello-world-plugin] slowOperationResolver step %d/%d done", step, steps)
+		}
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] slowOperationResolver completed")
+	return fmt.Sprintf("Completed %d steps in %s", steps, h.Clock.Since(started).Round(time.Millisecond)), nil
+}
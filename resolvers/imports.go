@@ -0,0 +1,302 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/csvimport"
+	"hc-hello-world-plugin/importjob"
+	"hc-hello-world-plugin/limits"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/validate"
+)
+
+// maxReportedImportErrors caps the per-row errors returned by importUsers
+const maxReportedImportErrors = 100
+
+// importRowBuffer is how many parsed rows the CSV parser may run ahead of
+// the inserts before it waits
+const importRowBuffer = 256
+
+// userImport is one importUsers run. Its methods run on the goroutine
+// inserting the rows and report progress to the Imports tracker.
+type userImport struct {
+	h      *handlers
+	jobID  string
+	dryRun bool
+	input  *csvimport.Counter
+
+	total, imported, errorCount int
+	rowErrors                   []interface{}
+	failedRows                  map[int]bool
+}
+
+// importUsersResolver bulk-creates users from a base64-encoded CSV or an
+// uploaded CSV file. The CSV is parsed as a stream, a bounded number of
+// rows ahead of the inserts, and each row is validated and inserted on its
+// own, so one bad row never aborts the import. Every run is tracked as a
+// job; with async it continues in the background and getImportJob reports
+// its progress.
+func (h *handlers) importUsersResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	// Raw args are not logged: the CSV can be large and contains PII
+	log.Printf("🚀 [hc-hello-world-plugin] importUsersResolver called")
+
+	args := registry.ParseArgs("importUsers", rawArgs)
+	data := sdk.GetStringArg(args, "csv", "")
+	fileID := sdk.GetStringArg(args, "fileId", "")
+	dryRun := sdk.GetBoolArg(args, "dryRun", false)
+	async := sdk.GetBoolArg(args, "async", false)
+	if (data == "") == (fileID == "") {
+		return nil, &validate.FieldError{Field: "csv", Code: validate.CodeRequired, Message: "exactly one of csv and fileId is required"}
+	}
+
+	jobID := h.IDs.NewID()
+	var input io.Reader
+	var size int64
+	maxRows := h.Limits.MaxItems
+	cleanup := func() {}
+	if fileID != "" {
+		f, rc, err := h.Uploads.Open(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		// Claimed so the upload cannot be imported twice; it is deleted
+		// once read
+		if _, err := h.Uploads.Claim(fileID, "import:"+jobID, "import.csv", "text/csv"); err != nil {
+			rc.Close()
+			return nil, err
+		}
+		input, size = rc, f.Size
+		// An upload is bounded by PLUGIN_UPLOAD_MAX_FILE_BYTES rather than
+		// by rows
+		maxRows = 0
+		cleanup = func() {
+			rc.Close()
+			h.Uploads.Delete(context.WithoutCancel(ctx), fileID)
+		}
+	} else {
+		input = csvimport.Base64Reader(data)
+	}
+
+	imp := &userImport{
+		h:          h,
+		jobID:      jobID,
+		dryRun:     dryRun,
+		input:      csvimport.NewCounter(input),
+		failedRows: make(map[int]bool),
+	}
+	h.Imports.Start(jobID, dryRun, size)
+	if !async {
+		defer cleanup()
+		return imp.run(ctx, maxRows)
+	}
+
+	// Built before the import starts, which changes the counts
+	started := imp.result(importjob.StatusRunning, "Import started; getImportJob reports its progress")
+	// The import outlives the request but keeps its values, such as the
+	// trace
+	go func() {
+		defer cleanup()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("❌ [hc-hello-world-plugin] event=import_panic job=%s panic=%q", jobID, r)
+				h.Imports.Finish(jobID, "", fmt.Errorf("import panicked: %v", r))
+			}
+		}()
+		imp.run(context.WithoutCancel(ctx), maxRows)
+	}()
+	log.Printf("📥 [hc-hello-world-plugin] event=import_started job=%s dryRun=%t bytes=%d", jobID, dryRun, size)
+	return started, nil
+}
+
+// run imports every row, then records the outcome with the job
+func (imp *userImport) run(ctx context.Context, maxRows int) (map[string]interface{}, error) {
+	err := csvimport.Pipe(ctx, imp.input, []string{"name", "email"}, maxRows, importRowBuffer,
+		func(row csvimport.Row) error {
+			defer imp.progress()
+			return imp.importRow(row)
+		},
+		func(line int, err error) {
+			defer imp.progress()
+			imp.total++
+			imp.addError(line, &validate.FieldError{Field: "row", Code: validate.CodeInvalid, Message: err.Error()})
+		})
+	if errors.Is(err, csvimport.ErrMaxRows) {
+		err = &limits.Error{Code: limits.CodeTooManyItems, Message: err.Error(), Limit: maxRows, Actual: maxRows + 1}
+		imp.finish("", err)
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("❌ [hc-hello-world-plugin] event=import_failed job=%s total=%d imported=%d error=%q", imp.jobID, imp.total, imp.imported, err)
+		imp.finish("", err)
+		return imp.result(importjob.StatusFailed, err.Error()), nil
+	}
+
+	message := fmt.Sprintf("Imported %d of %d rows", imp.imported, imp.total)
+	imp.finish(message, nil)
+	log.Printf("✅ [hc-hello-world-plugin] importUsersResolver finished - job: %s, total: %d, imported: %d, failed: %d, dryRun: %t", imp.jobID, imp.total, imp.imported, len(imp.failedRows), imp.dryRun)
+	return imp.result(importjob.StatusCompleted, message), nil
+}
+
+// importRow validates one row and inserts it unless this is a dry run
+func (imp *userImport) importRow(row csvimport.Row) error {
+	imp.total++
+	in := createUserInput{Name: row.Get("name"), Email: row.Get("email"), Handle: row.Get("handle")}
+	if in.Handle == "" {
+		in.Handle = row.Get("username")
+	}
+	var coordinateErrors validate.Errors
+	if row.Get("street") != "" || row.Get("city") != "" {
+		in.Address = &addressInput{
+			Street: row.Get("street"),
+			City:   row.Get("city"),
+			State:  row.Get("state"),
+			Zip:    row.Get("zip"),
+		}
+		for column, dst := range map[string]**float64{"latitude": &in.Address.Latitude, "longitude": &in.Address.Longitude} {
+			if raw := row.Get(column); raw != "" {
+				value, parseErr := strconv.ParseFloat(raw, 64)
+				if parseErr != nil {
+					coordinateErrors.Add(&validate.FieldError{Field: "address." + column, Code: validate.CodeInvalid, Message: column + " must be a number"})
+					continue
+				}
+				*dst = &value
+			}
+		}
+	}
+	in.normalize()
+
+	fieldErrors := validate.Struct(in)
+	if len(coordinateErrors) > 0 {
+		// An unparsable coordinate replaces any coordinate error the
+		// validator reported, e.g. its counterpart being "required with" it
+		fieldErrors = append(coordinateErrors, fieldErrors.Without("address.latitude", "address.longitude")...)
+	}
+	active := true
+	if raw := row.Get("active"); raw != "" {
+		var parseErr error
+		if active, parseErr = strconv.ParseBool(raw); parseErr != nil {
+			fieldErrors.Add(&validate.FieldError{Field: "active", Code: validate.CodeInvalid, Message: "active must be true or false"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		for _, fieldErr := range fieldErrors {
+			imp.addError(row.Line, fieldErr)
+		}
+		return nil
+	}
+
+	user := in.toUser(imp.h.IDs.NewID(), imp.h.Clock.Now())
+	user.Active = active
+	if imp.dryRun {
+		imp.imported++
+		return nil
+	}
+
+	_, err := imp.h.Store.CreateUser(user)
+	if fieldErrors := conflictErrors(err); len(fieldErrors) > 0 {
+		for _, fieldErr := range fieldErrors {
+			imp.addError(row.Line, fieldErr)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	imp.imported++
+	return nil
+}
+
+// addError records a problem with the row on line, keeping the first
+// maxReportedImportErrors for the response
+func (imp *userImport) addError(line int, fieldErr *validate.FieldError) {
+	imp.errorCount++
+	imp.failedRows[line] = true
+	if len(imp.rowErrors) < maxReportedImportErrors {
+		row := fieldErr.ToMap()
+		row["line"] = line
+		imp.rowErrors = append(imp.rowErrors, row)
+	}
+}
+
+// progress copies the counts so far to the job
+func (imp *userImport) progress() {
+	imp.h.Imports.Update(imp.jobID, func(job *importjob.Job) {
+		job.Total, job.Imported, job.Failed = imp.total, imp.imported, len(imp.failedRows)
+		job.BytesRead = imp.input.Bytes()
+	})
+}
+
+// finish records the final counts and errors and marks the job finished
+func (imp *userImport) finish(message string, err error) {
+	imp.progress()
+	imp.h.Imports.Update(imp.jobID, func(job *importjob.Job) {
+		job.Errors = imp.rowErrors
+		job.ErrorsTruncated = imp.errorCount > len(imp.rowErrors)
+	})
+	imp.h.Imports.Finish(imp.jobID, message, err)
+}
+
+// result converts the import so far to the ImportUsersResult object
+func (imp *userImport) result(status, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"jobId":           imp.jobID,
+		"status":          status,
+		"success":         status == importjob.StatusCompleted && len(imp.failedRows) == 0,
+		"message":         message,
+		"dryRun":          imp.dryRun,
+		"total":           imp.total,
+		"imported":        imp.imported,
+		"failed":          len(imp.failedRows),
+		"errors":          imp.rowErrors,
+		"errorsTruncated": imp.errorCount > len(imp.rowErrors),
+	}
+}
+
+// getImportJobResolver reports the progress of an importUsers run
+func (h *handlers) getImportJobResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getImportJobResolver called")
+
+	args := registry.ParseArgs("getImportJob", rawArgs)
+	job, err := h.Imports.Get(sdk.GetStringArg(args, "jobId", ""))
+	if err != nil {
+		return nil, err
+	}
+	return importJobToMap(job), nil
+}
+
+// importJobToMap converts a job to the ImportJob object
+func importJobToMap(job importjob.Job) map[string]interface{} {
+	result := map[string]interface{}{
+		"jobId":           job.ID,
+		"status":          job.Status,
+		"dryRun":          job.DryRun,
+		"total":           job.Total,
+		"imported":        job.Imported,
+		"failed":          job.Failed,
+		"bytesRead":       float64(job.BytesRead),
+		"bytesTotal":      nil,
+		"percent":         nil,
+		"message":         job.Message,
+		"errors":          job.Errors,
+		"errorsTruncated": job.ErrorsTruncated,
+		"startedAt":       timeutil.FormatUTC(job.StartedAt),
+		"updatedAt":       timeutil.FormatUTC(job.UpdatedAt),
+		"finishedAt":      nil,
+	}
+	if job.BytesTotal > 0 {
+		result["bytesTotal"] = float64(job.BytesTotal)
+		result["percent"] = min(100, float64(job.BytesRead)*100/float64(job.BytesTotal))
+	}
+	if !job.FinishedAt.IsZero() {
+		result["finishedAt"] = timeutil.FormatUTC(job.FinishedAt)
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/money"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/store"
+)
+
+// productToMap builds the Product response
+func productToMap(p store.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          p.ID,
+		"name":        p.Name,
+		"description": p.Description,
+		"price":       p.Price.Float(),
+		"priceMoney":  p.Price.ToMap(),
+		"stock":       p.Stock,
+		"tags":        p.Tags,
+		"categories":  p.Categories,
+	}
+}
+
+// getProductsPaginatedResolver demonstrates returning a paginated response
+func (h *handlers) getProductsPaginatedResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getProductsPaginatedResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("getProductsPaginated", rawArgs)
+	page := sdk.GetIntArg(args, "page", 1)
+	if page < 1 {
+		page = 1
+	}
+	pageSize := h.Watchdog.PageSize("getProductsPaginated", h.Limits.PageSize(sdk.GetIntArg(args, "pageSize", 5), 5))
+
+	if err := h.Limits.CheckComplexity("getProductsPaginated", page*pageSize, productItemCost); err != nil {
+		return nil, err
+	}
+	category := sdk.GetStringArg(args, "category", "")
+	currency := strings.ToUpper(sdk.GetStringArg(args, "currency", ""))
+
+	log.Printf("📄 [hc-hello-world-plugin] Pagination params - page: %d, pageSize: %d, category: %s, currency: %s", page, pageSize, category, currency)
+
+	// Reject unknown currency codes instead of silently returning nothing
+	if currency != "" {
+		if err := money.ValidateCurrency(currency); err != nil {
+			return map[string]interface{}{
+				"items":           []string{},
+				"totalCount":      0,
+				"pageSize":        pageSize,
+				"currentPage":     page,
+				"totalPages":      0,
+				"hasNextPage":     false,
+				"hasPreviousPage": false,
+				"success":         false,
+				"message":         err.Error(),
+			}, nil
+		}
+	}
+
+	// Filter by category and currency if provided
+	filteredProducts := h.Store.ListProducts(func(p store.Product) bool {
+		if currency != "" && p.Price.Currency != currency {
+			return false
+		}
+		if category == "" {
+			return true
+		}
+		for _, cat := range p.Categories {
+			if cat == category {
+				return true
+			}
+		}
+		return false
+	})
+	if err := ctx.Err(); err != nil {
+		log.Printf("⛔ [hc-hello-world-plugin] getProductsPaginatedResolver cancelled: %v", err)
+		return nil, err
+	}
+
+	// Calculate pagination
+	total := len(filteredProducts)
+	totalPages := (total + pageSize - 1) / pageSize
+	offset := (page - 1) * pageSize
+
+	// Get page items
+	var pageItems []store.Product
+	if offset < total {
+		end := offset + pageSize
+		if end > total {
+			end = total
+		}
+		pageItems = filteredProducts[offset:end]
+	}
+
+	// Return paginated response structure (updated for v0.1.6 simplified structure)
+	// Convert products to string array for simplified pagination
+	var itemStrings []string
+	for _, item := range pageItems {
+		itemStrings = append(itemStrings, fmt.Sprintf("%s - %s (%s %s)",
+			item.Name, item.Description, item.Price.Decimal(), item.Price.Currency))
+	}
+
+	response := map[string]interface{}{
+		"items":           itemStrings,
+		"totalCount":      total,
+		"pageSize":        pageSize,
+		"currentPage":     page,
+		"totalPages":      totalPages,
+		"hasNextPage":     page < totalPages,
+		"hasPreviousPage": page > 1,
+		"success":         true,
+		"message":         fmt.Sprintf("Retrieved %d products", len(pageItems)),
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] getProductsPaginatedResolver completed")
+	return response, nil
+}
+
+// transferSteps are the points at which transferStock can inject a failure
+var transferSteps = []string{"debit", "credit"}
+
+// transferStockResolver moves stock between two products in one store
+// transaction: either both products change or neither does. failAt injects
+// a failure after the named step to demonstrate the rollback.
+func (h *handlers) transferStockResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] transferStockResolver called")
+
+	args := registry.ParseArgs("transferStock", rawArgs)
+	fromID := sdk.GetStringArg(args, "fromProductId", "")
+	toID := sdk.GetStringArg(args, "toProductId", "")
+	qty := sdk.GetIntArg(args, "qty", 0)
+	failAt := sdk.GetStringArg(args, "failAt", "")
+
+	failure := func(message string) map[string]interface{} {
+		return map[string]interface{}{
+			"success":    false,
+			"message":    message,
+			"rolledBack": true,
+			"from":       nil,
+			"to":         nil,
+		}
+	}
+	if qty <= 0 {
+		return failure("qty must be greater than 0"), nil
+	}
+	if fromID == toID {
+		return failure("fromProductId and toProductId must differ"), nil
+	}
+	if failAt != "" && !slices.Contains(transferSteps, failAt) {
+		return failure(fmt.Sprintf("failAt must be one of %s", strings.Join(transferSteps, ", "))), nil
+	}
+	injectFailure := func(step string) error {
+		if failAt == step {
+			return fmt.Errorf("injected failure after %s", step)
+		}
+		return nil
+	}
+
+	var from, to store.Product
+	err := h.Store.WithTx(func(tx *store.Tx) error {
+		var err error
+		if from, err = tx.GetProduct(fromID); err != nil {
+			return err
+		}
+		if to, err = tx.GetProduct(toID); err != nil {
+			return err
+		}
+		if from.Stock < qty {
+			return fmt.Errorf("product %s has only %d in stock", fromID, from.Stock)
+		}
+
+		from.Stock -= qty
+		if err := tx.PutProduct(from); err != nil {
+			return err
+		}
+		if err := injectFailure("debit"); err != nil {
+			return err
+		}
+
+		to.Stock += qty
+		if err := tx.PutProduct(to); err != nil {
+			return err
+		}
+		return injectFailure("credit")
+	})
+	if err != nil {
+		log.Printf("↩️  [hc-hello-world-plugin] transferStock rolled back: %v", err)
+		return failure(err.Error()), nil
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] Transferred %d units from product %s to %s", qty, fromID, toID)
+	return map[string]interface{}{
+		"success":    true,
+		"message":    fmt.Sprintf("Transferred %d units", qty),
+		"rolledBack": false,
+		"from":       productToMap(from),
+		"to":         productToMap(to),
+	}, nil
+}
+
+// getProductResolver demonstrates returning a single Product object
+func (h *handlers) getProductResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getProductResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("getProduct", rawArgs)
+	productID := sdk.GetStringArg(args, "productId", "default-product")
+
+	log.Printf("📦 [hc-hello-world-plugin] Fetching product for ID: %s", productID)
+
+	// Stored products are returned as-is; unknown IDs get the demo product
+	if stored, err := h.Store.GetProduct(productID); err == nil {
+		return productToMap(stored), nil
+	}
+
+	// Return a complex Product object structure
+	product := map[string]interface{}{
+		"id":          productID,
+		"name":        "Sample Product",
+		"description": "This is a sample product from the plugin",
+		"price":       money.MustNew(2999, "USD").Float(),
+		"priceMoney":  money.MustNew(2999, "USD").ToMap(),
+		"stock":       100,
+		"tags":        []string{"sample", "plugin", "demo"},
+		"categories":  []string{"electronics", "gadgets"},
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] getProductResolver completed")
+	return product, nil
+}
@@ -0,0 +1,924 @@
+package resolvers
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/admin"
+	"hc-hello-world-plugin/app"
+	"hc-hello-world-plugin/compat"
+	"hc-hello-world-plugin/consent"
+	"hc-hello-world-plugin/instrument"
+	"hc-hello-world-plugin/limits"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/schema"
+	"hc-hello-world-plugin/store"
+)
+
+// schemaSDL declares the types and queries that are registered from SDL
+//
+//go:embed schema.graphql
+var schemaSDL string
+
+// Register registers the plugin's GraphQL queries and mutations, and the
+// object types they return, with plugin
+func Register(plugin *registry.Plugin, a *app.App) {
+	h := &handlers{App: a}
+
+	log.Printf("📋 [hc-hello-world-plugin] Registering GraphQL queries...")
+
+	// ========================================
+	// SIMPLE STRING EXAMPLE (Original)
+	// ========================================
+
+	plugin.Group(app.DemoGroup, func() {
+		// Register GraphQL queries - replaces 100+ lines of protobuf struct creation
+		plugin.RegisterQuery("helloWorldQueryFahim",
+			sdk.FieldWithArgs("String", "Hello World Plugin Query with Arguments", map[string]interface{}{
+				"name":   sdk.StringArg("Name to greet (optional)"),
+				"locale": sdk.StringArg("Response language: en, es or de (defaults to the host locale, then en)"),
+				"object": sdk.ObjectArg("Object argument", map[string]interface{}{
+					"name": sdk.StringProperty("Object name"),
+					"age":  sdk.IntProperty("Object age"),
+				}),
+				"arrayofObjects": sdk.ListArg("Object", "Array of objects"),
+			}),
+			instrument.Resolver("helloWorldQueryFahim", h.helloWorldResolver))
+
+		// Raw GraphQL variables versus parsed args
+		namedValueType := sdk.NewObjectType("NamedValue", "A named value with its JSON encoding and Go type").
+			AddStringField("name", "Variable or argument name", false).
+			AddStringField("value", "JSON-encoded value", false).
+			AddStringField("goType", "Go type the plugin received", false).
+			Build()
+
+		variablesReportType := sdk.NewObjectType("VariablesReport", "GraphQL variables compared with resolver args").
+			AddObjectListField("variables", "Raw operation variables from the context, by variable name", namedValueType, false, true).
+			AddObjectListField("args", "Parsed resolver args, by argument name", namedValueType, false, true).
+			AddBooleanField("hasVariables", "Whether the host passed a variables map", false).
+			Build()
+
+		plugin.RegisterQuery("inspectVariables",
+			sdk.ComplexObjectFieldWithArgs("Compare the raw GraphQL variables with this field's parsed args", variablesReportType, map[string]interface{}{
+				"limit": sdk.IntArg("Try binding an Int variable here"),
+				"name":  sdk.StringArg("Try binding a String variable here"),
+				"tags":  sdk.ListArg("String", "Try binding a [String] variable here"),
+			}),
+			instrument.Resolver("inspectVariables", inspectVariablesResolver))
+	})
+
+	plugin.RegisterQuery("renderMarkdown",
+		sdk.FieldWithArgs("String", "Render untrusted markdown as sanitized HTML; scripts, styles, event handlers and unsafe URLs are removed", map[string]interface{}{
+			"source": sdk.NonNullArg("String", fmt.Sprintf("Markdown source, at most %d bytes; raw HTML is allowed and sanitized", maxMarkdownBytes)),
+		}),
+		instrument.Resolver("renderMarkdown", renderMarkdownResolver))
+
+	// ========================================
+	// COMPLEX OBJECT EXAMPLES (New)
+	// ========================================
+
+	// Define an Address object type (nested object)
+	addressType := sdk.NewObjectType("Address", "A user's address").
+		AddStringField("street", "Street address", false).
+		AddStringField("city", "City", false).
+		AddStringField("state", "State", false).
+		AddStringField("zip", "Zip code", false).
+		AddFloatField("latitude", "Latitude in decimal degrees", true).
+		AddFloatField("longitude", "Longitude in decimal degrees", true).
+		Build()
+
+	// Define a Tag object type for the tags array
+	tagType := sdk.NewObjectType("Tag", "A tag with key and value").
+		AddStringField("key", "Tag key", false).
+		AddStringField("val", "Tag value", false).
+		Build()
+
+	// Define a Group object type; users and groups are many-to-many
+	groupType := sdk.NewObjectType("Group", "A named collection of users").
+		AddStringField("id", "Group ID", false).
+		AddStringField("name", "Unique group name", false).
+		AddStringField("description", "Group description", true).
+		AddStringField("createdAt", "When the group was created (RFC3339, UTC)", true).
+		AddIntField("memberCount", "Number of users in the group", false).
+		Build()
+
+	// Define a User object type with nested objects
+	userType := sdk.NewObjectType("User", "A user in the system").
+		AddStringField("id", "User ID", false).
+		AddStringField("name", "User's full name", false).
+		AddStringField("email", "User's email address; masked unless the caller's role is in PLUGIN_PII_ROLES", true).
+		AddStringField("phone", "User's phone number in E.164 form; masked like email", true).
+		AddStringField("username", "User's username", true).
+		AddStringField("handle", "User's public handle", true).
+		AddObjectField("address", "User's address", addressType, true).
+		AddObjectListField("tags", "User tags with key-value pairs", tagType, true, false).
+		AddObjectListField("groups", "Groups the user belongs to", groupType, true, true).
+		AddBooleanField("active", "Whether the user is active", false).
+		AddStringField("createdAt", "When the user was created (RFC3339, UTC)", true).
+		AddStringField("createdAtLocal", "When the user was created, in the requested timezone", true).
+		AddStringField("timezone", "Timezone used for createdAtLocal", true).
+		AddObjectField("metadata", "Free-form JSON object given at creation, returned as stored", schema.JSON, true).
+		AddIntField("version", "Starts at 1 and grows with every update; pass it to updateUserPartial as expectedVersion", true).
+		AddStringField("updatedAt", "When the user last changed (RFC3339, UTC)", true).
+		Build()
+	userType = schema.DeprecateField(userType, "username", "Use handle instead")
+
+	// Query that returns a single User object
+	plugin.RegisterQuery("getUserProfile",
+		sdk.ComplexObjectFieldWithArgs("Get user profile by ID", userType, map[string]interface{}{
+			"userId":   sdk.StringArg("User ID to fetch"),
+			"timezone": sdk.StringArg("IANA timezone for createdAtLocal, e.g. Europe/Berlin (default UTC)"),
+		}),
+		instrument.Resolver("getUserProfile", h.getUserProfileResolver))
+
+	// Query that returns an array of User objects; a page over 50 users or
+	// a response over 256 KiB is rejected before it reaches the host
+	plugin.Limit("getUsers", limits.Declared{MaxPageSize: 50, MaxResponseBytes: 256 << 10})
+	plugin.RegisterQuery("getUsers",
+		sdk.ListOfObjectsFieldWithArgs("Get a list of users", userType, map[string]interface{}{
+			"limit":        sdk.IntArg("Maximum number of users to return"),
+			"offset":       sdk.IntArg("Number of users to skip"),
+			"active":       sdk.BooleanArg("Filter by active status"),
+			"timezone":     sdk.StringArg("IANA timezone for createdAtLocal, e.g. Europe/Berlin (default UTC)"),
+			"createdAfter": sdk.StringArg("Only return users created after this RFC3339 timestamp"),
+		}),
+		instrument.Resolver("getUsers", h.Watchdog.Guard("getUsers", h.getUsersResolver)))
+
+	nearbyUserType := sdk.NewObjectType("NearbyUser", "A user found by a distance search").
+		AddObjectField("user", "The user", userType, false).
+		AddFloatField("distanceKm", "Great-circle distance from the search point in kilometres", false).
+		Build()
+
+	plugin.RegisterQuery("nearbyUsers",
+		sdk.ListOfObjectsFieldWithArgs("Find users who consented to location use and whose address is within a radius of a point, nearest first", nearbyUserType, map[string]interface{}{
+			"lat":      sdk.NonNullArg("Float", "Latitude of the search point, -90 to 90"),
+			"lng":      sdk.NonNullArg("Float", "Longitude of the search point, -180 to 180"),
+			"radiusKm": sdk.NonNullArg("Float", fmt.Sprintf("Search radius in kilometres (max %d)", maxNearbyRadiusKm)),
+			"limit":    sdk.IntArg("Maximum number of users to return (default 10)"),
+			"active":   sdk.BooleanArg("Only include users with this active status"),
+		}),
+		instrument.Resolver("nearbyUsers", h.Watchdog.Guard("nearbyUsers", h.nearbyUsersResolver)))
+
+	// Binary data travels through GraphQL as base64 strings
+	avatarType := sdk.NewObjectType("Avatar", "A user's avatar image").
+		AddStringField("userId", "Owner of the avatar", false).
+		AddStringField("fileId", "Stored file ID; changes with every upload", false).
+		AddStringField("mimeType", "Image MIME type detected from the uploaded bytes", false).
+		AddIntField("size", "Decoded size in bytes", false).
+		AddStringField("sha256", "Hex SHA-256 of the decoded bytes", false).
+		AddStringField("updatedAt", "When the avatar was uploaded (RFC3339, UTC)", false).
+		AddStringField("data", "Image bytes, standard base64 encoded", true).
+		AddStringField("dataUrl", "The image as a data: URL, usable directly as an img src", true).
+		Build()
+
+	plugin.RegisterQuery("getUserAvatar",
+		sdk.ComplexObjectFieldWithArgs("Get a user's avatar image as base64 with its MIME type; null when the user has none", avatarType, map[string]interface{}{
+			"userId": sdk.NonNullArg("String", "User whose avatar to fetch"),
+		}),
+		instrument.Resolver("getUserAvatar", h.getUserAvatarResolver))
+
+	exifTagType := sdk.NewObjectType("ExifTag", "One EXIF tag of an image").
+		AddStringField("name", "Tag name, e.g. Model or DateTimeOriginal", false).
+		AddStringField("value", "Value as text; lists are comma separated", false).
+		Build()
+
+	imageMetadataType := sdk.NewObjectType("ImageMetadata", "Format, dimensions and EXIF data of an uploaded image").
+		AddStringField("fileId", "Stored file ID", false).
+		AddStringField("format", "png, jpeg, gif or webp, detected from the file contents", false).
+		AddStringField("mimeType", "MIME type matching format", false).
+		AddIntField("width", "Width in pixels as stored, before any EXIF rotation", false).
+		AddIntField("height", "Height in pixels as stored, before any EXIF rotation", false).
+		AddIntField("size", "File size in bytes", false).
+		AddIntField("orientation", "EXIF orientation 1-8; 5-8 display rotated by 90 degrees", true).
+		AddObjectListField("exif", "Recognised EXIF tags in file order; empty when the image has none", exifTagType, false, true).
+		Build()
+
+	plugin.RegisterQuery("getImageMetadata",
+		sdk.ComplexObjectFieldWithArgs("Read the format, dimensions and EXIF data of an uploaded image; corrupt files are reported as errors", imageMetadataType, map[string]interface{}{
+			"fileId": sdk.NonNullArg("String", "ID of a stored upload, e.g. Avatar.fileId"),
+		}),
+		instrument.Resolver("getImageMetadata", h.getImageMetadataResolver))
+
+	plugin.RegisterMutation("uploadAvatar",
+		sdk.ComplexObjectFieldWithArgs("Replace a user's avatar with a PNG, JPEG, GIF or WebP image, sent as base64 data or as a multipart upload", avatarType, map[string]interface{}{
+			"userId":   sdk.NonNullArg("String", "User to set the avatar for"),
+			"data":     sdk.StringArg(fmt.Sprintf("Base64 image bytes or a data: URL, at most %d bytes decoded", h.Config.MaxAvatarBytes)),
+			"fileId":   sdk.StringArg("A file sent through POST /graphql/upload, instead of data"),
+			"mimeType": sdk.StringArg("Expected MIME type; the upload is rejected when the bytes are something else"),
+		}),
+		instrument.Resolver("uploadAvatar", h.uploadAvatarResolver))
+
+	// Chunked variant of getUsers for very large result sets
+	userChunkType := sdk.NewObjectType("UserChunk", "One chunk of a getUsersStream scan").
+		AddObjectListField("users", "Users in this chunk", userType, false, true).
+		AddIntField("count", "Number of users in this chunk", false).
+		AddStringField("nextCursor", "Pass as cursor to fetch the next chunk; null when done", true).
+		AddBooleanField("hasMore", "Whether more chunks remain", false).
+		Build()
+
+	plugin.RegisterQuery("getUsersStream",
+		sdk.ComplexObjectFieldWithArgs("Scan users chunk by chunk using a cursor", userChunkType, map[string]interface{}{
+			"cursor":    sdk.StringArg("Cursor from the previous chunk (omit to start)"),
+			"chunkSize": sdk.IntArg("Maximum users per chunk (default 100)"),
+			"active":    sdk.BooleanArg("Only include users with this active status"),
+			"timezone":  sdk.StringArg("IANA timezone for createdAtLocal (default UTC)"),
+		}),
+		instrument.Resolver("getUsersStream", h.Watchdog.Guard("getUsersStream", h.getUsersStreamResolver)))
+
+	// Define a Money object type - amounts are decimal strings, never floats
+	moneyType := sdk.NewObjectType("Money", "A monetary amount in a specific currency").
+		AddStringField("amount", "Decimal amount, e.g. \"29.99\"", false).
+		AddStringField("currency", "ISO 4217 currency code", false).
+		AddIntField("minorUnits", "Amount in the currency's minor units (e.g. cents)", false).
+		Build()
+
+	// Define a Product object type with nested structures
+	productType := sdk.NewObjectType("Product", "A product in our catalog").
+		AddStringField("id", "Product ID", false).
+		AddStringField("name", "Product name", false).
+		AddStringField("description", "Product description", true).
+		AddFloatField("price", "Product price (approximate, prefer priceMoney)", false).
+		AddObjectField("priceMoney", "Exact product price with currency", moneyType, false).
+		AddIntField("stock", "Stock quantity", false).
+		AddStringListField("tags", "Product tags", true, false).
+		AddStringListField("categories", "Product categories", true, false).
+		Build()
+
+	// Query that returns a single product
+	plugin.RegisterQuery("getProduct",
+		sdk.ComplexObjectFieldWithArgs("Get product by ID", productType, map[string]interface{}{
+			"productId": sdk.StringArg("Product ID to fetch"),
+		}),
+		instrument.Resolver("getProduct", h.cachedResolver("getProduct", recordTags(store.EntityProduct, "productId"),
+			h.coalescedResolver("getProduct", h.getProductResolver))))
+
+	transferStockResultType := sdk.NewObjectType("TransferStockResult", "Result of a stock transfer").
+		AddBooleanField("success", "Whether the transfer was committed", false).
+		AddStringField("message", "Response message", true).
+		AddBooleanField("rolledBack", "Whether the transaction was rolled back, leaving both products unchanged", false).
+		AddObjectField("from", "Source product after the transfer", productType, true).
+		AddObjectField("to", "Destination product after the transfer", productType, true).
+		Build()
+
+	// Mutation that updates two products atomically
+	plugin.RegisterMutation("transferStock",
+		sdk.ComplexObjectFieldWithArgs("Move stock between two products in a single transaction", transferStockResultType, map[string]interface{}{
+			"fromProductId": sdk.NonNullArg("String", "Product to take stock from"),
+			"toProductId":   sdk.NonNullArg("String", "Product to add stock to"),
+			"qty":           sdk.NonNullArg("Int", "Units to move (must be positive)"),
+			"failAt":        sdk.StringArg("Testing only: fail after this step (debit or credit) to force a rollback"),
+		}),
+		instrument.Resolver("transferStock", h.transferStockResolver))
+
+	changeType := sdk.NewObjectType("Change", "One entry in the change feed").
+		AddIntField("seq", "Position in the change log; increases with every change", false).
+		AddStringField("entity", "user, product, group, membership or comment", false).
+		AddStringField("id", "Record ID (memberships use userId:groupId)", false).
+		AddStringField("op", "created, updated or deleted", false).
+		AddStringField("changedAt", "When the change happened (RFC3339, UTC)", false).
+		AddObjectField("user", "Current state of a changed user; null once deleted", userType, true).
+		AddObjectField("product", "Current state of a changed product", productType, true).
+		Build()
+
+	changeFeedType := sdk.NewObjectType("ChangeFeed", "A page of the change feed").
+		AddObjectListField("changes", "Changes after the cursor, oldest first", changeType, false, true).
+		AddStringField("nextCursor", "Cursor for the next call; store it even when there are no changes", false).
+		AddBooleanField("hasMore", "Whether more changes are available right away", false).
+		Build()
+
+	plugin.RegisterQuery("getChangesSince",
+		sdk.ComplexObjectFieldWithArgs("Get records created, updated or deleted since a cursor, for incremental sync", changeFeedType, map[string]interface{}{
+			"cursor": sdk.StringArg("nextCursor from the previous call; omit for the full retained history"),
+			"limit":  sdk.IntArg("Maximum number of changes to return (default 100)"),
+		}),
+		instrument.Resolver("getChangesSince", h.getChangesSinceResolver))
+
+	webhookDeliveryType := sdk.NewObjectType("WebhookDelivery", "One event sent, or being sent, to PLUGIN_WEBHOOK_URL").
+		AddStringField("id", "Delivery ID, sent as X-Webhook-Delivery", false).
+		AddStringField("event", "Event name, e.g. UserCreated", false).
+		AddStringField("url", "Endpoint the delivery is sent to", false).
+		AddStringField("payload", "JSON request body", false).
+		AddStringField("status", "pending, delivered or failed", false).
+		AddIntField("attempts", "Requests made so far", false).
+		AddIntField("responseCode", "Status of the last response; null when none arrived", true).
+		AddStringField("responseBody", "Start of the last response's body (up to 1 KiB)", true).
+		AddStringField("lastError", "Why the last attempt failed", true).
+		AddStringField("nextRetryAt", "When the next attempt is due (RFC3339, UTC); null unless pending", true).
+		AddStringField("createdAt", "When the delivery was created (RFC3339, UTC)", false).
+		AddStringField("updatedAt", "When the delivery last changed (RFC3339, UTC)", false).
+		AddStringField("replayOf", "ID of the delivery this one resends", true).
+		AddStringField("traceId", "W3C trace ID the delivery's requests are sent under", true).
+		Build()
+
+	plugin.RegisterQuery("getWebhookDeliveries",
+		sdk.ListOfObjectsFieldWithArgs("Inspect recent webhook deliveries, most recently updated first", webhookDeliveryType, map[string]interface{}{
+			"limit":  sdk.IntArg("Maximum number of deliveries to return (default 20)"),
+			"status": sdk.StringArg("Only deliveries with this status: pending, delivered or failed"),
+		}),
+		instrument.Resolver("getWebhookDeliveries", h.getWebhookDeliveriesResolver))
+
+	plugin.RegisterMutation("replayWebhookDelivery",
+		sdk.ComplexObjectFieldWithArgs("Resend a delivered or failed webhook delivery's payload as a new delivery", webhookDeliveryType, map[string]interface{}{
+			"id": sdk.NonNullArg("String", "Delivery to resend"),
+		}),
+		instrument.Resolver("replayWebhookDelivery", h.replayWebhookDeliveryResolver))
+	plugin.Require("network:webhook", "store events are POSTed to PLUGIN_WEBHOOK_URL")
+
+	quotaUsageType := sdk.NewObjectType("QuotaUsage", "The calling tenant's consumption of its monthly call budget").
+		AddStringField("tenant", "Tenant the usage belongs to", false).
+		AddStringField("month", "Calendar month counted, in UTC (YYYY-MM)", false).
+		AddIntField("calls", "Calls made this month", false).
+		AddIntField("limit", "Monthly call budget; null when unlimited", true).
+		AddIntField("remaining", "Calls left this month; null when unlimited", true).
+		AddStringField("resetsAt", "When the count starts over (RFC3339, UTC)", false).
+		AddFloatField("rateLimitPerSecond", "Sustained calls per second allowed; null when not rate limited", true).
+		AddIntField("burst", "Calls allowed at once before the rate limit applies; null when not rate limited", true).
+		Build()
+
+	plugin.RegisterQuery("getQuotaUsage",
+		sdk.ComplexObjectField("Get the calling tenant's call count and limits for the current month; does not count against the quota", quotaUsageType),
+		instrument.Resolver("getQuotaUsage", h.getQuotaUsageResolver))
+
+	// Types and queries declared in schema.graphql
+	if err := schema.RegisterSDL(plugin, schemaSDL, map[string]sdk.ResolverFunc{
+		"getWeather": instrument.Resolver("getWeather", h.coalescedResolver("getWeather", h.getWeatherResolver)),
+		"pluginInfo": instrument.Resolver("pluginInfo", h.pluginInfoResolver),
+	}); err != nil {
+		log.Fatalf("❌ [hc-hello-world-plugin] Invalid schema.graphql: %v", err)
+	}
+	plugin.Require("network:weather", "getWeather calls PLUGIN_WEATHER_GEOCODING_URL and PLUGIN_WEATHER_FORECAST_URL")
+
+	plugin.Group(app.AdminGroup, func() {
+		// API keys for the REST endpoints, managed with PLUGIN_ADMIN_TOKEN
+		apiKeyType := sdk.NewObjectType("ApiKey", "A key authenticating REST requests in the X-API-Key header").
+			AddStringField("id", "Key ID", false).
+			AddStringField("name", "What the key is for", false).
+			AddStringField("hint", "Start of the key, to tell keys apart", false).
+			AddStringListField("scopes", "Endpoints the key may call: * or METHOD /path, where a trailing * matches any rest of the path", false, true).
+			AddStringField("createdAt", "When the key was created (RFC3339, UTC)", false).
+			AddStringField("lastUsedAt", "When the key last authenticated a request (RFC3339, UTC)", true).
+			AddStringField("revokedAt", "When the key was revoked (RFC3339, UTC); null while active", true).
+			Build()
+		createdApiKeyType := sdk.NewObjectType("CreatedApiKey", "A new API key, shown only once").
+			AddObjectField("apiKey", "The key's record", apiKeyType, false).
+			AddStringField("key", "The key itself; store it now, it cannot be retrieved again", false).
+			Build()
+		adminTokenArg := sdk.NonNullArg("String", "PLUGIN_ADMIN_TOKEN")
+
+		plugin.RegisterQuery("getApiKeys",
+			sdk.ListOfObjectsFieldWithArgs("List the API keys, newest first, including revoked ones", apiKeyType, map[string]interface{}{
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("getApiKeys", h.Guard.WrapResolver("getApiKeys", h.getApiKeysResolver)))
+
+		plugin.RegisterMutation("createApiKey",
+			sdk.ComplexObjectFieldWithArgs("Create an API key for the REST endpoints", createdApiKeyType, map[string]interface{}{
+				"name":         sdk.NonNullArg("String", "What the key is for"),
+				"scopes":       sdk.ListArg("String", "Endpoints the key may call, e.g. [\"GET /hello\", \"GET /emails/*\"] or [\"*\"]"),
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("createApiKey", h.Guard.WrapResolver("createApiKey", h.createApiKeyResolver)))
+
+		plugin.RegisterMutation("revokeApiKey",
+			sdk.ComplexObjectFieldWithArgs("Revoke an API key; requests with it are rejected from now on", apiKeyType, map[string]interface{}{
+				"id":           sdk.NonNullArg("String", "Key to revoke"),
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("revokeApiKey", h.Guard.WrapResolver("revokeApiKey", h.revokeApiKeyResolver)))
+
+		// What binary is running where, to compare environments
+		memoryStatsType := sdk.NewObjectType("MemoryStats", "Go runtime memory statistics; byte counts are Floats since they can exceed Int").
+			AddFloatField("heapAlloc", "Bytes of allocated heap objects", false).
+			AddFloatField("heapInuse", "Bytes in in-use heap spans", false).
+			AddFloatField("sys", "Bytes obtained from the OS", false).
+			AddIntField("numGC", "Completed garbage collections", false).
+			AddFloatField("pauseTotalMs", "Total garbage collection pause time in milliseconds", false).
+			AddStringField("lastGC", "When the last garbage collection finished (RFC3339, UTC); null before the first", true).
+			Build()
+		runtimeInfoType := sdk.NewObjectType("RuntimeInfo", "The process running the plugin").
+			AddStringField("os", "Operating system (GOOS)", false).
+			AddStringField("arch", "Architecture (GOARCH)", false).
+			AddIntField("numCPU", "Logical CPUs usable by the process", false).
+			AddIntField("gomaxprocs", "GOMAXPROCS: CPUs executing Go code at once", false).
+			AddIntField("numGoroutine", "Goroutines currently running", false).
+			AddStringListField("buildTags", "Build tags the binary was compiled with, e.g. sqlite", false, true).
+			AddBooleanField("cgoEnabled", "Whether the binary was built with cgo", false).
+			AddObjectField("memory", "Memory statistics", memoryStatsType, false).
+			Build()
+
+		plugin.RegisterQuery("runtimeInfo",
+			sdk.ComplexObjectFieldWithArgs("Get the OS, architecture, scheduler and memory state and build tags of the running plugin", runtimeInfoType, map[string]interface{}{
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("runtimeInfo", h.Guard.WrapResolver("runtimeInfo", h.runtimeInfoResolver)))
+
+		// Data subject requests: export everything about a user, or erase it
+		userDataExportType := sdk.NewObjectType("UserDataExport", "Everything the plugin stores about a user").
+			AddStringField("userId", "User ID", false).
+			AddStringField("exportedAt", "When the archive was built (RFC3339, UTC)", false).
+			AddObjectField("archive", "The user record with email and phone decrypted, their groups, comments, consents, logins, uploads and storage bucket, and the audit entries and webhook deliveries that mention them", schema.JSON, false).
+			Build()
+		erasureReceiptType := sdk.NewObjectType("ErasureReceipt", "Proof of a completed erasure, signed with PLUGIN_RECEIPT_SECRET").
+			AddStringField("id", "Receipt ID", false).
+			AddStringField("userId", "Erased user's ID", false).
+			AddStringField("outcome", "deleted, or anonymized when comments still refer to the user", false).
+			AddStringListField("erased", "What was removed or overwritten", false, false).
+			AddStringListField("retained", "What was kept, and why", false, false).
+			AddStringField("completedAt", "When the erasure completed (RFC3339, UTC)", false).
+			AddStringField("payload", "The receipt as signed JSON; keep it verbatim to verify it later", false).
+			AddStringField("signature", "Hex HMAC-SHA256 of payload", false).
+			Build()
+
+		plugin.RegisterQuery("exportUserData",
+			sdk.ComplexObjectFieldWithArgs("Export everything stored about a user as one JSON archive", userDataExportType, map[string]interface{}{
+				"userId":       sdk.NonNullArg("String", "User to export"),
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("exportUserData", h.Guard.WrapResolver("exportUserData", h.exportUserDataResolver)))
+
+		plugin.RegisterMutation("eraseUser",
+			sdk.ComplexObjectFieldWithArgs("Erase a user's personal data: delete the user, or anonymize them when comments refer to them, along with their logins and uploads", erasureReceiptType, map[string]interface{}{
+				"userId":       sdk.NonNullArg("String", "User to erase"),
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("eraseUser", h.Guard.WrapResolver("eraseUser", h.eraseUserResolver)))
+
+		plugin.RegisterQuery("verifyErasureReceipt",
+			sdk.FieldWithArgs("Boolean", "Check that an erasure receipt was signed by this plugin and not altered", map[string]interface{}{
+				"payload":      sdk.NonNullArg("String", "The receipt's payload, verbatim"),
+				"signature":    sdk.NonNullArg("String", "The receipt's signature"),
+				admin.TokenArg: adminTokenArg,
+			}),
+			instrument.Resolver("verifyErasureReceipt", h.Guard.WrapResolver("verifyErasureReceipt", h.verifyErasureReceiptResolver)))
+	})
+
+	// Resolver usage analytics, rolled up per hour
+	resolverUsageType := sdk.NewObjectType("ResolverUsage", "A resolver's usage over the requested range").
+		AddStringField("resolver", "Resolver name", false).
+		AddIntField("calls", "Number of calls", false).
+		AddIntField("errors", "Number of calls that returned an error", false).
+		AddFloatField("errorRate", "errors / calls", false).
+		AddIntField("peakDistinctArgs", "Highest number of distinct argument sets in any one hour", false).
+		AddStringField("lastHour", "Start of the last hour the resolver was called (RFC3339, UTC)", false).
+		Build()
+	usageRollupType := sdk.NewObjectType("UsageRollup", "A resolver's usage during one hour").
+		AddStringField("hour", "Start of the hour (RFC3339, UTC)", false).
+		AddStringField("resolver", "Resolver name", false).
+		AddIntField("calls", "Number of calls", false).
+		AddIntField("errors", "Number of calls that returned an error", false).
+		AddIntField("distinctArgs", "Number of distinct argument sets", false).
+		Build()
+	usageStatsType := sdk.NewObjectType("UsageStats", "Resolver usage analytics").
+		AddStringField("since", "Start of the first hour included (RFC3339, UTC)", false).
+		AddObjectListField("resolvers", "Totals per resolver, most called first", resolverUsageType, false, false).
+		AddObjectListField("hourly", "Hourly rollups, oldest first", usageRollupType, false, false).
+		Build()
+
+	plugin.RegisterQuery("getUsageStats",
+		sdk.ComplexObjectFieldWithArgs("Get call counts, error rates and argument cardinality per resolver", usageStatsType, map[string]interface{}{
+			"range":    sdk.StringArg("How far back to look, 1h to 90d (default 24h), e.g. 6h or 7d"),
+			"resolver": sdk.StringArg("Only report this resolver"),
+		}),
+		instrument.Resolver("getUsageStats", h.getUsageStatsResolver))
+
+	// Latency percentiles per handler since startup, as in /metrics
+	latencyBucketType := sdk.NewObjectType("LatencyBucket", "Calls that took at most leMs milliseconds").
+		AddFloatField("leMs", "Bucket upper bound in milliseconds", false).
+		AddIntField("count", "Calls at or below the bound, cumulative", false).
+		Build()
+	handlerPerformanceType := sdk.NewObjectType("HandlerPerformance", "A handler's latency since startup; percentiles are estimated from the histogram").
+		AddStringField("handler", "Resolver or function name, or METHOD /path for REST routes", false).
+		AddIntField("calls", "Number of calls", false).
+		AddIntField("errors", "Number of calls that returned an error", false).
+		AddFloatField("avgMs", "Mean duration in milliseconds", false).
+		AddFloatField("maxMs", "Slowest call in milliseconds", false).
+		AddFloatField("p50Ms", "Median duration in milliseconds", false).
+		AddFloatField("p95Ms", "95th percentile duration in milliseconds", false).
+		AddFloatField("p99Ms", "99th percentile duration in milliseconds", false).
+		AddObjectListField("histogram", "Cumulative latency histogram; calls beyond the last bound are calls minus its count", latencyBucketType, false, false).
+		Build()
+
+	plugin.RegisterQuery("getPerformanceStats",
+		sdk.ListOfObjectsFieldWithArgs("Get latency percentiles per resolver, REST route and function since startup, slowest p95 first", handlerPerformanceType, map[string]interface{}{
+			"handler": sdk.StringArg("Only report this handler, e.g. getUsers or GET /status"),
+			"limit":   sdk.IntArg("Maximum number of handlers to return (default all)"),
+		}),
+		instrument.Resolver("getPerformanceStats", h.getPerformanceStatsResolver))
+
+	h.registerFederation(plugin, userType, productType)
+
+	// Query that returns a paginated list of products
+	if h.Host.Supports(compat.PaginatedTypes, "getProductsPaginated not registered; use getProducts") {
+		paginatedProductType := plugin.PaginatedType("Product")
+		plugin.RegisterQuery("getProductsPaginated",
+			sdk.ComplexObjectFieldWithArgs("Get paginated list of products", paginatedProductType, map[string]interface{}{
+				"page":     sdk.IntArg("Page number (1-based)"),
+				"pageSize": sdk.IntArg("Number of items per page"),
+				"category": sdk.StringArg("Filter by category"),
+				"currency": sdk.StringArg("Filter by ISO 4217 price currency, e.g. USD"),
+			}),
+			instrument.Resolver("getProductsPaginated", h.Watchdog.Guard("getProductsPaginated",
+				h.cachedResolver("getProductsPaginated", entityTags(store.EntityProduct),
+					h.coalescedResolver("getProductsPaginated", h.getProductsPaginatedResolver)))))
+	}
+
+	// Relay global object identification - node(id) accepts base64("Type:id")
+	h.registerNodeTypes()
+	nodeType := sdk.NewObjectType("Node", "An object resolved by its Relay global ID").
+		AddStringField("id", "Global ID", false).
+		AddStringField("typename", "Type of the resolved object", false).
+		AddObjectField("user", "Set when the node is a User", userType, true).
+		AddObjectField("product", "Set when the node is a Product", productType, true).
+		Build()
+
+	plugin.RegisterQuery("node",
+		sdk.ComplexObjectFieldWithArgs("Fetch any object by its Relay global ID", nodeType, map[string]interface{}{
+			"id": sdk.NonNullArg("ID", "Global ID, e.g. base64(\"User:1\")"),
+		}),
+		instrument.Resolver("node", h.nodeResolver))
+
+	plugin.Group(app.DemoGroup, func() {
+		// Query that runs slowly on purpose to demonstrate cancellation
+		plugin.RegisterQuery("slowOperation",
+			sdk.FieldWithArgs("String", "Deliberately slow query - cancel the request to see it abort", map[string]interface{}{
+				"steps":   sdk.IntArg("Number of steps to run (default 10)"),
+				"delayMs": sdk.IntArg("Delay per step in milliseconds (default 1000)"),
+			}),
+			instrument.Resolver("slowOperation", h.Watchdog.Guard("slowOperation", h.slowOperationResolver)))
+	})
+
+	// ========================================
+	// REGISTER MUTATIONS
+	// ========================================
+
+	// Reminder scheduled on the job queue after a human-friendly delay
+	reminderType := sdk.NewObjectType("Reminder", "A message logged once its delay has passed").
+		AddStringField("id", "Job ID", false).
+		AddStringField("message", "Message to log", false).
+		AddStringField("after", "Delay, normalized, e.g. 1h30m0s", false).
+		AddStringField("dueAt", "When the reminder fires (RFC3339, UTC)", false).
+		Build()
+	plugin.RegisterMutation("scheduleReminder",
+		sdk.ComplexObjectFieldWithArgs("Log a message after a delay; reminders are kept in memory and lost on restart", reminderType, map[string]interface{}{
+			"after":   schema.NonNull(schema.DurationArg("Delay, from 1s to 7d")),
+			"message": sdk.NonNullArg("String", "Message to log (max 500 characters)"),
+		}),
+		instrument.Resolver("scheduleReminder", h.scheduleReminderResolver))
+
+	// Response wrapper type for mutations. Its errors field refers to Error by
+	// name, and building a type is what registers it with the host.
+	sdk.ErrorObjectType()
+	userResponseType := sdk.ResponseWrapperType("User")
+
+	// Older hosts get tags as a plain list of objects; the values arrive in
+	// the same shape, only the item schema is lost
+	tagsArg := sdk.ListArg("Object", "Optional key/value labels {key, val} (max 20, unique keys)")
+	if h.Host.Supports(compat.ArrayObjectArgs, "createUser input.tags declared as [Object]") {
+		tagsArg = sdk.ArrayObjectArg("Optional key/value labels (max 20, unique keys)", map[string]interface{}{
+			"key": sdk.StringProperty("Tag key"),
+			"val": sdk.StringProperty("Tag value"),
+		})
+	}
+
+	plugin.RegisterMutation("createUser",
+		sdk.ComplexObjectFieldWithArgs("Create a new user", userResponseType, map[string]interface{}{
+			"locale":   sdk.StringArg("Language for response messages: en, es or de"),
+			"timezone": sdk.StringArg("IANA timezone for createdAtLocal (default UTC)"),
+			"metadata": schema.JSONArg("Free-form JSON object stored with the user and returned as-is (limited by PLUGIN_MAX_JSON_DEPTH and PLUGIN_MAX_JSON_BYTES)"),
+			"input": sdk.ObjectArg("User creation data", map[string]interface{}{
+				"name":     sdk.StringProperty("User's full name"),
+				"email":    sdk.StringProperty("User's email address"),
+				"phone":    sdk.StringProperty("Optional phone number with country code, e.g. +14155550123"),
+				"username": schema.DeprecateArg("createUser", "input.username", sdk.StringProperty("User's username"), "Use handle instead"),
+				"handle":   sdk.StringProperty("User's public handle"),
+				"password": sdk.StringProperty("Optional password (min 8 characters); stored hashed, never returned"),
+				"address": sdk.ObjectArg("Optional postal address", map[string]interface{}{
+					"street":    sdk.StringProperty("Street (required with address)"),
+					"city":      sdk.StringProperty("City (required with address)"),
+					"state":     sdk.StringProperty("State or region"),
+					"zip":       sdk.StringProperty("Postal code"),
+					"latitude":  sdk.FloatProperty("Latitude, -90 to 90 (requires longitude)"),
+					"longitude": sdk.FloatProperty("Longitude, -180 to 180 (requires latitude)"),
+				}),
+				"tags": tagsArg,
+			}),
+		}),
+		instrument.Resolver("createUser", h.createUserResolver))
+
+	updateConflictType := sdk.NewObjectType("UpdateConflict", "A field an update set that someone else changed after expectedVersion").
+		AddStringField("field", "The updateUserPartial arg, e.g. name or handle", false).
+		AddStringField("requestValue", "The value the update set", false).
+		AddStringField("storedValue", "The value the other change stored, masked like the User field", false).
+		AddIntField("changedIn", "Version in which the other change was made", false).
+		AddStringField("kept", "request or stored: whose value the user has now", false).
+		Build()
+
+	userUpdateResponseType := sdk.NewObjectType("UserUpdateResponse", "updateUserPartial's result: the Response fields and the conflicts").
+		AddBooleanField("success", "Whether the operation was successful", false).
+		AddStringField("message", "Response message", true).
+		AddObjectField("data", "The updated user; after a rejected conflict, the current user", userType, true).
+		AddObjectListField("errors", "List of errors if any", "Error", true, false).
+		AddObjectListField("conflicts", "Fields both this update and a concurrent one changed", updateConflictType, true, true).
+		Build()
+
+	// Partial update: only the args given change; active: false is not the
+	// same as leaving active out
+	plugin.RegisterMutation("updateUserPartial",
+		sdk.ComplexObjectFieldWithArgs("Update only the given fields of a user", userUpdateResponseType, map[string]interface{}{
+			"id":              sdk.NonNullArg("String", "User ID"),
+			"name":            sdk.StringArg("New full name"),
+			"email":           sdk.StringArg("New email address"),
+			"phone":           sdk.StringArg("New phone number with country code; an empty string removes it"),
+			"handle":          sdk.StringArg("New public handle"),
+			"active":          sdk.BooleanArg("Activate or deactivate the user"),
+			"expectedVersion": sdk.IntArg("The user's version when it was read; a later version is a conflict"),
+			"onConflict":      sdk.StringArg("On a conflict: reject (default), lastWriteWins, or merge to keep concurrent changes to the same fields"),
+			"locale":          sdk.StringArg("Language for response messages: en, es or de"),
+			"timezone":        sdk.StringArg("IANA timezone for createdAtLocal (default UTC)"),
+		}),
+		instrument.Resolver("updateUserPartial", h.updateUserPartialResolver))
+
+	// Comment refers to itself by type name: object fields take either a
+	// built type or a name, and a name can point at a type that is still
+	// being built
+	commentType := sdk.NewObjectType("Comment", "A comment on a post; replies nest recursively").
+		AddStringField("id", "Comment ID", false).
+		AddStringField("postId", "Post the comment belongs to", false).
+		AddStringField("parentId", "Comment this replies to; null for top-level comments", true).
+		AddObjectField("author", "Comment author", userType, true).
+		AddStringField("body", "Comment text", false).
+		AddStringField("createdAt", "When the comment was written (RFC3339, UTC)", true).
+		AddIntField("replyCount", "Number of direct replies", false).
+		AddObjectListField("replies", "Direct replies; null below the requested depth", "Comment", true, true).
+		Build()
+
+	plugin.RegisterQuery("getCommentTree",
+		sdk.ListOfObjectsFieldWithArgs("Get a post's comment threads, nested up to depth levels", commentType, map[string]interface{}{
+			"postId":   sdk.NonNullArg("String", "Post ID"),
+			"depth":    sdk.IntArg(fmt.Sprintf("Levels of comments to include (default %d, max %d)", defaultCommentDepth, maxCommentDepth)),
+			"parentId": sdk.StringArg("Start below this comment, e.g. to continue a truncated thread"),
+		}),
+		instrument.Resolver("getCommentTree", h.getCommentTreeResolver))
+
+	// Group queries and mutations
+	plugin.RegisterQuery("getGroups",
+		sdk.ListOfObjectsField("List all groups", groupType),
+		// memberCount changes with every membership
+		instrument.Resolver("getGroups", h.cachedResolver("getGroups", entityTags(store.EntityGroup, store.EntityMembership),
+			h.getGroupsResolver)))
+
+	plugin.RegisterQuery("getGroupMembers",
+		sdk.ListOfObjectsFieldWithArgs("List the users in a group", userType, map[string]interface{}{
+			"groupId": sdk.NonNullArg("String", "Group ID"),
+			"limit":   sdk.IntArg("Maximum number of users to return (default 50)"),
+			"offset":  sdk.IntArg("Number of users to skip"),
+		}),
+		instrument.Resolver("getGroupMembers", h.getGroupMembersResolver))
+
+	groupResultType := sdk.NewObjectType("GroupResult", "Result of a group mutation").
+		AddBooleanField("success", "Whether the operation succeeded", false).
+		AddStringField("message", "Response message", true).
+		AddObjectField("group", "The group", groupType, true).
+		Build()
+
+	plugin.RegisterMutation("createGroup",
+		sdk.ComplexObjectFieldWithArgs("Create a group", groupResultType, map[string]interface{}{
+			"name":        sdk.NonNullArg("String", "Unique group name"),
+			"description": sdk.StringArg("Group description"),
+		}),
+		instrument.Resolver("createGroup", h.createGroupResolver))
+
+	membershipResultType := sdk.NewObjectType("MembershipResult", "Result of a membership change").
+		AddBooleanField("success", "Whether the user and group exist", false).
+		AddStringField("message", "Response message", true).
+		AddBooleanField("changed", "Whether the membership changed (false if it already had the requested state)", false).
+		AddObjectField("user", "The user with their updated groups", userType, true).
+		Build()
+
+	membershipArgs := map[string]interface{}{
+		"userId":  sdk.NonNullArg("String", "User ID"),
+		"groupId": sdk.NonNullArg("String", "Group ID"),
+	}
+	plugin.RegisterMutation("addUserToGroup",
+		sdk.ComplexObjectFieldWithArgs("Add a user to a group", membershipResultType, membershipArgs),
+		instrument.Resolver("addUserToGroup", h.membershipResolver("addUserToGroup", h.Store.AddUserToGroup,
+			"User added to group", "User was already a member")))
+	plugin.RegisterMutation("removeUserFromGroup",
+		sdk.ComplexObjectFieldWithArgs("Remove a user from a group", membershipResultType, membershipArgs),
+		instrument.Resolver("removeUserFromGroup", h.membershipResolver("removeUserFromGroup", h.Store.RemoveUserFromGroup,
+			"User removed from group", "User was not a member")))
+
+	// Consent per purpose; resolvers that process a user for a purpose
+	// refuse users who have not granted it
+	consentType := sdk.NewObjectType("Consent", "A user's decision about one purpose their data may be used for").
+		AddStringField("userId", "User ID", false).
+		AddStringField("purpose", "marketing, location or analytics", false).
+		AddStringField("description", "What the purpose covers", false).
+		AddBooleanField("granted", "Whether the user currently consents; false when revoked or never asked", false).
+		AddStringField("source", "Where the decision was made, e.g. signup form", true).
+		AddStringField("updatedAt", "When the decision was recorded (RFC3339, UTC); null when never asked", true).
+		Build()
+	consentArgs := map[string]interface{}{
+		"userId":  sdk.NonNullArg("String", "User ID"),
+		"purpose": sdk.NonNullArg("String", "marketing, location or analytics"),
+		"source":  sdk.StringArg("Where the decision was made, e.g. signup form"),
+	}
+
+	plugin.RegisterQuery("getUserConsents",
+		sdk.ListOfObjectsFieldWithArgs("Get a user's consent for every purpose, ordered by purpose", consentType, map[string]interface{}{
+			"userId": sdk.NonNullArg("String", "User ID"),
+		}),
+		instrument.Resolver("getUserConsents", h.getUserConsentsResolver))
+
+	plugin.RegisterMutation("grantConsent",
+		sdk.ComplexObjectFieldWithArgs("Record that a user consents to a purpose", consentType, consentArgs),
+		instrument.Resolver("grantConsent", h.setConsentResolver("grantConsent", true)))
+	plugin.RegisterMutation("revokeConsent",
+		sdk.ComplexObjectFieldWithArgs("Record that a user withdraws consent to a purpose; processing for it stops at once", consentType, consentArgs),
+		instrument.Resolver("revokeConsent", h.setConsentResolver("revokeConsent", false)))
+
+	marketingEmailResultType := sdk.NewObjectType("MarketingEmailResult", "A queued marketing email").
+		AddBooleanField("queued", "Whether the email was queued", false).
+		AddStringField("jobId", "ID of the job sending it", false).
+		Build()
+
+	plugin.RegisterMutation("sendMarketingEmail",
+		sdk.ComplexObjectFieldWithArgs("Queue a promotional email to a user; refused unless they granted marketing consent", marketingEmailResultType, map[string]interface{}{
+			"userId":  sdk.NonNullArg("String", "Recipient"),
+			"subject": sdk.NonNullArg("String", "Email subject"),
+			"body":    sdk.NonNullArg("String", "Plain text body"),
+		}),
+		instrument.Resolver("sendMarketingEmail", h.requireConsent("sendMarketingEmail", consent.Marketing, h.sendMarketingEmailResolver)))
+
+	// Login response - the password hash is never part of any response type
+	loginResponseType := sdk.NewObjectType("LoginResponse", "Result of a login attempt").
+		AddBooleanField("success", "Whether the login succeeded", false).
+		AddStringField("message", "Response message", true).
+		AddStringField("token", "Bearer token for subsequent requests", true).
+		AddStringField("userId", "ID of the logged-in user", true).
+		AddStringField("expiresAt", "When the token expires (RFC3339, UTC)", true).
+		Build()
+
+	plugin.RegisterMutation("login",
+		sdk.ComplexObjectFieldWithArgs("Log in with username and password", loginResponseType, map[string]interface{}{
+			"username": sdk.StringArg("Username"),
+			"password": sdk.StringArg("Password"),
+		}),
+		instrument.Resolver("login", h.loginResolver))
+
+	// Bulk import from CSV with per-row error reporting
+	importRowErrorType := sdk.NewObjectType("ImportRowError", "A problem with one CSV row").
+		AddIntField("line", "Line number in the CSV (the header is line 1)", false).
+		AddStringField("field", "Column the error refers to, or \"row\" for malformed rows", false).
+		AddStringField("code", "Machine-readable error code", false).
+		AddStringField("message", "Human-readable description", false).
+		Build()
+
+	importUsersResultType := sdk.NewObjectType("ImportUsersResult", "Outcome of a CSV user import").
+		AddStringField("jobId", "ID of the import for getImportJob", false).
+		AddStringField("status", "running for async imports, otherwise completed or failed", false).
+		AddBooleanField("success", "Whether every row was imported", false).
+		AddStringField("message", "Summary message", false).
+		AddBooleanField("dryRun", "Whether rows were only validated", false).
+		AddIntField("total", "Data rows read", false).
+		AddIntField("imported", "Rows imported (or that would be, in a dry run)", false).
+		AddIntField("failed", "Rows rejected", false).
+		AddObjectListField("errors", "Per-row errors (first 100)", importRowErrorType, true, true).
+		AddBooleanField("errorsTruncated", "Whether more errors occurred than were returned", false).
+		Build()
+
+	plugin.RegisterMutation("importUsers",
+		sdk.ComplexObjectFieldWithArgs("Import users from a CSV with columns name,email,handle[,active,street,city,state,zip,latitude,longitude]", importUsersResultType, map[string]interface{}{
+			"csv":    sdk.StringArg("Base64-encoded CSV file with a header row"),
+			"fileId": sdk.StringArg("A CSV file sent through POST /graphql/upload, instead of csv; not limited in rows"),
+			"dryRun": sdk.BooleanArg("Validate rows without inserting them"),
+			"async":  sdk.BooleanArg("Return right away and import in the background; poll getImportJob"),
+		}),
+		instrument.Resolver("importUsers", h.Watchdog.Guard("importUsers", h.importUsersResolver)))
+
+	importJobType := sdk.NewObjectType("ImportJob", "Progress of an importUsers run").
+		AddStringField("jobId", "Import ID", false).
+		AddStringField("status", "running, completed or failed", false).
+		AddBooleanField("dryRun", "Whether rows are only validated", false).
+		AddIntField("total", "Data rows read so far", false).
+		AddIntField("imported", "Rows imported so far (or that would be, in a dry run)", false).
+		AddIntField("failed", "Rows rejected so far", false).
+		AddFloatField("bytesRead", "Bytes of CSV parsed so far", false).
+		AddFloatField("bytesTotal", "Size of the uploaded CSV; null for base64 input", true).
+		AddFloatField("percent", "Share of bytesTotal parsed, 0-100", true).
+		AddStringField("message", "Outcome once finished", false).
+		AddObjectListField("errors", "Per-row errors (first 100), once finished", importRowErrorType, true, true).
+		AddBooleanField("errorsTruncated", "Whether more errors occurred than were returned", false).
+		AddStringField("startedAt", "When the import started", false).
+		AddStringField("updatedAt", "When the counts last changed", false).
+		AddStringField("finishedAt", "When the import finished", true).
+		Build()
+
+	plugin.RegisterQuery("getImportJob",
+		sdk.ComplexObjectFieldWithArgs("Get the progress of an importUsers run; the last 50 runs are kept", importJobType, map[string]interface{}{
+			"jobId": sdk.NonNullArg("String", "ImportUsersResult.jobId"),
+		}),
+		instrument.Resolver("getImportJob", h.getImportJobResolver))
+
+	sagaStepType := sdk.NewObjectType("SagaStep", "Outcome of one workflow step").
+		AddStringField("name", "Step name", false).
+		AddStringField("status", "COMPLETED, FAILED, COMPENSATED, COMPENSATION_FAILED or SKIPPED", false).
+		AddStringField("error", "Why the step or its compensation failed", true).
+		Build()
+
+	onboardUserResultType := sdk.NewObjectType("OnboardUserResult", "Result of the onboardUser workflow").
+		AddBooleanField("success", "Whether every step completed", false).
+		AddStringField("message", "Response message", true).
+		AddObjectField("user", "The onboarded user; null when the workflow was rolled back", userType, true).
+		AddObjectListField("steps", "Status of each step, in order", sagaStepType, false, true).
+		AddObjectListField("errors", "Field errors for invalid input or taken handle/email", "Error", true, false).
+		Build()
+
+	plugin.RegisterMutation("onboardUser",
+		sdk.ComplexObjectFieldWithArgs("Create a user, provision their storage and send a welcome email, undoing completed steps if one fails", onboardUserResultType, map[string]interface{}{
+			"name":   sdk.NonNullArg("String", "User's full name"),
+			"email":  sdk.NonNullArg("String", "User's email address"),
+			"handle": sdk.NonNullArg("String", "User's public handle"),
+			"failAt": sdk.StringArg("Testing only: make this step fail (createUser, provisionStorage or sendWelcomeEmail)"),
+		}),
+		instrument.Resolver("onboardUser", h.onboardUserResolver))
+
+	// ========================================
+	// NEW: ARRAY OBJECT ARGUMENT EXAMPLE
+	// ========================================
+
+	// Demonstrates the new ArrayObjectArg functionality, so it is skipped
+	// entirely on hosts that cannot declare it
+	if h.Host.Supports(compat.ArrayObjectArgs, "processBulkTags not registered") {
+		plugin.Limit("processBulkTags", limits.Declared{MaxArrayLength: 100, MaxResponseBytes: 64 << 10})
+		bulkTagFailureType := sdk.NewObjectType("BulkTagFailure", "A tag that could not be processed").
+			AddIntField("index", "Position of the tag in tags", false).
+			AddStringField("tagId", "The tag's tag_id, empty when missing", false).
+			AddStringField("field", "The invalid tag field", false).
+			AddStringField("code", "Machine-readable error code", false).
+			AddStringField("message", "Human-readable message", false).
+			Build()
+		bulkTagsSummaryType := sdk.NewObjectType("BulkTagsSummary", "The outcome of processBulkTags").
+			AddStringField("jobId", "ID of the run, as in its BulkTagsProgress and BulkTagsCompleted events", false).
+			AddStringField("userId", "User the tags were processed for", false).
+			AddIntField("total", "Number of tags received", false).
+			AddIntField("processed", "Number of tags processed", false).
+			AddIntField("failed", "Number of tags that failed", false).
+			AddIntField("batches", "Number of batches the tags were split into", false).
+			AddIntField("workers", "Number of batches processed concurrently", false).
+			AddFloatField("durationMs", "Wall-clock time in milliseconds", false).
+			AddFloatField("batchTimeMs", "Time spent in batches in milliseconds, summed over workers", false).
+			AddFloatField("slowestBatchMs", "Slowest batch in milliseconds", false).
+			AddObjectListField("failures", "The tags that failed, in order", bulkTagFailureType, false, true).
+			Build()
+		plugin.RegisterMutation("processBulkTags",
+			sdk.ComplexObjectFieldWithArgs("Process tag objects in concurrent batches - demonstrates ArrayObjectArg", bulkTagsSummaryType, map[string]interface{}{
+				"userId": sdk.StringArg("User ID to process tags for"),
+				"tags": sdk.ArrayObjectArg("Array of tag objects with structured data", map[string]interface{}{
+					"tag_id":   sdk.StringProperty("Tag identifier"),
+					"name":     sdk.StringProperty("Tag name"),
+					"value":    sdk.StringProperty("Tag value"),
+					"weight":   sdk.FloatProperty("Tag weight/importance"),
+					"active":   sdk.BooleanProperty("Whether tag is active"),
+					"metadata": sdk.StringProperty("Additional metadata"),
+				}),
+			}),
+			instrument.Resolver("processBulkTags", h.Watchdog.Guard("processBulkTags", h.processBulkTagsResolver)))
+	}
+
+	// Capability discovery; the lists are read per request, so they include
+	// everything registered below as well
+	operationType := sdk.NewObjectType("Operation", "A registered query or mutation").
+		AddStringField("name", "Operation name", false).
+		AddStringField("type", "Return type in GraphQL notation", false).
+		AddStringField("description", "Operation description", true).
+		AddStringField("args", "Argument schemas as a JSON object; null without arguments", true).
+		Build()
+	routeType := sdk.NewObjectType("Route", "A registered REST endpoint").
+		AddStringField("method", "HTTP method", false).
+		AddStringField("path", "Route path", false).
+		AddStringField("description", "Route description", true).
+		AddStringField("schema", "Request body JSON Schema as JSON; null when none", true).
+		Build()
+	featureType := sdk.NewObjectType("Feature", "A schema feature gated on the host SDK version").
+		AddStringField("name", "Feature name", false).
+		AddBooleanField("enabled", "Whether the host supports it", false).
+		AddStringField("since", "First host SDK version that supports it", false).
+		AddStringField("reason", "What goes wrong on older hosts", true).
+		Build()
+	capabilitiesType := sdk.NewObjectType("PluginCapabilities", "Everything the plugin registered with the host").
+		AddStringField("hostSdkVersion", "Negotiated host SDK version", false).
+		AddObjectListField("queries", "Registered queries", operationType, false, false).
+		AddObjectListField("mutations", "Registered mutations", operationType, false, false).
+		AddObjectListField("rest", "Registered REST endpoints", routeType, false, false).
+		AddStringListField("functions", "Registered custom functions", false, false).
+		AddObjectListField("features", "Schema features and whether they are enabled", featureType, false, false).
+		Build()
+
+	plugin.RegisterQuery("pluginCapabilities",
+		sdk.ComplexObjectField("List the plugin's queries, mutations, REST routes, functions and features", capabilitiesType),
+		instrument.Resolver("pluginCapabilities", h.pluginCapabilitiesResolver(plugin)))
+}
@@ -0,0 +1,55 @@
+package resolvers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/validate"
+)
+
+// Reminders wait in memory, so they are kept short
+const (
+	minReminderDelay = time.Second
+	maxReminderDelay = 7 * 24 * time.Hour
+)
+
+// scheduleReminderResolver queues a job that logs message once after has
+// passed. after is a human-friendly duration such as "30s" or "1d12h".
+func (h *handlers) scheduleReminderResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] scheduleReminderResolver called")
+
+	args := registry.ParseArgs("scheduleReminder", rawArgs)
+	after, fieldErr := validate.Duration("after", sdk.GetStringArg(args, "after", ""), minReminderDelay, maxReminderDelay)
+	if fieldErr != nil {
+		return nil, fieldErr
+	}
+	message := strings.TrimSpace(sdk.GetStringArg(args, "message", ""))
+	if fieldErr := validate.Required("message", message); fieldErr != nil {
+		return nil, fieldErr
+	}
+	if len(message) > 500 {
+		return nil, &validate.FieldError{Field: "message", Code: validate.CodeTooLong, Message: "message must be at most 500 characters"}
+	}
+
+	dueAt := h.Clock.Now().Add(after)
+	jobID, err := h.Jobs.Schedule("reminder", after, func(ctx context.Context) error {
+		log.Printf("⏰ [hc-hello-world-plugin] Reminder due (scheduled for %s): %s", timeutil.FormatUTC(dueAt), message)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":      jobID,
+		"message": message,
+		"after":   after.String(),
+		"dueAt":   timeutil.FormatUTC(dueAt),
+	}, nil
+}
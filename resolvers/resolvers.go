@@ -0,0 +1,140 @@
+// Package resolvers implements the plugin's GraphQL queries and mutations.
+// Register declares the object types and registers every resolver; the
+// resolvers are methods of handlers, which reach the plugin's shared
+// components through the application container.
+package resolvers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/app"
+	"hc-hello-world-plugin/cache"
+	"hc-hello-world-plugin/events"
+	"hc-hello-world-plugin/pii"
+)
+
+// handlers implements the resolvers on top of the application container
+type handlers struct {
+	*app.App
+
+	// provisionedStorage simulates an external storage service for
+	// onboardUser, mapping user IDs to their bucket names
+	provisionedStorage sync.Map
+}
+
+// Estimated cost of building one item, used for complexity scoring.
+// Users carry a nested address and tag list, products are flat.
+const (
+	userItemCost    = 3
+	productItemCost = 1
+)
+
+// resolverKey identifies a read-only resolver call by its own args plus
+// tenant, project and whether the caller sees protected user fields in
+// clear, but not per-request context such as request or session IDs. The
+// second result is false when the args cannot be encoded.
+func resolverKey(ctx context.Context, name string, rawArgs map[string]interface{}) (string, bool) {
+	keyArgs := make(map[string]interface{}, len(rawArgs))
+	for k, v := range rawArgs {
+		if !strings.HasPrefix(k, "context_") {
+			keyArgs[k] = v
+		}
+	}
+	keyArgs["context_tenant_id"] = sdk.GetTenantID(rawArgs)
+	keyArgs["context_project_id"] = sdk.GetProjectID(rawArgs)
+	keyArgs["context_pii_revealed"] = pii.Revealed(ctx)
+	return cache.Key(name, keyArgs)
+}
+
+// coalescedResolver shares one execution between identical concurrent calls
+// of a read-only resolver, so a burst of the same request does the work
+// once. Waiting callers get the first caller's result, including its error
+// if its context was cancelled.
+func (h *handlers) coalescedResolver(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		key, ok := resolverKey(ctx, name, rawArgs)
+		if !ok {
+			return resolver(ctx, rawArgs)
+		}
+		result, err, shared := h.Inflight.Do(key, func() (interface{}, error) {
+			return resolver(ctx, rawArgs)
+		})
+		if shared {
+			log.Printf("🔗 [hc-hello-world-plugin] Coalesced %s with an in-flight call", name)
+		}
+		return result, err
+	}
+}
+
+// cachedResolver wraps a read-only resolver with the response cache, keyed
+// by resolverKey. tags names the records a result is built from (see
+// events.Tag); store changes to them invalidate it. Within the stale window
+// an expired result is returned at once and refreshed in the background. The
+// cache is bypassed while the responseCache flag is off.
+func (h *handlers) cachedResolver(name string, tags func(rawArgs map[string]interface{}) []string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+		if !h.ResponseCacheFlag.Enabled() {
+			return resolver(ctx, rawArgs)
+		}
+		key, cacheable := resolverKey(ctx, name, rawArgs)
+		if cacheable {
+			switch value, state := h.Cache.Lookup(key); state {
+			case cache.Fresh:
+				log.Printf("⚡ [hc-hello-world-plugin] Cache hit for %s", name)
+				return value, nil
+			case cache.Stale:
+				log.Printf("⚡ [hc-hello-world-plugin] Stale cache hit for %s (refresh in progress)", name)
+				return value, nil
+			case cache.Refresh:
+				log.Printf("⚡ [hc-hello-world-plugin] Stale cache hit for %s - refreshing in the background", name)
+				// The request may finish before the refresh does
+				go h.refreshCached(context.WithoutCancel(ctx), name, key, tags(rawArgs), resolver, rawArgs)
+				return value, nil
+			}
+		}
+
+		gen := h.Cache.Generation()
+		result, err := resolver(ctx, rawArgs)
+		if err == nil && cacheable {
+			h.Cache.SetSince(gen, key, result, tags(rawArgs)...)
+		}
+		return result, err
+	}
+}
+
+// refreshCached recomputes an expired cache entry in the background
+func (h *handlers) refreshCached(ctx context.Context, name, key string, tags []string, resolver sdk.ResolverFunc, rawArgs map[string]interface{}) {
+	gen := h.Cache.Generation()
+	result, err := resolver(ctx, rawArgs)
+	if err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Background refresh of %s failed: %v", name, err)
+		h.Cache.EndRefresh(key)
+		return
+	}
+	if !h.Cache.SetSince(gen, key, result, tags...) {
+		h.Cache.EndRefresh(key)
+	}
+}
+
+// entityTags tags results listing every record of the given entities
+func entityTags(entities ...string) func(map[string]interface{}) []string {
+	tags := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		tags = append(tags, events.Tag(entity, ""))
+	}
+	return func(map[string]interface{}) []string {
+		return tags
+	}
+}
+
+// recordTags tags results built from the single record whose ID is in arg
+func recordTags(entity, arg string) func(map[string]interface{}) []string {
+	return func(rawArgs map[string]interface{}) []string {
+		return []string{events.Tag(entity, sdk.GetStringArg(rawArgs, arg, ""))}
+	}
+}
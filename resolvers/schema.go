@@ -0,0 +1,128 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/instrument"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/schema"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/store"
+)
+
+// registerNodeTypes makes the plugin's object types resolvable by global ID
+func (h *handlers) registerNodeTypes() {
+	h.Nodes.Register("User", func(ctx context.Context, id string) (map[string]interface{}, error) {
+		instrument.RecordLookup(ctx, "User", id)
+		user, err := h.Store.GetUser(id)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return h.userToMap(ctx, user, nil, time.UTC), nil
+	})
+
+	h.Nodes.Register("Product", func(ctx context.Context, id string) (map[string]interface{}, error) {
+		instrument.RecordLookup(ctx, "Product", id)
+		product, err := h.Store.GetProduct(id)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return productToMap(product), nil
+	})
+}
+
+// nodeResolver implements the Relay node(id) query. The SDK has no GraphQL
+// interfaces, so the object is returned in a field named after its type.
+func (h *handlers) nodeResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] nodeResolver called")
+
+	args := registry.ParseArgs("node", rawArgs)
+	globalID := sdk.GetStringArg(args, "id", "")
+
+	typeName, obj, err := h.Nodes.Resolve(ctx, globalID)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		log.Printf("🔍 [hc-hello-world-plugin] node %s (%s) not found", globalID, typeName)
+		return nil, nil
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] nodeResolver resolved %s", typeName)
+	return map[string]interface{}{
+		"id":                      globalID,
+		"typename":                typeName,
+		strings.ToLower(typeName): obj,
+	}, nil
+}
+
+// registerFederation exposes User and Product as federation entities keyed
+// by id, so a federated gateway in front of Apito can reference and extend
+// them. _service returns the subgraph SDL with @key directives and
+// _entities resolves references. The SDK cannot declare the _Any scalar or
+// the _Entity union, so representations are plain objects and _Entity is an
+// object with a typename plus one field per entity type; the gateway side
+// maps it back onto the union.
+func (h *handlers) registerFederation(plugin *registry.Plugin, userType, productType sdk.ObjectTypeDefinition) {
+	schema.Entity(userType, "id", func(ctx context.Context, rep map[string]interface{}) (interface{}, error) {
+		id, _ := rep["id"].(string)
+		user, err := h.Store.GetUser(id)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return h.userToMap(ctx, user, selection.FromContext(ctx).Sub("user"), time.UTC), nil
+	})
+	schema.Entity(productType, "id", func(ctx context.Context, rep map[string]interface{}) (interface{}, error) {
+		id, _ := rep["id"].(string)
+		product, err := h.Store.GetProduct(id)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return productToMap(product), nil
+	})
+
+	serviceType := sdk.NewObjectType("_Service", "Federation subgraph metadata").
+		AddStringField("sdl", "Subgraph SDL with @key directives", false).
+		Build()
+	plugin.RegisterQuery("_service",
+		sdk.ComplexObjectField("Federation subgraph metadata", serviceType),
+		instrument.Resolver("_service", func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"sdl": schema.FederationSDL(plugin.GetAllObjectTypes())}, nil
+		}))
+
+	entityType := sdk.NewObjectType("_Entity", "A resolved federation entity; exactly one entity field is set").
+		AddStringField("typename", "The entity's type: User or Product", false).
+		AddObjectField(schema.EntityField(userType.TypeName), "Set when typename is User", userType, true).
+		AddObjectField(schema.EntityField(productType.TypeName), "Set when typename is Product", productType, true).
+		Build()
+	plugin.RegisterQuery("_entities",
+		sdk.ListOfObjectsFieldWithArgs("Resolve federation entity references", entityType, map[string]interface{}{
+			"representations": sdk.NonNullArg("[Object]", `Entity representations, e.g. {"__typename": "User", "id": "1"}`),
+		}),
+		instrument.Resolver("_entities", func(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+			args := registry.ParseArgs("_entities", rawArgs)
+			representations := sdk.GetArrayArg(args, "representations")
+			if err := h.Limits.CheckItems("representations", len(representations)); err != nil {
+				return nil, err
+			}
+			return schema.ResolveEntities(ctx, representations)
+		}))
+}
@@ -0,0 +1,217 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/apikey"
+	"hc-hello-world-plugin/quota"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/validate"
+	"hc-hello-world-plugin/weather"
+	"hc-hello-world-plugin/webhook"
+)
+
+// getQuotaUsageResolver reports the calling tenant's consumption of its
+// monthly call budget and its rate limit
+func (h *handlers) getQuotaUsageResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getQuotaUsageResolver called")
+
+	usage := h.Quotas.Usage(quota.Tenant(ctx, rawArgs))
+	result := map[string]interface{}{
+		"tenant":             usage.Tenant,
+		"month":              usage.Month,
+		"calls":              usage.Calls,
+		"limit":              nil,
+		"remaining":          nil,
+		"resetsAt":           timeutil.FormatUTC(usage.ResetsAt),
+		"rateLimitPerSecond": nil,
+		"burst":              nil,
+	}
+	if usage.Limit > 0 {
+		result["limit"] = usage.Limit
+		result["remaining"] = usage.Remaining()
+	}
+	if rate := h.Quotas.RatePerSecond(); rate > 0 {
+		result["rateLimitPerSecond"] = rate
+		result["burst"] = h.Quotas.Burst()
+	}
+	return result, nil
+}
+
+// webhookDeliveryToMap converts a delivery to the WebhookDelivery object
+func webhookDeliveryToMap(d webhook.Delivery) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":           d.ID,
+		"event":        d.Event,
+		"url":          d.URL,
+		"payload":      d.Payload,
+		"status":       d.Status,
+		"attempts":     d.Attempts,
+		"responseCode": nil,
+		"responseBody": nil,
+		"lastError":    nil,
+		"nextRetryAt":  nil,
+		"createdAt":    timeutil.FormatUTC(d.CreatedAt),
+		"updatedAt":    timeutil.FormatUTC(d.UpdatedAt),
+		"replayOf":     nil,
+		"traceId":      nil,
+	}
+	if d.ResponseCode != 0 {
+		result["responseCode"] = d.ResponseCode
+		result["responseBody"] = d.ResponseBody
+	}
+	if d.LastError != "" {
+		result["lastError"] = d.LastError
+	}
+	if !d.NextRetry.IsZero() {
+		result["nextRetryAt"] = timeutil.FormatUTC(d.NextRetry)
+	}
+	if d.ReplayOf != "" {
+		result["replayOf"] = d.ReplayOf
+	}
+	if d.Trace.Valid() {
+		result["traceId"] = d.Trace.TraceID
+	}
+	return result
+}
+
+// getWebhookDeliveriesResolver lists recent webhook deliveries, most
+// recently updated first, optionally only those with one status
+func (h *handlers) getWebhookDeliveriesResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getWebhookDeliveriesResolver called")
+
+	args := registry.ParseArgs("getWebhookDeliveries", rawArgs)
+	limit := h.Limits.PageSize(sdk.GetIntArg(args, "limit", 20), 20)
+	status := sdk.GetStringArg(args, "status", "")
+	switch status {
+	case "", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusFailed:
+	default:
+		return nil, fmt.Errorf("status must be %s, %s or %s", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusFailed)
+	}
+
+	deliveries := h.Webhooks.Deliveries(status, limit)
+	result := make([]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		result = append(result, webhookDeliveryToMap(d))
+	}
+	return result, nil
+}
+
+// replayWebhookDeliveryResolver resends a delivered or failed delivery's
+// payload as a new delivery and returns it
+func (h *handlers) replayWebhookDeliveryResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] replayWebhookDeliveryResolver called")
+
+	args := registry.ParseArgs("replayWebhookDelivery", rawArgs)
+	if !h.Webhooks.Enabled() {
+		return nil, fmt.Errorf("webhooks are disabled; set PLUGIN_WEBHOOK_URL")
+	}
+	replay, err := h.Webhooks.Replay(ctx, sdk.GetStringArg(args, "id", ""))
+	if err != nil {
+		return nil, err
+	}
+	return webhookDeliveryToMap(replay), nil
+}
+
+// apiKeyToMap converts a key to the ApiKey object
+func apiKeyToMap(k apikey.Key) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":         k.ID,
+		"name":       k.Name,
+		"hint":       k.Hint,
+		"scopes":     k.Scopes,
+		"createdAt":  timeutil.FormatUTC(k.CreatedAt),
+		"lastUsedAt": nil,
+		"revokedAt":  nil,
+	}
+	if !k.LastUsedAt.IsZero() {
+		result["lastUsedAt"] = timeutil.FormatUTC(k.LastUsedAt)
+	}
+	if k.Revoked() {
+		result["revokedAt"] = timeutil.FormatUTC(k.RevokedAt)
+	}
+	return result
+}
+
+// createApiKeyResolver provisions an API key. The key is only returned
+// here; the plugin keeps nothing but its hash.
+func (h *handlers) createApiKeyResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] createApiKeyResolver called")
+
+	args := registry.ParseArgs("createApiKey", rawArgs)
+	scopes, _ := args["scopes"].([]string)
+	key, secret, err := h.APIKeys.Create(sdk.GetStringArg(args, "name", ""), scopes)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔑 [hc-hello-world-plugin] event=api_key_created key_id=%s name=%q scopes=%q", key.ID, key.Name, key.Scopes)
+	return map[string]interface{}{
+		"apiKey": apiKeyToMap(key),
+		"key":    secret,
+	}, nil
+}
+
+// revokeApiKeyResolver stops a key from authenticating
+func (h *handlers) revokeApiKeyResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] revokeApiKeyResolver called")
+
+	args := registry.ParseArgs("revokeApiKey", rawArgs)
+	key, err := h.APIKeys.Revoke(sdk.GetStringArg(args, "id", ""))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔑 [hc-hello-world-plugin] event=api_key_revoked key_id=%s name=%q", key.ID, key.Name)
+	return apiKeyToMap(key), nil
+}
+
+// getApiKeysResolver lists the provisioned keys, newest first
+func (h *handlers) getApiKeysResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getApiKeysResolver called")
+
+	keys := h.APIKeys.List()
+	result := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, apiKeyToMap(k))
+	}
+	return result, nil
+}
+
+// getWeatherResolver reports the current weather in a city from Open-Meteo
+func (h *handlers) getWeatherResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getWeatherResolver called")
+
+	args := registry.ParseArgs("getWeather", rawArgs)
+	city := weather.NormalizeCity(sdk.GetStringArg(args, "city", ""))
+	if fieldErr := validate.Required("city", city); fieldErr != nil {
+		return nil, fieldErr
+	}
+	if len(city) > 100 {
+		return nil, &validate.FieldError{Field: "city", Code: validate.CodeTooLong, Message: "city must be at most 100 characters"}
+	}
+	if !h.Weather.Enabled() {
+		return nil, fmt.Errorf("getWeather is disabled; set PLUGIN_WEATHER_GEOCODING_URL and PLUGIN_WEATHER_FORECAST_URL")
+	}
+
+	report, err := h.Weather.Current(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"city":         report.City,
+		"country":      report.Country,
+		"latitude":     report.Latitude,
+		"longitude":    report.Longitude,
+		"timezone":     report.Timezone,
+		"temperatureC": report.TemperatureC,
+		"humidity":     report.Humidity,
+		"windSpeedKmh": report.WindSpeedKmh,
+		"weatherCode":  report.Code,
+		"description":  report.Description,
+		"observedAt":   timeutil.FormatUTC(report.ObservedAt),
+	}, nil
+}
@@ -0,0 +1,182 @@
+package resolvers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/events"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/validate"
+)
+
+// processBulkTags splits its tags into batches of bulkTagBatchSize and
+// processes at most bulkTagWorkers batches at a time
+const (
+	bulkTagBatchSize = 10
+	bulkTagWorkers   = 4
+)
+
+// tagBatch is the outcome of processing one batch of tags
+type tagBatch struct {
+	processed int
+	failures  []map[string]interface{}
+	elapsed   time.Duration
+}
+
+// processBulkTagsResolver demonstrates the ArrayObjectArg functionality:
+// it processes the tags in concurrent batches, publishes BulkTagsProgress
+// after each batch and BulkTagsCompleted at the end, and returns a summary
+// with the failed tags
+func (h *handlers) processBulkTagsResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] processBulkTagsResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("processBulkTags", rawArgs)
+	userId := sdk.GetStringArg(args, "userId", "default-user")
+	tags := sdk.GetArrayObjectArg(args, "tags")
+	if err := h.Limits.CheckItems("tags", len(tags)); err != nil {
+		return nil, err
+	}
+
+	jobID := h.IDs.NewID()
+	batches := (len(tags) + bulkTagBatchSize - 1) / bulkTagBatchSize
+	workers := min(bulkTagWorkers, batches)
+	log.Printf("🏷️  [hc-hello-world-plugin] event=bulk_tags_started job=%s user=%q tags=%d batches=%d workers=%d", jobID, userId, len(tags), batches, workers)
+
+	start := h.Clock.Now()
+	results := make([]tagBatch, batches)
+	var (
+		mu                   sync.Mutex
+		done, processed, bad int
+	)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range work {
+				results[b] = h.processTagBatch(tags, b)
+
+				// Published under the lock so subscribers see the counts grow
+				mu.Lock()
+				done++
+				processed += results[b].processed
+				bad += len(results[b].failures)
+				h.Events.Publish(events.Event{
+					Name: events.BulkTagsProgress,
+					ID:   jobID,
+					At:   h.Clock.Now(),
+					Data: map[string]interface{}{
+						"userId":         userId,
+						"total":          len(tags),
+						"processed":      processed,
+						"failed":         bad,
+						"batchesDone":    done,
+						"batchesPending": batches - done,
+					},
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for b := 0; b < batches; b++ {
+		// Stop handing out batches once cancelled; running ones finish
+		select {
+		case work <- b:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	elapsed := h.Clock.Now().Sub(start)
+
+	if err := ctx.Err(); err != nil {
+		log.Printf("⛔ [hc-hello-world-plugin] processBulkTagsResolver cancelled after %d/%d batches: %v", done, batches, err)
+		return nil, err
+	}
+
+	failures := make([]interface{}, 0, bad)
+	var slowest, total time.Duration
+	for _, result := range results {
+		for _, failure := range result.failures {
+			failures = append(failures, failure)
+		}
+		slowest = max(slowest, result.elapsed)
+		total += result.elapsed
+	}
+	summary := map[string]interface{}{
+		"jobId":          jobID,
+		"userId":         userId,
+		"total":          len(tags),
+		"processed":      processed,
+		"failed":         bad,
+		"batches":        batches,
+		"workers":        workers,
+		"durationMs":     milliseconds(elapsed),
+		"batchTimeMs":    milliseconds(total),
+		"slowestBatchMs": milliseconds(slowest),
+		"failures":       failures,
+	}
+	h.Events.Publish(events.Event{
+		Name: events.BulkTagsCompleted,
+		ID:   jobID,
+		At:   h.Clock.Now(),
+		Data: map[string]interface{}{
+			"userId":     userId,
+			"total":      len(tags),
+			"processed":  processed,
+			"failed":     bad,
+			"durationMs": milliseconds(elapsed),
+		},
+	})
+
+	log.Printf("✅ [hc-hello-world-plugin] event=bulk_tags_completed job=%s processed=%d failed=%d duration=%s", jobID, processed, bad, elapsed)
+	return summary, nil
+}
+
+// processTagBatch processes batch b of tags. A tag without a tag_id or name
+// fails and is reported with its index in tags.
+func (h *handlers) processTagBatch(tags []map[string]interface{}, b int) tagBatch {
+	start := h.Clock.Now()
+	first := b * bulkTagBatchSize
+	last := min(first+bulkTagBatchSize, len(tags))
+
+	var result tagBatch
+	for i := first; i < last; i++ {
+		// Use SDK helper functions for type-safe extraction
+		tagID := sdk.GetStringArg(tags[i], "tag_id", "")
+		name := sdk.GetStringArg(tags[i], "name", "")
+		weight := sdk.GetFloatArg(tags[i], "weight", 0.0)
+		active := sdk.GetBoolArg(tags[i], "active", false)
+
+		var problems validate.Errors
+		problems.Add(validate.Required("tag_id", tagID))
+		problems.Add(validate.Required("name", name))
+		if len(problems) > 0 {
+			failure := problems[0].ToMap()
+			failure["index"] = i
+			failure["tagId"] = tagID
+			result.failures = append(result.failures, failure)
+			continue
+		}
+
+		result.processed++
+		log.Printf("📋 [hc-hello-world-plugin] Processed tag %d: ID=%s, Name=%s, Weight=%.2f, Active=%t",
+			i+1, tagID, name, weight, active)
+	}
+	result.elapsed = h.Clock.Now().Sub(start)
+	return result
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
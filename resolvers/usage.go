@@ -0,0 +1,129 @@
+package resolvers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/metrics"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/usage"
+)
+
+// maxUsageRange is the longest getUsageStats range; rollups are retained for
+// as long
+const maxUsageRange = 90 * 24 * time.Hour
+
+// parseUsageRange parses ranges such as "6h" or "7d"
+func parseUsageRange(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(value)
+	}
+	if err != nil || d < time.Hour || d > maxUsageRange {
+		return 0, fmt.Errorf("range must be between 1h and 90d, e.g. 24h or 7d")
+	}
+	return d, nil
+}
+
+// getUsageStatsResolver reports resolver usage over the requested range,
+// totalled per resolver and hour by hour
+func (h *handlers) getUsageStatsResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUsageStatsResolver called")
+
+	args := registry.ParseArgs("getUsageStats", rawArgs)
+	window, err := parseUsageRange(sdk.GetStringArg(args, "range", "24h"))
+	if err != nil {
+		return nil, err
+	}
+	// Include the hour the range starts in
+	since := h.Clock.Now().UTC().Add(-window).Truncate(time.Hour)
+
+	rollups := h.Usage.Rollups(since)
+	if resolver := sdk.GetStringArg(args, "resolver", ""); resolver != "" {
+		rollups = slices.DeleteFunc(rollups, func(r store.UsageRollup) bool { return r.Resolver != resolver })
+	}
+
+	resolvers := make([]interface{}, 0)
+	for _, summary := range usage.Summarize(rollups) {
+		resolvers = append(resolvers, map[string]interface{}{
+			"resolver":         summary.Resolver,
+			"calls":            summary.Calls,
+			"errors":           summary.Errors,
+			"errorRate":        summary.ErrorRate,
+			"peakDistinctArgs": summary.PeakDistinctArgs,
+			"lastHour":         timeutil.FormatUTC(summary.LastHour),
+		})
+	}
+	hourly := make([]interface{}, 0, len(rollups))
+	for _, r := range rollups {
+		hourly = append(hourly, map[string]interface{}{
+			"hour":         timeutil.FormatUTC(r.Hour),
+			"resolver":     r.Resolver,
+			"calls":        r.Calls,
+			"errors":       r.Errors,
+			"distinctArgs": r.DistinctArgs,
+		})
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] getUsageStatsResolver returned %d resolvers over %s", len(resolvers), window)
+	return map[string]interface{}{
+		"since":     timeutil.FormatUTC(since),
+		"resolvers": resolvers,
+		"hourly":    hourly,
+	}, nil
+}
+
+// getPerformanceStatsResolver reports latency percentiles per resolver, REST
+// route and function since startup, slowest p95 first
+func (h *handlers) getPerformanceStatsResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getPerformanceStatsResolver called")
+
+	args := registry.ParseArgs("getPerformanceStats", rawArgs)
+	handler := sdk.GetStringArg(args, "handler", "")
+	limit := sdk.GetIntArg(args, "limit", 0)
+
+	stats := h.Metrics.Handlers()
+	if handler != "" {
+		stats = slices.DeleteFunc(stats, func(s metrics.Handler) bool { return s.Name != handler })
+	}
+	slices.SortStableFunc(stats, func(a, b metrics.Handler) int { return cmp.Compare(b.P95Ms, a.P95Ms) })
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+
+	result := make([]interface{}, 0, len(stats))
+	for _, s := range stats {
+		histogram := make([]interface{}, 0, len(s.Histogram))
+		for _, b := range s.Histogram {
+			histogram = append(histogram, map[string]interface{}{"leMs": b.LeMs, "count": int(b.Count)})
+		}
+		result = append(result, map[string]interface{}{
+			"handler":   s.Name,
+			"calls":     int(s.Calls),
+			"errors":    int(s.Errors),
+			"avgMs":     s.AvgMs,
+			"maxMs":     s.MaxMs,
+			"p50Ms":     s.P50Ms,
+			"p95Ms":     s.P95Ms,
+			"p99Ms":     s.P99Ms,
+			"histogram": histogram,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,852 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/auth"
+	"hc-hello-world-plugin/consent"
+	"hc-hello-world-plugin/functions"
+	"hc-hello-world-plugin/geo"
+	"hc-hello-world-plugin/i18n"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/optarg"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/saga"
+	"hc-hello-world-plugin/schema"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/validate"
+)
+
+// applyTimezone sets createdAt in UTC and createdAtLocal in the requester's timezone
+func applyTimezone(user map[string]interface{}, createdAt time.Time, loc *time.Location) {
+	user["createdAt"] = timeutil.FormatUTC(createdAt)
+	user["createdAtLocal"] = timeutil.FormatIn(createdAt, loc)
+	user["timezone"] = loc.String()
+}
+
+// getUserProfileResolver demonstrates returning a complex User object
+func (h *handlers) getUserProfileResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUserProfileResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("getUserProfile", rawArgs)
+	userID := sdk.GetStringArg(args, "userId", "default-user")
+
+	// Resolve the requester's timezone from the arg or the host context
+	loc, err := timeutil.LocationFromContext(ctx, sdk.GetStringArg(args, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("👤 [hc-hello-world-plugin] Fetching user profile for ID: %s", userID)
+
+	// Stored users are returned as-is; unknown IDs get the demo profile
+	if stored, err := h.Store.GetUser(userID); err == nil {
+		return h.userToMap(ctx, stored, nil, loc), nil
+	}
+
+	// Return a complex User object structure with nested objects
+	user := map[string]interface{}{
+		"id":       userID,
+		"name":     "John Doe",
+		"email":    "john.doe@example.com",
+		"username": "johndoe",
+		"handle":   "johndoe",
+		"address": map[string]interface{}{
+			"street": "123 Main St",
+			"city":   "New York",
+			"state":  "NY",
+			"zip":    "10001",
+		},
+		"tags": []interface{}{
+			map[string]interface{}{
+				"key": "department",
+				"val": "engineering",
+			},
+			map[string]interface{}{
+				"key": "level",
+				"val": "senior",
+			},
+			map[string]interface{}{
+				"key": "team",
+				"val": "backend",
+			},
+		},
+		"active": true,
+	}
+	applyTimezone(user, h.Clock.Now(), loc)
+
+	logging.Debugf("[NESTED-OBJECT-DEBUG] [PLUGIN] getUserProfileResolver returning user: %s", logging.Redact(user))
+	if address, exists := user["address"]; exists {
+		logging.Debugf("[NESTED-OBJECT-DEBUG] [PLUGIN] User address: %s (type: %T)", logging.Redact(address), address)
+	}
+	return user, nil
+}
+
+// userToMap builds the User response, including only fields present in sel.
+// The email and phone number are shown as the caller's role allows.
+func (h *handlers) userToMap(ctx context.Context, u store.User, sel selection.Set, loc *time.Location) map[string]interface{} {
+	user := make(map[string]interface{})
+	scalars := map[string]interface{}{
+		"id":       u.ID,
+		"name":     u.Name,
+		"email":    h.PII.Show(ctx, "email", u.Email),
+		"username": u.Username,
+		"handle":   u.Username,
+		"active":   u.Active,
+		"metadata": u.Metadata,
+		"version":  u.Version,
+	}
+	for field, value := range scalars {
+		if sel.Has(field) {
+			user[field] = value
+		}
+	}
+
+	if sel.Has("createdAt") || sel.Has("createdAtLocal") || sel.Has("timezone") {
+		applyTimezone(user, u.CreatedAt, loc)
+	}
+
+	if sel.Has("updatedAt") {
+		user["updatedAt"] = timeutil.FormatUTC(u.UpdatedAt)
+	}
+
+	if sel.Has("phone") && u.Phone != "" {
+		user["phone"] = h.PII.Show(ctx, "phone", u.Phone)
+	}
+
+	if sel.Has("address") && u.Address != nil {
+		address := map[string]interface{}{
+			"street": u.Address.Street,
+			"city":   u.Address.City,
+			"state":  u.Address.State,
+			"zip":    u.Address.Zip,
+		}
+		if location, ok := u.Address.Location(); ok {
+			address["latitude"] = location.Lat
+			address["longitude"] = location.Lng
+		}
+		user["address"] = address
+	}
+
+	if sel.Has("tags") {
+		tags := make([]interface{}, 0, len(u.Tags))
+		for _, tag := range u.Tags {
+			tags = append(tags, map[string]interface{}{"key": tag.Key, "val": tag.Val})
+		}
+		user["tags"] = tags
+	}
+
+	// Join through the store's membership index
+	if sel.Has("groups") {
+		groups := h.Store.GroupsOfUser(u.ID)
+		list := make([]interface{}, 0, len(groups))
+		for _, g := range groups {
+			list = append(list, h.groupToMap(g))
+		}
+		user["groups"] = list
+	}
+
+	return user
+}
+
+// getUsersResolver demonstrates returning an array of User objects
+func (h *handlers) getUsersResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUsersResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("getUsers", rawArgs)
+	limit := h.Watchdog.PageSize("getUsers", h.Limits.PageSize(sdk.GetIntArg(args, "limit", 10), 10))
+	offset := sdk.GetIntArg(args, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	activeFilter := sdk.GetBoolArg(args, "active", true)
+
+	// Deep offsets still require generating every skipped item
+	if err := h.Limits.CheckComplexity("getUsers", offset+limit, userItemCost); err != nil {
+		return nil, err
+	}
+
+	loc, err := timeutil.LocationFromContext(ctx, sdk.GetStringArg(args, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional lower bound on createdAt, parsed strictly as RFC3339
+	var createdAfter time.Time
+	if raw := sdk.GetStringArg(args, "createdAfter", ""); raw != "" {
+		if createdAfter, err = timeutil.ParseRFC3339(raw); err != nil {
+			return nil, fmt.Errorf("invalid createdAfter: %w", err)
+		}
+	}
+
+	log.Printf("📊 [hc-hello-world-plugin] Query params - limit: %d, offset: %d, active: %t, timezone: %s", limit, offset, activeFilter, loc)
+
+	// Only build the fields the client selected; nested address and tags
+	// are skipped entirely when not requested
+	sel := selection.FromContext(ctx)
+	log.Printf("🎯 [hc-hello-world-plugin] getUsersResolver selection: address=%t tags=%t", sel.Has("address"), sel.Has("tags"))
+
+	// Apply filters, scanning only as far as the requested page
+	matching, _, _ := h.Store.ScanUsers(0, offset+limit, func(user store.User) bool {
+		if user.Active != activeFilter {
+			return false
+		}
+		return createdAfter.IsZero() || user.CreatedAt.After(createdAfter)
+	})
+
+	// Apply pagination
+	if offset > len(matching) {
+		offset = len(matching)
+	}
+	paginatedUsers := make([]interface{}, 0, len(matching)-offset)
+	for _, user := range matching[offset:] {
+		// Stop early if the host cancelled the request
+		if err := ctx.Err(); err != nil {
+			log.Printf("⛔ [hc-hello-world-plugin] getUsersResolver cancelled: %v", err)
+			return nil, err
+		}
+		paginatedUsers = append(paginatedUsers, h.userToMap(ctx, user, sel, loc))
+	}
+
+	log.Printf("[NESTED-OBJECT-DEBUG] [PLUGIN] getUsersResolver returning %d users", len(paginatedUsers))
+	for i, user := range paginatedUsers {
+		logging.Debugf("[NESTED-OBJECT-DEBUG] [PLUGIN] User %d: %s", i, logging.Redact(user))
+		if userMap, ok := user.(map[string]interface{}); ok {
+			if address, exists := userMap["address"]; exists {
+				logging.Debugf("[NESTED-OBJECT-DEBUG] [PLUGIN] User %d address: %s (type: %T)", i, logging.Redact(address), address)
+			}
+		}
+	}
+	return paginatedUsers, nil
+}
+
+// encodeUserCursor turns a store scan position into an opaque cursor
+func encodeUserCursor(pos int) string {
+	return base64.StdEncoding.EncodeToString([]byte("users:" + strconv.Itoa(pos)))
+}
+
+// decodeUserCursor reverses encodeUserCursor; an empty cursor is the start
+func decodeUserCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	value, ok := strings.CutPrefix(string(raw), "users:")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	pos, err := strconv.Atoi(value)
+	if err != nil || pos < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return pos, nil
+}
+
+// getUsersStreamResolver delivers users in chunks. Each call copies only one
+// chunk out of the store and returns a cursor for the next, so the host can
+// page through very large user sets without the plugin ever materializing
+// the full list.
+func (h *handlers) getUsersStreamResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] getUsersStreamResolver called")
+
+	args := registry.ParseArgs("getUsersStream", rawArgs)
+	chunkSize := h.Watchdog.PageSize("getUsersStream", h.Limits.PageSize(sdk.GetIntArg(args, "chunkSize", 100), 100))
+	if err := h.Limits.CheckComplexity("getUsersStream", chunkSize, userItemCost); err != nil {
+		return nil, err
+	}
+	from, err := decodeUserCursor(sdk.GetStringArg(args, "cursor", ""))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := timeutil.LocationFromContext(ctx, sdk.GetStringArg(args, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// The active filter is optional here so a full export is possible
+	var match func(store.User) bool
+	if _, ok := args["active"]; ok {
+		activeFilter := sdk.GetBoolArg(args, "active", true)
+		match = func(user store.User) bool { return user.Active == activeFilter }
+	}
+
+	chunk, next, done := h.Store.ScanUsers(from, chunkSize, match)
+	sel := selection.FromContext(ctx).Sub("users")
+	users := make([]interface{}, 0, len(chunk))
+	for _, user := range chunk {
+		users = append(users, h.userToMap(ctx, user, sel, loc))
+	}
+
+	var nextCursor interface{}
+	if !done {
+		nextCursor = encodeUserCursor(next)
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] getUsersStreamResolver returning %d users (done: %t)", len(users), done)
+	return map[string]interface{}{
+		"users":      users,
+		"count":      len(users),
+		"nextCursor": nextCursor,
+		"hasMore":    !done,
+	}, nil
+}
+
+// maxNearbyRadiusKm caps the nearbyUsers search radius
+const maxNearbyRadiusKm = 1000
+
+// nearbyUsersResolver finds users whose address coordinates lie within
+// radiusKm of a point, nearest first
+func (h *handlers) nearbyUsersResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] nearbyUsersResolver called")
+
+	args := registry.ParseArgs("nearbyUsers", rawArgs)
+	center := geo.Point{Lat: sdk.GetFloatArg(args, "lat", 0), Lng: sdk.GetFloatArg(args, "lng", 0)}
+	if err := center.Validate(); err != nil {
+		return nil, err
+	}
+	radiusKm := sdk.GetFloatArg(args, "radiusKm", 0)
+	if !(radiusKm > 0 && radiusKm <= maxNearbyRadiusKm) {
+		return nil, fmt.Errorf("radiusKm must be greater than 0 and at most %d", maxNearbyRadiusKm)
+	}
+	limit := h.Watchdog.PageSize("nearbyUsers", h.Limits.PageSize(sdk.GetIntArg(args, "limit", 10), 10))
+
+	var match func(store.User) bool
+	if _, ok := args["active"]; ok {
+		activeFilter := sdk.GetBoolArg(args, "active", true)
+		match = func(user store.User) bool { return user.Active == activeFilter }
+	}
+
+	// Users are only found by location if they consented to it
+	nearby := slices.DeleteFunc(h.Store.UsersNear(center, radiusKm, match), func(n store.NearbyUser) bool {
+		return !h.Store.HasConsent(n.User.ID, consent.Location)
+	})
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+
+	sel := selection.FromContext(ctx).Sub("user")
+	result := make([]interface{}, 0, len(nearby))
+	for _, n := range nearby {
+		result = append(result, map[string]interface{}{
+			"user": h.userToMap(ctx, n.User, sel, time.UTC),
+			// Rounded to metres; more precision than that is noise
+			"distanceKm": math.Round(n.DistanceKm*1000) / 1000,
+		})
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] nearbyUsersResolver found %d users within %.1f km", len(result), radiusKm)
+	return result, nil
+}
+
+// createUserInput is the bound form of createUser's input object
+type createUserInput struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Phone    string `json:"phone" validate:"omitempty,phone"`
+	Handle   string `json:"handle" validate:"required,handle"`
+	Username string `json:"username" validate:"-"`
+	// Password is optional, but users without one cannot log in
+	Password string        `json:"password" validate:"omitempty,password"`
+	Address  *addressInput `json:"address" validate:"omitempty"`
+	Tags     []tagInput    `json:"tags" validate:"max=20,unique=Key,dive"`
+}
+
+// addressInput is the optional nested address of createUserInput
+type addressInput struct {
+	Street string `json:"street" validate:"required,max=200"`
+	City   string `json:"city" validate:"required,max=100"`
+	State  string `json:"state" validate:"max=100"`
+	Zip    string `json:"zip" validate:"max=20"`
+	// Coordinates are optional but must be given together
+	Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+}
+
+// tagInput is one key/value label of createUserInput
+type tagInput struct {
+	Key string `json:"key" validate:"required,max=50"`
+	Val string `json:"val" validate:"max=200"`
+}
+
+// normalize trims the input and lowercases the case-insensitive fields
+func (in *createUserInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	in.Handle = strings.ToLower(strings.TrimSpace(in.Handle))
+	// An invalid number is left for the phone validation to report
+	if phone, fieldErr := validate.Phone("phone", in.Phone); fieldErr == nil {
+		in.Phone = phone
+	}
+	if in.Address != nil {
+		in.Address.Street = strings.TrimSpace(in.Address.Street)
+		in.Address.City = strings.TrimSpace(in.Address.City)
+		in.Address.State = strings.TrimSpace(in.Address.State)
+		in.Address.Zip = strings.TrimSpace(in.Address.Zip)
+	}
+	for i := range in.Tags {
+		in.Tags[i].Key = strings.TrimSpace(in.Tags[i].Key)
+		in.Tags[i].Val = strings.TrimSpace(in.Tags[i].Val)
+	}
+}
+
+// toUser converts validated input into a store record
+func (in createUserInput) toUser(id string, createdAt time.Time) store.User {
+	user := store.User{
+		ID:        id,
+		Name:      in.Name,
+		Email:     in.Email,
+		Phone:     in.Phone,
+		Username:  in.Handle,
+		Active:    true,
+		CreatedAt: createdAt,
+	}
+	if in.Address != nil {
+		user.Address = &store.Address{
+			Street:    in.Address.Street,
+			City:      in.Address.City,
+			State:     in.Address.State,
+			Zip:       in.Address.Zip,
+			Latitude:  in.Address.Latitude,
+			Longitude: in.Address.Longitude,
+		}
+	}
+	for _, tag := range in.Tags {
+		user.Tags = append(user.Tags, store.Tag{Key: tag.Key, Val: tag.Val})
+	}
+	return user
+}
+
+// jsonObjectArg returns the free-form JSON object argument name, or nil if
+// it is absent or null. The SDK turns any other value into an empty object,
+// so the raw value is checked.
+func jsonObjectArg(rawArgs map[string]interface{}, name string) (map[string]interface{}, *validate.FieldError) {
+	raw, ok := rawArgs[name]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, &validate.FieldError{Field: name, Code: validate.CodeInvalid, Message: name + " must be a JSON object"}
+	}
+	return object, nil
+}
+
+// openField returns a stored email or phone number in clear, for comparing
+// with input. A value that cannot be opened is returned as stored, so it
+// compares as changed.
+func (h *handlers) openField(value string) string {
+	plain, err := h.PII.Open(value)
+	if err != nil {
+		return value
+	}
+	return plain
+}
+
+// conflictErrors maps the store's unique-field conflicts onto ALREADY_TAKEN
+// field errors, reporting the store's username under its input name handle
+func conflictErrors(err error) validate.Errors {
+	var fieldErrors validate.Errors
+	for _, conflict := range store.Conflicts(err) {
+		field := conflict.Field
+		if field == "username" {
+			field = "handle"
+		}
+		fieldErrors.Add(&validate.FieldError{Field: field, Code: validate.CodeTaken, Message: field + " is already taken"})
+	}
+	return fieldErrors
+}
+
+// createUserResolver demonstrates returning a wrapped response for mutations
+func (h *handlers) createUserResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	// Raw args are not logged here because they may contain a password
+	log.Printf("🚀 [hc-hello-world-plugin] createUserResolver called")
+
+	// Use the SDK's automatic argument parsing
+	args := registry.ParseArgs("createUser", rawArgs)
+	input := sdk.GetObjectArg(args, "input")
+	locale := i18n.FromContext(ctx, sdk.GetStringArg(args, "locale", ""))
+	loc, err := timeutil.LocationFromContext(ctx, sdk.GetStringArg(args, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// "handle" replaces the deprecated "username"; accept either, preferring handle
+	schema.WarnDeprecatedArgs("createUser", args)
+	var in createUserInput
+	if err := validate.Bind(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Handle == "" {
+		in.Handle = in.Username
+	}
+	in.normalize()
+
+	log.Printf("👤 [hc-hello-world-plugin] Creating user - handle: %s, email: %s", in.Handle, logging.MaskEmail(in.Email))
+
+	// Validate against the struct tags, collecting an error per invalid field
+	fieldErrors := validate.Struct(in)
+	metadata, metadataErr := jsonObjectArg(rawArgs, "metadata")
+	fieldErrors.Add(metadataErr)
+	if len(fieldErrors) > 0 {
+		log.Printf("⚠️  [hc-hello-world-plugin] createUserResolver validation failed: %v", fieldErrors)
+		return map[string]interface{}{
+			"success": false,
+			"message": i18n.T(locale, "user.invalid"),
+			"data":    nil,
+			"errors":  fieldErrors.ToList(),
+		}, nil
+	}
+
+	if metadata != nil {
+		if err := h.Limits.CheckJSON("metadata", metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	// Persist the user with its address, tags and metadata in one atomic
+	// insert; the store enforces unique handles and emails and reports both
+	// if both clash
+	user := in.toUser(h.IDs.NewID(), h.Clock.Now())
+	user.Metadata = metadata
+	stored, err := h.Store.CreateUser(user)
+	if fieldErrors := conflictErrors(err); len(fieldErrors) > 0 {
+		return map[string]interface{}{
+			"success": false,
+			"message": i18n.T(locale, "user.invalid"),
+			"data":    nil,
+			"errors":  fieldErrors.ToList(),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	newUser := h.userToMap(ctx, stored, nil, loc)
+
+	// Hash the password before storing it; the hash is never returned
+	if in.Password != "" {
+		hash, err := auth.HashPassword(in.Password)
+		if err != nil {
+			return nil, err
+		}
+		h.Credentials.Save(auth.Credential{
+			UserID:       stored.ID,
+			Username:     in.Handle,
+			PasswordHash: hash,
+		})
+	}
+
+	// Send the welcome email in the background; failures never fail the mutation
+	if !h.WelcomeEmailsFlag.Enabled() {
+		log.Printf("📧 [hc-hello-world-plugin] Welcome emails are switched off - not emailing %s", in.Handle)
+	} else if _, err := functions.SendWelcomeEmail(ctx, h.App, functions.WelcomeEmailInput{
+		Email:    in.Email,
+		Name:     in.Name,
+		Username: in.Handle,
+	}); err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] Failed to queue welcome email for %s: %v", in.Handle, err)
+	}
+
+	// Return success response
+	response := map[string]interface{}{
+		"success": true,
+		"message": i18n.T(locale, "user.created"),
+		"data":    newUser,
+		"errors":  nil,
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] createUserResolver completed successfully")
+	return response, nil
+}
+
+// updateUserPartialResolver changes only the fields given. The optional
+// args are read as pointers, so active: false deactivates a user while
+// leaving active out keeps it as it is. With expectedVersion, a user
+// changed since that version is a conflict, resolved as onConflict says;
+// the response lists the fields both changed and whose value was kept.
+func (h *handlers) updateUserPartialResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] updateUserPartialResolver called")
+
+	args := registry.ParseArgs("updateUserPartial", rawArgs)
+	id := sdk.GetStringArg(args, "id", "")
+	locale := i18n.FromContext(ctx, sdk.GetStringArg(args, "locale", ""))
+	loc, err := timeutil.LocationFromContext(ctx, sdk.GetStringArg(args, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	name := optarg.String(args, "name")
+	email := optarg.String(args, "email")
+	phone := optarg.String(args, "phone")
+	handle := optarg.String(args, "handle")
+	active := optarg.Bool(args, "active")
+	expectedVersion := optarg.Int(args, "expectedVersion")
+	strategy := sdk.GetStringArg(args, "onConflict", conflictReject)
+
+	var fieldErrors validate.Errors
+	if expectedVersion != nil && *expectedVersion < 1 {
+		fieldErrors.Add(&validate.FieldError{Field: "expectedVersion", Code: validate.CodeInvalid, Message: "expectedVersion must be at least 1"})
+	}
+	if !slices.Contains(conflictStrategies, strategy) {
+		fieldErrors.Add(&validate.FieldError{Field: "onConflict", Code: validate.CodeInvalid, Message: "onConflict must be one of " + strings.Join(conflictStrategies, ", ")})
+	}
+	if name != nil {
+		*name = strings.TrimSpace(*name)
+		fieldErrors.Add(validate.Required("name", *name))
+		if len(*name) > 100 {
+			fieldErrors.Add(&validate.FieldError{Field: "name", Code: validate.CodeTooLong, Message: "name must be at most 100 characters"})
+		}
+	}
+	if email != nil {
+		var fieldErr *validate.FieldError
+		*email, fieldErr = validate.Email("email", *email)
+		fieldErrors.Add(fieldErr)
+	}
+	// An empty phone removes the number
+	if phone != nil && strings.TrimSpace(*phone) != "" {
+		var fieldErr *validate.FieldError
+		*phone, fieldErr = validate.Phone("phone", *phone)
+		fieldErrors.Add(fieldErr)
+	} else if phone != nil {
+		*phone = ""
+	}
+	if handle != nil {
+		var fieldErr *validate.FieldError
+		*handle, fieldErr = validate.Username("handle", *handle)
+		fieldErrors.Add(fieldErr)
+	}
+	if len(fieldErrors) > 0 {
+		return map[string]interface{}{
+			"success": false,
+			"message": i18n.T(locale, "user.invalid"),
+			"data":    nil,
+			"errors":  fieldErrors.ToList(),
+		}, nil
+	}
+
+	update := &versionedUpdate{
+		strategy: strategy,
+		show:     func(field, value string) string { return h.PII.Show(ctx, field, value) },
+	}
+	if expectedVersion != nil {
+		update.expected = *expectedVersion
+	}
+	if name != nil {
+		update.changes = append(update.changes, stringChange("name", "name", *name,
+			func(u store.User) string { return u.Name }, func(u *store.User, v string) { u.Name = v }))
+	}
+	if email != nil {
+		update.changes = append(update.changes, stringChange("email", "email", *email,
+			func(u store.User) string { return h.openField(u.Email) }, func(u *store.User, v string) { u.Email = v }))
+	}
+	if phone != nil {
+		update.changes = append(update.changes, stringChange("phone", "phone", *phone,
+			func(u store.User) string { return h.openField(u.Phone) }, func(u *store.User, v string) { u.Phone = v }))
+	}
+	if handle != nil {
+		update.changes = append(update.changes, stringChange("handle", "username", *handle,
+			func(u store.User) string { return u.Username }, func(u *store.User, v string) { u.Username = v }))
+	}
+	if active != nil {
+		update.changes = append(update.changes, boolChange("active", "active", *active,
+			func(u store.User) bool { return u.Active }, func(u *store.User, v bool) { u.Active = v }))
+	}
+
+	updated, err := h.Store.TryUpdateUser(id, update.apply)
+	var versionErr *store.VersionConflictError
+	if errors.As(err, &versionErr) {
+		log.Printf("⚔️  [hc-hello-world-plugin] event=update_conflict user=%s expected=%d current=%d", id, versionErr.Expected, versionErr.Current)
+		var data interface{}
+		if current, err := h.Store.GetUser(id); err == nil {
+			data = h.userToMap(ctx, current, nil, loc)
+		}
+		fieldErrors := validate.Errors{{Field: "expectedVersion", Code: validate.CodeVersionConflict, Message: err.Error()}}
+		return map[string]interface{}{
+			"success":   false,
+			"message":   i18n.T(locale, "user.conflict"),
+			"data":      data,
+			"errors":    fieldErrors.ToList(),
+			"conflicts": update.conflicts,
+		}, nil
+	}
+	if fieldErrors := conflictErrors(err); len(fieldErrors) > 0 {
+		return map[string]interface{}{
+			"success": false,
+			"message": i18n.T(locale, "user.invalid"),
+			"data":    nil,
+			"errors":  fieldErrors.ToList(),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The user logs in with their handle, so their password moves with it
+	if handle != nil {
+		h.Credentials.Rename(id, updated.Username)
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] updateUserPartialResolver updated user %s to version %d, conflicts: %d", id, updated.Version, len(update.conflicts))
+	return map[string]interface{}{
+		"success":   true,
+		"message":   i18n.T(locale, "user.updated"),
+		"data":      h.userToMap(ctx, updated, nil, loc),
+		"errors":    nil,
+		"conflicts": update.conflicts,
+	}, nil
+}
+
+// onboardingSteps are the onboardUser saga steps, in order
+var onboardingSteps = []string{"createUser", "provisionStorage", "sendWelcomeEmail"}
+
+// onboardUserResolver creates a user, provisions their storage and sends
+// the welcome email as a saga: if a step fails, the completed steps are
+// compensated (storage released, user deleted) and every step's status is
+// returned. failAt makes the named step fail before doing any work.
+func (h *handlers) onboardUserResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	log.Printf("🚀 [hc-hello-world-plugin] onboardUserResolver called")
+
+	args := registry.ParseArgs("onboardUser", rawArgs)
+	in := createUserInput{
+		Name:   sdk.GetStringArg(args, "name", ""),
+		Email:  sdk.GetStringArg(args, "email", ""),
+		Handle: sdk.GetStringArg(args, "handle", ""),
+	}
+	in.normalize()
+	failAt := sdk.GetStringArg(args, "failAt", "")
+
+	failure := func(message string, fieldErrors validate.Errors) map[string]interface{} {
+		return map[string]interface{}{
+			"success": false,
+			"message": message,
+			"user":    nil,
+			"steps":   []interface{}{},
+			"errors":  fieldErrors.ToList(),
+		}
+	}
+	if fieldErrors := validate.Struct(in); len(fieldErrors) > 0 {
+		return failure("Invalid input", fieldErrors), nil
+	}
+	if failAt != "" && !slices.Contains(onboardingSteps, failAt) {
+		return failure(fmt.Sprintf("failAt must be one of %s", strings.Join(onboardingSteps, ", ")), nil), nil
+	}
+	injectFailure := func(step string) error {
+		if failAt == step {
+			return fmt.Errorf("injected failure in %s", step)
+		}
+		return nil
+	}
+
+	var user store.User
+	results, err := saga.Run(ctx, "onboardUser", []saga.Step{
+		{
+			Name: "createUser",
+			Action: func(ctx context.Context) error {
+				if err := injectFailure("createUser"); err != nil {
+					return err
+				}
+				var err error
+				user, err = h.Store.CreateUser(in.toUser(h.IDs.NewID(), h.Clock.Now()))
+				return err
+			},
+			Compensate: func(ctx context.Context) error {
+				return h.Store.DeleteUser(user.ID)
+			},
+		},
+		{
+			Name: "provisionStorage",
+			Action: func(ctx context.Context) error {
+				if err := injectFailure("provisionStorage"); err != nil {
+					return err
+				}
+				h.provisionedStorage.Store(user.ID, "user-"+user.ID)
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				h.provisionedStorage.Delete(user.ID)
+				return nil
+			},
+		},
+		{
+			// The last step needs no compensation: nothing runs after it
+			Name: "sendWelcomeEmail",
+			Action: func(ctx context.Context) error {
+				if err := injectFailure("sendWelcomeEmail"); err != nil {
+					return err
+				}
+				_, err := functions.SendWelcomeEmail(ctx, h.App, functions.WelcomeEmailInput{Email: in.Email, Name: in.Name, Username: in.Handle})
+				return err
+			},
+		},
+	})
+
+	steps := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		steps = append(steps, result.ToMap())
+	}
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+			"user":    nil,
+			"steps":   steps,
+			"errors":  conflictErrors(err).ToList(),
+		}, nil
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] Onboarded user %s", user.ID)
+	return map[string]interface{}{
+		"success": true,
+		"message": "User onboarded",
+		"user":    h.userToMap(ctx, user, selection.FromContext(ctx).Sub("user"), time.UTC),
+		"steps":   steps,
+		"errors":  []interface{}{},
+	}, nil
+}
+
+// loginResolver verifies a username/password pair and returns a demo token
+func (h *handlers) loginResolver(ctx context.Context, rawArgs map[string]interface{}) (interface{}, error) {
+	// Raw args are not logged here because they contain the password
+	log.Printf("🚀 [hc-hello-world-plugin] loginResolver called")
+
+	args := registry.ParseArgs("login", rawArgs)
+	username := strings.ToLower(strings.TrimSpace(sdk.GetStringArg(args, "username", "")))
+	password := sdk.GetStringArg(args, "password", "")
+
+	token, err := h.Credentials.Login(username, password)
+	if err != nil {
+		log.Printf("🔒 [hc-hello-world-plugin] Login failed for username: %s", username)
+		return map[string]interface{}{
+			"success":   false,
+			"message":   err.Error(),
+			"token":     nil,
+			"userId":    nil,
+			"expiresAt": nil,
+		}, nil
+	}
+
+	log.Printf("✅ [hc-hello-world-plugin] Login succeeded for username: %s", username)
+	return map[string]interface{}{
+		"success":   true,
+		"message":   "Login successful",
+		"token":     token.Value,
+		"userId":    token.UserID,
+		"expiresAt": timeutil.FormatUTC(token.ExpiresAt),
+	}, nil
+}
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/fakedata"
+	"hc-hello-world-plugin/logging"
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+func (h *handlers) snapshotRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// A finished hour may still be waiting for the next call to roll it up
+	h.Usage.Flush()
+	snap := h.Store.Snapshot()
+	if err := store.WriteSnapshotFile(h.Config.SnapshotPath, snap, h.Sealer); err != nil {
+		h.Dependencies.MarkFailed("storage", err)
+		return nil, err
+	}
+	log.Printf("💾 [hc-hello-world-plugin] Snapshot of %d users and %d products written to %s", len(snap.Users), len(snap.Products), h.Config.SnapshotPath)
+	return map[string]interface{}{
+		"path":      h.Config.SnapshotPath,
+		"takenAt":   timeutil.FormatUTC(snap.TakenAt),
+		"users":     len(snap.Users),
+		"products":  len(snap.Products),
+		"encrypted": h.Sealer.Enabled(),
+	}, nil
+}
+
+func (h *handlers) restoreRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	snap, err := store.ReadSnapshotFile(h.Config.SnapshotPath, h.Sealer)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Store.Restore(snap); err != nil {
+		return nil, err
+	}
+	// Cached responses describe the old state
+	h.Cache.Clear()
+
+	log.Printf("💾 [hc-hello-world-plugin] Restored %d users and %d products from %s", len(snap.Users), len(snap.Products), h.Config.SnapshotPath)
+	return map[string]interface{}{
+		"path":     h.Config.SnapshotPath,
+		"takenAt":  timeutil.FormatUTC(snap.TakenAt),
+		"users":    len(snap.Users),
+		"products": len(snap.Products),
+	}, nil
+}
+
+// adminFlagsRESTHandler lists the runtime flags
+func (h *handlers) adminFlagsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"flags": h.Flags.List()}, nil
+}
+
+// adminSetFlagRESTHandler switches one runtime flag
+func (h *handlers) adminSetFlagRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	name := sdk.GetStringArg(args, "name", "")
+	enabled := sdk.GetBoolArg(args, "enabled")
+	previous, err := h.Flags.Set(name, enabled)
+	if err != nil {
+		return restError(404, err.Error(), nil), nil
+	}
+	log.Printf("🚩 [hc-hello-world-plugin] Flag %s switched from %t to %t", name, previous, enabled)
+	return map[string]interface{}{
+		"name":     name,
+		"enabled":  enabled,
+		"previous": previous,
+	}, nil
+}
+
+// adminClearCacheRESTHandler drops every cached resolver response
+func (h *handlers) adminClearCacheRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	removed := h.Cache.Clear()
+	log.Printf("🧹 [hc-hello-world-plugin] Cleared %d cached responses", removed)
+	return map[string]interface{}{"removed": removed}, nil
+}
+
+// adminResetStoreRESTHandler empties the store and, unless empty is set,
+// generates the startup demo data again
+func (h *handlers) adminResetStoreRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Readers would see a partly generated store until the seed finishes
+	h.Readiness.Set("store", false, "resetting")
+	defer h.Readiness.Set("store", true, "reset by an operator")
+	h.Store.Reset()
+	// Uploads belong to the users that are gone
+	h.Uploads.DeleteAll(ctx)
+	// Cached responses describe the old state
+	h.Cache.Clear()
+	if !sdk.GetBoolArg(args, "empty") {
+		if err := fakedata.Seed(h.Store, h.SampleData, h.Clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := h.Store.Counts()
+	log.Printf("🧹 [hc-hello-world-plugin] Store reset to %d users and %d products", counts.Users, counts.Products)
+	return map[string]interface{}{"store": counts}, nil
+}
+
+// adminLogLevelRESTHandler changes the log level without a restart
+func adminLogLevelRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	level, err := logging.ParseLevel(sdk.GetStringArg(args, "level", ""))
+	if err != nil {
+		return restError(400, err.Error(), nil), nil
+	}
+	previous := logging.CurrentLevel()
+	logging.SetLevel(level)
+	log.Printf("🔧 [hc-hello-world-plugin] Log level changed from %s to %s", previous, level)
+	return map[string]interface{}{
+		"level":    level.String(),
+		"previous": previous.String(),
+	}, nil
+}
+
+// adminReloadRESTHandler reloads like SIGHUP, or an interrupt on Windows,
+// and reports the configuration changes
+func (h *handlers) adminReloadRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	report := h.Reload()
+	return map[string]interface{}{
+		"changes":         report.Changes,
+		"restartRequired": report.RestartRequired,
+		"cacheFlushed":    report.CacheFlushed,
+	}, nil
+}
+
+// adminAuditRESTHandler returns the most recent admin requests, newest first
+func (h *handlers) adminAuditRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Query parameters arrive as strings, JSON bodies as numbers
+	limit := sdk.GetIntArg(args, "limit", 50)
+	if raw := sdk.GetStringArg(args, "limit", ""); raw != "" {
+		limit, _ = strconv.Atoi(raw)
+	}
+	if limit < 1 {
+		return restError(400, "limit must be a positive integer", nil), nil
+	}
+	return map[string]interface{}{"entries": h.Audit.Entries(limit)}, nil
+}
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/assets"
+	"hc-hello-world-plugin/httpcache"
+)
+
+// assetsCacheControl lets clients reuse an asset for an hour, then
+// revalidate it with its ETag; assets only change with a new build
+const assetsCacheControl = "public, max-age=3600"
+
+// assetRESTHandler serves an embedded static file with its content type and
+// ETag. A request whose If-None-Match matches gets a 304 without the body.
+func assetRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	name := sdk.GetStringArg(args, "path", "")
+	asset, err := assets.Get(name)
+	if err != nil {
+		return restError(404, fmt.Sprintf("no asset %q", name), nil), nil
+	}
+
+	headers := map[string]interface{}{
+		"Content-Type":  asset.ContentType,
+		"ETag":          asset.ETag,
+		"Cache-Control": assetsCacheControl,
+	}
+	if httpcache.Matches(httpcache.IfNoneMatch(ctx, args), asset.ETag) {
+		return map[string]interface{}{
+			"statusCode": 304,
+			"headers":    headers,
+			"body":       nil,
+		}, nil
+	}
+	if asset.Download() {
+		headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=%q", path.Base(asset.Name))
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    headers,
+		"body":       string(asset.Body),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// Long-poll limits: how long one request may wait, how many may wait at
+// once, and how many events one response carries
+const (
+	longPollTimeout    = 30 * time.Second
+	maxLongPollers     = 100
+	longPollMaxEvents  = 100
+	longPollRetryAfter = "5"
+)
+
+const longPollExpiredMessage = "cursor has expired; resync with getChangesSince and poll from its cursor"
+
+// pollEventsRESTHandler is a long-poll over the store's change log for
+// clients that cannot use streaming. It responds as soon as there are
+// changes after since, or with no events once the wait (at most 30s)
+// elapses. Without since it waits for the next new change.
+func (h *handlers) pollEventsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	var seq uint64
+	if since := sdk.GetStringArg(args, "since", ""); since != "" {
+		var err error
+		if seq, err = h.Store.ParseChangeCursor(since); errors.Is(err, store.ErrCursorExpired) {
+			return restError(410, longPollExpiredMessage, nil), nil
+		} else if err != nil {
+			return restError(400, err.Error(), nil), nil
+		}
+	} else {
+		seq = h.Store.LatestChange()
+	}
+
+	// Query parameters may arrive as strings
+	wait := longPollTimeout
+	seconds := sdk.GetIntArg(args, "timeout", 0)
+	if raw := sdk.GetStringArg(args, "timeout", ""); raw != "" {
+		seconds, _ = strconv.Atoi(raw)
+	}
+	if seconds > 0 && time.Duration(seconds)*time.Second < wait {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	select {
+	case h.longPollSlots <- struct{}{}:
+		defer func() { <-h.longPollSlots }()
+	default:
+		return restError(503, "too many open long-poll requests", map[string]interface{}{"Retry-After": longPollRetryAfter}), nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	err := h.Store.WaitForChange(waitCtx, seq)
+	switch {
+	case errors.Is(err, store.ErrCursorExpired):
+		return restError(410, longPollExpiredMessage, nil), nil
+	case ctx.Err() != nil:
+		// The caller went away; nobody will read the response
+		return nil, ctx.Err()
+	}
+	timedOut := err != nil
+
+	changes, next, more, err := h.Store.ChangesSince(seq, longPollMaxEvents)
+	if errors.Is(err, store.ErrCursorExpired) {
+		return restError(410, longPollExpiredMessage, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		events = append(events, map[string]interface{}{
+			"seq":       int(change.Seq),
+			"entity":    change.Entity,
+			"id":        change.ID,
+			"op":        change.Op,
+			"changedAt": timeutil.FormatUTC(change.At),
+		})
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers": map[string]interface{}{
+			"Content-Type":  "application/json",
+			"Cache-Control": "no-store",
+		},
+		"body": map[string]interface{}{
+			"events":   events,
+			"cursor":   h.Store.ChangeCursor(next),
+			"hasMore":  more,
+			"timedOut": timedOut,
+		},
+	}, nil
+}
@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hc-hello-world-plugin/buildinfo"
+)
+
+func (h *handlers) helloRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{
+		"message":   "Hello World from REST API (SDK Version)!",
+		"timestamp": h.Clock.Now().Format(time.RFC3339),
+		"plugin":    "hc-hello-world-plugin",
+		"version":   buildinfo.Version,
+	}, nil
+}
+
+func (h *handlers) customHelloRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	name := "World"
+	message := "Hello"
+
+	if nameArg, ok := args["name"].(string); ok {
+		if nameArg = h.Sanitizer.Field("name", nameArg); nameArg != "" {
+			name = nameArg
+		}
+	}
+	if msgArg, ok := args["message"].(string); ok {
+		if msgArg = h.Sanitizer.Field("message", msgArg); msgArg != "" {
+			message = msgArg
+		}
+	}
+
+	return map[string]interface{}{
+		"greeting": fmt.Sprintf("%s, %s! (SDK Version)", message, name),
+		"plugin":   "hc-hello-world-plugin",
+		"version":  buildinfo.Version,
+	}, nil
+}
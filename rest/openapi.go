@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/admin"
+	"hc-hello-world-plugin/apikey"
+	"hc-hello-world-plugin/buildinfo"
+	"hc-hello-world-plugin/jsonschema"
+	"hc-hello-world-plugin/registry"
+)
+
+// openAPIVersion is the OpenAPI version of the generated document; 3.1
+// takes the endpoints' JSON Schemas as they are
+const openAPIVersion = "3.1.0"
+
+// pathParam matches a path parameter such as {template}
+var pathParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// openAPIRESTHandler describes every registered REST endpoint as an
+// OpenAPI document, built on each request so it always matches the
+// registrations
+func (h *handlers) openAPIRESTHandler(plugin *registry.Plugin) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return h.openAPIDocument(plugin.Routes()), nil
+	}
+}
+
+// docsRESTHandler serves Swagger UI for the document at openapi.json
+func (h *handlers) docsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	page, err := h.Templates.Render("swagger-ui", map[string]interface{}{
+		"Title":   h.Name,
+		"SpecURL": "openapi.json",
+	})
+	if err != nil {
+		return restError(500, err.Error(), nil), nil
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    map[string]interface{}{"Content-Type": "text/html; charset=utf-8"},
+		"body":       page,
+	}, nil
+}
+
+// openAPIDocument builds the OpenAPI document for routes. Endpoints are
+// tagged with their first path segment. Admin endpoints take the admin
+// token as a query parameter; the others take an API key unless exempt,
+// optionally when keys are not required.
+func (h *handlers) openAPIDocument(routes []registry.Route) map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, route := range routes {
+		item, _ := paths[route.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = h.openAPIOperation(route)
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   h.Name,
+			"version": buildinfo.Version,
+		},
+		// Relative to the document, wherever the host mounts the plugin
+		"servers": []interface{}{map[string]interface{}{"url": "."}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": apikey.Header},
+			},
+		},
+	}
+}
+
+func (h *handlers) openAPIOperation(route registry.Route) map[string]interface{} {
+	endpoint := route.Method + " " + route.Path
+	tag := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]
+	op := map[string]interface{}{
+		"summary":     route.Description,
+		"operationId": operationID(route),
+		"tags":        []interface{}{tag},
+		"responses": map[string]interface{}{
+			"200":     map[string]interface{}{"description": "Success"},
+			"default": map[string]interface{}{"description": "Error, with the message in error"},
+		},
+	}
+
+	var parameters []interface{}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	if strings.HasPrefix(route.Path, "/admin/") {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        admin.TokenArg,
+			"in":          "query",
+			"required":    true,
+			"description": "PLUGIN_ADMIN_TOKEN",
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	} else if !h.APIKeys.Exempt(endpoint) {
+		security := []interface{}{map[string]interface{}{"apiKey": []interface{}{}}}
+		if !h.APIKeys.Required() {
+			// An empty requirement makes the key optional
+			security = append(security, map[string]interface{}{})
+		}
+		op["security"] = security
+	}
+	if parameters != nil {
+		op["parameters"] = parameters
+	}
+
+	if jsonschema.IsSchema(route.Schema) {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": route.Schema},
+			},
+		}
+	}
+	return op
+}
+
+// operationID names an operation after its method and path, e.g.
+// GET /emails/preview/{template} becomes get_emails_preview_template
+func operationID(route registry.Route) string {
+	id := strings.ToLower(route.Method)
+	for _, segment := range strings.Split(route.Path, "/") {
+		segment = strings.Trim(segment, "{}")
+		segment = strings.NewReplacer("-", "_", ".", "_").Replace(segment)
+		if segment != "" {
+			id += "_" + segment
+		}
+	}
+	return id
+}
@@ -0,0 +1,345 @@
+// Package rest implements the plugin's REST endpoints. Register registers
+// every endpoint; request bodies are validated against an endpoint's JSON
+// Schema before its handler runs.
+package rest
+
+import (
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/app"
+	"hc-hello-world-plugin/jsonschema"
+	"hc-hello-world-plugin/registry"
+)
+
+// handlers implements the endpoints on top of the application container
+type handlers struct {
+	*app.App
+
+	// longPollSlots bounds the number of requests blocked in /poll/events
+	longPollSlots chan struct{}
+}
+
+// Register registers the plugin's REST endpoints with plugin
+func Register(plugin *registry.Plugin, a *app.App) {
+	h := &handlers{
+		App:           a,
+		longPollSlots: make(chan struct{}, maxLongPollers),
+	}
+
+	// ========================================
+	// REGISTER REST APIS (examples)
+	// ========================================
+
+	plugin.Group(app.DemoGroup, func() {
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "GET",
+			Path:        "/hello",
+			Description: "Simple hello endpoint",
+			Schema:      map[string]interface{}{},
+		}, h.helloRESTHandler)
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/custom-hello",
+			Description: "Custom hello endpoint with POST data",
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":    map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 100},
+					"message": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 200},
+				},
+				"additionalProperties": false,
+			},
+		}, h.customHelloRESTHandler)
+	})
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/status",
+		Description: "Plugin status endpoint",
+		Schema:      map[string]interface{}{},
+	}, h.statusRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/health",
+		Description: "Optional dependencies, with degraded ones and their fallback behaviour",
+		Schema:      map[string]interface{}{},
+	}, h.healthRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/health/live",
+		Description: "Liveness: the plugin process is up",
+		Schema:      map[string]interface{}{},
+	}, h.livenessRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/health/ready",
+		Description: "Readiness: the store is loaded and dependencies have been checked; 503 otherwise",
+		Schema:      map[string]interface{}{},
+	}, h.readinessRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/metrics",
+		Description: "Plugin metrics (cache counters, memory, coalesced calls and per-handler calls)",
+		Schema:      map[string]interface{}{},
+	}, h.metricsRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/poll/events",
+		Description: "Long-poll for store changes: ?since=<cursor>&timeout=<seconds, max 30>",
+		Schema:      map[string]interface{}{},
+	}, h.pollEventsRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/sync",
+		Description: "Delta sync for offline clients: ?checkpoint=<checkpoint from the last sync>; without one returns every record",
+		Schema:      map[string]interface{}{},
+	}, h.syncRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "POST",
+		Path:        "/graphql/upload",
+		Description: "GraphQL multipart request (operations, map, files); body is the base64 encoded multipart body",
+		Schema:      map[string]interface{}{},
+	}, h.graphqlUploadRESTHandler(plugin))
+
+	// Resumable uploads: start a session, PATCH its chunks at the offset it
+	// has reached, then complete it to get a fileId
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "POST",
+		Path:        "/uploads",
+		Description: "Start a resumable upload of a file of the given size; returns the session with offset 0",
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":        map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 255},
+				"contentType": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 255},
+				"size":        map[string]interface{}{"type": "integer", "minimum": 0},
+				"sha256":      map[string]interface{}{"type": "string", "pattern": "^[0-9a-fA-F]{64}$"},
+			},
+			"required": []interface{}{"size"},
+		},
+	}, h.startUploadRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/uploads/{id}",
+		Description: "Get a resumable upload's offset, to resume after a dropped connection",
+		Schema:      map[string]interface{}{},
+	}, h.uploadStatusRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "PATCH",
+		Path:        "/uploads/{id}",
+		Description: "Append a base64 encoded chunk at the upload's offset; 409 with Upload-Offset when the offset is not the upload's",
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"offset": map[string]interface{}{"type": "integer", "minimum": 0},
+				"data":   map[string]interface{}{"type": "string", "minLength": 1},
+			},
+			"required": []interface{}{"offset", "data"},
+		},
+	}, h.uploadChunkRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "POST",
+		Path:        "/uploads/{id}/complete",
+		Description: "Store a fully received upload and return its fileId, checking the declared sha256",
+		Schema:      map[string]interface{}{},
+	}, h.completeUploadRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "DELETE",
+		Path:        "/uploads/{id}",
+		Description: "Abort a resumable upload and discard its chunks",
+		Schema:      map[string]interface{}{},
+	}, h.abortUploadRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/capabilities",
+		Description: "Registered queries, mutations, REST routes, functions and features with their argument schemas",
+		Schema:      map[string]interface{}{},
+	}, h.capabilitiesRESTHandler(plugin))
+
+	plugin.Group(app.DebugGroup, func() {
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "GET",
+			Path:        "/debug/stats",
+			Description: "Goroutines, heap, GC, uptime, store counts and cache hit ratios (debug mode only)",
+			Schema:      map[string]interface{}{},
+		}, h.debugStatsRESTHandler)
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "GET",
+			Path:        "/debug/registrations",
+			Description: "Every registration as sent to the host, with raw types, object types and unresolved type references",
+			Schema:      map[string]interface{}{},
+		}, debugRegistrationsRESTHandler(plugin))
+	})
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/functions",
+		Description: "Input and output schemas of the custom functions",
+		Schema:      map[string]interface{}{},
+	}, functionsRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "POST",
+		Path:        "/templates/render",
+		Description: "Render an HTML template with data and return the page",
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 100},
+				"data": map[string]interface{}{"type": "object"},
+			},
+			"required": []interface{}{"name"},
+		},
+	}, h.renderTemplateRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/emails/preview/{template}",
+		Description: "Render an email template with sample data as an HTML page; ?name= sets the recipient's name",
+		Schema:      map[string]interface{}{},
+	}, h.emailPreviewRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/openapi.json",
+		Description: "OpenAPI document describing every REST endpoint",
+		Schema:      map[string]interface{}{},
+	}, h.openAPIRESTHandler(plugin))
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/docs",
+		Description: "Swagger UI for the REST endpoints, to try them from a browser",
+		Schema:      map[string]interface{}{},
+	}, h.docsRESTHandler)
+
+	registerRESTAPI(plugin, sdk.RESTEndpoint{
+		Method:      "GET",
+		Path:        "/assets/{path}",
+		Description: "Static files built into the plugin: docs.html, logo.svg and the CSV import templates",
+		Schema:      map[string]interface{}{},
+	}, assetRESTHandler)
+
+	plugin.Group(app.AdminGroup, func() {
+		// Admin endpoints require PLUGIN_ADMIN_TOKEN, passed as the adminToken arg
+		plugin.Require("filesystem:write", "/admin/snapshot writes PLUGIN_SNAPSHOT_PATH")
+		plugin.Require("filesystem:read", "/admin/restore and PLUGIN_RESTORE_ON_START read PLUGIN_SNAPSHOT_PATH")
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/snapshot",
+			Description: "Write the in-memory store to the snapshot file",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("POST /admin/snapshot", h.snapshotRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/restore",
+			Description: "Replace the in-memory store with the snapshot file",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("POST /admin/restore", h.restoreRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "GET",
+			Path:        "/admin/flags",
+			Description: "List the runtime feature flags",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("GET /admin/flags", h.adminFlagsRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/flags",
+			Description: "Switch a runtime feature flag on or off",
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":    map[string]interface{}{"type": "string", "minLength": 1},
+					"enabled": map[string]interface{}{"type": "boolean"},
+				},
+				"required": []interface{}{"name", "enabled"},
+			},
+		}, h.Guard.Wrap("POST /admin/flags", h.adminSetFlagRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/cache/clear",
+			Description: "Drop every cached resolver response",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("POST /admin/cache/clear", h.adminClearCacheRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/store/reset",
+			Description: "Replace the store's contents with freshly generated demo data, or nothing with empty: true",
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"empty": map[string]interface{}{"type": "boolean"},
+				},
+			},
+		}, h.Guard.Wrap("POST /admin/store/reset", h.adminResetStoreRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/log-level",
+			Description: "Change the log level to debug or info",
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"level": map[string]interface{}{"type": "string", "enum": []interface{}{"debug", "info"}},
+				},
+				"required": []interface{}{"level"},
+			},
+		}, h.Guard.Wrap("POST /admin/log-level", adminLogLevelRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "POST",
+			Path:        "/admin/reload",
+			Description: "Flush the response cache and reload the configuration and HTTP fixtures, as SIGHUP does",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("POST /admin/reload", h.adminReloadRESTHandler))
+
+		registerRESTAPI(plugin, sdk.RESTEndpoint{
+			Method:      "GET",
+			Path:        "/admin/audit",
+			Description: "Recent admin requests, newest first (limit defaults to 50)",
+			Schema:      map[string]interface{}{},
+		}, h.Guard.Wrap("GET /admin/audit", h.adminAuditRESTHandler))
+	})
+}
+
+// restError builds a REST error envelope
+func restError(status int, message string, headers map[string]interface{}) map[string]interface{} {
+	allHeaders := map[string]interface{}{"Content-Type": "application/json"}
+	for key, value := range headers {
+		allHeaders[key] = value
+	}
+	return map[string]interface{}{
+		"statusCode": status,
+		"headers":    allHeaders,
+		"body":       map[string]interface{}{"error": message},
+	}
+}
+
+// registerRESTAPI registers a REST endpoint. When the endpoint's Schema is a
+// JSON Schema, request bodies are validated against it before the handler
+// runs and invalid requests get a 400 response listing the violations.
+func registerRESTAPI(plugin *registry.Plugin, endpoint sdk.RESTEndpoint, handler sdk.RESTHandlerFunc) {
+	if jsonschema.IsSchema(endpoint.Schema) {
+		handler = jsonschema.ValidateBody(endpoint.Schema, handler)
+	}
+	plugin.RegisterRESTAPI(endpoint, handler)
+}
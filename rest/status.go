@@ -0,0 +1,229 @@
+package rest
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/buildinfo"
+	"hc-hello-world-plugin/httpcache"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/schema"
+	"hc-hello-world-plugin/timeutil"
+	"hc-hello-world-plugin/typedfn"
+)
+
+func (h *handlers) metricsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	stats := h.Cache.Stats()
+	return map[string]interface{}{
+		"cache": map[string]interface{}{
+			"entries":   stats.Entries,
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"evictions": stats.Evictions,
+			"staleHits": stats.StaleHits,
+			"refreshes": stats.Refreshes,
+			"hitRatio":  stats.HitRatio(),
+		},
+		"memory":        h.Watchdog.Stats(),
+		"coalesced":     h.Inflight.Stats(),
+		"handlers":      h.Metrics.Handlers(),
+		"databasePools": h.DBPools.Stats(),
+	}, nil
+}
+
+// debugStatsRESTHandler reports runtime and data statistics for diagnosing a
+// misbehaving instance; it is only available in debug mode or with the
+// debugEndpoints flag on
+func (h *handlers) debugStatsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if !h.DebugEndpointsFlag.Enabled() {
+		return restError(404, "debug endpoints are disabled: set PLUGIN_DEBUG_MODE", nil), nil
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var lastGC, lastPause interface{}
+	if mem.NumGC > 0 {
+		lastGC = timeutil.FormatUTC(time.Unix(0, int64(mem.LastGC)))
+		lastPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String()
+	}
+
+	// A negative limit reads the soft memory limit without changing it
+	var memoryLimit interface{}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		memoryLimit = limit
+	}
+
+	cacheStats := h.Cache.Stats()
+	return map[string]interface{}{
+		"uptime":     h.Clock.Since(h.StartedAt).Round(time.Second).String(),
+		"startedAt":  timeutil.FormatUTC(h.StartedAt),
+		"goroutines": runtime.NumGoroutine(),
+		"heap": map[string]interface{}{
+			"allocBytes":    mem.HeapAlloc,
+			"inuseBytes":    mem.HeapInuse,
+			"sysBytes":      mem.HeapSys,
+			"objects":       mem.HeapObjects,
+			"totalAlloc":    mem.TotalAlloc,
+			"nextGCBytes":   mem.NextGC,
+			"processMemory": h.Watchdog.Stats(),
+		},
+		"gc": map[string]interface{}{
+			"count":       mem.NumGC,
+			"forced":      mem.NumForcedGC,
+			"pauseTotal":  time.Duration(mem.PauseTotalNs).String(),
+			"lastPause":   lastPause,
+			"lastRun":     lastGC,
+			"cpuFraction": mem.GCCPUFraction,
+			"memoryLimit": memoryLimit,
+		},
+		"store": h.Store.Counts(),
+		"cache": map[string]interface{}{
+			"entries":   cacheStats.Entries,
+			"hits":      cacheStats.Hits,
+			"misses":    cacheStats.Misses,
+			"evictions": cacheStats.Evictions,
+			"staleHits": cacheStats.StaleHits,
+			"refreshes": cacheStats.Refreshes,
+			"hitRatio":  cacheStats.HitRatio(),
+		},
+		"coalesced": h.Inflight.Stats(),
+	}, nil
+}
+
+func functionsRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{
+		"functions": typedfn.Specs(),
+	}, nil
+}
+
+// capabilitiesRESTHandler returns plugin's registrations with argument and
+// body schemas as JSON objects
+func (h *handlers) capabilitiesRESTHandler(plugin *registry.Plugin) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"hostSdkVersion": h.Host.Version,
+			"queries":        plugin.Queries(),
+			"mutations":      plugin.Mutations(),
+			"rest":           plugin.Routes(),
+			"functions":      plugin.Functions(),
+			"features":       h.Host.Features(),
+		}, nil
+	}
+}
+
+// debugRegistrationsRESTHandler dumps registrations exactly as the SDK sends
+// them to the host - raw type definitions, resolver and handler names and
+// every object type - plus any type references the host cannot resolve
+func debugRegistrationsRESTHandler(plugin *registry.Plugin) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		fields := func(ops []registry.Operation, lookup func(string) (sdk.GraphQLField, bool)) []interface{} {
+			result := make([]interface{}, 0, len(ops))
+			for _, op := range ops {
+				field, _ := lookup(op.Name)
+				result = append(result, map[string]interface{}{
+					"name":       op.Name,
+					"returnType": op.Type,
+					"resolve":    field.Resolve,
+					"type":       field.Type,
+					"args":       field.Args,
+				})
+			}
+			return result
+		}
+
+		routes := plugin.Routes()
+		rest := make([]interface{}, 0, len(routes))
+		for _, route := range routes {
+			rest = append(rest, map[string]interface{}{
+				"method":  route.Method,
+				"path":    route.Path,
+				"handler": route.Method + "_" + route.Path,
+				"schema":  route.Schema,
+			})
+		}
+
+		objectTypes := plugin.GetAllObjectTypes()
+		names := make([]string, 0, len(objectTypes))
+		for name := range objectTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		types := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			def := objectTypes[name]
+			typeFields := make(map[string]interface{}, len(def.Fields))
+			for fieldName, field := range def.Fields {
+				typeFields[fieldName] = schema.FieldType(field)
+			}
+			types = append(types, map[string]interface{}{
+				"name":        name,
+				"description": def.Description,
+				"fields":      typeFields,
+			})
+		}
+
+		return map[string]interface{}{
+			"queries":     fields(plugin.Queries(), plugin.GetQueryField),
+			"mutations":   fields(plugin.Mutations(), plugin.GetMutationField),
+			"functions":   plugin.Functions(),
+			"rest":        rest,
+			"objectTypes": types,
+			"groups":      plugin.Groups(),
+			"problems":    plugin.Problems(),
+		}, nil
+	}
+}
+
+// healthRESTHandler reports each optional dependency. A degraded dependency
+// still answers 200, since the plugin keeps serving with its fallback.
+func (h *handlers) healthRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return h.Dependencies.Report(), nil
+}
+
+// livenessRESTHandler answers as long as the process is up and serving
+func (h *handlers) livenessRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{
+		"status": "alive",
+		"uptime": h.Clock.Since(h.StartedAt).Round(time.Second).String(),
+	}, nil
+}
+
+// readinessRESTHandler answers 503 while any readiness condition is pending
+func (h *handlers) readinessRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	report := h.Readiness.Report()
+	if !report.Ready {
+		return map[string]interface{}{
+			"statusCode": 503,
+			"headers":    map[string]interface{}{"Content-Type": "application/json"},
+			"body":       report,
+		}, nil
+	}
+	return report, nil
+}
+
+func (h *handlers) statusRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Clients sending If-None-Match with the last ETag get a 304 instead of the body
+	return httpcache.Respond(ctx, args, map[string]interface{}{
+		"status":  "running",
+		"version": buildinfo.Version,
+		"sdk":     "github.com/apito-io/go-apito-plugin-sdk",
+		"build":   buildinfo.Get(),
+		"features": []string{
+			"GraphQL Queries",
+			"GraphQL Mutations",
+			"REST APIs",
+			"Custom Functions",
+		},
+		"host": map[string]interface{}{
+			"sdkVersion": h.Host.Version,
+			"detected":   h.Host.Detected,
+			"gated":      h.Host.Gates(),
+		},
+	})
+}
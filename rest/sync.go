@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/store"
+)
+
+// syncMaxChanges caps the change log entries one /sync response covers;
+// hasMore tells the client to sync again right away
+const syncMaxChanges = 500
+
+const syncExpiredMessage = "checkpoint has expired; sync without one for a full snapshot"
+
+// syncDelta collects the records a /sync response carries, each once in
+// its current state, with the deleted ones as entity and ID
+type syncDelta struct {
+	users       []store.User
+	products    []store.Product
+	groups      []store.Group
+	memberships []store.Membership
+	comments    []store.Comment
+	deleted     []interface{}
+}
+
+// syncRESTHandler is a delta sync for offline clients. Without a
+// checkpoint it returns every record and a checkpoint; with one it returns
+// only the records created, updated or deleted since, and the checkpoint
+// to keep for next time. Records are sent in their current state, so a
+// record changed several times is sent once, and applying a response twice
+// is harmless. Emails and phone numbers are shown as the caller's role
+// allows.
+func (h *handlers) syncRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	checkpoint := sdk.GetStringArg(args, "checkpoint", "")
+	if checkpoint == "" {
+		return h.fullSync(ctx), nil
+	}
+
+	seq, err := h.Store.ParseChangeCursor(checkpoint)
+	if errors.Is(err, store.ErrCursorExpired) {
+		return restError(410, syncExpiredMessage, nil), nil
+	} else if err != nil {
+		return restError(400, "invalid checkpoint", nil), nil
+	}
+	changes, next, more, err := h.Store.ChangesSince(seq, syncMaxChanges)
+	if errors.Is(err, store.ErrCursorExpired) {
+		return restError(410, syncExpiredMessage, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a record's last change matters: its state is looked up now
+	last := make(map[string]int, len(changes))
+	for i, change := range changes {
+		last[change.Entity+"/"+change.ID] = i
+	}
+	var delta syncDelta
+	for i, change := range changes {
+		if last[change.Entity+"/"+change.ID] == i {
+			h.addToSync(ctx, &delta, change)
+		}
+	}
+	return syncResponse(delta, h.Store.ChangeCursor(next), more, false), nil
+}
+
+// fullSync returns every record. The checkpoint is taken first, so a
+// change made while copying is sent again by the next sync rather than
+// missed.
+func (h *handlers) fullSync(ctx context.Context) map[string]interface{} {
+	checkpoint := h.Store.ChangeCursor(h.Store.LatestChange())
+	snap := h.Store.Snapshot()
+	for i, user := range snap.Users {
+		snap.Users[i] = h.PII.ShowUser(ctx, user)
+	}
+	delta := syncDelta{
+		users:       snap.Users,
+		products:    snap.Products,
+		groups:      snap.Groups,
+		memberships: snap.Memberships,
+		comments:    snap.Comments,
+	}
+	return syncResponse(delta, checkpoint, false, true)
+}
+
+// addToSync adds the changed record to delta, or to its deleted records
+// when it no longer exists
+func (h *handlers) addToSync(ctx context.Context, delta *syncDelta, change store.Change) {
+	exists := false
+	switch change.Entity {
+	case store.EntityUser:
+		if user, err := h.Store.GetUser(change.ID); err == nil {
+			delta.users, exists = append(delta.users, h.PII.ShowUser(ctx, user)), true
+		}
+	case store.EntityProduct:
+		if product, err := h.Store.GetProduct(change.ID); err == nil {
+			delta.products, exists = append(delta.products, product), true
+		}
+	case store.EntityGroup:
+		if group, err := h.Store.GetGroup(change.ID); err == nil {
+			delta.groups, exists = append(delta.groups, group), true
+		}
+	case store.EntityComment:
+		if comment, err := h.Store.GetComment(change.ID); err == nil {
+			delta.comments, exists = append(delta.comments, comment), true
+		}
+	case store.EntityConsent:
+		// Consents are personal and not synced; getUserConsents reads them
+		return
+	case store.EntityMembership:
+		userID, groupID, _ := strings.Cut(change.ID, ":")
+		for _, group := range h.Store.GroupsOfUser(userID) {
+			if group.ID == groupID {
+				delta.memberships, exists = append(delta.memberships, store.Membership{UserID: userID, GroupID: groupID}), true
+				break
+			}
+		}
+	}
+	if !exists {
+		delta.deleted = append(delta.deleted, map[string]interface{}{"entity": change.Entity, "id": change.ID})
+	}
+}
+
+// syncResponse is the envelope for a sync; lists are never null so
+// clients can apply them without checks
+func syncResponse(delta syncDelta, checkpoint string, more, full bool) map[string]interface{} {
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers": map[string]interface{}{
+			"Content-Type":  "application/json",
+			"Cache-Control": "no-store",
+		},
+		"body": map[string]interface{}{
+			"checkpoint":  checkpoint,
+			"hasMore":     more,
+			"full":        full,
+			"users":       orEmpty(delta.users),
+			"products":    orEmpty(delta.products),
+			"groups":      orEmpty(delta.groups),
+			"memberships": orEmpty(delta.memberships),
+			"comments":    orEmpty(delta.comments),
+			"deleted":     orEmpty(delta.deleted),
+		},
+	}
+}
+
+// orEmpty returns an empty slice for nil, which encodes as [] not null
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
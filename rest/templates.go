@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/email"
+	"hc-hello-world-plugin/render"
+)
+
+// emailPreviewRecipient is the To address shown in email previews
+const emailPreviewRecipient = "jane@example.com"
+
+// emailPreviewRESTHandler renders an email template with its sample data
+// and wraps the message in an HTML page, so the output can be checked in a
+// browser without sending mail. The name query parameter overrides the
+// sample recipient's name.
+func (h *handlers) emailPreviewRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	name := sdk.GetStringArg(args, "template", "")
+	sample, ok := email.SampleData[name]
+	if !ok {
+		return restError(404, fmt.Sprintf("no preview for email template %q", name), nil), nil
+	}
+
+	data := make(map[string]interface{}, len(sample))
+	for key, value := range sample {
+		data[key] = value
+	}
+	if recipient := sdk.GetStringArg(args, "name", ""); recipient != "" {
+		if len(recipient) > 100 {
+			return restError(400, "name must be at most 100 characters", nil), nil
+		}
+		data["Name"] = recipient
+	}
+
+	msg, err := email.Render(name, emailPreviewRecipient, data)
+	if errors.Is(err, email.ErrUnknownTemplate) {
+		return restError(404, err.Error(), nil), nil
+	} else if err != nil {
+		return restError(500, err.Error(), nil), nil
+	}
+	page, err := h.Templates.Render("email-preview", map[string]interface{}{
+		"Template": name,
+		"To":       msg.To,
+		"Subject":  msg.Subject,
+		"Body":     msg.Body,
+	})
+	if err != nil {
+		return restError(500, err.Error(), nil), nil
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    map[string]interface{}{"Content-Type": "text/html; charset=utf-8"},
+		"body":       page,
+	}, nil
+}
+
+// renderTemplateRESTHandler renders the template named in the body with its
+// data object and returns the page itself, so it can be opened in a browser.
+// Unknown templates are a 404; data missing a key the template uses is a 422.
+func (h *handlers) renderTemplateRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	name := sdk.GetStringArg(args, "name", "")
+	data, _ := args["data"].(map[string]interface{})
+
+	rendered, err := h.Templates.Render(name, data)
+	if errors.Is(err, render.ErrUnknownTemplate) {
+		return restError(404, err.Error(), nil), nil
+	} else if err != nil {
+		return restError(422, err.Error(), nil), nil
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    map[string]interface{}{"Content-Type": "text/html; charset=utf-8"},
+		"body":       rendered,
+	}, nil
+}
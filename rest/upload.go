@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/files"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/upload"
+)
+
+// graphqlUploadRESTHandler executes GraphQL multipart requests (see the
+// upload package). The host only forwards REST bodies as args, so the raw
+// multipart body arrives base64 encoded in body, with the request's
+// Content-Type. Files are streamed into the upload store, then each
+// operation's root fields are run through their registered resolvers.
+// Uploads no operation claimed are deleted once the request is done.
+func (h *handlers) graphqlUploadRESTHandler(plugin *registry.Plugin) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		body, _, err := files.Base64Reader(sdk.GetStringArg(args, "body", ""))
+		if err != nil {
+			return restError(400, "body must be the base64 encoded multipart request", nil), nil
+		}
+
+		parsed, err := upload.Parse(ctx, body, upload.ContentType(ctx, args), h.Uploads, h.UploadOptions)
+		switch {
+		case errors.Is(err, files.ErrTooLarge):
+			return restError(413, err.Error(), nil), nil
+		case err != nil:
+			return restError(400, files.Base64Error(err).Error(), nil), nil
+		}
+		defer func() {
+			for _, f := range parsed.Files {
+				if stored, err := h.Uploads.Get(f.ID); err == nil && stored.Owner == "" {
+					h.Uploads.Delete(context.WithoutCancel(ctx), f.ID)
+				}
+			}
+		}()
+		log.Printf("📤 [hc-hello-world-plugin] event=multipart_upload operations=%d files=%d", len(parsed.Requests), len(parsed.Files))
+
+		// The host's context values, such as the tenant, go to every resolver
+		contextArgs := make(map[string]interface{})
+		for key, value := range args {
+			if strings.HasPrefix(key, "context_") {
+				contextArgs[key] = value
+			}
+		}
+		results := make([]interface{}, 0, len(parsed.Requests))
+		for _, req := range parsed.Requests {
+			results = append(results, upload.Execute(ctx, plugin, req, contextArgs))
+		}
+
+		var response interface{} = results
+		if !parsed.Batch {
+			response = results[0]
+		}
+		return map[string]interface{}{
+			"statusCode": 200,
+			"headers":    map[string]interface{}{"Content-Type": "application/json"},
+			"body":       response,
+		}, nil
+	}
+}
@@ -0,0 +1,149 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/files"
+	"hc-hello-world-plugin/resumable"
+	"hc-hello-world-plugin/timeutil"
+)
+
+// maxChunkBytes caps one PATCH /uploads/{id} chunk, decoded
+const maxChunkBytes = 8 << 20
+
+// startUploadRESTHandler opens a resumable upload session. The response
+// points at the session in Location and reports its offset in
+// Upload-Offset, as PATCH and GET /uploads/{id} do.
+func (h *handlers) startUploadRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	session, err := h.UploadSessions.Start(
+		sdk.GetStringArg(args, "name", "upload"),
+		sdk.GetStringArg(args, "contentType", "application/octet-stream"),
+		int64(sdk.GetIntArg(args, "size", 0)),
+		strings.ToLower(sdk.GetStringArg(args, "sha256", "")),
+	)
+	switch {
+	case errors.Is(err, files.ErrTooLarge):
+		return restError(413, err.Error(), nil), nil
+	case err != nil:
+		return nil, err
+	}
+	return sessionResponse(201, session, map[string]interface{}{"Location": "uploads/" + session.ID}), nil
+}
+
+// uploadStatusRESTHandler reports a session's offset, where a client whose
+// connection dropped resumes
+func (h *handlers) uploadStatusRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	session, err := h.UploadSessions.Get(sdk.GetStringArg(args, "id", ""))
+	if err != nil {
+		return uploadError(err, session), nil
+	}
+	return sessionResponse(200, session, nil), nil
+}
+
+// uploadChunkRESTHandler appends the base64 encoded chunk in data at
+// offset. A chunk at the wrong offset gets a 409 with the session's
+// offset, e.g. when a retried chunk had arrived after all.
+func (h *handlers) uploadChunkRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	data := sdk.GetStringArg(args, "data", "")
+	if len(data)/4*3 > maxChunkBytes {
+		return restError(413, fmt.Sprintf("chunks are at most %d bytes", maxChunkBytes), nil), nil
+	}
+	chunk, _, err := files.Base64Reader(data)
+	if err != nil {
+		return restError(400, "data must be the base64 encoded chunk", nil), nil
+	}
+
+	session, err := h.UploadSessions.Write(sdk.GetStringArg(args, "id", ""), int64(sdk.GetIntArg(args, "offset", 0)), chunk)
+	if err != nil {
+		return uploadError(files.Base64Error(err), session), nil
+	}
+	return sessionResponse(200, session, nil), nil
+}
+
+// completeUploadRESTHandler stores a fully received upload. The file's ID
+// is the session's, and like a multipart upload it can be passed as
+// fileId, e.g. to importUsers or uploadAvatar.
+func (h *handlers) completeUploadRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	id := sdk.GetStringArg(args, "id", "")
+	f, err := h.UploadSessions.Complete(ctx, id)
+	if err != nil {
+		session, _ := h.UploadSessions.Get(id)
+		return uploadError(err, session), nil
+	}
+	return map[string]interface{}{
+		"statusCode": 200,
+		"headers":    map[string]interface{}{"Content-Type": "application/json"},
+		"body": map[string]interface{}{
+			"fileId":      f.ID,
+			"name":        f.Name,
+			"contentType": f.ContentType,
+			"size":        f.Size,
+			"sha256":      f.SHA256,
+		},
+	}, nil
+}
+
+// abortUploadRESTHandler ends a session and discards its chunks
+func (h *handlers) abortUploadRESTHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if err := h.UploadSessions.Abort(sdk.GetStringArg(args, "id", "")); err != nil {
+		return uploadError(err, resumable.Session{}), nil
+	}
+	return map[string]interface{}{
+		"statusCode": 204,
+		"headers":    map[string]interface{}{},
+		"body":       nil,
+	}, nil
+}
+
+// sessionResponse is the envelope for a session, with its offset also in
+// the Upload-Offset header
+func sessionResponse(status int, s resumable.Session, headers map[string]interface{}) map[string]interface{} {
+	allHeaders := map[string]interface{}{
+		"Content-Type":  "application/json",
+		"Upload-Offset": fmt.Sprint(s.Offset),
+	}
+	for key, value := range headers {
+		allHeaders[key] = value
+	}
+	return map[string]interface{}{
+		"statusCode": status,
+		"headers":    allHeaders,
+		"body": map[string]interface{}{
+			"id":          s.ID,
+			"name":        s.Name,
+			"contentType": s.ContentType,
+			"size":        s.Size,
+			"offset":      s.Offset,
+			"complete":    s.Offset == s.Size,
+			"createdAt":   timeutil.FormatUTC(s.CreatedAt),
+			"expiresAt":   timeutil.FormatUTC(s.ExpiresAt),
+		},
+	}
+}
+
+// uploadError maps a session error to its response. Offset conflicts and
+// incomplete uploads carry the session's offset so the client knows where
+// to resume.
+func uploadError(err error, s resumable.Session) map[string]interface{} {
+	var offsetErr *resumable.OffsetError
+	switch {
+	case errors.As(err, &offsetErr):
+		return restError(409, err.Error(), map[string]interface{}{"Upload-Offset": fmt.Sprint(offsetErr.Expected)})
+	case errors.Is(err, resumable.ErrIncomplete):
+		return restError(409, err.Error(), map[string]interface{}{"Upload-Offset": fmt.Sprint(s.Offset)})
+	case errors.Is(err, resumable.ErrNotFound):
+		return restError(404, err.Error(), nil)
+	case errors.Is(err, files.ErrTooLarge):
+		return restError(413, err.Error(), nil)
+	case errors.Is(err, files.ErrInvalidBase64):
+		return restError(400, err.Error(), nil)
+	case errors.Is(err, resumable.ErrChecksumMismatch):
+		return restError(422, err.Error(), nil)
+	}
+	return restError(500, err.Error(), nil)
+}
@@ -0,0 +1,291 @@
+// Package resumable implements resumable uploads. A client starts a
+// session for a file of known size, sends the file in chunks, each at the
+// offset the session has reached, and completes the session once every
+// byte has arrived; the file then moves to the upload store. After a
+// dropped connection the client asks for the session's offset and carries
+// on from there instead of starting over.
+//
+// Chunks are appended to a temporary file, so a large upload is never held
+// in memory while it is in progress.
+package resumable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/files"
+)
+
+// ErrNotFound is returned for unknown, completed or expired sessions
+var ErrNotFound = errors.New("upload session not found")
+
+// ErrIncomplete is returned when completing a session that is missing bytes
+var ErrIncomplete = errors.New("upload is incomplete")
+
+// ErrChecksumMismatch is returned when the completed file does not have the
+// SHA-256 declared when the session started
+var ErrChecksumMismatch = errors.New("upload checksum mismatch")
+
+// OffsetError is returned for a chunk sent at another offset than the one
+// the session has reached; the client resumes from Expected
+type OffsetError struct {
+	Offset   int64
+	Expected int64
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("chunk offset is %d, the upload is at %d", e.Offset, e.Expected)
+}
+
+// Config configures a Manager
+type Config struct {
+	// Dir holds the partial files; empty means the system temp dir
+	Dir string
+	// MaxBytes caps a file's declared size; 0 means no limit
+	MaxBytes int64
+	// TTL is how long a session lives without a chunk
+	TTL time.Duration
+	// NewID names sessions; the completed file keeps the session's ID
+	NewID func() string
+	// Clock stamps sessions; nil uses the system clock
+	Clock clock.Clock
+}
+
+// Session is an upload in progress
+type Session struct {
+	ID          string
+	Name        string
+	ContentType string
+	// Size is the file's declared size and Offset how many bytes arrived
+	Size   int64
+	Offset int64
+	// SHA256 is the hex checksum the file must have, if declared
+	SHA256    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// session is a Session with its partial file; mu serializes its chunks
+type session struct {
+	mu      sync.Mutex
+	info    Session
+	path    string
+	removed bool
+}
+
+// Manager holds the sessions, safe for concurrent use
+type Manager struct {
+	cfg   Config
+	store *files.Store
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// New creates a manager moving completed uploads to store
+func New(cfg Config, store *files.Store) *Manager {
+	cfg.Clock = clock.OrSystem(cfg.Clock)
+	return &Manager{cfg: cfg, store: store, sessions: make(map[string]*session)}
+}
+
+// Start opens a session for a file of size bytes. sha256, when not empty,
+// is checked on completion.
+func (m *Manager) Start(name, contentType string, size int64, sha256 string) (Session, error) {
+	if size < 0 {
+		return Session{}, fmt.Errorf("size must not be negative")
+	}
+	if m.cfg.MaxBytes > 0 && size > m.cfg.MaxBytes {
+		return Session{}, fmt.Errorf("%w: %d bytes, the maximum is %d", files.ErrTooLarge, size, m.cfg.MaxBytes)
+	}
+	m.expire()
+
+	f, err := os.CreateTemp(m.cfg.Dir, "upload-*.part")
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	f.Close()
+
+	now := m.cfg.Clock.Now()
+	s := &session{
+		info: Session{
+			ID:          m.cfg.NewID(),
+			Name:        name,
+			ContentType: contentType,
+			Size:        size,
+			SHA256:      sha256,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.cfg.TTL),
+		},
+		path: f.Name(),
+	}
+	m.mu.Lock()
+	m.sessions[s.info.ID] = s
+	m.mu.Unlock()
+	log.Printf("📤 [hc-hello-world-plugin] event=upload_session_started id=%s size=%d", s.info.ID, size)
+	return s.info, nil
+}
+
+// Get returns a session, e.g. to learn where to resume
+func (m *Manager) Get(id string) (Session, error) {
+	s, err := m.lookup(id)
+	if err != nil {
+		return Session{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed {
+		return Session{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return s.info, nil
+}
+
+// Write appends chunk to the session at offset, which must be the
+// session's offset. A chunk is taken whole or not at all: when reading it
+// fails the session stays where it was, and a chunk running past the
+// declared size is rejected with files.ErrTooLarge.
+func (m *Manager) Write(id string, offset int64, chunk io.Reader) (Session, error) {
+	s, err := m.lookup(id)
+	if err != nil {
+		return Session{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed {
+		return Session{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if offset != s.info.Offset {
+		return s.info, &OffsetError{Offset: offset, Expected: s.info.Offset}
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err != nil {
+		return s.info, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return s.info, fmt.Errorf("failed to seek upload file: %w", err)
+	}
+	remaining := s.info.Size - offset
+	n, err := io.Copy(f, io.LimitReader(chunk, remaining+1))
+	if err == nil && n > remaining {
+		err = fmt.Errorf("%w: the chunk runs %d bytes past the declared size", files.ErrTooLarge, n-remaining)
+	}
+	if err != nil {
+		f.Truncate(offset)
+		return s.info, err
+	}
+
+	s.info.Offset += n
+	s.info.ExpiresAt = m.cfg.Clock.Now().Add(m.cfg.TTL)
+	return s.info, nil
+}
+
+// Complete moves a session's file, once every byte has arrived, to the
+// upload store as an unowned file with the session's ID, and ends the
+// session
+func (m *Manager) Complete(ctx context.Context, id string) (files.File, error) {
+	s, err := m.lookup(id)
+	if err != nil {
+		return files.File{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed {
+		return files.File{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if s.info.Offset != s.info.Size {
+		return files.File{}, fmt.Errorf("%w: %d of %d bytes received", ErrIncomplete, s.info.Offset, s.info.Size)
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return files.File{}, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	stored, err := m.store.Save(ctx, files.File{ID: s.info.ID, Name: s.info.Name, ContentType: s.info.ContentType}, f, 0)
+	f.Close()
+	if err != nil {
+		return files.File{}, err
+	}
+	// A mismatch ends the session too: the bytes are wrong, so the client
+	// has to start over
+	m.remove(s)
+	if s.info.SHA256 != "" && stored.SHA256 != s.info.SHA256 {
+		m.store.Delete(ctx, stored.ID)
+		return files.File{}, fmt.Errorf("%w: got %s, expected %s", ErrChecksumMismatch, stored.SHA256, s.info.SHA256)
+	}
+	log.Printf("✅ [hc-hello-world-plugin] event=upload_session_completed id=%s size=%d", stored.ID, stored.Size)
+	return stored, nil
+}
+
+// Abort ends a session and discards what it received
+func (m *Manager) Abort(id string) error {
+	s, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	m.remove(s)
+	log.Printf("🗑️  [hc-hello-world-plugin] event=upload_session_aborted id=%s offset=%d size=%d", s.info.ID, s.info.Offset, s.info.Size)
+	return nil
+}
+
+// lookup returns an unexpired session
+func (m *Manager) lookup(id string) (*session, error) {
+	m.expire()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return s, nil
+}
+
+// expire ends the sessions that went without a chunk for TTL
+func (m *Manager) expire() {
+	now := m.cfg.Clock.Now()
+	m.mu.Lock()
+	var expired []*session
+	for _, s := range m.sessions {
+		// A session busy with a chunk is not expiring
+		if s.mu.TryLock() {
+			if now.After(s.info.ExpiresAt) {
+				expired = append(expired, s)
+			}
+			s.mu.Unlock()
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range expired {
+		s.mu.Lock()
+		log.Printf("⌛ [hc-hello-world-plugin] event=upload_session_expired id=%s offset=%d size=%d", s.info.ID, s.info.Offset, s.info.Size)
+		m.remove(s)
+		s.mu.Unlock()
+	}
+}
+
+// remove forgets s and deletes its partial file; the caller holds s.mu
+func (m *Manager) remove(s *session) {
+	if s.removed {
+		return
+	}
+	s.removed = true
+	m.mu.Lock()
+	delete(m.sessions, s.info.ID)
+	m.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("⚠️  [hc-hello-world-plugin] event=upload_file_remove_failed id=%s err=%q", s.info.ID, err)
+	}
+}
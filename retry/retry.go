@@ -0,0 +1,127 @@
+// Package retry provides a small retry helper with exponential backoff and
+// jitter for outbound calls made by the plugin (webhooks, external APIs).
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Policy controls how Do retries a failing operation
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after every failed attempt
+	Multiplier float64
+	// Jitter is the fraction (0..1) of each delay that is randomized
+	Jitter float64
+	// Retryable decides whether an error is worth retrying; nil retries everything
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy returns a policy suitable for most outbound HTTP calls
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Do stops retrying immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, the attempts
+// are exhausted, or ctx is cancelled. The last error is returned.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		// Return the error Permanent marked, however the caller wrapped it
+		var p *permanentError
+		if errors.As(err, &p) {
+			return p.err
+		}
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			return err
+		}
+		if attempt == policy.MaxAttempts {
+			break
+		}
+
+		delay := policy.Backoff(attempt)
+		log.Printf("🔁 [hc-hello-world-plugin] Attempt %d/%d failed: %v (retrying in %s)", attempt, policy.MaxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry aborted after %d attempts: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", policy.MaxAttempts, err)
+}
+
+// Backoff returns the jittered delay to wait after the given failed attempt
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		// Spread the delay uniformly over [delay*(1-jitter), delay*(1+jitter)]
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
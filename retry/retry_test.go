@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var errGone = errors.New("gone")
+
+func TestPermanentStopsRetrying(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"bare", Permanent(errGone)},
+		{"wrapped by the caller", fmt.Errorf("fetch: %w", Permanent(errGone))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), Policy{MaxAttempts: 3}, func(context.Context) error {
+				attempts++
+				return tt.err
+			})
+			if attempts != 1 {
+				t.Errorf("ran %d attempts, want 1", attempts)
+			}
+			if err != errGone {
+				t.Errorf("got %v (%T), want errGone", err, err)
+			}
+			if IsPermanent(err) {
+				t.Error("the Permanent marker leaked to the caller")
+			}
+		})
+	}
+}
+
+func TestRetriesUntilAttemptsRunOut(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 3}, func(context.Context) error {
+		attempts++
+		return errGone
+	})
+	if attempts != 3 {
+		t.Errorf("ran %d attempts, want 3", attempts)
+	}
+	if !errors.Is(err, errGone) {
+		t.Errorf("got %v, want it to wrap errGone", err)
+	}
+}
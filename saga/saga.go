@@ -0,0 +1,94 @@
+// Package saga runs a workflow as a sequence of steps with compensating
+// actions: when a step fails, the steps that already completed are undone
+// in reverse order, leaving the system as it was before the workflow.
+package saga
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Step statuses reported in Result.Status
+const (
+	StatusCompleted          = "COMPLETED"
+	StatusFailed             = "FAILED"
+	StatusCompensated        = "COMPENSATED"
+	StatusCompensationFailed = "COMPENSATION_FAILED"
+	StatusSkipped            = "SKIPPED"
+)
+
+// Step is one unit of a workflow. Compensate undoes a completed Action; it
+// may be nil for steps with nothing to undo, such as the final step.
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// Result is the outcome of one step
+type Result struct {
+	Name   string
+	Status string
+	Error  string
+}
+
+// ToMap converts the result into the SagaStep GraphQL object structure
+func (r Result) ToMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"name":   r.Name,
+		"status": r.Status,
+		"error":  nil,
+	}
+	if r.Error != "" {
+		result["error"] = r.Error
+	}
+	return result
+}
+
+// Run executes steps in order and returns a result for every step. If a
+// step fails, the completed steps are compensated newest first, later steps
+// are skipped, and the failed step's error is returned. Compensation runs
+// even if ctx has been cancelled, since abandoning it would leave partial
+// state behind.
+func Run(ctx context.Context, name string, steps []Step) ([]Result, error) {
+	results := make([]Result, len(steps))
+	for i, step := range steps {
+		results[i] = Result{Name: step.Name, Status: StatusSkipped}
+	}
+
+	for i, step := range steps {
+		err := ctx.Err()
+		if err == nil {
+			err = step.Action(ctx)
+		}
+		if err == nil {
+			results[i].Status = StatusCompleted
+			continue
+		}
+
+		log.Printf("↩️  [hc-hello-world-plugin] saga=%s step=%s failed, compensating: %v", name, step.Name, err)
+		results[i].Status = StatusFailed
+		results[i].Error = err.Error()
+		compensate(context.WithoutCancel(ctx), name, steps[:i], results[:i])
+		return results, fmt.Errorf("%s failed: %w", step.Name, err)
+	}
+	return results, nil
+}
+
+// compensate undoes completed steps in reverse order. A failed compensation
+// is recorded and the remaining ones still run.
+func compensate(ctx context.Context, name string, steps []Step, results []Result) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Compensate == nil {
+			continue
+		}
+		if err := steps[i].Compensate(ctx); err != nil {
+			log.Printf("⚠️  [hc-hello-world-plugin] saga=%s step=%s compensation failed: %v", name, steps[i].Name, err)
+			results[i].Status = StatusCompensationFailed
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Status = StatusCompensated
+	}
+}
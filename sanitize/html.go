@@ -0,0 +1,159 @@
+package sanitize
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags maps each element kept by HTML to the attributes it may carry
+var allowedTags = map[string][]string{
+	"a": {"href", "title"}, "img": {"src", "alt", "title"},
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil, "pre": nil, "code": {"class"},
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "del": nil, "s": nil, "sup": nil, "sub": nil,
+	"ul": nil, "ol": {"start"}, "li": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+}
+
+// droppedTags are removed together with everything inside them
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"template": true, "noscript": true, "textarea": true, "svg": true, "math": true,
+	"head": true, "title": true,
+}
+
+// voidTags have no content and no end tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// languageClass is the only class kept, as set on fenced code blocks
+var languageClass = regexp.MustCompile(`^language-[A-Za-z0-9_+-]+$`)
+
+// HTML cleans untrusted HTML down to a small set of formatting elements.
+// Script, style and other active elements are removed with their content,
+// other unknown elements are unwrapped to their text, and only harmless
+// attributes survive: no event handlers, no inline styles, and links and
+// images only with http(s), mailto or relative URLs. Links get
+// rel="nofollow noopener". Comments are dropped and unclosed elements are
+// closed at the end.
+func HTML(s string) string {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var open []string
+	// skipping is the dropped element being skipped and depth its nesting
+	var skipping string
+	depth := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				// The tokenizer only fails on read errors, which a string
+				// reader does not have
+				return ""
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case html.TextToken:
+			if skipping == "" {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			name := tok.Data
+			if skipping != "" {
+				if name == skipping && tt == html.StartTagToken {
+					depth++
+				}
+				continue
+			}
+			if droppedTags[name] {
+				if tt == html.StartTagToken {
+					skipping, depth = name, 1
+				}
+				continue
+			}
+			attrs, ok := allowedTags[name]
+			if !ok {
+				continue
+			}
+
+			b.WriteString("<" + name)
+			for _, attr := range tok.Attr {
+				if attr.Namespace == "" && allowedAttr(attrs, attr.Key, attr.Val) {
+					b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+				}
+			}
+			if name == "a" {
+				b.WriteString(` rel="nofollow noopener"`)
+			}
+			b.WriteString(">")
+			if !voidTags[name] {
+				open = append(open, name)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if skipping != "" {
+				if string(name) == skipping {
+					if depth--; depth == 0 {
+						skipping = ""
+					}
+				}
+				continue
+			}
+			// Close up to the matching open element; stray end tags are dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+}
+
+func allowedAttr(allowed []string, key, value string) bool {
+	switch {
+	case !slices.Contains(allowed, key):
+		return false
+	case key == "href" || key == "src":
+		return safeURL(value, key == "href")
+	case key == "class":
+		return languageClass.MatchString(value)
+	case key == "align":
+		return value == "left" || value == "center" || value == "right"
+	case key == "start":
+		return strings.Trim(value, "0123456789") == "" && len(value) <= 9
+	}
+	return true
+}
+
+// safeURL accepts relative URLs and http(s) ones, plus mailto for links.
+// URLs with control characters, which browsers strip to reveal schemes
+// like "java\tscript:", fail to parse and are rejected.
+func safeURL(raw string, link bool) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https":
+		return true
+	case "mailto":
+		return link
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	const rel = ` rel="nofollow noopener"`
+	tests := []struct {
+		name, in, want string
+	}{
+		// Schemes
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"padded upper-case scheme", `<a href=" JAVASCRIPT:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"tab inside scheme", "<a href=\"java\tscript:alert(1)\">x</a>", `<a` + rel + `>x</a>`},
+		{"entity-encoded scheme", `<a href="&#106;&#97;vascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"entity-encoded colon", `<a href="javascript&#58;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"named-entity colon", `<a href="&#x6A;avascript&colon;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"data link", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, `<a` + rel + `>x</a>`},
+		{"data image", `<img src="data:image/svg+xml,<svg onload=alert(1)>">`, `<img>`},
+		{"mailto image", `<img src="mailto:a@b.example">`, `<img>`},
+		{"mailto link", `<a href="mailto:a@b.example">m</a>`, `<a href="mailto:a@b.example"` + rel + `>m</a>`},
+		{"relative link", `<a href="/rel?q=1&amp;b=2">r</a>`, `<a href="/rel?q=1&amp;b=2"` + rel + `>r</a>`},
+
+		// Attributes
+		{"img onerror", `<img src=x onerror=alert(1)>`, `<img src="x">`},
+		{"self-closing img onerror", `<img src="x" onerror="alert(1)"/>`, `<img src="x">`},
+		{"quote breakout", `<a title='x" onclick="alert(1)'>t</a>`, `<a title="x&#34; onclick=&#34;alert(1)"` + rel + `>t</a>`},
+		{"tag breakout", `<a title="&quot;><script>alert(1)</script>">t</a>`,
+			`<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;"` + rel + `>t</a>`},
+		{"style and handlers", `<p style="x" onclick="y">p</p>`, `<p>p</p>`},
+		{"other classes", `<code class="language-go x">c</code>`, `<code>c</code>`},
+		{"language class", `<code class="language-go">c</code>`, `<code class="language-go">c</code>`},
+		{"list start", `<ol start="3"><li>a</li></ol>`, `<ol start="3"><li>a</li></ol>`},
+
+		// Elements
+		{"script", `<script>alert(1)</script>after`, `after`},
+		{"upper-case script", `<SCRIPT SRC=//evil.example/x.js></SCRIPT>`, ``},
+		{"script in svg", `<svg><script>alert(1)</script></svg>ok`, `ok`},
+		{"split script tag", `<scr<script>ipt>alert(1)</script>`, `ipt&gt;alert(1)`},
+		{"comment", `<!-- <script> -->x`, `x`},
+		{"unknown element", `<div onmouseover="x">d</div>`, `d`},
+		{"misnested", `<b>a<i>b</b>c`, `<b>a<i>b</i></b>c`},
+		{"unclosed", `<p><em>open`, `<p><em>open</em></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in); got != tt.want {
+				t.Errorf("HTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package sanitize cleans user-supplied strings before the plugin echoes
+// them back to callers.
+package sanitize
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Options selects which cleaning steps are applied to a string
+type Options struct {
+	// Trim removes leading and trailing whitespace
+	Trim bool
+	// MaxLength caps the string length in runes (0 means unlimited)
+	MaxLength int
+	// EscapeHTML escapes <, >, &, ' and " so values are safe to embed in HTML
+	EscapeHTML bool
+	// StripControl removes control characters other than newline and tab
+	StripControl bool
+}
+
+// DefaultOptions returns the options applied to free-text arguments
+func DefaultOptions() Options {
+	return Options{
+		Trim:         true,
+		MaxLength:    256,
+		EscapeHTML:   true,
+		StripControl: true,
+	}
+}
+
+// String applies opts to s
+func String(s string, opts Options) string {
+	if opts.StripControl {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\n' && r != '\t' {
+				return -1
+			}
+			return r
+		}, s)
+	}
+
+	if opts.Trim {
+		s = strings.TrimSpace(s)
+	}
+
+	// Truncate before escaping so entities are never cut in half
+	if opts.MaxLength > 0 {
+		if runes := []rune(s); len(runes) > opts.MaxLength {
+			s = string(runes[:opts.MaxLength])
+		}
+	}
+
+	if opts.EscapeHTML {
+		s = html.EscapeString(s)
+	}
+
+	return s
+}
+
+// Sanitizer applies the same options to every field except those that opted out
+type Sanitizer struct {
+	opts   Options
+	optOut map[string]bool
+}
+
+// New creates a Sanitizer; fields listed in optOut are returned unchanged
+func New(opts Options, optOut ...string) *Sanitizer {
+	s := &Sanitizer{
+		opts:   opts,
+		optOut: make(map[string]bool, len(optOut)),
+	}
+	for _, field := range optOut {
+		s.optOut[field] = true
+	}
+	return s
+}
+
+// Field sanitizes value unless field opted out
+func (s *Sanitizer) Field(field, value string) string {
+	if s.optOut[field] {
+		return value
+	}
+	return String(value, s.opts)
+}
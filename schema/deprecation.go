@@ -0,0 +1,75 @@
+// Package schema contains helpers layered on top of the SDK's schema
+// builders for features the SDK does not model directly.
+package schema
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// deprecations records every deprecated field and argument, keyed by
+// "Type.field" or "resolver(arg)", so usage can be reported at runtime
+var deprecations sync.Map
+
+// DeprecateArg marks an argument definition as deprecated. Argument
+// definitions are plain maps that the SDK forwards to the host as-is, so the
+// standard isDeprecated/deprecationReason keys reach the host schema.
+func DeprecateArg(resolver, name string, arg map[string]interface{}, reason string) map[string]interface{} {
+	arg["isDeprecated"] = true
+	arg["deprecationReason"] = reason
+	if description, ok := arg["description"].(string); ok {
+		arg["description"] = fmt.Sprintf("%s (DEPRECATED: %s)", description, reason)
+	}
+	deprecations.Store(resolver+"("+name+")", reason)
+	return arg
+}
+
+// DeprecateField marks a field of an object type as deprecated. The SDK's
+// ObjectFieldDef has no deprecation slot, so the reason is appended to the
+// field description where schema explorers and clients will surface it.
+func DeprecateField(def sdk.ObjectTypeDefinition, field, reason string) sdk.ObjectTypeDefinition {
+	fieldDef, ok := def.Fields[field]
+	if !ok {
+		log.Printf("⚠️  [hc-hello-world-plugin] Cannot deprecate unknown field %s.%s", def.TypeName, field)
+		return def
+	}
+	fieldDef.Description = fmt.Sprintf("%s (DEPRECATED: %s)", fieldDef.Description, reason)
+	def.Fields[field] = fieldDef
+	deprecations.Store(def.TypeName+"."+field, reason)
+	return def
+}
+
+// WarnDeprecatedArgs logs a warning for every deprecated argument of
+// resolver that is present in args. Properties of object arguments are
+// matched by dotted path, e.g. createUser(input.username).
+func WarnDeprecatedArgs(resolver string, args map[string]interface{}) {
+	warnDeprecated(resolver, "", args)
+}
+
+func warnDeprecated(resolver, prefix string, args map[string]interface{}) {
+	for name, value := range args {
+		if value == nil {
+			continue
+		}
+		path := prefix + name
+		if reason, ok := deprecations.Load(resolver + "(" + path + ")"); ok {
+			log.Printf("⚠️  [hc-hello-world-plugin] Deprecated argument %s(%s) used: %s", resolver, path, reason)
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			warnDeprecated(resolver, path+".", nested)
+		}
+	}
+}
+
+// Deprecations returns all recorded deprecations and their reasons
+func Deprecations() map[string]string {
+	result := make(map[string]string)
+	deprecations.Range(func(key, value interface{}) bool {
+		result[key.(string)] = value.(string)
+		return true
+	})
+	return result
+}
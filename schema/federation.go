@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// federationLink is the schema extension declaring the federation version
+// and the directives the SDL uses
+const federationLink = `extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key"])`
+
+// ReferenceResolver loads an entity from its representation, e.g.
+// {"__typename": "User", "id": "42"}. It returns nil when the entity does
+// not exist.
+type ReferenceResolver func(ctx context.Context, representation map[string]interface{}) (interface{}, error)
+
+type entity struct {
+	def     sdk.ObjectTypeDefinition
+	keys    string
+	resolve ReferenceResolver
+}
+
+var (
+	entitiesMu sync.RWMutex
+	entities   = make(map[string]entity)
+)
+
+// Entity marks def as a federation entity identified by keys (a selection
+// such as "id") and registers how to resolve its references. It returns
+// def so it can wrap a type definition inline.
+func Entity(def sdk.ObjectTypeDefinition, keys string, resolve ReferenceResolver) sdk.ObjectTypeDefinition {
+	entitiesMu.Lock()
+	defer entitiesMu.Unlock()
+	entities[def.TypeName] = entity{def: def, keys: keys, resolve: resolve}
+	return def
+}
+
+// EntityField is the _Entity field holding entities of typeName, e.g.
+// "user" for User. The SDK cannot declare the _Entity union, so _Entity is
+// an object with one nullable field per entity type instead.
+func EntityField(typeName string) string {
+	runes := []rune(typeName)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// ResolveEntities resolves each representation with its type's reference
+// resolver, in order. Unknown entities resolve to nil as the federation
+// spec requires; unknown types are an error.
+func ResolveEntities(ctx context.Context, representations []interface{}) ([]interface{}, error) {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+
+	result := make([]interface{}, 0, len(representations))
+	for i, raw := range representations {
+		representation, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("representation %d is not an object", i)
+		}
+		typeName, _ := representation["__typename"].(string)
+		e, ok := entities[typeName]
+		if !ok {
+			return nil, fmt.Errorf("representation %d: %q is not an entity type", i, typeName)
+		}
+		value, err := e.resolve(ctx, representation)
+		if err != nil {
+			return nil, fmt.Errorf("representation %d (%s): %w", i, typeName, err)
+		}
+		result = append(result, map[string]interface{}{
+			"typename":            typeName,
+			EntityField(typeName): value,
+		})
+	}
+	return result, nil
+}
+
+// FederationSDL renders the entity types and every object type they reach
+// as SDL with @key directives, for the _service query. types supplies the
+// definitions of referenced types (see sdk.Plugin.GetAllObjectTypes).
+func FederationSDL(types map[string]sdk.ObjectTypeDefinition) string {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+
+	// Collect the entity types plus everything reachable from them
+	reachable := make(map[string]sdk.ObjectTypeDefinition)
+	var visit func(def sdk.ObjectTypeDefinition)
+	visit = func(def sdk.ObjectTypeDefinition) {
+		if _, seen := reachable[def.TypeName]; seen {
+			return
+		}
+		reachable[def.TypeName] = def
+		for _, field := range def.Fields {
+			if nested, ok := types[field.Type]; ok {
+				visit(nested)
+			}
+		}
+	}
+	for _, e := range entities {
+		visit(e.def)
+	}
+
+	names := make([]string, 0, len(reachable))
+	for name := range reachable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(federationLink + "\n")
+	for _, name := range names {
+		def := reachable[name]
+		b.WriteString("\n")
+		if def.Description != "" {
+			fmt.Fprintf(&b, "%q\n", def.Description)
+		}
+		fmt.Fprintf(&b, "type %s", name)
+		if e, ok := entities[name]; ok {
+			fmt.Fprintf(&b, " @key(fields: %q)", e.keys)
+		}
+		b.WriteString(" {\n")
+
+		fieldNames := make([]string, 0, len(def.Fields))
+		for field := range def.Fields {
+			fieldNames = append(fieldNames, field)
+		}
+		sort.Strings(fieldNames)
+		for _, field := range fieldNames {
+			fmt.Fprintf(&b, "  %s: %s\n", field, FieldType(def.Fields[field]))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// FieldType renders an object field's type reference, e.g. "[Tag!]" or "String!"
+func FieldType(field sdk.ObjectFieldDef) string {
+	typ := field.Type
+	if field.List {
+		if field.ListOfNonNull {
+			typ += "!"
+		}
+		typ = "[" + typ + "]"
+	}
+	if !field.Nullable {
+		typ += "!"
+	}
+	return typ
+}
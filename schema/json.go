@@ -0,0 +1,15 @@
+package schema
+
+import sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+// JSON is the host's free-form scalar. The SDK calls it Object; the host
+// accepts any JSON object for it and passes it to resolvers decoded, with
+// nested maps, slices and float64 numbers, and returns values as they are.
+const JSON = "Object"
+
+// JSONArg declares a free-form JSON object argument. Unlike sdk.ObjectArg it
+// lists no properties, so the SDK passes the object through untouched;
+// bound its size with limits.Config.CheckJSON.
+func JSONArg(description string) map[string]interface{} {
+	return sdk.Arg(JSON, description)
+}
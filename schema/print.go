@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"hc-hello-world-plugin/registry"
+)
+
+// PrintSDL renders everything plugin registered as one SDL document: its
+// queries and mutations as the fields of Query and Mutation, under the
+// names the host exposes, and every object type. The host's Object scalar
+// is declared when used, so the document stands on its own, e.g. for
+// graphql-js's buildSchema.
+func PrintSDL(plugin *registry.Plugin) string {
+	types := plugin.GetAllObjectTypes()
+	usesObject := false
+	ref := func(typ string) string {
+		if strings.Trim(typ, "[]!") == "Object" {
+			usesObject = true
+		}
+		return typ
+	}
+
+	var body strings.Builder
+	for _, root := range []struct {
+		name string
+		ops  []registry.Operation
+	}{{"Query", plugin.Queries()}, {"Mutation", plugin.Mutations()}} {
+		if len(root.ops) == 0 {
+			continue
+		}
+		fmt.Fprintf(&body, "\ntype %s {\n", root.name)
+		for _, op := range root.ops {
+			writeDescription(&body, "  ", op.Description)
+			fmt.Fprintf(&body, "  %s%s: %s\n", op.Name, printArgs(op.Args, ref), ref(op.Type))
+		}
+		body.WriteString("}\n")
+	}
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := types[name]
+		body.WriteString("\n")
+		writeDescription(&body, "", def.Description)
+		fmt.Fprintf(&body, "type %s", name)
+		if len(def.Fields) == 0 {
+			body.WriteString("\n")
+			continue
+		}
+		body.WriteString(" {\n")
+		fieldNames := make([]string, 0, len(def.Fields))
+		for field := range def.Fields {
+			fieldNames = append(fieldNames, field)
+		}
+		sort.Strings(fieldNames)
+		for _, field := range fieldNames {
+			writeDescription(&body, "  ", def.Fields[field].Description)
+			fmt.Fprintf(&body, "  %s: %s\n", field, ref(FieldType(def.Fields[field])))
+		}
+		body.WriteString("}\n")
+	}
+
+	var b strings.Builder
+	if usesObject {
+		b.WriteString("\"Any JSON value\"\nscalar Object\n")
+	}
+	b.WriteString(body.String())
+	return strings.TrimPrefix(b.String(), "\n")
+}
+
+// printArgs renders an operation's arguments, one per line, sorted by name
+func printArgs(args map[string]interface{}, ref func(string) string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("(\n")
+	for _, name := range names {
+		arg, _ := args[name].(map[string]interface{})
+		typ, _ := arg["type"].(string)
+		description, _ := arg["description"].(string)
+		writeDescription(&b, "    ", description)
+		fmt.Fprintf(&b, "    %s: %s\n", name, ref(typ))
+	}
+	b.WriteString("  )")
+	return b.String()
+}
+
+// writeDescription writes a description string before a definition
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%q\n", indent, description)
+	}
+}
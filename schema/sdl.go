@@ -0,0 +1,661 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/registry"
+)
+
+// SDL is a parsed GraphQL schema document. It supports the subset the SDK
+// can express: object types, and the fields of Query and Mutation (with
+// "type" or "extend type") as the plugin's operations. Fields and arguments
+// take descriptions and @deprecated; input types, enums, interfaces,
+// unions, default values and nested lists are rejected.
+type SDL struct {
+	types     []sdlType
+	queries   []sdlField
+	mutations []sdlField
+}
+
+type sdlType struct {
+	pos         sdlPos
+	name        string
+	description string
+	fields      []sdlField
+}
+
+type sdlField struct {
+	pos         sdlPos
+	name        string
+	description string
+	typ         sdlTypeRef
+	args        []sdlArg
+	deprecated  *string
+}
+
+type sdlArg struct {
+	pos         sdlPos
+	name        string
+	description string
+	typ         sdlTypeRef
+	deprecated  *string
+}
+
+// sdlTypeRef is a type reference such as "String", "User!" or "[User!]!"
+type sdlTypeRef struct {
+	name        string
+	nonNull     bool
+	list        bool
+	itemNonNull bool
+}
+
+func (t sdlTypeRef) String() string {
+	s := t.name
+	if t.list {
+		if t.itemNonNull {
+			s += "!"
+		}
+		s = "[" + s + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// sdlScalars are the types the host resolves without an object type; only
+// these may be used for arguments
+var sdlScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true, "Object": true}
+
+// defaultDeprecationReason is what GraphQL reports for a bare @deprecated
+const defaultDeprecationReason = "No longer supported"
+
+// ParseSDL parses source, checking that names are unique and that
+// arguments are scalars. References to object types are checked by
+// Register, since they may name types registered in Go.
+func ParseSDL(source string) (*SDL, error) {
+	tokens, err := lexSDL(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &sdlParser{tokens: tokens}
+	doc := &SDL{}
+	seen := make(map[string]sdlPos)
+	for p.peek().kind != sdlEOF {
+		description := p.description()
+		extend := p.acceptName("extend")
+		start := p.peek()
+		if !p.acceptName("type") {
+			return nil, start.pos.errorf("expected an object type definition, found %s", start)
+		}
+		nameTok, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		fields, err := p.fields()
+		if err != nil {
+			return nil, err
+		}
+
+		switch nameTok.text {
+		case "Query":
+			doc.queries = append(doc.queries, fields...)
+		case "Mutation":
+			doc.mutations = append(doc.mutations, fields...)
+		default:
+			if extend {
+				return nil, nameTok.pos.errorf("only Query and Mutation can be extended, not %s", nameTok.text)
+			}
+			if sdlScalars[nameTok.text] {
+				return nil, nameTok.pos.errorf("type %s redefines a built-in scalar", nameTok.text)
+			}
+			if prev, ok := seen[nameTok.text]; ok {
+				return nil, nameTok.pos.errorf("type %s is already defined at %s", nameTok.text, prev)
+			}
+			seen[nameTok.text] = nameTok.pos
+			for _, field := range fields {
+				if len(field.args) > 0 {
+					return nil, field.pos.errorf("field %s.%s has arguments; only Query and Mutation fields can", nameTok.text, field.name)
+				}
+			}
+			doc.types = append(doc.types, sdlType{pos: nameTok.pos, name: nameTok.text, description: description, fields: fields})
+		}
+	}
+
+	// Resolvers are bound by name, so a query and a mutation cannot share one
+	if err := uniqueFields(append(append([]sdlField(nil), doc.queries...), doc.mutations...)); err != nil {
+		return nil, err
+	}
+	for _, t := range doc.types {
+		if err := uniqueFields(t.fields); err != nil {
+			return nil, fmt.Errorf("%w in type %s", err, t.name)
+		}
+	}
+	return doc, nil
+}
+
+// Register builds the document's object types and registers its queries
+// and mutations with plugin, each bound to the resolver of the same name.
+// Nothing is registered if an operation has no resolver, a resolver has no
+// operation, or a field names a type that is neither defined in the
+// document nor already registered with plugin.
+func (s *SDL) Register(plugin *registry.Plugin, resolvers map[string]sdk.ResolverFunc) error {
+	registered := plugin.GetAllObjectTypes()
+	if err := s.check(registered, resolvers); err != nil {
+		return err
+	}
+
+	defs := make(map[string]sdk.ObjectTypeDefinition, len(registered)+len(s.types))
+	for name, def := range registered {
+		defs[name] = def
+	}
+	for _, t := range s.types {
+		builder := sdk.NewObjectType(t.name, t.description)
+		for _, field := range t.fields {
+			if field.typ.list {
+				builder.AddListField(field.name, field.description, field.typ.name, !field.typ.nonNull, field.typ.itemNonNull)
+			} else {
+				builder.AddObjectField(field.name, field.description, field.typ.name, !field.typ.nonNull)
+			}
+		}
+		def := builder.Build()
+		for _, field := range t.fields {
+			if field.deprecated != nil {
+				def = DeprecateField(def, field.name, *field.deprecated)
+			}
+		}
+		defs[t.name] = def
+	}
+
+	for _, op := range s.queries {
+		plugin.RegisterQuery(op.name, op.graphQLField(defs), resolvers[op.name])
+	}
+	for _, op := range s.mutations {
+		plugin.RegisterMutation(op.name, op.graphQLField(defs), resolvers[op.name])
+	}
+	return nil
+}
+
+// RegisterSDL parses source and registers it with plugin; see Register
+func RegisterSDL(plugin *registry.Plugin, source string, resolvers map[string]sdk.ResolverFunc) error {
+	doc, err := ParseSDL(source)
+	if err != nil {
+		return err
+	}
+	return doc.Register(plugin, resolvers)
+}
+
+// check reports every unbound operation, unused resolver and unknown type
+func (s *SDL) check(registered map[string]sdk.ObjectTypeDefinition, resolvers map[string]sdk.ResolverFunc) error {
+	known := func(name string) bool {
+		if sdlScalars[name] {
+			return true
+		}
+		if _, ok := registered[name]; ok {
+			return true
+		}
+		for _, t := range s.types {
+			if t.name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var problems []string
+	for _, t := range s.types {
+		for _, field := range t.fields {
+			if !known(field.typ.name) {
+				problems = append(problems, field.pos.errorf("field %s.%s has unknown type %s", t.name, field.name, field.typ.name).Error())
+			}
+		}
+	}
+
+	operations := make(map[string]bool)
+	for _, op := range append(append([]sdlField(nil), s.queries...), s.mutations...) {
+		operations[op.name] = true
+		if !known(op.typ.name) {
+			problems = append(problems, op.pos.errorf("%s returns unknown type %s", op.name, op.typ.name).Error())
+		}
+		if resolvers[op.name] == nil {
+			problems = append(problems, op.pos.errorf("no resolver bound to %s", op.name).Error())
+		}
+	}
+	var unused []string
+	for name := range resolvers {
+		if !operations[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		problems = append(problems, fmt.Sprintf("resolver %s has no query or mutation in the schema", name))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// graphQLField is op as the SDK's field helpers would build it: object
+// results carry their definition, and list and non-null markers wrap the
+// named type
+func (op sdlField) graphQLField(defs map[string]sdk.ObjectTypeDefinition) sdk.GraphQLField {
+	args := make(map[string]interface{}, len(op.args))
+	for _, arg := range op.args {
+		def := sdk.Arg(arg.typ.String(), arg.description)
+		if arg.deprecated != nil {
+			def = DeprecateArg(op.name, arg.name, def, *arg.deprecated)
+		}
+		args[arg.name] = def
+	}
+
+	var field sdk.GraphQLField
+	if def, ok := defs[op.typ.name]; ok {
+		field = sdk.ComplexObjectFieldWithArgs(op.description, def, args)
+	} else {
+		field = sdk.FieldWithArgs(op.typ.name, op.description, args)
+	}
+	if base, ok := field.Type.(sdk.GraphQLTypeDefinition); ok {
+		field.Type = wrapType(base, op.typ)
+	}
+	if op.deprecated != nil {
+		field.Description = fmt.Sprintf("%s (DEPRECATED: %s)", field.Description, *op.deprecated)
+	}
+	return field
+}
+
+func wrapType(base sdk.GraphQLTypeDefinition, ref sdlTypeRef) sdk.GraphQLTypeDefinition {
+	t := base
+	if ref.list {
+		if ref.itemNonNull {
+			item := t
+			t = sdk.GraphQLTypeDefinition{Kind: "non_null", OfType: &item}
+		}
+		item := t
+		t = sdk.GraphQLTypeDefinition{Kind: "list", OfType: &item}
+	}
+	if ref.nonNull {
+		inner := t
+		t = sdk.GraphQLTypeDefinition{Kind: "non_null", OfType: &inner}
+	}
+	return t
+}
+
+func uniqueFields(fields []sdlField) error {
+	seen := make(map[string]sdlPos)
+	for _, field := range fields {
+		if prev, ok := seen[field.name]; ok {
+			return field.pos.errorf("field %s is already defined at %s", field.name, prev)
+		}
+		seen[field.name] = field.pos
+	}
+	return nil
+}
+
+// =====================================================
+// PARSER
+// =====================================================
+
+type sdlParser struct {
+	tokens []sdlToken
+	next   int
+}
+
+func (p *sdlParser) peek() sdlToken { return p.tokens[p.next] }
+
+func (p *sdlParser) advance() sdlToken {
+	tok := p.tokens[p.next]
+	if tok.kind != sdlEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *sdlParser) acceptName(name string) bool {
+	if tok := p.peek(); tok.kind == sdlName && tok.text == name {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *sdlParser) accept(punct string) bool {
+	if tok := p.peek(); tok.kind == sdlPunct && tok.text == punct {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *sdlParser) expect(punct string) error {
+	if !p.accept(punct) {
+		tok := p.peek()
+		return tok.pos.errorf("expected %q, found %s", punct, tok)
+	}
+	return nil
+}
+
+func (p *sdlParser) expectName() (sdlToken, error) {
+	tok := p.advance()
+	if tok.kind != sdlName {
+		return tok, tok.pos.errorf("expected a name, found %s", tok)
+	}
+	return tok, nil
+}
+
+// description consumes an optional description string
+func (p *sdlParser) description() string {
+	if tok := p.peek(); tok.kind == sdlString {
+		p.next++
+		return tok.text
+	}
+	return ""
+}
+
+// fields parses "{ field... }"
+func (p *sdlParser) fields() ([]sdlField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []sdlField
+	for !p.accept("}") {
+		description := p.description()
+		nameTok, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		field := sdlField{pos: nameTok.pos, name: nameTok.text, description: description}
+		if p.accept("(") {
+			if field.args, err = p.args(field.name); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if field.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if field.deprecated, err = p.directives(); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, p.tokens[p.next-1].pos.errorf("type has no fields")
+	}
+	return fields, nil
+}
+
+// args parses "arg: Type ...)" after the opening parenthesis
+func (p *sdlParser) args(fieldName string) ([]sdlArg, error) {
+	var args []sdlArg
+	seen := make(map[string]bool)
+	for !p.accept(")") {
+		description := p.description()
+		nameTok, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if seen[nameTok.text] {
+			return nil, nameTok.pos.errorf("argument %s(%s) is already defined", fieldName, nameTok.text)
+		}
+		seen[nameTok.text] = true
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		arg := sdlArg{pos: nameTok.pos, name: nameTok.text, description: description}
+		typePos := p.peek().pos
+		if arg.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if !sdlScalars[arg.typ.name] {
+			return nil, typePos.errorf("argument %s(%s) has type %s; only scalar arguments are supported", fieldName, arg.name, arg.typ)
+		}
+		if tok := p.peek(); tok.kind == sdlPunct && tok.text == "=" {
+			return nil, tok.pos.errorf("default values are not supported")
+		}
+		if arg.deprecated, err = p.directives(); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// typeRef parses "Name", "Name!", "[Name]" and their combinations
+func (p *sdlParser) typeRef() (sdlTypeRef, error) {
+	var ref sdlTypeRef
+	if p.accept("[") {
+		ref.list = true
+		if tok := p.peek(); tok.kind == sdlPunct && tok.text == "[" {
+			return ref, tok.pos.errorf("nested lists are not supported")
+		}
+		nameTok, err := p.expectName()
+		if err != nil {
+			return ref, err
+		}
+		ref.name = nameTok.text
+		ref.itemNonNull = p.accept("!")
+		if err := p.expect("]"); err != nil {
+			return ref, err
+		}
+	} else {
+		nameTok, err := p.expectName()
+		if err != nil {
+			return ref, err
+		}
+		ref.name = nameTok.text
+	}
+	ref.nonNull = p.accept("!")
+	return ref, nil
+}
+
+// directives parses trailing directives, of which only @deprecated is
+// supported; it returns the deprecation reason, if any
+func (p *sdlParser) directives() (*string, error) {
+	var reason *string
+	for p.peek().kind == sdlPunct && p.peek().text == "@" {
+		p.next++
+		nameTok, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if nameTok.text != "deprecated" {
+			return nil, nameTok.pos.errorf("unsupported directive @%s", nameTok.text)
+		}
+		r := defaultDeprecationReason
+		if p.accept("(") {
+			if !p.acceptName("reason") {
+				tok := p.peek()
+				return nil, tok.pos.errorf("expected reason, found %s", tok)
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			tok := p.advance()
+			if tok.kind != sdlString {
+				return nil, tok.pos.errorf("deprecation reason must be a string, found %s", tok)
+			}
+			r = tok.text
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		reason = &r
+	}
+	return reason, nil
+}
+
+// =====================================================
+// LEXER
+// =====================================================
+
+type sdlTokenKind int
+
+const (
+	sdlEOF sdlTokenKind = iota
+	sdlName
+	sdlString
+	sdlNumber
+	sdlPunct
+)
+
+type sdlPos struct{ line, col int }
+
+func (p sdlPos) String() string { return fmt.Sprintf("%d:%d", p.line, p.col) }
+
+// errorf prefixes the message with the position, as the Go compiler does
+func (p sdlPos) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", p, fmt.Sprintf(format, args...))
+}
+
+type sdlToken struct {
+	kind sdlTokenKind
+	text string
+	pos  sdlPos
+}
+
+func (t sdlToken) String() string {
+	switch t.kind {
+	case sdlEOF:
+		return "end of input"
+	case sdlString:
+		return "a string"
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lexSDL splits source into tokens. Commas are insignificant in GraphQL,
+// so they are skipped along with whitespace and # comments.
+func lexSDL(source string) ([]sdlToken, error) {
+	source = strings.TrimPrefix(source, "\ufeff")
+	var tokens []sdlToken
+	line, col := 1, 1
+	i := 0
+	advance := func(n int) {
+		for _, r := range source[i : i+n] {
+			if r == '\n' {
+				line, col = line+1, 1
+			} else {
+				col++
+			}
+		}
+		i += n
+	}
+
+	for i < len(source) {
+		c := source[i]
+		pos := sdlPos{line, col}
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',':
+			advance(1)
+		case c == '#':
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			advance(end)
+		case strings.HasPrefix(source[i:], `"""`):
+			end := blockStringEnd(source[i+3:])
+			if end < 0 {
+				return nil, pos.errorf("unterminated block string")
+			}
+			tokens = append(tokens, sdlToken{kind: sdlString, text: blockString(source[i+3 : i+3+end]), pos: pos})
+			advance(end + 6)
+		case c == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' && source[end] != '\n' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) || source[end] != '"' {
+				return nil, pos.errorf("unterminated string")
+			}
+			var text string
+			if err := json.Unmarshal([]byte(source[i:end+1]), &text); err != nil {
+				return nil, pos.errorf("invalid string: %v", err)
+			}
+			tokens = append(tokens, sdlToken{kind: sdlString, text: text, pos: pos})
+			advance(end + 1 - i)
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := i + 1
+			for end < len(source) && (source[end] == '_' || source[end] >= 'a' && source[end] <= 'z' || source[end] >= 'A' && source[end] <= 'Z' || source[end] >= '0' && source[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, sdlToken{kind: sdlName, text: source[i:end], pos: pos})
+			advance(end - i)
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(source) && strings.IndexByte("0123456789.eE+-", source[end]) >= 0 {
+				end++
+			}
+			tokens = append(tokens, sdlToken{kind: sdlNumber, text: source[i:end], pos: pos})
+			advance(end - i)
+		case strings.IndexByte("{}()[]:!@=", c) >= 0:
+			tokens = append(tokens, sdlToken{kind: sdlPunct, text: string(c), pos: pos})
+			advance(1)
+		default:
+			return nil, pos.errorf("unexpected character %q", source[i])
+		}
+	}
+	return append(tokens, sdlToken{kind: sdlEOF, pos: sdlPos{line, col}}), nil
+}
+
+// blockStringEnd is the index of the closing triple quote in body, skipping
+// escaped ones, or -1
+func blockStringEnd(body string) int {
+	for i := 0; i+3 <= len(body); i++ {
+		if strings.HasPrefix(body[i:], `\"""`) {
+			i += 3
+			continue
+		}
+		if strings.HasPrefix(body[i:], `"""`) {
+			return i
+		}
+	}
+	return -1
+}
+
+// blockString removes a block string's common indentation and its leading
+// and trailing blank lines, as GraphQL does
+func blockString(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.ReplaceAll(strings.Join(lines, "\n"), `\"""`, `"""`)
+}
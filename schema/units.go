@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+)
+
+// DurationArg declares a duration argument. The host has no duration
+// scalar, so it is a String in the formats units.ParseDuration accepts;
+// read it with validate.Duration.
+func DurationArg(description string) map[string]interface{} {
+	return sdk.Arg("String", description+` - a duration such as "30s", "5m" or "1d12h"`)
+}
+
+// ByteSizeArg declares a byte size argument, a String in the formats
+// units.ParseByteSize accepts; read it with validate.ByteSize
+func ByteSizeArg(description string) map[string]interface{} {
+	return sdk.Arg("String", description+` - a size such as "512KB" or "10MiB"`)
+}
+
+// NonNull makes an argument definition such as DurationArg's required
+func NonNull(arg map[string]interface{}) map[string]interface{} {
+	if typ, ok := arg["type"].(string); ok && !strings.HasSuffix(typ, "!") {
+		arg["type"] = typ + "!"
+	}
+	return arg
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hc-hello-world-plugin/config"
+	"hc-hello-world-plugin/schema"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, or rewrites the file with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run go test -run %s -update to create it", err, t.Name())
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s is out of date; review the change and run go test -run %s -update\n\ngot:\n%s", path, t.Name(), got)
+	}
+}
+
+// TestSchemaGolden prints the schema every resolver registers, so a
+// changed signature shows up as a diff of testdata/schema.graphql
+func TestSchemaGolden(t *testing.T) {
+	// Registrations depend on the environment, groups and prefix
+	t.Setenv("PLUGIN_CONFIG_FILE", "")
+	t.Setenv("PLUGIN_ENV", "development")
+	t.Setenv("PLUGIN_GROUPS_ENABLED", "")
+	t.Setenv("PLUGIN_GROUPS_DISABLED", "")
+	t.Setenv("PLUGIN_NAME_PREFIX", "")
+	t.Setenv("PLUGIN_DATASOURCE", "memory")
+
+	cfg := config.Load()
+	a := newApp(cfg)
+	defer a.Store.Close()
+	plugin := newPlugin(cfg, a)
+	if err := plugin.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "schema.graphql", []byte(schema.PrintSDL(plugin)))
+}
@@ -0,0 +1,164 @@
+// Package selection reads the GraphQL selection set passed by the host in
+// the resolver context, so resolvers can skip building fields the client
+// did not request.
+package selection
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// ContextKey is the host context key holding the selection set
+const ContextKey = "selectionSet"
+
+// Set is a tree of selected field names. A nil Set means the selection is
+// unknown, in which case every field is treated as selected.
+type Set map[string]Set
+
+// FromContext parses the selection set from ctx. The host may send it as a
+// GraphQL selection string ("id name address { city }"), a list of field
+// names or {name, selections} objects, or a map keyed by field name.
+func FromContext(ctx context.Context) Set {
+	return Parse(ctx.Value(ContextKey))
+}
+
+// Parse converts any supported selection representation into a Set
+func Parse(raw interface{}) Set {
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case Set:
+		return v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		set, _ := parseString(tokenize(v), 0)
+		return set
+	case []string:
+		set := make(Set, len(v))
+		for _, name := range v {
+			set[name] = nil
+		}
+		return set
+	case []interface{}:
+		set := make(Set, len(v))
+		for _, item := range v {
+			switch field := item.(type) {
+			case string:
+				set[field] = nil
+			case map[string]interface{}:
+				if name, ok := field["name"].(string); ok {
+					set[name] = Parse(field["selections"])
+				}
+			}
+		}
+		return set
+	case map[string]interface{}:
+		set := make(Set, len(v))
+		for name, sub := range v {
+			switch sub.(type) {
+			case bool:
+				set[name] = nil
+			default:
+				set[name] = Parse(sub)
+			}
+		}
+		return set
+	default:
+		return nil
+	}
+}
+
+// Has reports whether field was selected; always true for an unknown selection
+func (s Set) Has(field string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[field]
+	return ok
+}
+
+// Sub returns the nested selection for field (nil when unknown)
+func (s Set) Sub(field string) Set {
+	if s == nil {
+		return nil
+	}
+	return s[field]
+}
+
+// tokenize splits a GraphQL selection string into names and braces,
+// dropping arguments, directives and commas
+func tokenize(src string) []string {
+	var tokens []string
+	depth := 0 // parenthesis depth, used to skip arguments
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range src {
+		switch {
+		case r == '(':
+			flush()
+			depth++
+		case r == ')':
+			depth--
+		case depth > 0:
+			continue
+		case r == '{' || r == '}' || r == ':':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@':
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parseString builds a Set from tokens starting at i and returns it with the
+// index after the closing brace
+func parseString(tokens []string, i int) (Set, int) {
+	set := make(Set)
+	last := ""
+	for i < len(tokens) {
+		tok := tokens[i]
+		switch {
+		case tok == "{":
+			if last == "" {
+				// Outer braces around the whole selection
+				sub, next := parseString(tokens, i+1)
+				for k, v := range sub {
+					set[k] = v
+				}
+				i = next
+				continue
+			}
+			sub, next := parseString(tokens, i+1)
+			set[last] = sub
+			i = next
+			continue
+		case tok == "}":
+			return set, i + 1
+		case tok == ":":
+			// "alias: field" - the real field name follows, drop the alias
+			delete(set, last)
+			last = ""
+		case strings.HasPrefix(tok, "@"):
+			// Directive names are not fields
+		default:
+			last = tok
+			set[tok] = nil
+		}
+		i++
+	}
+	return set, i
+}
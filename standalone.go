@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"hc-hello-world-plugin/playground"
+	"hc-hello-world-plugin/registry"
+)
+
+// standaloneAddr serves GraphQL over HTTP with a playground instead of
+// connecting to a host
+var standaloneAddr = flag.String("standalone", "", "serve the GraphQL operations and a GraphiQL playground on this address (e.g. localhost:4000) instead of connecting to a host")
+
+// serveStandalone serves plugin's operations on addr until interrupted
+func serveStandalone(addr string, plugin *registry.Plugin) {
+	if err := plugin.Validate(); err != nil {
+		log.Fatalf("❌ [hc-hello-world-plugin] Invalid registrations, not serving: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           playground.Handler(pluginName, plugin),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("🛝 [hc-hello-world-plugin] event=standalone_serving playground=http://%s/ endpoint=http://%s%s", addr, addr, playground.Endpoint)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("❌ [hc-hello-world-plugin] Standalone server failed: %v", err)
+	}
+	log.Printf("👋 [hc-hello-world-plugin] event=standalone_stopped")
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetainedChanges bounds the change log; older changes are dropped and
+// cursors pointing before them expire
+const maxRetainedChanges = 10000
+
+// ErrCursorExpired is returned when a change cursor predates the retained
+// history; the client must resync from scratch
+var ErrCursorExpired = errors.New("change cursor has expired")
+
+// Entities recorded in the change log
+const (
+	EntityUser       = "user"
+	EntityProduct    = "product"
+	EntityGroup      = "group"
+	EntityMembership = "membership"
+	EntityComment    = "comment"
+	EntityConsent    = "consent" // IDs are "userID:purpose"
+)
+
+// Operations recorded in the change log
+const (
+	OpCreated = "created"
+	OpUpdated = "updated"
+	OpDeleted = "deleted"
+)
+
+// Change is one entry in the store's change log. Seq increases by one with
+// every change and is never reused, even across restores.
+type Change struct {
+	Seq    uint64
+	Entity string
+	// ID is the record ID; memberships use "userID:groupID"
+	ID string
+	Op string
+	At time.Time
+}
+
+// recordLocked appends a change; the caller must hold the write lock
+func (s *Store) recordLocked(entity, id, op string) {
+	s.changeSeq++
+	change := Change{Seq: s.changeSeq, Entity: entity, ID: id, Op: op, At: s.clock.Now().UTC()}
+	s.changes = append(s.changes, change)
+	s.notifyLocked()
+	if s.onChange != nil {
+		s.onChange(change)
+	}
+	if len(s.changes) > maxRetainedChanges {
+		// Drop the oldest half at once so trimming is amortized
+		dropped := len(s.changes) - maxRetainedChanges/2
+		s.changeFloor = s.changes[dropped-1].Seq
+		s.changes = append([]Change(nil), s.changes[dropped:]...)
+	}
+}
+
+// OnChange registers fn to be called with every change as it is recorded,
+// before the mutation returns, so derived state such as caches never lags
+// behind the store. fn runs under the store's write lock and must not call
+// back into the store. Restores and resets record no changes.
+func (s *Store) OnChange(fn func(Change)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// ChangesSince returns up to limit changes after the cursor seq (0 means
+// from the start of the retained history) and the cursor to pass next
+// time. more reports whether further changes are already available.
+func (s *Store) ChangesSince(seq uint64, limit int) (changes []Change, next uint64, more bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if seq < s.changeFloor {
+		return nil, 0, false, ErrCursorExpired
+	}
+	if seq > s.changeSeq {
+		// A cursor from the future cannot have come from this store
+		return nil, 0, false, ErrCursorExpired
+	}
+
+	// Seqs in the log are contiguous, so the position is a subtraction
+	start := 0
+	if len(s.changes) > 0 {
+		start = int(seq + 1 - s.changes[0].Seq)
+	}
+	end := min(start+limit, len(s.changes))
+	changes = append([]Change(nil), s.changes[start:end]...)
+
+	next = seq
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].Seq
+	}
+	return changes, next, end < len(s.changes), nil
+}
+
+// notifyLocked wakes every WaitForChange caller; the caller must hold the
+// write lock
+func (s *Store) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// WaitForChange blocks until a change after seq is recorded, returning nil,
+// or until ctx is done, returning its error. It returns ErrCursorExpired if
+// seq stops being servable, e.g. because a snapshot was restored.
+func (s *Store) WaitForChange(ctx context.Context, seq uint64) error {
+	for {
+		s.mu.RLock()
+		latest, floor, changed := s.changeSeq, s.changeFloor, s.changed
+		s.mu.RUnlock()
+
+		if seq < floor || seq > latest {
+			return ErrCursorExpired
+		}
+		if latest > seq {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ChangeEpoch identifies this store instance's change history. Seqs are only
+// meaningful within one epoch; a restarted plugin starts a new one.
+func (s *Store) ChangeEpoch() string {
+	return s.changeEpoch
+}
+
+// ChangeCursor turns a change seq into an opaque cursor tied to the
+// store's change epoch
+func (s *Store) ChangeCursor(seq uint64) string {
+	return base64.StdEncoding.EncodeToString([]byte("changes:" + s.ChangeEpoch() + ":" + strconv.FormatUint(seq, 10)))
+}
+
+// ParseChangeCursor reverses ChangeCursor; an empty cursor is the start of
+// the history. Cursors from another epoch (e.g. before a restart) report
+// ErrCursorExpired.
+func (s *Store) ParseChangeCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	value, ok := strings.CutPrefix(string(raw), "changes:")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	epoch, value, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	if epoch != s.ChangeEpoch() {
+		return 0, ErrCursorExpired
+	}
+	return seq, nil
+}
+
+// LatestChange returns the seq of the most recent change
+func (s *Store) LatestChange() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changeSeq
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// Comment is a comment on a post. Replies point at their parent through
+// ParentID, so a post's comments form a tree; top-level comments have an
+// empty ParentID.
+type Comment struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"postId"`
+	ParentID  string    `json:"parentId,omitempty"`
+	AuthorID  string    `json:"authorId"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddComment inserts c. The author must exist and a reply's parent must be
+// a comment on the same post, which also rules out cycles.
+func (s *Store) AddComment(c Comment) (Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.comments[c.ID]; exists {
+		return Comment{}, &ConflictError{Field: "id", Value: c.ID}
+	}
+	if _, ok := s.users[c.AuthorID]; !ok {
+		return Comment{}, fmt.Errorf("user %s: %w", c.AuthorID, ErrNotFound)
+	}
+	if c.ParentID != "" {
+		parent, ok := s.comments[c.ParentID]
+		if !ok || parent.PostID != c.PostID {
+			return Comment{}, fmt.Errorf("comment %s on post %s: %w", c.ParentID, c.PostID, ErrNotFound)
+		}
+	}
+
+	s.comments[c.ID] = c
+	s.postComments[c.PostID] = append(s.postComments[c.PostID], c.ID)
+	s.recordLocked(EntityComment, c.ID, OpCreated)
+	return c, nil
+}
+
+// GetComment returns the comment with id
+func (s *Store) GetComment(id string) (Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return Comment{}, fmt.Errorf("comment %s: %w", id, ErrNotFound)
+	}
+	return c, nil
+}
+
+// CommentsForPost returns every comment on a post in insertion order, so a
+// parent always precedes its replies
+func (s *Store) CommentsForPost(postID string) []Comment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.postComments[postID]
+	result := make([]Comment, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, s.comments[id])
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Consent records a user's decision about one purpose their data may be
+// processed for. Revoking keeps the record with Granted false, so the
+// store tells a revoked consent from one never asked for.
+type Consent struct {
+	UserID  string `json:"userId"`
+	Purpose string `json:"purpose"`
+	Granted bool   `json:"granted"`
+	// Source says where the decision was made, e.g. "signup form"
+	Source    string    `json:"source,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetConsent records c, replacing the user's earlier decision for the same
+// purpose. The user must exist.
+func (s *Store) SetConsent(c Consent) (Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[c.UserID]; !ok {
+		return Consent{}, fmt.Errorf("user %s: %w", c.UserID, ErrNotFound)
+	}
+	op := OpUpdated
+	if _, exists := s.consents[c.UserID][c.Purpose]; !exists {
+		op = OpCreated
+	}
+	if s.consents[c.UserID] == nil {
+		s.consents[c.UserID] = make(map[string]Consent)
+	}
+	s.consents[c.UserID][c.Purpose] = c
+	s.recordLocked(EntityConsent, c.UserID+":"+c.Purpose, op)
+	return c, nil
+}
+
+// ConsentsOfUser returns a user's recorded decisions ordered by purpose
+func (s *Store) ConsentsOfUser(userID string) []Consent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Consent, 0, len(s.consents[userID]))
+	for _, c := range s.consents[userID] {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Purpose < result[j].Purpose })
+	return result
+}
+
+// HasConsent reports whether a user has granted purpose and not revoked it
+func (s *Store) HasConsent(userID, purpose string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.consents[userID][purpose].Granted
+}
+
+// deleteConsentsLocked removes every decision of a user; the caller must
+// hold the write lock
+func (s *Store) deleteConsentsLocked(userID string) {
+	purposes := make([]string, 0, len(s.consents[userID]))
+	for purpose := range s.consents[userID] {
+		purposes = append(purposes, purpose)
+	}
+	sort.Strings(purposes)
+	for _, purpose := range purposes {
+		s.recordLocked(EntityConsent, userID+":"+purpose, OpDeleted)
+	}
+	delete(s.consents, userID)
+}
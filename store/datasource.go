@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hc-hello-world-plugin/atrest"
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/dbpool"
+	"hc-hello-world-plugin/geo"
+)
+
+// Datasource kinds accepted by Open
+const (
+	DatasourceMemory   = "memory"
+	DatasourceSQLite   = "sqlite"
+	DatasourcePostgres = "postgres"
+)
+
+// Datasources lists every kind Open accepts, in the order the conformance
+// runner tries them
+var Datasources = []string{DatasourceMemory, DatasourceSQLite, DatasourcePostgres}
+
+// Datasource is everything the plugin does with its data. *Store is the
+// in-memory implementation; *SQLStore persists a snapshot of it to SQLite
+// or PostgreSQL. storetest.Run checks that implementations behave alike.
+type Datasource interface {
+	CreateUser(u User) (User, error)
+	UpdateUser(id string, update func(u *User)) (User, error)
+	TryUpdateUser(id string, update func(u *User) error) (User, error)
+	DeleteUser(id string) error
+	GetUser(id string) (User, error)
+	ListUsers(match func(User) bool) []User
+	ScanUsers(from, limit int, match func(User) bool) ([]User, int, bool)
+	EachUserChunk(ctx context.Context, size int, match func(User) bool, fn func([]User) error) error
+	CountUsers() int
+	UsersNear(center geo.Point, radiusKm float64, match func(User) bool) []NearbyUser
+
+	PutProduct(p Product)
+	GetProduct(id string) (Product, error)
+	ListProducts(match func(Product) bool) []Product
+	CountProducts() int
+	Begin() *Tx
+	WithTx(fn func(tx *Tx) error) error
+
+	CreateGroup(g Group) (Group, error)
+	GetGroup(id string) (Group, error)
+	ListGroups() []Group
+	AddUserToGroup(userID, groupID string) (bool, error)
+	RemoveUserFromGroup(userID, groupID string) (bool, error)
+	GroupsOfUser(userID string) []Group
+	UsersInGroup(groupID string) ([]User, error)
+	CountMembers(groupID string) int
+
+	AddComment(c Comment) (Comment, error)
+	GetComment(id string) (Comment, error)
+	CommentsForPost(postID string) []Comment
+
+	SetConsent(c Consent) (Consent, error)
+	ConsentsOfUser(userID string) []Consent
+	HasConsent(userID, purpose string) bool
+
+	ConsumeQuota(tenant string, at time.Time, limit int) (QuotaUsage, bool)
+	QuotaUsage(tenant string, at time.Time) QuotaUsage
+	AddUsageRollups(rollups []UsageRollup)
+	UsageRollups(since time.Time) []UsageRollup
+
+	OnChange(fn func(Change))
+	ChangesSince(seq uint64, limit int) (changes []Change, next uint64, more bool, err error)
+	WaitForChange(ctx context.Context, seq uint64) error
+	ChangeEpoch() string
+	ChangeCursor(seq uint64) string
+	ParseChangeCursor(cursor string) (uint64, error)
+	LatestChange() uint64
+
+	Snapshot() Snapshot
+	Restore(snap Snapshot) error
+	Counts() Counts
+	Reset()
+
+	// Close flushes pending writes and releases the datasource
+	Close() error
+}
+
+var _ Datasource = (*Store)(nil)
+
+// DatasourceConfig selects and configures the datasource Open returns
+type DatasourceConfig struct {
+	// Kind is one of Datasources; empty means memory
+	Kind string
+	// DSN is passed to the SQL driver; memory ignores it
+	DSN string
+	// FlushInterval is how often SQL datasources write pending changes
+	FlushInterval time.Duration
+	// Sealer encrypts the snapshot row SQL datasources write; nil stores it
+	// as plain JSON
+	Sealer *atrest.Sealer
+	// Fields encrypts users' personal fields; see Store.WithFieldSealer
+	Fields FieldSealer
+	Clock  clock.Clock
+	// Pool sizes the SQL datasources' connection pool and bounds each
+	// statement they run
+	Pool dbpool.Config
+}
+
+// Open returns the datasource cfg.Kind names. SQL kinds fail here, rather
+// than on the first query, when their driver is not built in or the
+// database cannot be reached.
+func Open(cfg DatasourceConfig) (Datasource, error) {
+	switch cfg.Kind {
+	case "", DatasourceMemory:
+		return New().WithClock(cfg.Clock).WithFieldSealer(cfg.Fields), nil
+	case DatasourceSQLite, DatasourcePostgres:
+		return OpenSQL(cfg)
+	default:
+		return nil, fmt.Errorf("unknown datasource %q (expected one of %v)", cfg.Kind, Datasources)
+	}
+}
+
+// Close does nothing; the memory store holds no resources
+func (s *Store) Close() error {
+	return nil
+}
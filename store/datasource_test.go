@@ -0,0 +1,51 @@
+package store_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hc-hello-world-plugin/store"
+	"hc-hello-world-plugin/store/storetest"
+)
+
+// TestDatasourceConformance runs the storetest cases against every built-in
+// datasource. The SQL ones need their driver built in, with -tags sqlite
+// or -tags postgres; PostgreSQL also needs a database to overwrite, named
+// by PLUGIN_DATASOURCE_TEST_POSTGRES_DSN.
+func TestDatasourceConformance(t *testing.T) {
+	dsns := map[string]string{
+		store.DatasourceSQLite:   filepath.Join(t.TempDir(), "store.db"),
+		store.DatasourcePostgres: os.Getenv("PLUGIN_DATASOURCE_TEST_POSTGRES_DSN"),
+	}
+	for _, kind := range store.Datasources {
+		t.Run(kind, func(t *testing.T) {
+			cfg := store.DatasourceConfig{Kind: kind, DSN: dsns[kind]}
+			open := func() (store.Datasource, error) { return store.Open(cfg) }
+
+			if kind != store.DatasourceMemory {
+				if cfg.DSN == "" {
+					t.Skip("no DSN given")
+				}
+				ds, err := open()
+				if errors.Is(err, store.ErrNoDriver) {
+					t.Skip(err)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				ds.Close()
+			}
+
+			if err := storetest.Run(open); err != nil {
+				t.Fatal(err)
+			}
+			if kind != store.DatasourceMemory {
+				if err := storetest.CheckPersistence(open); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+
+package store
+
+// pgx's database/sql adapter registers itself as "pgx"; it is required in
+// go.mod but only linked into builds with -tags postgres
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package store
+
+// The pure-Go SQLite driver registers itself as "sqlite"; it is required
+// in go.mod but only linked into builds with -tags sqlite
+import _ "modernc.org/sqlite"
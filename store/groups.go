@@ -0,0 +1,158 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Group is a named collection of users. Users and groups are related
+// many-to-many through memberships.
+type Group struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Membership links a user to a group
+type Membership struct {
+	UserID  string `json:"userId"`
+	GroupID string `json:"groupId"`
+}
+
+// CreateGroup inserts g; IDs and names must be unique
+func (s *Store) CreateGroup(g Group) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.groups[g.ID]; exists {
+		return Group{}, &ConflictError{Field: "id", Value: g.ID}
+	}
+	for _, existing := range s.groups {
+		if existing.Name == g.Name {
+			return Group{}, &ConflictError{Field: "name", Value: g.Name}
+		}
+	}
+
+	s.groups[g.ID] = g
+	s.groupOrder = append(s.groupOrder, g.ID)
+	s.recordLocked(EntityGroup, g.ID, OpCreated)
+	return g, nil
+}
+
+// GetGroup returns the group with id
+func (s *Store) GetGroup(id string) (Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return Group{}, fmt.Errorf("group %s: %w", id, ErrNotFound)
+	}
+	return g, nil
+}
+
+// ListGroups returns all groups in insertion order
+func (s *Store) ListGroups() []Group {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Group, 0, len(s.groupOrder))
+	for _, id := range s.groupOrder {
+		result = append(result, s.groups[id])
+	}
+	return result
+}
+
+// AddUserToGroup records a membership. Adding an existing membership is a
+// no-op; it reports whether anything changed.
+func (s *Store) AddUserToGroup(userID, groupID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkMembershipLocked(userID, groupID); err != nil {
+		return false, err
+	}
+	if s.members[groupID][userID] {
+		return false, nil
+	}
+	if s.members[groupID] == nil {
+		s.members[groupID] = make(map[string]bool)
+	}
+	if s.memberOf[userID] == nil {
+		s.memberOf[userID] = make(map[string]bool)
+	}
+	s.members[groupID][userID] = true
+	s.memberOf[userID][groupID] = true
+	s.recordLocked(EntityMembership, userID+":"+groupID, OpCreated)
+	return true, nil
+}
+
+// RemoveUserFromGroup deletes a membership; it reports whether the user was
+// a member
+func (s *Store) RemoveUserFromGroup(userID, groupID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkMembershipLocked(userID, groupID); err != nil {
+		return false, err
+	}
+	if !s.members[groupID][userID] {
+		return false, nil
+	}
+	delete(s.members[groupID], userID)
+	delete(s.memberOf[userID], groupID)
+	s.recordLocked(EntityMembership, userID+":"+groupID, OpDeleted)
+	return true, nil
+}
+
+// checkMembershipLocked verifies both sides of a membership exist; the
+// caller must hold the lock
+func (s *Store) checkMembershipLocked(userID, groupID string) error {
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("user %s: %w", userID, ErrNotFound)
+	}
+	if _, ok := s.groups[groupID]; !ok {
+		return fmt.Errorf("group %s: %w", groupID, ErrNotFound)
+	}
+	return nil
+}
+
+// GroupsOfUser returns the groups a user belongs to, sorted by name
+func (s *Store) GroupsOfUser(userID string) []Group {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Group, 0, len(s.memberOf[userID]))
+	for groupID := range s.memberOf[userID] {
+		result = append(result, s.groups[groupID])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// UsersInGroup returns the members of a group in user insertion order
+func (s *Store) UsersInGroup(groupID string) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.groups[groupID]; !ok {
+		return nil, fmt.Errorf("group %s: %w", groupID, ErrNotFound)
+	}
+	members := s.members[groupID]
+	result := make([]User, 0, len(members))
+	for _, id := range s.userOrder {
+		if members[id] {
+			result = append(result, s.users[id].clone())
+		}
+	}
+	return result, nil
+}
+
+// CountMembers returns the number of users in a group
+func (s *Store) CountMembers(groupID string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.members[groupID])
+}
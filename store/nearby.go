@@ -0,0 +1,39 @@
+package store
+
+import (
+	"sort"
+
+	"hc-hello-world-plugin/geo"
+)
+
+// NearbyUser is a user found by UsersNear with their distance from the
+// search centre
+type NearbyUser struct {
+	User       User
+	DistanceKm float64
+}
+
+// UsersNear returns users whose address lies within radiusKm of center,
+// nearest first (ties keep insertion order). Users without coordinates are
+// skipped.
+// match, if non-nil, filters the candidates.
+func (s *Store) UsersNear(center geo.Point, radiusKm float64, match func(User) bool) []NearbyUser {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []NearbyUser
+	for _, id := range s.userOrder {
+		u := s.users[id]
+		location, ok := u.Address.Location()
+		if !ok || (match != nil && !match(u)) {
+			continue
+		}
+		if distance := geo.DistanceKm(center, location); distance <= radiusKm {
+			result = append(result, NearbyUser{User: u.clone(), DistanceKm: distance})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DistanceKm < result[j].DistanceKm
+	})
+	return result
+}
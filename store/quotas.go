@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxQuotaMonths is how many months of quota usage are kept, the current
+// one included
+const maxQuotaMonths = 13
+
+// QuotaMonthLayout formats the month a quota counter belongs to
+const QuotaMonthLayout = "2006-01"
+
+// QuotaUsage is a tenant's call count for one calendar month (UTC)
+type QuotaUsage struct {
+	Tenant string `json:"tenant"`
+	// Month is formatted as QuotaMonthLayout
+	Month string `json:"month"`
+	Calls int    `json:"calls"`
+}
+
+// quotaKey identifies a quota counter
+type quotaKey struct {
+	tenant string
+	month  string
+}
+
+// QuotaMonth returns the month t falls in, formatted as QuotaMonthLayout
+func QuotaMonth(t time.Time) string {
+	return t.UTC().Format(QuotaMonthLayout)
+}
+
+// ConsumeQuota counts one call by tenant in the month of at, unless the
+// tenant has already made limit calls that month; limit <= 0 is unlimited.
+// It returns the usage after the call and whether the call was counted.
+func (s *Store) ConsumeQuota(tenant string, at time.Time, limit int) (QuotaUsage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := quotaKey{tenant: tenant, month: QuotaMonth(at)}
+	usage, ok := s.quotas[key]
+	if !ok {
+		usage = QuotaUsage{Tenant: tenant, Month: key.month}
+		s.pruneQuotasLocked(at)
+	}
+	if limit > 0 && usage.Calls >= limit {
+		return usage, false
+	}
+	usage.Calls++
+	s.quotas[key] = usage
+	return usage, true
+}
+
+// QuotaUsage returns tenant's usage in the month of at
+func (s *Store) QuotaUsage(tenant string, at time.Time) QuotaUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := quotaKey{tenant: tenant, month: QuotaMonth(at)}
+	if usage, ok := s.quotas[key]; ok {
+		return usage
+	}
+	return QuotaUsage{Tenant: tenant, Month: key.month}
+}
+
+// pruneQuotasLocked drops counters for months past the retention window;
+// called when a month's first counter is created
+func (s *Store) pruneQuotasLocked(at time.Time) {
+	year, month, _ := at.UTC().Date()
+	cutoff := QuotaMonth(time.Date(year, month-maxQuotaMonths+1, 1, 0, 0, 0, 0, time.UTC))
+	for key := range s.quotas {
+		// QuotaMonthLayout sorts chronologically as text
+		if key.month < cutoff {
+			delete(s.quotas, key)
+		}
+	}
+}
+
+func sortQuotas(quotas []QuotaUsage) {
+	sort.Slice(quotas, func(i, j int) bool {
+		if quotas[i].Month != quotas[j].Month {
+			return quotas[i].Month < quotas[j].Month
+		}
+		return quotas[i].Tenant < quotas[j].Tenant
+	})
+}
+
+// validateQuota checks a quota counter loaded from a snapshot
+func validateQuota(q QuotaUsage) error {
+	if _, err := time.Parse(QuotaMonthLayout, q.Month); err != nil {
+		return fmt.Errorf("quota usage for tenant %q has invalid month %q", q.Tenant, q.Month)
+	}
+	if q.Calls < 0 {
+		return fmt.Errorf("quota usage for tenant %q in %s is negative", q.Tenant, q.Month)
+	}
+	return nil
+}
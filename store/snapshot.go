@@ -0,0 +1,319 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"hc-hello-world-plugin/atrest"
+	"hc-hello-world-plugin/money"
+)
+
+// SnapshotVersion is the current snapshot file format version
+const SnapshotVersion = 1
+
+// Snapshot is a point-in-time copy of the store's contents
+type Snapshot struct {
+	Version  int       `json:"version"`
+	TakenAt  time.Time `json:"takenAt"`
+	Users    []User    `json:"users"`
+	Products []Product `json:"products"`
+	// Groups, Memberships, Comments, Consents, Usage and Quotas are absent
+	// from older snapshots
+	Groups      []Group       `json:"groups,omitempty"`
+	Memberships []Membership  `json:"memberships,omitempty"`
+	Comments    []Comment     `json:"comments,omitempty"`
+	Consents    []Consent     `json:"consents,omitempty"`
+	Usage       []UsageRollup `json:"usage,omitempty"`
+	Quotas      []QuotaUsage  `json:"quotas,omitempty"`
+}
+
+// Snapshot copies every record, in insertion order, under a single read lock
+// so the result is consistent
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		Version:  SnapshotVersion,
+		TakenAt:  s.clock.Now().UTC(),
+		Users:    make([]User, 0, len(s.userOrder)),
+		Products: make([]Product, 0, len(s.productOrder)),
+	}
+	for _, id := range s.userOrder {
+		snap.Users = append(snap.Users, s.users[id].clone())
+	}
+	for _, id := range s.productOrder {
+		snap.Products = append(snap.Products, s.products[id].clone())
+	}
+	for _, groupID := range s.groupOrder {
+		snap.Groups = append(snap.Groups, s.groups[groupID])
+		// Memberships follow user insertion order so snapshots are stable
+		for _, userID := range s.userOrder {
+			if s.members[groupID][userID] {
+				snap.Memberships = append(snap.Memberships, Membership{UserID: userID, GroupID: groupID})
+			}
+		}
+	}
+	for _, ids := range s.postComments {
+		for _, id := range ids {
+			snap.Comments = append(snap.Comments, s.comments[id])
+		}
+	}
+	// Map iteration order is random; sort posts so snapshots are stable while
+	// keeping each post's comments in insertion order
+	sort.SliceStable(snap.Comments, func(i, j int) bool { return snap.Comments[i].PostID < snap.Comments[j].PostID })
+	// Consents follow user insertion order, then purpose
+	for _, userID := range s.userOrder {
+		start := len(snap.Consents)
+		for _, c := range s.consents[userID] {
+			snap.Consents = append(snap.Consents, c)
+		}
+		added := snap.Consents[start:]
+		sort.Slice(added, func(i, j int) bool { return added[i].Purpose < added[j].Purpose })
+	}
+	for _, r := range s.usage {
+		snap.Usage = append(snap.Usage, r)
+	}
+	sortUsage(snap.Usage)
+	for _, q := range s.quotas {
+		snap.Quotas = append(snap.Quotas, q)
+	}
+	sortQuotas(snap.Quotas)
+	return snap
+}
+
+// Restore replaces the store's contents with snap. The snapshot is fully
+// validated first; on error the store is left untouched. A successful
+// restore expires all change cursors.
+func (s *Store) Restore(snap Snapshot) error {
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (expected %d)", snap.Version, SnapshotVersion)
+	}
+
+	users := make(map[string]User, len(snap.Users))
+	userOrder := make([]string, 0, len(snap.Users))
+	usernames := make(map[string]bool, len(snap.Users))
+	emails := make(map[string]bool, len(snap.Users))
+	for i, u := range snap.Users {
+		if u.ID == "" {
+			return fmt.Errorf("user %d has no id", i)
+		}
+		// Snapshots taken before fields were sealed are sealed as they load
+		s.sealFields(&u)
+		if _, dup := users[u.ID]; dup {
+			return &ConflictError{Field: "id", Value: u.ID}
+		}
+		if usernames[u.Username] {
+			return &ConflictError{Field: "username", Value: u.Username}
+		}
+		if emails[u.Email] {
+			return &ConflictError{Field: "email", Value: u.Email}
+		}
+		if location, ok := u.Address.Location(); ok {
+			if err := location.Validate(); err != nil {
+				return fmt.Errorf("user %s: %w", u.ID, err)
+			}
+		}
+		// Users from snapshots taken before versioning start at version 1
+		if u.Version == 0 {
+			u.Version, u.UpdatedAt = 1, u.CreatedAt
+		}
+		users[u.ID] = u.clone()
+		userOrder = append(userOrder, u.ID)
+		usernames[u.Username] = true
+		emails[u.Email] = true
+	}
+
+	products := make(map[string]Product, len(snap.Products))
+	productOrder := make([]string, 0, len(snap.Products))
+	for i, p := range snap.Products {
+		if p.ID == "" {
+			return fmt.Errorf("product %d has no id", i)
+		}
+		if _, dup := products[p.ID]; dup {
+			return fmt.Errorf("product %s: %w", p.ID, ErrConflict)
+		}
+		if err := money.ValidateCurrency(p.Price.Currency); err != nil {
+			return fmt.Errorf("product %s: %w", p.ID, err)
+		}
+		products[p.ID] = p.clone()
+		productOrder = append(productOrder, p.ID)
+	}
+
+	groups := make(map[string]Group, len(snap.Groups))
+	groupOrder := make([]string, 0, len(snap.Groups))
+	for i, g := range snap.Groups {
+		if g.ID == "" {
+			return fmt.Errorf("group %d has no id", i)
+		}
+		if _, dup := groups[g.ID]; dup {
+			return &ConflictError{Field: "id", Value: g.ID}
+		}
+		groups[g.ID] = g
+		groupOrder = append(groupOrder, g.ID)
+	}
+
+	members := make(map[string]map[string]bool)
+	memberOf := make(map[string]map[string]bool)
+	for _, m := range snap.Memberships {
+		if _, ok := users[m.UserID]; !ok {
+			return fmt.Errorf("membership references user %s: %w", m.UserID, ErrNotFound)
+		}
+		if _, ok := groups[m.GroupID]; !ok {
+			return fmt.Errorf("membership references group %s: %w", m.GroupID, ErrNotFound)
+		}
+		if members[m.GroupID] == nil {
+			members[m.GroupID] = make(map[string]bool)
+		}
+		if memberOf[m.UserID] == nil {
+			memberOf[m.UserID] = make(map[string]bool)
+		}
+		members[m.GroupID][m.UserID] = true
+		memberOf[m.UserID][m.GroupID] = true
+	}
+
+	comments := make(map[string]Comment, len(snap.Comments))
+	postComments := make(map[string][]string)
+	for i, c := range snap.Comments {
+		if c.ID == "" {
+			return fmt.Errorf("comment %d has no id", i)
+		}
+		if _, dup := comments[c.ID]; dup {
+			return &ConflictError{Field: "id", Value: c.ID}
+		}
+		if _, ok := users[c.AuthorID]; !ok {
+			return fmt.Errorf("comment %s references user %s: %w", c.ID, c.AuthorID, ErrNotFound)
+		}
+		// Parents must come first, exactly as AddComment requires
+		if c.ParentID != "" {
+			if parent, ok := comments[c.ParentID]; !ok || parent.PostID != c.PostID {
+				return fmt.Errorf("comment %s references parent %s: %w", c.ID, c.ParentID, ErrNotFound)
+			}
+		}
+		comments[c.ID] = c
+		postComments[c.PostID] = append(postComments[c.PostID], c.ID)
+	}
+
+	consents := make(map[string]map[string]Consent)
+	for _, c := range snap.Consents {
+		if _, ok := users[c.UserID]; !ok {
+			return fmt.Errorf("consent references user %s: %w", c.UserID, ErrNotFound)
+		}
+		if c.Purpose == "" {
+			return fmt.Errorf("consent of user %s has no purpose", c.UserID)
+		}
+		if _, dup := consents[c.UserID][c.Purpose]; dup {
+			return fmt.Errorf("duplicate consent of user %s for %s", c.UserID, c.Purpose)
+		}
+		if consents[c.UserID] == nil {
+			consents[c.UserID] = make(map[string]Consent)
+		}
+		consents[c.UserID][c.Purpose] = c
+	}
+
+	usage := make(map[usageKey]UsageRollup, len(snap.Usage))
+	for _, r := range snap.Usage {
+		if err := validateUsage(r); err != nil {
+			return err
+		}
+		r.Hour = r.Hour.UTC().Truncate(time.Hour)
+		key := usageKey{hour: r.Hour, resolver: r.Resolver}
+		if _, dup := usage[key]; dup {
+			return fmt.Errorf("duplicate usage rollup for %s at %s", r.Resolver, r.Hour.Format(time.RFC3339))
+		}
+		usage[key] = r
+	}
+
+	quotas := make(map[quotaKey]QuotaUsage, len(snap.Quotas))
+	for _, q := range snap.Quotas {
+		if err := validateQuota(q); err != nil {
+			return err
+		}
+		key := quotaKey{tenant: q.Tenant, month: q.Month}
+		if _, dup := quotas[key]; dup {
+			return fmt.Errorf("duplicate quota usage for tenant %q in %s", q.Tenant, q.Month)
+		}
+		quotas[key] = q
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users, s.userOrder = users, userOrder
+	s.products, s.productOrder = products, productOrder
+	s.groups, s.groupOrder = groups, groupOrder
+	s.members, s.memberOf = members, memberOf
+	s.comments, s.postComments = comments, postComments
+	s.consents = consents
+	s.usage = usage
+	s.quotas = quotas
+	// Changes before the restore no longer describe the data, so every
+	// outstanding cursor expires and clients resync. Skipping a seq expires
+	// even cursors that were fully caught up.
+	s.changeSeq++
+	s.changes, s.changeFloor = nil, s.changeSeq
+	s.notifyLocked()
+	return nil
+}
+
+// WriteSnapshotFile writes snap to path as JSON, encrypted by sealer unless
+// it is nil. The file is written to a temporary name and renamed so a crash
+// never leaves a truncated snapshot.
+func WriteSnapshotFile(path string, snap Snapshot, sealer *atrest.Sealer) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if data, err = sealer.Seal(data); err != nil {
+		return fmt.Errorf("failed to encrypt snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// CheckSnapshotDir verifies that snapshots can be written next to path by
+// creating and removing a temporary file there
+func CheckSnapshotDir(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.probe")
+	if err != nil {
+		return fmt.Errorf("snapshot directory not writable: %w", err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// ReadSnapshotFile reads a snapshot written by WriteSnapshotFile. An
+// unencrypted snapshot is read whether or not sealer is nil.
+func ReadSnapshotFile(path string, sealer *atrest.Sealer) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if data, err = sealer.Open(data); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return snap, nil
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/atrest"
+	"hc-hello-world-plugin/clock"
+)
+
+// ErrNoDriver is returned by OpenSQL when the database/sql driver for the
+// datasource was not compiled in; see the README for the build tags
+var ErrNoDriver = errors.New("database driver is not built in")
+
+// defaultFlushInterval is used when DatasourceConfig.FlushInterval is unset
+const defaultFlushInterval = time.Second
+
+// sqlDialect is how a SQL datasource reaches its database
+type sqlDialect struct {
+	// driver is the database/sql driver name, registered by the file built
+	// with the datasource's tag
+	driver string
+	// upsert writes the single snapshot row
+	upsert string
+}
+
+var sqlDialects = map[string]sqlDialect{
+	DatasourceSQLite: {
+		driver: "sqlite",
+		upsert: `INSERT INTO plugin_snapshot (id, version, data, saved_at) VALUES (1, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET version = excluded.version, data = excluded.data, saved_at = excluded.saved_at`,
+	},
+	DatasourcePostgres: {
+		driver: "pgx",
+		upsert: `INSERT INTO plugin_snapshot (id, version, data, saved_at) VALUES (1, $1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET version = excluded.version, data = excluded.data, saved_at = excluded.saved_at`,
+	},
+}
+
+const createSnapshotTable = `CREATE TABLE IF NOT EXISTS plugin_snapshot (
+	id INTEGER PRIMARY KEY,
+	version INTEGER NOT NULL,
+	data TEXT NOT NULL,
+	saved_at TEXT NOT NULL
+)`
+
+// SQLStore is a Datasource kept in SQLite or PostgreSQL. Reads and writes
+// are served by an embedded in-memory Store, so every query behaves exactly
+// as it does with the memory datasource; the contents are loaded when the
+// datasource opens and written back as a snapshot row at most once per
+// flush interval, and on Close. With DatasourceConfig.Sealer set the row
+// is encrypted.
+type SQLStore struct {
+	*Store
+	kind     string
+	db       *sql.DB
+	dialect  sqlDialect
+	sealer   *atrest.Sealer
+	clock    clock.Clock
+	interval time.Duration
+	timeout  time.Duration
+
+	// writeMu serializes flushes
+	writeMu sync.Mutex
+
+	// timerMu guards timer and closed; it is taken under the store's write
+	// lock, so nothing holding it may call into the store
+	timerMu sync.Mutex
+	timer   clock.Timer
+	closed  bool
+}
+
+// OpenSQL connects to the database cfg describes, creates the snapshot
+// table if needed and loads the last snapshot saved there
+func OpenSQL(cfg DatasourceConfig) (*SQLStore, error) {
+	dialect, ok := sqlDialects[cfg.Kind]
+	if !ok {
+		return nil, fmt.Errorf("datasource %q is not a SQL datasource", cfg.Kind)
+	}
+	if !slices.Contains(sql.Drivers(), dialect.driver) {
+		return nil, fmt.Errorf("datasource %s needs the %s driver (build with -tags %s): %w", cfg.Kind, dialect.driver, cfg.Kind, ErrNoDriver)
+	}
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("datasource %s needs a DSN", cfg.Kind)
+	}
+
+	db, err := sql.Open(dialect.driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("datasource %s: %w", cfg.Kind, err)
+	}
+	cfg.Pool.Apply(db)
+	s := &SQLStore{
+		kind:     cfg.Kind,
+		db:       db,
+		dialect:  dialect,
+		sealer:   cfg.Sealer,
+		clock:    clock.OrSystem(cfg.Clock),
+		interval: cfg.FlushInterval,
+		timeout:  cfg.Pool.Timeout(),
+	}
+	if s.interval <= 0 {
+		s.interval = defaultFlushInterval
+	}
+	s.Store = New().WithClock(s.clock).WithFieldSealer(cfg.Fields)
+	plaintext, err := s.load()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("datasource %s: %w", cfg.Kind, err)
+	}
+	s.OnChange(nil)
+	if plaintext && s.sealer.Enabled() {
+		log.Printf("🔐 [hc-hello-world-plugin] event=datasource_encrypting datasource=%s", s.kind)
+		s.markDirty()
+	}
+	return s, nil
+}
+
+// load creates the snapshot table and restores the snapshot it holds, if
+// any, reporting whether that snapshot was stored unencrypted
+func (s *SQLStore) load() (plaintext bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return false, err
+	}
+	if _, err := s.db.ExecContext(ctx, createSnapshotTable); err != nil {
+		return false, fmt.Errorf("creating snapshot table: %w", err)
+	}
+
+	var data string
+	err = s.db.QueryRowContext(ctx, `SELECT data FROM plugin_snapshot WHERE id = 1`).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading snapshot: %w", err)
+	}
+	plain, err := s.sealer.Open([]byte(data))
+	if err != nil {
+		return false, fmt.Errorf("decrypting snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(plain, &snap); err != nil {
+		return false, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return !atrest.IsSealed([]byte(data)), s.Store.Restore(snap)
+}
+
+// Kind returns the datasource kind, sqlite or postgres
+func (s *SQLStore) Kind() string {
+	return s.kind
+}
+
+// DBStats returns the connection pool's statistics
+func (s *SQLStore) DBStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// OnChange registers fn like Store.OnChange; every change also schedules a
+// flush
+func (s *SQLStore) OnChange(fn func(Change)) {
+	s.Store.OnChange(func(c Change) {
+		s.markDirty()
+		if fn != nil {
+			fn(c)
+		}
+	})
+}
+
+// Restore replaces the contents like Store.Restore and schedules a flush
+func (s *SQLStore) Restore(snap Snapshot) error {
+	if err := s.Store.Restore(snap); err != nil {
+		return err
+	}
+	s.markDirty()
+	return nil
+}
+
+// Reset empties the store like Store.Reset and schedules a flush
+func (s *SQLStore) Reset() {
+	s.Store.Reset()
+	s.markDirty()
+}
+
+// ConsumeQuota counts a call like Store.ConsumeQuota and schedules a flush
+func (s *SQLStore) ConsumeQuota(tenant string, at time.Time, limit int) (QuotaUsage, bool) {
+	usage, ok := s.Store.ConsumeQuota(tenant, at, limit)
+	s.markDirty()
+	return usage, ok
+}
+
+// AddUsageRollups merges rollups like Store.AddUsageRollups and schedules a
+// flush
+func (s *SQLStore) AddUsageRollups(rollups []UsageRollup) {
+	s.Store.AddUsageRollups(rollups)
+	s.markDirty()
+}
+
+// markDirty schedules a flush unless one is already pending
+func (s *SQLStore) markDirty() {
+	s.timerMu.Lock()
+	defer s.timerMu.Unlock()
+	if s.closed || s.timer != nil {
+		return
+	}
+	s.timer = s.clock.AfterFunc(s.interval, s.scheduledFlush)
+}
+
+func (s *SQLStore) scheduledFlush() {
+	s.timerMu.Lock()
+	s.timer = nil
+	s.timerMu.Unlock()
+
+	if err := s.flush(); err != nil {
+		log.Printf("⚠️  [hc-hello-world-plugin] event=datasource_flush_failed datasource=%s err=%q", s.kind, err)
+		s.markDirty()
+	}
+}
+
+// flush writes the current contents as the snapshot row
+func (s *SQLStore) flush() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	snap := s.Store.Snapshot()
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if data, err = s.sealer.Seal(data); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err = s.db.ExecContext(ctx, s.dialect.upsert, snap.Version, string(data), snap.TakenAt.Format(time.RFC3339Nano))
+	return err
+}
+
+// Close writes pending changes and closes the database. Changes made after
+// Close are kept in memory only.
+func (s *SQLStore) Close() error {
+	s.timerMu.Lock()
+	if s.closed {
+		s.timerMu.Unlock()
+		return nil
+	}
+	s.closed = true
+	pending := s.timer != nil
+	if pending {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.timerMu.Unlock()
+
+	var err error
+	if pending {
+		err = s.flush()
+	}
+	// Wait for a flush the timer already started
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return errors.Join(err, s.db.Close())
+}
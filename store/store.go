@@ -0,0 +1,576 @@
+// Package store is the plugin's data store. Store keeps everything in
+// memory; SQLStore persists the same engine to SQLite or PostgreSQL. All
+// methods are safe for concurrent use; values are copied in and out so
+// callers can never mutate shared state without holding the lock.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/geo"
+	"hc-hello-world-plugin/money"
+)
+
+// ErrNotFound is returned when a record does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a unique field is already taken
+var ErrConflict = errors.New("already exists")
+
+// ConflictError reports which unique field collided; it matches ErrConflict
+// with errors.Is
+type ConflictError struct {
+	Field string
+	Value string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %q %s", e.Field, e.Value, ErrConflict)
+}
+
+// Is reports whether target is ErrConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// Conflicts returns every ConflictError in err's tree; err may wrap one or
+// join several
+func Conflicts(err error) []*ConflictError {
+	switch e := err.(type) {
+	case *ConflictError:
+		return []*ConflictError{e}
+	case interface{ Unwrap() []error }:
+		var conflicts []*ConflictError
+		for _, inner := range e.Unwrap() {
+			conflicts = append(conflicts, Conflicts(inner)...)
+		}
+		return conflicts
+	case interface{ Unwrap() error }:
+		return Conflicts(e.Unwrap())
+	}
+	return nil
+}
+
+// Address is a user's postal address
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	State  string `json:"state"`
+	Zip    string `json:"zip"`
+	// Latitude and Longitude are optional and always set together
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// Location returns the address coordinates, if it has any
+func (a *Address) Location() (geo.Point, bool) {
+	if a == nil || a.Latitude == nil || a.Longitude == nil {
+		return geo.Point{}, false
+	}
+	return geo.Point{Lat: *a.Latitude, Lng: *a.Longitude}, true
+}
+
+// Tag is a key/value label attached to a user
+type Tag struct {
+	Key string `json:"key"`
+	Val string `json:"val"`
+}
+
+// User is a stored user record
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone,omitempty"` // optional, E.164
+	Username  string    `json:"username"`
+	Address   *Address  `json:"address,omitempty"`
+	Tags      []Tag     `json:"tags"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Metadata is a free-form JSON object stored as given
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Version starts at 1 and grows with every update that changes a field;
+	// FieldVersions holds the version at which each field last changed,
+	// leaving out those unchanged since creation
+	Version       int            `json:"version"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	FieldVersions map[string]int `json:"fieldVersions,omitempty"`
+}
+
+// clone returns a deep copy of u
+func (u User) clone() User {
+	if u.Address != nil {
+		addr := *u.Address
+		if addr.Latitude != nil {
+			lat := *addr.Latitude
+			addr.Latitude = &lat
+		}
+		if addr.Longitude != nil {
+			lng := *addr.Longitude
+			addr.Longitude = &lng
+		}
+		u.Address = &addr
+	}
+	u.Tags = append([]Tag(nil), u.Tags...)
+	if u.Metadata != nil {
+		u.Metadata = cloneJSON(u.Metadata).(map[string]interface{})
+	}
+	if u.FieldVersions != nil {
+		u.FieldVersions = maps.Clone(u.FieldVersions)
+	}
+	return u
+}
+
+// cloneJSON deep-copies a decoded JSON value
+func cloneJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = cloneJSON(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = cloneJSON(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// Product is a stored catalog product
+type Product struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Stock       int         `json:"stock"`
+	Tags        []string    `json:"tags"`
+	Categories  []string    `json:"categories"`
+}
+
+// clone returns a deep copy of p
+func (p Product) clone() Product {
+	p.Tags = append([]string(nil), p.Tags...)
+	p.Categories = append([]string(nil), p.Categories...)
+	return p
+}
+
+// Store holds users and products guarded by a single RWMutex. Lists keep
+// insertion order so paginated reads are stable.
+type Store struct {
+	mu sync.RWMutex
+	// clock stamps changes, snapshots and usage retention
+	clock clock.Clock
+
+	users     map[string]User
+	userOrder []string
+
+	products     map[string]Product
+	productOrder []string
+
+	groups     map[string]Group
+	groupOrder []string
+	// members and memberOf index the user/group relation in both directions
+	members  map[string]map[string]bool // group ID -> user IDs
+	memberOf map[string]map[string]bool // user ID -> group IDs
+
+	comments map[string]Comment
+	// postComments holds each post's comment IDs in insertion order
+	postComments map[string][]string
+
+	consents map[string]map[string]Consent // user ID -> purpose -> decision
+
+	// changes is the change log; changeSeq is the latest seq and changeFloor
+	// the newest seq no longer retained (cursors below it have expired).
+	// changeEpoch distinguishes this store's seqs from another process's.
+	changes     []Change
+	changeSeq   uint64
+	changeFloor uint64
+	changeEpoch string
+	// changed is closed and replaced on every change to wake waiters
+	changed chan struct{}
+	// onChange is called with every recorded change; see OnChange
+	onChange func(Change)
+
+	// usage holds hourly resolver usage rollups
+	usage map[usageKey]UsageRollup
+	// quotas holds each tenant's monthly call counts
+	quotas map[quotaKey]QuotaUsage
+
+	// fields encrypts personal user fields; see WithFieldSealer
+	fields FieldSealer
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{
+		clock:    clock.System,
+		users:    make(map[string]User),
+		products: make(map[string]Product),
+		groups:   make(map[string]Group),
+		members:  make(map[string]map[string]bool),
+		memberOf: make(map[string]map[string]bool),
+
+		comments:     make(map[string]Comment),
+		postComments: make(map[string][]string),
+		consents:     make(map[string]map[string]Consent),
+
+		changeEpoch: newEpoch(clock.System),
+		changed:     make(chan struct{}),
+
+		usage:  make(map[usageKey]UsageRollup),
+		quotas: make(map[quotaKey]QuotaUsage),
+	}
+}
+
+// WithClock replaces the system clock; call it before the store is used.
+// The change epoch is derived from the clock too, so a fake clock makes
+// change cursors reproducible.
+func (s *Store) WithClock(c clock.Clock) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.OrSystem(c)
+	s.changeEpoch = newEpoch(s.clock)
+	return s
+}
+
+// FieldSealer encrypts a personal user field, such as an email address,
+// before it is stored. Sealing must be deterministic, since the store
+// compares sealed emails to keep them unique, and must leave a value it
+// sealed before unchanged.
+type FieldSealer interface {
+	SealField(value string) string
+}
+
+// WithFieldSealer stores users' emails and phone numbers sealed by f; call
+// it before the store is used. Users then come back from the store with
+// those fields sealed, and callers open them for display.
+func (s *Store) WithFieldSealer(f FieldSealer) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fields = f
+	return s
+}
+
+// sealFields seals u's personal fields when a FieldSealer is set
+func (s *Store) sealFields(u *User) {
+	if s.fields == nil {
+		return
+	}
+	u.Email = s.fields.SealField(u.Email)
+	u.Phone = s.fields.SealField(u.Phone)
+}
+
+func newEpoch(c clock.Clock) string {
+	return strconv.FormatInt(c.Now().UnixNano(), 36)
+}
+
+// CreateUser inserts u; IDs, usernames and emails must be unique. When both
+// the username and email are taken the error joins both conflicts; use
+// Conflicts to list them.
+func (s *Store) CreateUser(u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[u.ID]; exists {
+		return User{}, &ConflictError{Field: "id", Value: u.ID}
+	}
+	s.sealFields(&u)
+	var usernameTaken, emailTaken bool
+	for _, existing := range s.users {
+		usernameTaken = usernameTaken || existing.Username == u.Username
+		emailTaken = emailTaken || existing.Email == u.Email
+	}
+	switch {
+	case usernameTaken && emailTaken:
+		return User{}, errors.Join(
+			&ConflictError{Field: "username", Value: u.Username},
+			&ConflictError{Field: "email", Value: u.Email})
+	case usernameTaken:
+		return User{}, &ConflictError{Field: "username", Value: u.Username}
+	case emailTaken:
+		return User{}, &ConflictError{Field: "email", Value: u.Email}
+	}
+
+	u.Version, u.UpdatedAt, u.FieldVersions = 1, u.CreatedAt, nil
+	s.users[u.ID] = u.clone()
+	s.userOrder = append(s.userOrder, u.ID)
+	s.recordLocked(EntityUser, u.ID, OpCreated)
+	return u.clone(), nil
+}
+
+// UpdateUser applies update to a copy of the user with id and stores the
+// result; the ID and version cannot change. Like CreateUser it rejects a
+// username or email another user has, joining both conflicts. When a field
+// changed the user moves to the next version.
+func (s *Store) UpdateUser(id string, update func(u *User)) (User, error) {
+	return s.TryUpdateUser(id, func(u *User) error {
+		update(u)
+		return nil
+	})
+}
+
+// TryUpdateUser is UpdateUser with an update that may refuse, e.g. after
+// comparing versions: its error is returned as is and the user is left
+// untouched. update runs under the store's write lock, so nothing changes
+// the user between its check and the write.
+func (s *Store) TryUpdateUser(id string, update func(u *User) error) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	u := existing.clone()
+	if err := update(&u); err != nil {
+		return User{}, err
+	}
+	u.ID = id
+	s.sealFields(&u)
+	u.bumpVersion(existing, s.clock.Now().UTC())
+
+	var conflicts []error
+	for otherID, other := range s.users {
+		if otherID == id {
+			continue
+		}
+		if other.Username == u.Username && u.Username != existing.Username {
+			conflicts = append(conflicts, &ConflictError{Field: "username", Value: u.Username})
+		}
+		if other.Email == u.Email && u.Email != existing.Email {
+			conflicts = append(conflicts, &ConflictError{Field: "email", Value: u.Email})
+		}
+	}
+	if len(conflicts) > 0 {
+		return User{}, errors.Join(conflicts...)
+	}
+
+	s.users[id] = u.clone()
+	s.recordLocked(EntityUser, id, OpUpdated)
+	return u, nil
+}
+
+// DeleteUser removes the user with id along with their group memberships.
+// Users who have written comments cannot be deleted, since removing the
+// comments would break the reply threads below them.
+func (s *Store) DeleteUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	for _, c := range s.comments {
+		if c.AuthorID == id {
+			return fmt.Errorf("user %s has written comments: %w", id, ErrConflict)
+		}
+	}
+	delete(s.users, id)
+	s.userOrder = slices.DeleteFunc(s.userOrder, func(existing string) bool { return existing == id })
+	for groupID := range s.memberOf[id] {
+		delete(s.members[groupID], id)
+		s.recordLocked(EntityMembership, id+":"+groupID, OpDeleted)
+	}
+	delete(s.memberOf, id)
+	s.deleteConsentsLocked(id)
+	s.recordLocked(EntityUser, id, OpDeleted)
+	return nil
+}
+
+// GetUser returns the user with id
+func (s *Store) GetUser(id string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return u.clone(), nil
+}
+
+// ListUsers returns copies of all users accepted by match (nil matches all)
+// in insertion order
+func (s *Store) ListUsers(match func(User) bool) []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]User, 0, len(s.userOrder))
+	for _, id := range s.userOrder {
+		u := s.users[id]
+		if match == nil || match(u) {
+			result = append(result, u.clone())
+		}
+	}
+	return result
+}
+
+// ScanUsers returns up to limit users accepted by match (nil matches all),
+// starting at insertion position from, the position to resume from, and
+// whether the end of the store was reached.
+// Only one chunk is copied per call, so very large stores can be read
+// without materializing every user at once. Positions stay valid because
+// users are only ever appended.
+func (s *Store) ScanUsers(from, limit int, match func(User) bool) ([]User, int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if from < 0 {
+		from = 0
+	}
+	result := make([]User, 0, limit)
+	pos := from
+	for ; pos < len(s.userOrder) && len(result) < limit; pos++ {
+		u := s.users[s.userOrder[pos]]
+		if match == nil || match(u) {
+			result = append(result, u.clone())
+		}
+	}
+	return result, pos, pos >= len(s.userOrder)
+}
+
+// EachUserChunk calls fn with consecutive chunks of at most size users
+// accepted by match. The lock is released between chunks, so fn may run
+// slowly (e.g. stream to a client) without blocking writers. Iteration stops
+// when fn returns an error or ctx is cancelled.
+func (s *Store) EachUserChunk(ctx context.Context, size int, match func(User) bool, fn func([]User) error) error {
+	if size <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", size)
+	}
+	pos := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk, next, done := s.ScanUsers(pos, size, match)
+		if len(chunk) > 0 {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+		pos = next
+	}
+}
+
+// CountUsers returns the number of stored users
+func (s *Store) CountUsers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.users)
+}
+
+// PutProduct inserts or replaces p
+func (s *Store) PutProduct(p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op := OpUpdated
+	if _, exists := s.products[p.ID]; !exists {
+		s.productOrder = append(s.productOrder, p.ID)
+		op = OpCreated
+	}
+	s.products[p.ID] = p.clone()
+	s.recordLocked(EntityProduct, p.ID, op)
+}
+
+// GetProduct returns the product with id
+func (s *Store) GetProduct(id string) (Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.products[id]
+	if !ok {
+		return Product{}, fmt.Errorf("product %s: %w", id, ErrNotFound)
+	}
+	return p.clone(), nil
+}
+
+// ListProducts returns copies of all products accepted by match (nil
+// matches all) in insertion order
+func (s *Store) ListProducts(match func(Product) bool) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Product, 0, len(s.productOrder))
+	for _, id := range s.productOrder {
+		p := s.products[id]
+		if match == nil || match(p) {
+			result = append(result, p.clone())
+		}
+	}
+	return result
+}
+
+// CountProducts returns the number of stored products
+func (s *Store) CountProducts() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.products)
+}
+
+// Counts is the number of records of each kind
+type Counts struct {
+	Users       int `json:"users"`
+	Products    int `json:"products"`
+	Groups      int `json:"groups"`
+	Memberships int `json:"memberships"`
+	Comments    int `json:"comments"`
+	// Changes is the number of retained change log entries
+	Changes      int `json:"changes"`
+	UsageRollups int `json:"usageRollups"`
+}
+
+// Counts returns the record counts under a single read lock
+func (s *Store) Counts() Counts {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	memberships := 0
+	for _, members := range s.members {
+		memberships += len(members)
+	}
+	return Counts{
+		Users:        len(s.users),
+		Products:     len(s.products),
+		Groups:       len(s.groups),
+		Memberships:  memberships,
+		Comments:     len(s.comments),
+		Changes:      len(s.changes),
+		UsageRollups: len(s.usage),
+	}
+}
+
+// Reset removes every user, product, group, membership, comment and
+// consent. Usage
+// rollups and quota counters describe the plugin rather than its data and
+// are kept. Like
+// Restore, a reset expires all change cursors.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users, s.userOrder = make(map[string]User), nil
+	s.products, s.productOrder = make(map[string]Product), nil
+	s.groups, s.groupOrder = make(map[string]Group), nil
+	s.members, s.memberOf = make(map[string]map[string]bool), make(map[string]map[string]bool)
+	s.comments, s.postComments = make(map[string]Comment), make(map[string][]string)
+	s.consents = make(map[string]map[string]Consent)
+	s.changeSeq++
+	s.changes, s.changeFloor = nil, s.changeSeq
+	s.notifyLocked()
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// These tests hammer the store from many goroutines; run them with -race
+// to check the locking as well as the results.
+
+func testUser(i int) User {
+	return User{
+		ID:        fmt.Sprintf("u%d", i),
+		Name:      fmt.Sprintf("User %d", i),
+		Email:     fmt.Sprintf("user%d@example.com", i),
+		Username:  fmt.Sprintf("user%d", i),
+		Tags:      []Tag{{Key: "n", Val: fmt.Sprint(i)}},
+		Active:    true,
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParallelCreateAndRead(t *testing.T) {
+	const workers, reads = 32, 10
+	s := New()
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := s.CreateUser(testUser(i))
+			if err != nil {
+				t.Errorf("CreateUser(%d): %v", i, err)
+				return
+			}
+			// The returned copy is the caller's to change
+			u.Tags[0].Val = "changed"
+			s.PutProduct(Product{ID: fmt.Sprintf("p%d", i), Tags: []string{"t"}})
+		}()
+		// Readers run alongside the writers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range reads {
+				for _, u := range s.ListUsers(nil) {
+					_ = u.Tags[0].Val
+				}
+				_, _ = s.GetUser(fmt.Sprintf("u%d", i))
+				_ = s.ListProducts(nil)
+				_ = s.CountUsers()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s.CountUsers() != workers || s.CountProducts() != workers {
+		t.Fatalf("got %d users and %d products, want %d of each", s.CountUsers(), s.CountProducts(), workers)
+	}
+	for _, u := range s.ListUsers(nil) {
+		if u.Tags[0].Val == "changed" {
+			t.Errorf("user %s shares its tags with a caller", u.ID)
+		}
+	}
+}
+
+func TestParallelCreateUpdateDelete(t *testing.T) {
+	const workers, updates = 32, 10
+	s := New()
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := s.CreateUser(testUser(i))
+			if err != nil {
+				t.Errorf("CreateUser(%d): %v", i, err)
+				return
+			}
+			for n := range updates {
+				if _, err := s.UpdateUser(u.ID, func(u *User) { u.Name = fmt.Sprintf("User %d v%d", i, n) }); err != nil {
+					t.Errorf("UpdateUser(%s): %v", u.ID, err)
+				}
+			}
+			if i%2 == 0 {
+				if err := s.DeleteUser(u.ID); err != nil {
+					t.Errorf("DeleteUser(%s): %v", u.ID, err)
+				}
+			}
+		}()
+		// Readers run alongside the writers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range updates {
+				for _, u := range s.ListUsers(nil) {
+					_ = u.Tags[0].Val
+				}
+				_, _ = s.GetUser(fmt.Sprintf("u%d", i))
+				_ = s.Counts()
+				_ = s.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	users := s.ListUsers(nil)
+	if len(users) != workers/2 {
+		t.Fatalf("got %d users, want %d", len(users), workers/2)
+	}
+	for _, u := range users {
+		if u.Version != updates+1 {
+			t.Errorf("user %s at version %d, want %d", u.ID, u.Version, updates+1)
+		}
+	}
+	// Every create, update and delete is in the change log exactly once
+	changes, _, _, err := s.ChangesSince(0, 10*workers*updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := workers + workers*updates + workers/2; len(changes) != want {
+		t.Errorf("got %d changes, want %d", len(changes), want)
+	}
+}
+
+func TestParallelCreateSameUsername(t *testing.T) {
+	const workers = 32
+	s := New()
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := testUser(i)
+			u.Username = "taken"
+			_, errs[i] = s.CreateUser(u)
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrConflict):
+			t.Errorf("CreateUser: %v, want a conflict", err)
+		}
+	}
+	if created != 1 || s.CountUsers() != 1 {
+		t.Errorf("created %d users, store has %d; want exactly 1", created, s.CountUsers())
+	}
+}
+
+func TestParallelMembershipChanges(t *testing.T) {
+	const workers = 16
+	s := New()
+	if _, err := s.CreateGroup(Group{ID: "g1", Name: "Group"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range workers {
+		if _, err := s.CreateUser(testUser(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("u%d", i)
+			for range 5 {
+				if _, err := s.AddUserToGroup(id, "g1"); err != nil {
+					t.Errorf("AddUserToGroup(%s): %v", id, err)
+				}
+				_ = s.GroupsOfUser(id)
+				_ = s.CountMembers("g1")
+				if i%2 == 1 {
+					if _, err := s.RemoveUserFromGroup(id, "g1"); err != nil {
+						t.Errorf("RemoveUserFromGroup(%s): %v", id, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.CountMembers("g1"); got != workers/2 {
+		t.Errorf("group has %d members, want %d", got, workers/2)
+	}
+}
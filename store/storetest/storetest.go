@@ -0,0 +1,440 @@
+// Package storetest checks that a store.Datasource behaves like the
+// in-memory store, in the manner of testing/fstest. cmd/dsconformance runs
+// the same cases against every datasource built into the binary.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"hc-hello-world-plugin/money"
+	"hc-hello-world-plugin/store"
+)
+
+// Case is one behaviour every datasource must share
+type Case struct {
+	Name string
+	Run  func(ds store.Datasource) error
+}
+
+// Cases are run by Run, each against a freshly opened, reset datasource
+var Cases = []Case{
+	{"users", checkUsers},
+	{"user versions", checkUserVersions},
+	{"user order and paging", checkUserOrder},
+	{"products and transactions", checkProducts},
+	{"groups and memberships", checkGroups},
+	{"comments", checkComments},
+	{"consents", checkConsents},
+	{"change log", checkChanges},
+	{"snapshot round trip", checkSnapshot},
+	{"quotas", checkQuotas},
+}
+
+// Run opens a datasource for every case, resets it so cases start empty,
+// runs the case and closes the datasource. It returns every failure joined,
+// or nil.
+func Run(open func() (store.Datasource, error)) error {
+	var errs []error
+	for _, c := range Cases {
+		if err := runCase(open, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runCase(open func() (store.Datasource, error), c Case) (err error) {
+	ds, err := open()
+	if err != nil {
+		return fmt.Errorf("opening datasource: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, ds.Close())
+	}()
+	ds.Reset()
+	return c.Run(ds)
+}
+
+// CheckPersistence checks that a user created before Close is there after
+// reopening; only durable datasources are expected to pass
+func CheckPersistence(open func() (store.Datasource, error)) error {
+	ds, err := open()
+	if err != nil {
+		return fmt.Errorf("opening datasource: %w", err)
+	}
+	ds.Reset()
+	if _, err := ds.CreateUser(user("persisted")); err != nil {
+		ds.Close()
+		return err
+	}
+	if err := ds.Close(); err != nil {
+		return fmt.Errorf("closing datasource: %w", err)
+	}
+
+	ds, err = open()
+	if err != nil {
+		return fmt.Errorf("reopening datasource: %w", err)
+	}
+	defer ds.Close()
+	if _, err := ds.GetUser("persisted"); err != nil {
+		return fmt.Errorf("after reopening: %w", err)
+	}
+	return nil
+}
+
+// user returns a valid user whose username and email derive from id
+func user(id string) store.User {
+	return store.User{
+		ID:        id,
+		Name:      "User " + id,
+		Email:     id + "@example.com",
+		Username:  id,
+		Active:    true,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func product(id string, stock int) store.Product {
+	return store.Product{ID: id, Name: "Product " + id, Price: money.MustNew(1999, "USD"), Stock: stock}
+}
+
+// unexpected reports that op returned err instead of target
+func unexpected(op string, err, target error) error {
+	return fmt.Errorf("%s: got error %v, want %v", op, err, target)
+}
+
+func ids[T any](items []T, id func(T) string) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = id(item)
+	}
+	return out
+}
+
+func userID(u store.User) string { return u.ID }
+
+func checkUsers(ds store.Datasource) error {
+	if _, err := ds.CreateUser(user("u1")); err != nil {
+		return err
+	}
+	taken := user("u2")
+	taken.Username = "u1"
+	if _, err := ds.CreateUser(taken); !errors.Is(err, store.ErrConflict) {
+		return unexpected("duplicate username", err, store.ErrConflict)
+	}
+
+	updated, err := ds.UpdateUser("u1", func(u *store.User) { u.Name = "Renamed" })
+	if err != nil {
+		return err
+	}
+	if got, _ := ds.GetUser("u1"); got.Name != "Renamed" || updated.Name != "Renamed" {
+		return fmt.Errorf("update: got name %q, want %q", got.Name, "Renamed")
+	}
+	if _, err := ds.UpdateUser("missing", func(*store.User) {}); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("update missing", err, store.ErrNotFound)
+	}
+
+	if err := ds.DeleteUser("u1"); err != nil {
+		return err
+	}
+	if _, err := ds.GetUser("u1"); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("get deleted", err, store.ErrNotFound)
+	}
+	if n := ds.CountUsers(); n != 0 {
+		return fmt.Errorf("count after delete: got %d, want 0", n)
+	}
+	return nil
+}
+
+func checkUserVersions(ds store.Datasource) error {
+	created, err := ds.CreateUser(user("u1"))
+	if err != nil {
+		return err
+	}
+	if created.Version != 1 {
+		return fmt.Errorf("created: got version %d, want 1", created.Version)
+	}
+	renamed, err := ds.UpdateUser("u1", func(u *store.User) { u.Name = "Renamed" })
+	if err != nil {
+		return err
+	}
+	if renamed.Version != 2 || renamed.FieldVersion("name") != 2 || renamed.FieldVersion("email") != 1 {
+		return fmt.Errorf("renamed: got version %d, name at %d, email at %d, want 2, 2, 1",
+			renamed.Version, renamed.FieldVersion("name"), renamed.FieldVersion("email"))
+	}
+	if same, err := ds.UpdateUser("u1", func(u *store.User) { u.Version = 9 }); err != nil || same.Version != 2 {
+		return fmt.Errorf("update changing nothing: got version %d (err %v), want 2", same.Version, err)
+	}
+
+	refused := errors.New("refused")
+	if _, err := ds.TryUpdateUser("u1", func(u *store.User) error {
+		u.Name = "Refused"
+		return refused
+	}); !errors.Is(err, refused) {
+		return unexpected("refused update", err, refused)
+	}
+	if got, _ := ds.GetUser("u1"); got.Name != "Renamed" || got.Version != 2 {
+		return fmt.Errorf("after refused update: got name %q at version %d, want %q at 2", got.Name, got.Version, "Renamed")
+	}
+	if fields := renamed.ChangedSince(1); !slices.Equal(fields, []string{"name"}) {
+		return fmt.Errorf("changed since 1: got %v, want [name]", fields)
+	}
+	return nil
+}
+
+func checkUserOrder(ds store.Datasource) error {
+	for _, id := range []string{"c", "a", "b", "d"} {
+		if _, err := ds.CreateUser(user(id)); err != nil {
+			return err
+		}
+	}
+	if got := ids(ds.ListUsers(nil), userID); !slices.Equal(got, []string{"c", "a", "b", "d"}) {
+		return fmt.Errorf("list: got %v, want insertion order", got)
+	}
+
+	page, next, done := ds.ScanUsers(1, 2, nil)
+	if got := ids(page, userID); !slices.Equal(got, []string{"a", "b"}) || next != 3 || done {
+		return fmt.Errorf("scan: got %v next=%d done=%t, want [a b] next=3 done=false", got, next, done)
+	}
+	if got := ids(ds.ListUsers(func(u store.User) bool { return u.ID != "a" }), userID); !slices.Equal(got, []string{"c", "b", "d"}) {
+		return fmt.Errorf("filtered list: got %v", got)
+	}
+	return nil
+}
+
+func checkProducts(ds store.Datasource) error {
+	ds.PutProduct(product("p1", 5))
+	if err := ds.WithTx(func(tx *store.Tx) error {
+		if err := tx.PutProduct(product("p2", 1)); err != nil {
+			return err
+		}
+		return errors.New("abandon")
+	}); err == nil {
+		return errors.New("transaction: want the callback's error")
+	}
+	if _, err := ds.GetProduct("p2"); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("rolled back product", err, store.ErrNotFound)
+	}
+
+	if err := ds.WithTx(func(tx *store.Tx) error {
+		p, err := tx.GetProduct("p1")
+		if err != nil {
+			return err
+		}
+		p.Stock--
+		return tx.PutProduct(p)
+	}); err != nil {
+		return err
+	}
+	p, err := ds.GetProduct("p1")
+	if err != nil {
+		return err
+	}
+	if p.Stock != 4 || p.Price != money.MustNew(1999, "USD") {
+		return fmt.Errorf("committed product: got stock %d price %s, want 4 and USD 19.99", p.Stock, p.Price)
+	}
+	if n := ds.CountProducts(); n != 1 {
+		return fmt.Errorf("count: got %d, want 1", n)
+	}
+	return nil
+}
+
+func checkGroups(ds store.Datasource) error {
+	for _, id := range []string{"u1", "u2"} {
+		if _, err := ds.CreateUser(user(id)); err != nil {
+			return err
+		}
+	}
+	if _, err := ds.CreateGroup(store.Group{ID: "g1", Name: "Group"}); err != nil {
+		return err
+	}
+	if added, err := ds.AddUserToGroup("u1", "g1"); err != nil || !added {
+		return fmt.Errorf("add member: got %t, %v", added, err)
+	}
+	if added, err := ds.AddUserToGroup("u1", "g1"); err != nil || added {
+		return fmt.Errorf("add member twice: got %t, %v, want false", added, err)
+	}
+	if _, err := ds.AddUserToGroup("missing", "g1"); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("add missing user", err, store.ErrNotFound)
+	}
+	if _, err := ds.AddUserToGroup("u2", "g1"); err != nil {
+		return err
+	}
+
+	members, err := ds.UsersInGroup("g1")
+	if err != nil {
+		return err
+	}
+	if got := ids(members, userID); !slices.Equal(got, []string{"u1", "u2"}) {
+		return fmt.Errorf("members: got %v", got)
+	}
+	if groups := ds.GroupsOfUser("u1"); len(groups) != 1 || groups[0].ID != "g1" {
+		return fmt.Errorf("groups of user: got %v", groups)
+	}
+
+	if err := ds.DeleteUser("u2"); err != nil {
+		return err
+	}
+	if n := ds.CountMembers("g1"); n != 1 {
+		return fmt.Errorf("members after deleting a user: got %d, want 1", n)
+	}
+	if removed, err := ds.RemoveUserFromGroup("u1", "g1"); err != nil || !removed {
+		return fmt.Errorf("remove member: got %t, %v", removed, err)
+	}
+	return nil
+}
+
+func checkComments(ds store.Datasource) error {
+	if _, err := ds.CreateUser(user("u1")); err != nil {
+		return err
+	}
+	comments := []store.Comment{
+		{ID: "c1", PostID: "p1", AuthorID: "u1", Body: "first"},
+		{ID: "c2", PostID: "p1", ParentID: "c1", AuthorID: "u1", Body: "reply"},
+		{ID: "c3", PostID: "p2", AuthorID: "u1", Body: "elsewhere"},
+	}
+	for _, c := range comments {
+		if _, err := ds.AddComment(c); err != nil {
+			return err
+		}
+	}
+	crossPost := store.Comment{ID: "c4", PostID: "p2", ParentID: "c1", AuthorID: "u1"}
+	if _, err := ds.AddComment(crossPost); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("reply across posts", err, store.ErrNotFound)
+	}
+	if _, err := ds.AddComment(store.Comment{ID: "c5", PostID: "p1", AuthorID: "missing"}); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("unknown author", err, store.ErrNotFound)
+	}
+
+	got := ids(ds.CommentsForPost("p1"), func(c store.Comment) string { return c.ID })
+	if !slices.Equal(got, []string{"c1", "c2"}) {
+		return fmt.Errorf("comments for post: got %v", got)
+	}
+	return nil
+}
+
+func checkConsents(ds store.Datasource) error {
+	if _, err := ds.CreateUser(user("u1")); err != nil {
+		return err
+	}
+	if _, err := ds.SetConsent(store.Consent{UserID: "missing", Purpose: "marketing", Granted: true}); !errors.Is(err, store.ErrNotFound) {
+		return unexpected("consent of unknown user", err, store.ErrNotFound)
+	}
+	for _, c := range []store.Consent{
+		{UserID: "u1", Purpose: "marketing", Granted: true},
+		{UserID: "u1", Purpose: "location", Granted: true},
+		{UserID: "u1", Purpose: "marketing", Granted: false},
+	} {
+		if _, err := ds.SetConsent(c); err != nil {
+			return err
+		}
+	}
+	if ds.HasConsent("u1", "marketing") || !ds.HasConsent("u1", "location") || ds.HasConsent("u1", "analytics") {
+		return errors.New("has consent: want only location granted")
+	}
+	got := ids(ds.ConsentsOfUser("u1"), func(c store.Consent) string { return c.Purpose })
+	if !slices.Equal(got, []string{"location", "marketing"}) {
+		return fmt.Errorf("consents of user: got %v", got)
+	}
+
+	snap := ds.Snapshot()
+	ds.Reset()
+	if err := ds.Restore(snap); err != nil {
+		return err
+	}
+	if !ds.HasConsent("u1", "location") {
+		return errors.New("consent after restore: want location granted")
+	}
+	if err := ds.DeleteUser("u1"); err != nil {
+		return err
+	}
+	if n := len(ds.ConsentsOfUser("u1")); n != 0 {
+		return fmt.Errorf("consents after deleting the user: got %d, want 0", n)
+	}
+	return nil
+}
+
+func checkChanges(ds store.Datasource) error {
+	start := ds.LatestChange()
+	if _, err := ds.CreateUser(user("u1")); err != nil {
+		return err
+	}
+	ds.PutProduct(product("p1", 1))
+	if err := ds.DeleteUser("u1"); err != nil {
+		return err
+	}
+
+	changes, next, more, err := ds.ChangesSince(start, 10)
+	if err != nil {
+		return err
+	}
+	got := ids(changes, func(c store.Change) string { return c.Entity + ":" + c.Op })
+	want := []string{"user:created", "product:created", "user:deleted"}
+	if !slices.Equal(got, want) || more || next != ds.LatestChange() {
+		return fmt.Errorf("changes: got %v next=%d more=%t, want %v", got, next, more, want)
+	}
+
+	cursor := ds.ChangeCursor(next)
+	if seq, err := ds.ParseChangeCursor(cursor); err != nil || seq != next {
+		return fmt.Errorf("cursor round trip: got %d, %v, want %d", seq, err, next)
+	}
+	ds.Reset()
+	if _, _, _, err := ds.ChangesSince(start, 10); !errors.Is(err, store.ErrCursorExpired) {
+		return unexpected("changes after reset", err, store.ErrCursorExpired)
+	}
+	return nil
+}
+
+func checkSnapshot(ds store.Datasource) error {
+	if _, err := ds.CreateUser(user("u1")); err != nil {
+		return err
+	}
+	ds.PutProduct(product("p1", 3))
+	if _, err := ds.CreateGroup(store.Group{ID: "g1", Name: "Group"}); err != nil {
+		return err
+	}
+	if _, err := ds.AddUserToGroup("u1", "g1"); err != nil {
+		return err
+	}
+	before := ds.Counts()
+	snap := ds.Snapshot()
+
+	ds.Reset()
+	if n := ds.CountUsers(); n != 0 {
+		return fmt.Errorf("users after reset: got %d, want 0", n)
+	}
+	if err := ds.Restore(snap); err != nil {
+		return err
+	}
+	after := ds.Counts()
+	// Restores record no changes, so only the records are compared
+	before.Changes, after.Changes = 0, 0
+	if before != after {
+		return fmt.Errorf("counts after restore: got %+v, want %+v", after, before)
+	}
+
+	snap.Version++
+	if err := ds.Restore(snap); err == nil {
+		return errors.New("restoring an unsupported version: want an error")
+	}
+	return nil
+}
+
+func checkQuotas(ds store.Datasource) error {
+	at := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 2; i++ {
+		if usage, ok := ds.ConsumeQuota("acme", at, 2); !ok || usage.Calls != i {
+			return fmt.Errorf("call %d: got %d calls, counted=%t", i, usage.Calls, ok)
+		}
+	}
+	if usage, ok := ds.ConsumeQuota("acme", at, 2); ok || usage.Calls != 2 {
+		return fmt.Errorf("call over the limit: got %d calls, counted=%t", usage.Calls, ok)
+	}
+	if usage := ds.QuotaUsage("acme", at.AddDate(0, 1, 0)); usage.Calls != 0 {
+		return fmt.Errorf("next month: got %d calls, want 0", usage.Calls)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxDone is returned when a transaction is used after Commit or Rollback
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Tx is a unit of work over products with database-style
+// Begin/Commit/Rollback. Writes are staged on copies (copy-on-write) and
+// only become visible on Commit; Rollback discards them. The store's write
+// lock is held from Begin until Commit or Rollback, so transactions are
+// serialized and never observe each other's partial writes.
+type Tx struct {
+	s      *Store
+	done   bool
+	staged map[string]Product
+	// order keeps new products in the order they were staged
+	order []string
+}
+
+// Begin starts a transaction. Every Begin must be followed by Commit or
+// Rollback; prefer WithTx, which guarantees it.
+func (s *Store) Begin() *Tx {
+	s.mu.Lock()
+	return &Tx{s: s, staged: make(map[string]Product)}
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back if it returns an error or panics
+func (s *Store) WithTx(fn func(tx *Tx) error) error {
+	tx := s.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetProduct returns the product with id as seen by this transaction,
+// including its own staged writes
+func (tx *Tx) GetProduct(id string) (Product, error) {
+	if tx.done {
+		return Product{}, ErrTxDone
+	}
+	if p, ok := tx.staged[id]; ok {
+		return p.clone(), nil
+	}
+	p, ok := tx.s.products[id]
+	if !ok {
+		return Product{}, fmt.Errorf("product %s: %w", id, ErrNotFound)
+	}
+	return p.clone(), nil
+}
+
+// PutProduct stages an insert or replace of p
+func (tx *Tx) PutProduct(p Product) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if _, staged := tx.staged[p.ID]; !staged {
+		tx.order = append(tx.order, p.ID)
+	}
+	tx.staged[p.ID] = p.clone()
+	return nil
+}
+
+// Commit applies every staged write at once and ends the transaction
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	for _, id := range tx.order {
+		op := OpUpdated
+		if _, exists := tx.s.products[id]; !exists {
+			tx.s.productOrder = append(tx.s.productOrder, id)
+			op = OpCreated
+		}
+		tx.s.products[id] = tx.staged[id]
+		tx.s.recordLocked(EntityProduct, id, op)
+	}
+	tx.end()
+	return nil
+}
+
+// Rollback discards the staged writes and ends the transaction
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.end()
+	return nil
+}
+
+func (tx *Tx) end() {
+	tx.done = true
+	tx.staged, tx.order = nil, nil
+	tx.s.mu.Unlock()
+}
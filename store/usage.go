@@ -0,0 +1,91 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxUsageAge is how long hourly usage rollups are kept
+const maxUsageAge = 90 * 24 * time.Hour
+
+// UsageRollup is one resolver's usage during one hour
+type UsageRollup struct {
+	// Hour is the start of the hour, in UTC
+	Hour     time.Time `json:"hour"`
+	Resolver string    `json:"resolver"`
+	Calls    int       `json:"calls"`
+	Errors   int       `json:"errors"`
+	// DistinctArgs is the number of distinct argument sets seen
+	DistinctArgs int `json:"distinctArgs"`
+}
+
+// usageKey identifies a rollup
+type usageKey struct {
+	hour     time.Time
+	resolver string
+}
+
+// AddUsageRollups merges rollups into the stored ones. Calls and errors for
+// an hour that is already stored are added; distinct argument counts cannot
+// be merged exactly, so the larger one is kept. Rollups older than the
+// retention window are dropped.
+func (s *Store) AddUsageRollups(rollups []UsageRollup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range rollups {
+		r.Hour = r.Hour.UTC().Truncate(time.Hour)
+		key := usageKey{hour: r.Hour, resolver: r.Resolver}
+		if existing, ok := s.usage[key]; ok {
+			r.Calls += existing.Calls
+			r.Errors += existing.Errors
+			r.DistinctArgs = max(r.DistinctArgs, existing.DistinctArgs)
+		}
+		s.usage[key] = r
+	}
+
+	cutoff := s.clock.Now().UTC().Add(-maxUsageAge)
+	for key := range s.usage {
+		if key.hour.Before(cutoff) {
+			delete(s.usage, key)
+		}
+	}
+}
+
+// UsageRollups returns the rollups for hours starting at or after since,
+// ordered by hour then resolver
+func (s *Store) UsageRollups(since time.Time) []UsageRollup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since = since.UTC().Truncate(time.Hour)
+	var result []UsageRollup
+	for key, r := range s.usage {
+		if !key.hour.Before(since) {
+			result = append(result, r)
+		}
+	}
+	sortUsage(result)
+	return result
+}
+
+func sortUsage(rollups []UsageRollup) {
+	sort.Slice(rollups, func(i, j int) bool {
+		if !rollups[i].Hour.Equal(rollups[j].Hour) {
+			return rollups[i].Hour.Before(rollups[j].Hour)
+		}
+		return rollups[i].Resolver < rollups[j].Resolver
+	})
+}
+
+// validateUsage checks a rollup loaded from a snapshot
+func validateUsage(r UsageRollup) error {
+	if r.Resolver == "" {
+		return fmt.Errorf("usage rollup for %s has no resolver", r.Hour.Format(time.RFC3339))
+	}
+	if r.Hour.IsZero() || r.Calls < 0 || r.Errors < 0 || r.Errors > r.Calls || r.DistinctArgs < 0 {
+		return fmt.Errorf("usage rollup for %s at %s is invalid", r.Resolver, r.Hour.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// VersionConflictError is returned when a user was changed after the
+// version a client read; it matches ErrConflict with errors.Is
+type VersionConflictError struct {
+	ID       string
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("user %s is at version %d, not %d", e.ID, e.Current, e.Expected)
+}
+
+// Is reports whether target is ErrConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// userFields reads the User fields whose changes are versioned, by JSON
+// name
+var userFields = map[string]func(User) interface{}{
+	"name":     func(u User) interface{} { return u.Name },
+	"email":    func(u User) interface{} { return u.Email },
+	"phone":    func(u User) interface{} { return u.Phone },
+	"username": func(u User) interface{} { return u.Username },
+	"address":  func(u User) interface{} { return u.Address },
+	"tags":     func(u User) interface{} { return u.Tags },
+	"active":   func(u User) interface{} { return u.Active },
+	"metadata": func(u User) interface{} { return u.Metadata },
+}
+
+// FieldVersion returns the version at which field, a User field's JSON
+// name, last changed. Fields unchanged since creation are at version 1.
+func (u User) FieldVersion(field string) int {
+	if version, ok := u.FieldVersions[field]; ok {
+		return version
+	}
+	return 1
+}
+
+// ChangedSince lists the fields that changed after version, by JSON name
+func (u User) ChangedSince(version int) []string {
+	var fields []string
+	for field := range userFields {
+		if u.FieldVersion(field) > version {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// bumpVersion gives u, an update of existing, the next version when any
+// versioned field changed and stamps the fields that did
+func (u *User) bumpVersion(existing User, now time.Time) {
+	// Callers may not set the version themselves
+	u.Version, u.UpdatedAt, u.FieldVersions = existing.Version, existing.UpdatedAt, existing.FieldVersions
+
+	var changed []string
+	for field, value := range userFields {
+		if !reflect.DeepEqual(value(existing), value(*u)) {
+			changed = append(changed, field)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	u.Version++
+	u.UpdatedAt = now
+	u.FieldVersions = make(map[string]int, len(existing.FieldVersions)+len(changed))
+	for field, version := range existing.FieldVersions {
+		u.FieldVersions[field] = version
+	}
+	for _, field := range changed {
+		u.FieldVersions[field] = u.Version
+	}
+}
@@ -0,0 +1,69 @@
+// Package timeutil contains helpers for timezone-aware timestamps and safe
+// parsing of RFC3339 input.
+package timeutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// contextKeys are the host context keys checked for a timezone, in order
+var contextKeys = []string{"timezone", "time_zone", "tz"}
+
+// LoadLocation resolves an IANA zone name ("Europe/Berlin") or "UTC"/"Local".
+// An empty name resolves to UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "UTC") {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// LocationFromContext resolves the requester's timezone from an explicit
+// argument first, then from values passed by the host in the context
+func LocationFromContext(ctx context.Context, arg string) (*time.Location, error) {
+	if arg != "" {
+		return LoadLocation(arg)
+	}
+	for _, key := range contextKeys {
+		if value, ok := ctx.Value(key).(string); ok && value != "" {
+			return LoadLocation(value)
+		}
+	}
+	return time.UTC, nil
+}
+
+// ParseRFC3339 parses user input in RFC3339 (with or without fractional
+// seconds) and returns it in UTC. Inputs without a zone offset are rejected
+// because they are ambiguous.
+func ParseRFC3339(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("timestamp is empty")
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp %q is not valid RFC3339 (expected e.g. 2024-01-02T15:04:05Z)", value)
+	}
+	return t.UTC(), nil
+}
+
+// FormatUTC formats t as RFC3339 in UTC
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FormatIn formats t as RFC3339 in loc
+func FormatIn(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
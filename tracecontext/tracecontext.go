@@ -0,0 +1,204 @@
+// Package tracecontext propagates the W3C trace context (the traceparent
+// and tracestate headers) from the engine's requests to the plugin's
+// outbound HTTP calls, so downstream systems join the engine's trace.
+//
+// Resolver wraps every handler to pick the headers up from the request;
+// Doer adds them to outbound requests whose context carries a trace.
+// Each outbound request gets a new parent ID, since the plugin's call is a
+// child of the request that caused it.
+package tracecontext
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/outbound"
+)
+
+// Header names, as sent on outbound requests
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// maxTraceState is the longest tracestate forwarded; longer values are
+// dropped as the spec allows
+const maxTraceState = 512
+
+// Context is a validated trace context
+type Context struct {
+	// TraceID is 32 lowercase hex digits
+	TraceID string
+	// ParentID is the caller's span, 16 lowercase hex digits
+	ParentID string
+	// Flags is the two hex digit trace-flags field; "01" means sampled
+	Flags string
+	// State is the vendor-specific tracestate header, passed on unchanged
+	State string
+}
+
+// Valid reports whether c holds a trace
+func (c Context) Valid() bool {
+	return c.TraceID != ""
+}
+
+// TraceParent formats c as a version 00 traceparent header
+func (c Context) TraceParent() string {
+	return "00-" + c.TraceID + "-" + c.ParentID + "-" + c.Flags
+}
+
+// Child returns c with a new random parent ID, for an outbound call made
+// on behalf of c
+func (c Context) Child() Context {
+	var id [8]byte
+	for {
+		rand.Read(id[:])
+		if id != [8]byte{} {
+			break
+		}
+	}
+	c.ParentID = hex.EncodeToString(id[:])
+	return c
+}
+
+// Parse validates a traceparent header and pairs it with tracestate.
+// Versions other than 00 are read by their version 00 fields, as the spec
+// requires; version ff, all-zero IDs and malformed values are rejected.
+func Parse(traceparent, tracestate string) (Context, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return Context{}, false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	switch {
+	case !isHex(version, 2) || version == "ff":
+		return Context{}, false
+	case version == "00" && len(parts) != 4:
+		return Context{}, false
+	case !isHex(traceID, 32) || strings.Trim(traceID, "0") == "":
+		return Context{}, false
+	case !isHex(parentID, 16) || strings.Trim(parentID, "0") == "":
+		return Context{}, false
+	case !isHex(flags, 2):
+		return Context{}, false
+	}
+
+	tracestate = strings.TrimSpace(tracestate)
+	if len(tracestate) > maxTraceState {
+		tracestate = ""
+	}
+	return Context{TraceID: traceID, ParentID: parentID, Flags: flags, State: tracestate}, true
+}
+
+// isHex reports whether s is n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// headerKeys are the argument/context keys checked for each header
+var headerKeys = map[string][]string{
+	TraceParentHeader: {"traceparent", "Traceparent", "context_traceparent"},
+	TraceStateHeader:  {"tracestate", "Tracestate", "context_tracestate"},
+}
+
+// Extract reads the trace context from handler args, a nested "headers"
+// map, or the request context; the first valid traceparent wins
+func Extract(ctx context.Context, args map[string]interface{}) (Context, bool) {
+	sources := []map[string]interface{}{args}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		sources = append(sources, headers)
+	}
+	fromCtx := map[string]interface{}{}
+	for _, key := range []string{TraceParentHeader, TraceStateHeader} {
+		if value, ok := ctx.Value(key).(string); ok {
+			fromCtx[key] = value
+		}
+	}
+	sources = append(sources, fromCtx)
+	if headers, ok := ctx.Value("headers").(map[string]interface{}); ok {
+		sources = append(sources, headers)
+	}
+
+	for _, values := range sources {
+		if tc, ok := Parse(lookup(values, TraceParentHeader), lookup(values, TraceStateHeader)); ok {
+			return tc, true
+		}
+	}
+	return Context{}, false
+}
+
+func lookup(values map[string]interface{}, header string) string {
+	for _, key := range headerKeys[header] {
+		if value, ok := values[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying tc
+func NewContext(ctx context.Context, tc Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// FromContext returns the trace context carried by ctx
+func FromContext(ctx context.Context) (Context, bool) {
+	tc, ok := ctx.Value(contextKey{}).(Context)
+	return tc, ok && tc.Valid()
+}
+
+// Resolver makes the request's trace context available to handler through
+// FromContext. It has the registry.Middleware signature, so it covers
+// resolvers, REST handlers and functions alike.
+func Resolver(name string, handler sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		if tc, ok := Extract(ctx, args); ok {
+			ctx = NewContext(ctx, tc)
+		}
+		return handler(ctx, args)
+	}
+}
+
+// Inject sets the traceparent and tracestate headers of req for a call
+// made on behalf of tc
+func Inject(req *http.Request, tc Context) {
+	if !tc.Valid() {
+		return
+	}
+	req.Header.Set(TraceParentHeader, tc.Child().TraceParent())
+	if tc.State != "" {
+		req.Header.Set(TraceStateHeader, tc.State)
+	} else {
+		req.Header.Del(TraceStateHeader)
+	}
+}
+
+// Doer wraps next so requests whose context carries a trace are sent with
+// its headers
+func Doer(next outbound.Doer) outbound.Doer {
+	return outbound.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		tc, ok := FromContext(req.Context())
+		if !ok {
+			return next.Do(req)
+		}
+		// The caller's request is left as it was
+		req = req.Clone(req.Context())
+		Inject(req, tc)
+		return next.Do(req)
+	})
+}
@@ -0,0 +1,158 @@
+// Package typedfn lets custom functions declare JSON Schemas for their input
+// and output and be written against typed structs instead of raw maps. REST
+// handlers can be written against typed structs too; see REST.
+package typedfn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/jsonschema"
+)
+
+// Spec describes a custom function's payloads. Input and Output are JSON
+// Schemas; either may be nil to skip validation.
+type Spec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Input       map[string]interface{} `json:"input,omitempty"`
+	Output      map[string]interface{} `json:"output,omitempty"`
+}
+
+// Func is a custom function body working on typed payloads
+type Func[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+var (
+	specsMu sync.RWMutex
+	specs   = make(map[string]Spec)
+)
+
+// Wrap adapts fn to the SDK's untyped function signature. Raw args (minus
+// host context_ values) are validated against spec.Input and decoded into
+// In; the result is validated against spec.Output before it is returned, so
+// a handler can never break its declared contract. The spec is recorded for
+// Specs.
+func Wrap[In, Out any](spec Spec, fn Func[In, Out]) sdk.FunctionHandlerFunc {
+	specsMu.Lock()
+	specs[spec.Name] = spec
+	specsMu.Unlock()
+
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		payload := make(map[string]interface{}, len(args))
+		for key, value := range args {
+			if !strings.HasPrefix(key, "context_") {
+				payload[key] = value
+			}
+		}
+		if spec.Input != nil {
+			if violations := jsonschema.Validate(spec.Input, payload); len(violations) > 0 {
+				return nil, payloadError(spec.Name, "input", violations)
+			}
+		}
+
+		var in In
+		if err := convert(payload, &in); err != nil {
+			return nil, fmt.Errorf("%s: failed to decode input: %w", spec.Name, err)
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		if err := convert(out, &result); err != nil {
+			return nil, fmt.Errorf("%s: failed to encode output: %w", spec.Name, err)
+		}
+		if spec.Output != nil {
+			if violations := jsonschema.Validate(spec.Output, result); len(violations) > 0 {
+				return nil, payloadError(spec.Name, "output", violations)
+			}
+		}
+		return result, nil
+	}
+}
+
+// REST adapts fn to the SDK's REST handler signature. Path, query and body
+// args (minus host context_ values) arrive merged and are decoded into In;
+// args that do not fit In get a 400 response envelope. An fn returning
+// errors.ErrUnsupported, as generated stubs do, gets a 501; other errors
+// are returned as is. Validate the args first with jsonschema.ValidateBody.
+func REST[In, Out any](name string, fn Func[In, Out]) sdk.RESTHandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		payload := make(map[string]interface{}, len(args))
+		for key, value := range args {
+			if !strings.HasPrefix(key, "context_") {
+				payload[key] = value
+			}
+		}
+
+		var in In
+		if err := convert(payload, &in); err != nil {
+			return restError(400, fmt.Sprintf("invalid request: %v", err)), nil
+		}
+
+		out, err := fn(ctx, in)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return restError(501, name+" is not implemented"), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{}
+		if err := convert(out, &result); err != nil {
+			return nil, fmt.Errorf("%s: failed to encode response: %w", name, err)
+		}
+		return result, nil
+	}
+}
+
+// restError builds a REST error envelope
+func restError(status int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"statusCode": status,
+		"headers":    map[string]interface{}{"Content-Type": "application/json"},
+		"body":       map[string]interface{}{"error": message},
+	}
+}
+
+// Specs returns every spec registered through Wrap, sorted by name
+func Specs() []Spec {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+	result := make([]Spec, 0, len(specs))
+	for _, spec := range specs {
+		result = append(result, spec)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// convert copies src into dst through JSON so struct tags apply
+func convert(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func payloadError(name, direction string, violations []jsonschema.Violation) error {
+	details := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		path := violation.Path
+		if path == "" {
+			path = "/"
+		}
+		details = append(details, path+" "+violation.Message)
+	}
+	return fmt.Errorf("%s: invalid %s: %s", name, direction, strings.Join(details, "; "))
+}
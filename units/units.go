@@ -0,0 +1,88 @@
+// Package units parses human-friendly durations such as "30s", "5m" or
+// "1d12h" and byte sizes such as "512KB" or "10MiB".
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParseDuration parses a duration in Go syntax ("1h30m", "250ms") that may
+// start with a number of days ("2d", "1d12h"), which time.ParseDuration
+// does not accept
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	days, rest, hasDays := strings.Cut(s, "d")
+	if !hasDays {
+		return time.ParseDuration(s)
+	}
+	n, err := strconv.ParseUint(days, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	d := time.Duration(n) * 24 * time.Hour
+	if d/(24*time.Hour) != time.Duration(n) {
+		return 0, fmt.Errorf("duration %q is too long", s)
+	}
+	if rest == "" {
+		return d, nil
+	}
+	extra, err := time.ParseDuration(rest)
+	if err != nil || extra < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if d+extra < d {
+		return 0, fmt.Errorf("duration %q is too long", s)
+	}
+	return d + extra, nil
+}
+
+// byteUnits maps size suffixes to bytes: KB, MB, GB and TB are decimal,
+// KiB, MiB, GiB and TiB binary
+var byteUnits = map[string]float64{
+	"":   1,
+	"B":  1,
+	"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+	"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30, "TIB": 1 << 40,
+}
+
+// ParseByteSize parses a size such as "10MB", "1.5GiB", "512 kb" or "4096"
+// (bytes) into a number of bytes. Units are case-insensitive.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, s[i:])
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	size := math.Round(n * multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(size), nil
+}
+
+// FormatByteSize renders n bytes in the largest binary unit that keeps at
+// least one whole unit, to one decimal, e.g. 1536 as "1.5KiB"
+func FormatByteSize(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	size := float64(n)
+	i := 0
+	for i < len(units)-1 && math.Abs(size) >= 1024 {
+		size /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(size*10)/10, 'f', -1, 64) + units[i]
+}
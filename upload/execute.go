@@ -0,0 +1,75 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hc-hello-world-plugin/limits"
+	"hc-hello-world-plugin/registry"
+	"hc-hello-world-plugin/selection"
+	"hc-hello-world-plugin/variables"
+)
+
+// Execute runs one operation through the plugin's registered resolvers and
+// returns a GraphQL response: data keyed by alias, plus errors with their
+// paths, and a limit violation's details in the error's extensions. A
+// failing field is null and does not stop the others; root mutation fields
+// run one after another, as GraphQL requires. It serves the requests the
+// host does not route: multipart uploads, and every request in standalone
+// mode. contextArgs, such as the host's context_ values, are passed to
+// every resolver.
+func Execute(ctx context.Context, plugin *registry.Plugin, req Request, contextArgs map[string]interface{}) map[string]interface{} {
+	op, err := ParseQuery(req.Query, req.OperationName)
+	if err != nil {
+		return map[string]interface{}{
+			"errors": []interface{}{map[string]interface{}{"message": err.Error()}},
+		}
+	}
+
+	ctx = context.WithValue(ctx, variables.ContextKey, req.Variables)
+	data := make(map[string]interface{}, len(op.Fields))
+	var errs []interface{}
+	for _, field := range op.Fields {
+		data[field.Alias] = nil
+		resolver, ok := plugin.Resolver(op.Type, field.Name)
+		if !ok {
+			errs = append(errs, map[string]interface{}{
+				"message": fmt.Sprintf("unknown %s field %s", op.Type, field.Name),
+				"path":    []interface{}{field.Alias},
+			})
+			continue
+		}
+
+		rawArgs := field.Args(req.Variables)
+		for key, value := range contextArgs {
+			rawArgs[key] = value
+		}
+		rawArgs["context_variables"] = req.Variables
+		var fieldCtx context.Context = ctx
+		if field.Selection != "" {
+			fieldCtx = context.WithValue(ctx, selection.ContextKey, field.Selection)
+		}
+
+		result, err := resolver(fieldCtx, rawArgs)
+		if err != nil {
+			entry := map[string]interface{}{
+				"message": err.Error(),
+				"path":    []interface{}{field.Alias},
+			}
+			var limitErr *limits.Error
+			if errors.As(err, &limitErr) {
+				entry["extensions"] = limitErr.ToMap()
+			}
+			errs = append(errs, entry)
+			continue
+		}
+		data[field.Alias] = result
+	}
+
+	response := map[string]interface{}{"data": data}
+	if errs != nil {
+		response["errors"] = errs
+	}
+	return response
+}
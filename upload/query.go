@@ -0,0 +1,404 @@
+package upload
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operation is a parsed GraphQL operation: its type and root fields
+type Operation struct {
+	// Type is "query" or "mutation"
+	Type   string
+	Name   string
+	Fields []Field
+}
+
+// Field is one root field of an operation
+type Field struct {
+	// Alias is the key of the field in the response; the field name unless
+	// the query aliased it
+	Alias string
+	Name  string
+	// Selection is the field's selection set without the outer braces, in
+	// the form the selection package parses
+	Selection string
+
+	args map[string]interface{}
+}
+
+// variable is a "$name" reference in an argument value
+type variable string
+
+// Args returns the field's arguments with variables substituted. Arguments
+// bound to variables the request did not send are left out.
+func (f Field) Args(vars map[string]interface{}) map[string]interface{} {
+	args := make(map[string]interface{}, len(f.args))
+	for name, value := range f.args {
+		if resolved := substitute(value, vars); resolved != nil {
+			args[name] = resolved
+		}
+	}
+	return args
+}
+
+func substitute(value interface{}, vars map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case variable:
+		return vars[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = substitute(item, vars)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = substitute(item, vars)
+		}
+		return object
+	default:
+		return v
+	}
+}
+
+// ParseQuery reads the operation called operationName from a GraphQL
+// document, or its only operation when operationName is empty. It covers
+// what upload requests need - root fields with arguments, aliases and
+// selection sets - and rejects fragments, directives and subscriptions.
+func ParseQuery(query, operationName string) (Operation, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return Operation{}, err
+	}
+	p := &parser{src: query, tokens: tokens}
+
+	var ops []Operation
+	for p.peek().kind != tokEOF {
+		op, err := p.operation()
+		if err != nil {
+			return Operation{}, err
+		}
+		ops = append(ops, op)
+	}
+
+	switch {
+	case len(ops) == 0:
+		return Operation{}, fmt.Errorf("query has no operation")
+	case operationName == "" && len(ops) == 1:
+		return ops[0], nil
+	case operationName == "":
+		return Operation{}, fmt.Errorf("operationName is required when the query has %d operations", len(ops))
+	}
+	for _, op := range ops {
+		if op.Name == operationName {
+			return op, nil
+		}
+	}
+	return Operation{}, fmt.Errorf("operation %q not found in query", operationName)
+}
+
+const (
+	tokEOF = iota
+	tokName
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind int
+	text string
+	// pos is the token's byte offset in the source
+	pos int
+}
+
+// lex splits a GraphQL document into tokens, dropping whitespace, commas
+// and comments
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokString, src[i+3 : i+3+end], i})
+			i += end + 6
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			// GraphQL string escapes are the same as JSON's
+			var s string
+			if err := json.Unmarshal([]byte(src[i:j+1]), &s); err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %v", i, err)
+			}
+			tokens = append(tokens, token{tokString, s, i})
+			i = j + 1
+		case c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{tokName, src[i:j], i})
+			i = j
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(src) && strings.IndexByte("0123456789.eE+-", src[j]) >= 0 {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, src[i:j], i})
+			i = j
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+			tokens = append(tokens, token{tokPunct, string(c), i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+// accept consumes the punctuator s if it comes next
+func (p *parser) accept(s string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == s {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return p.unexpected("expected " + s)
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	t := p.peek()
+	if t.kind != tokName {
+		return "", p.unexpected("expected a name")
+	}
+	p.next++
+	return t.text, nil
+}
+
+func (p *parser) unexpected(context string) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("%s, found end of query", context)
+	}
+	return fmt.Errorf("%s, found %q at offset %d", context, t.text, t.pos)
+}
+
+func (p *parser) operation() (Operation, error) {
+	op := Operation{Type: "query"}
+	if t := p.peek(); t.kind == tokName {
+		switch t.text {
+		case "query", "mutation":
+			op.Type = t.text
+		case "subscription":
+			return Operation{}, fmt.Errorf("subscriptions are not supported")
+		case "fragment":
+			return Operation{}, fmt.Errorf("fragments are not supported")
+		default:
+			return Operation{}, p.unexpected("expected an operation")
+		}
+		p.next++
+		if p.peek().kind == tokName {
+			op.Name = p.advance().text
+		}
+		// Variable definitions only declare types; the values come from
+		// the request's variables
+		if p.accept("(") {
+			if err := p.skipUntil(")"); err != nil {
+				return Operation{}, err
+			}
+		}
+	}
+	if p.peek().text == "@" {
+		return Operation{}, fmt.Errorf("directives are not supported")
+	}
+
+	if err := p.expect("{"); err != nil {
+		return Operation{}, err
+	}
+	for !p.accept("}") {
+		field, err := p.field()
+		if err != nil {
+			return Operation{}, err
+		}
+		op.Fields = append(op.Fields, field)
+	}
+	return op, nil
+}
+
+func (p *parser) field() (Field, error) {
+	if p.peek().text == "..." {
+		return Field{}, fmt.Errorf("fragments are not supported")
+	}
+	name, err := p.name()
+	if err != nil {
+		return Field{}, err
+	}
+	field := Field{Alias: name, Name: name}
+	if p.accept(":") {
+		if field.Name, err = p.name(); err != nil {
+			return Field{}, err
+		}
+	}
+
+	if p.accept("(") {
+		field.args = make(map[string]interface{})
+		for !p.accept(")") {
+			arg, err := p.name()
+			if err != nil {
+				return Field{}, err
+			}
+			if err := p.expect(":"); err != nil {
+				return Field{}, err
+			}
+			if field.args[arg], err = p.value(); err != nil {
+				return Field{}, err
+			}
+		}
+	}
+	if p.peek().text == "@" {
+		return Field{}, fmt.Errorf("directives are not supported")
+	}
+
+	if t := p.peek(); t.kind == tokPunct && t.text == "{" {
+		p.next++
+		if err := p.skipUntil("}"); err != nil {
+			return Field{}, err
+		}
+		closing := p.tokens[p.next-1]
+		field.Selection = strings.TrimSpace(p.src[t.pos+1 : closing.pos])
+	}
+	return field, nil
+}
+
+// skipUntil consumes tokens up to and including the closing punctuator,
+// stepping over nested brackets
+func (p *parser) skipUntil(closing string) error {
+	depth := 0
+	for {
+		t := p.advance()
+		switch {
+		case t.kind == tokEOF:
+			return fmt.Errorf("missing %s, found end of query", closing)
+		case t.kind != tokPunct:
+		case t.text == "(" || t.text == "{" || t.text == "[":
+			depth++
+		case t.text == ")" || t.text == "}" || t.text == "]":
+			if depth == 0 {
+				if t.text != closing {
+					return fmt.Errorf("expected %s, found %q at offset %d", closing, t.text, t.pos)
+				}
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// value reads an argument value. Numbers become float64 and enum values
+// strings, matching what JSON-decoded variables look like.
+func (p *parser) value() (interface{}, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokString:
+		return t.text, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return n, nil
+	case tokName:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t.text, nil
+	case tokPunct:
+		switch t.text {
+		case "$":
+			name, err := p.name()
+			return variable(name), err
+		case "[":
+			list := []interface{}{}
+			for !p.accept("]") {
+				item, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, nil
+		case "{":
+			object := map[string]interface{}{}
+			for !p.accept("}") {
+				key, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if object[key], err = p.value(); err != nil {
+					return nil, err
+				}
+			}
+			return object, nil
+		}
+	}
+	if t.kind != tokEOF {
+		p.next--
+	}
+	return nil, p.unexpected("expected a value")
+}
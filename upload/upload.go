@@ -0,0 +1,298 @@
+// Package upload implements the GraphQL multipart request spec
+// (https://github.com/jaydenseric/graphql-multipart-request-spec), which
+// sends files alongside a GraphQL operation as multipart/form-data:
+//
+//	operations: {"query": "mutation($file: String) {...}", "variables": {"file": null}}
+//	map:        {"0": ["variables.file"]}
+//	0:          <file contents>
+//
+// The operations field comes first, then the map from file field names to
+// the variable paths each file fills, then the files. Because of that order
+// every file can be streamed straight to storage as it is read. Once stored,
+// the null placeholder a file maps to is replaced with the stored file's ID,
+// so resolvers take an upload as a plain String argument.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"hc-hello-world-plugin/files"
+)
+
+// ErrInvalidRequest is returned for requests that do not follow the spec
+var ErrInvalidRequest = errors.New("invalid multipart request")
+
+// maxFieldBytes caps the operations and map fields
+const maxFieldBytes = 1 << 20
+
+// Request is one GraphQL operation of a multipart request, with file
+// variables replaced by stored file IDs
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Options limits what a multipart request may upload
+type Options struct {
+	// MaxFileBytes caps each file; 0 means no limit
+	MaxFileBytes int64
+	// MaxFiles caps the number of files; 0 means no limit
+	MaxFiles int
+	// NewID names stored files
+	NewID func() string
+}
+
+// Parsed is a multipart request whose files have been stored
+type Parsed struct {
+	Requests []Request
+	// Batch is set when operations was a list; the response is then a list
+	// too
+	Batch bool
+	Files []files.File
+}
+
+// Parse reads a multipart request from body, storing each file in store as
+// it arrives. Files are stored without an owner; it is up to the
+// operations to claim them. When parsing fails every file stored so far is
+// deleted again.
+func Parse(ctx context.Context, body io.Reader, contentType string, store *files.Store, opts Options) (*Parsed, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, fmt.Errorf("%w: content type must be multipart/form-data with a boundary, got %q", ErrInvalidRequest, contentType)
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+
+	var operations interface{}
+	if err := readField(reader, "operations", &operations); err != nil {
+		return nil, err
+	}
+	var batch bool
+	switch operations.(type) {
+	case map[string]interface{}:
+	case []interface{}:
+		batch = true
+	default:
+		return nil, fmt.Errorf("%w: operations must be an object or a list of objects", ErrInvalidRequest)
+	}
+
+	var fileMap fileMap
+	if err := readField(reader, "map", &fileMap); err != nil {
+		return nil, err
+	}
+	if opts.MaxFiles > 0 && len(fileMap) > opts.MaxFiles {
+		return nil, fmt.Errorf("%w: %d files, at most %d allowed", ErrInvalidRequest, len(fileMap), opts.MaxFiles)
+	}
+
+	// Check every path before storing anything
+	setters := make(map[string][]func(interface{}), len(fileMap))
+	claimed := make(map[string]string)
+	for name, paths := range fileMap {
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("%w: map entry %q has no paths", ErrInvalidRequest, name)
+		}
+		for _, path := range paths {
+			if other, ok := claimed[path]; ok {
+				return nil, fmt.Errorf("%w: map path %q is listed for both %q and %q", ErrInvalidRequest, path, other, name)
+			}
+			claimed[path] = name
+			set, err := locate(operations, path, batch)
+			if err != nil {
+				return nil, err
+			}
+			setters[name] = append(setters[name], set)
+		}
+	}
+
+	parsed := &Parsed{Batch: batch}
+	fail := func(err error) (*Parsed, error) {
+		for _, f := range parsed.Files {
+			store.Delete(ctx, f.ID)
+		}
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+		}
+		name := part.FormName()
+		set, ok := setters[name]
+		if !ok {
+			return fail(fmt.Errorf("%w: file field %q is not in the map or was sent twice", ErrInvalidRequest, name))
+		}
+		delete(setters, name)
+
+		contentType := part.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		f, err := store.Save(ctx, files.File{
+			ID:          opts.NewID(),
+			Name:        part.FileName(),
+			ContentType: contentType,
+		}, part, opts.MaxFileBytes)
+		if err != nil {
+			return fail(fmt.Errorf("file %q: %w", name, err))
+		}
+		parsed.Files = append(parsed.Files, f)
+		for _, s := range set {
+			s(f.ID)
+		}
+	}
+	for name := range setters {
+		return fail(fmt.Errorf("%w: file %q is in the map but was not sent", ErrInvalidRequest, name))
+	}
+
+	// Round-trip through JSON to turn the substituted tree into requests
+	if !batch {
+		operations = []interface{}{operations}
+	}
+	data, err := json.Marshal(operations)
+	if err == nil {
+		err = json.Unmarshal(data, &parsed.Requests)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("%w: operations: %v", ErrInvalidRequest, err))
+	}
+	return parsed, nil
+}
+
+// fileMap is the map field. It rejects a file name listed twice, which
+// decoding into a plain map would silently collapse to the last entry.
+type fileMap map[string][]string
+
+func (m *fileMap) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errors.New("must be an object of file names to lists of paths")
+	}
+	*m = fileMap{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		if _, ok := (*m)[name]; ok {
+			return fmt.Errorf("file %q is listed twice", name)
+		}
+		var paths []string
+		if err := dec.Decode(&paths); err != nil {
+			return fmt.Errorf("file %q: %v", name, err)
+		}
+		(*m)[name] = paths
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// readField reads the next part, which must be the form field name, as JSON
+func readField(reader *multipart.Reader, name string, v interface{}) error {
+	part, err := reader.NextPart()
+	if err != nil {
+		return fmt.Errorf("%w: expected the %s field first: %v", ErrInvalidRequest, name, err)
+	}
+	if part.FormName() != name {
+		return fmt.Errorf("%w: expected the %s field, got %q", ErrInvalidRequest, name, part.FormName())
+	}
+	data, err := io.ReadAll(io.LimitReader(part, maxFieldBytes+1))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidRequest, name, err)
+	}
+	if len(data) > maxFieldBytes {
+		return fmt.Errorf("%w: %s field is larger than %d bytes", ErrInvalidRequest, name, maxFieldBytes)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s is not valid JSON: %v", ErrInvalidRequest, name, err)
+	}
+	return nil
+}
+
+// locate resolves a map path such as "variables.files.1", or
+// "0.variables.file" in a batch, to the null placeholder it names and
+// returns a function replacing it. Paths may only point into variables.
+func locate(operations interface{}, path string, batch bool) (func(interface{}), error) {
+	segments := strings.Split(path, ".")
+	variablesAt := 0
+	if batch {
+		variablesAt = 1
+	}
+	if len(segments) <= variablesAt+1 || segments[variablesAt] != "variables" {
+		return nil, fmt.Errorf("%w: map path %q does not point into an operation's variables", ErrInvalidRequest, path)
+	}
+
+	node := operations
+	for i, segment := range segments {
+		var value interface{}
+		var set func(interface{})
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[segment]
+			if !ok {
+				return nil, fmt.Errorf("%w: map path %q: no %q in operations", ErrInvalidRequest, path, segment)
+			}
+			value, set = v, func(v interface{}) { n[segment] = v }
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(n) {
+				return nil, fmt.Errorf("%w: map path %q: index %q out of range", ErrInvalidRequest, path, segment)
+			}
+			value, set = n[index], func(v interface{}) { n[index] = v }
+		default:
+			return nil, fmt.Errorf("%w: map path %q: %q is not an object or list", ErrInvalidRequest, path, strings.Join(segments[:i], "."))
+		}
+
+		if i == len(segments)-1 {
+			if value != nil {
+				return nil, fmt.Errorf("%w: map path %q must point to a null placeholder", ErrInvalidRequest, path)
+			}
+			return set, nil
+		}
+		node = value
+	}
+	return nil, fmt.Errorf("%w: empty map path", ErrInvalidRequest)
+}
+
+// headerKeys are the argument/context keys checked for the request's
+// Content-Type
+var headerKeys = []string{"Content-Type", "content-type", "content_type", "contentType"}
+
+// ContentType extracts the request's Content-Type from REST args, a nested
+// "headers" map, or the request context
+func ContentType(ctx context.Context, args map[string]interface{}) string {
+	if value := lookupHeader(args); value != "" {
+		return value
+	}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		if value := lookupHeader(headers); value != "" {
+			return value
+		}
+	}
+	if headers, ok := ctx.Value("headers").(map[string]interface{}); ok {
+		return lookupHeader(headers)
+	}
+	return ""
+}
+
+func lookupHeader(values map[string]interface{}) string {
+	for _, key := range headerKeys {
+		if value, ok := values[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"testing"
+
+	"hc-hello-world-plugin/files"
+)
+
+// part is one multipart form field, sent in order
+type part struct {
+	name, body string
+}
+
+func parse(t *testing.T, store *files.Store, parts ...part) (*Parsed, error) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.name == "operations" || p.name == "map" {
+			err = w.WriteField(p.name, p.body)
+		} else {
+			var fw interface{ Write([]byte) (int, error) }
+			if fw, err = w.CreateFormFile(p.name, p.name+".txt"); err == nil {
+				_, err = fw.Write([]byte(p.body))
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	n := 0
+	return Parse(context.Background(), &body, w.FormDataContentType(), store, Options{
+		NewID: func() string { n++; return fmt.Sprintf("file-%d", n) },
+	})
+}
+
+const operations = `{"query": "mutation($a: String, $b: String, $list: [String]) { f }",
+	"variables": {"a": null, "b": null, "list": [null, null], "set": "taken"}}`
+
+func TestParse(t *testing.T) {
+	store := files.NewStore(files.NewMemoryDriver())
+	parsed, err := parse(t, store,
+		part{"operations", operations},
+		part{"map", `{"0": ["variables.a", "variables.list.1"], "1": ["variables.b"]}`},
+		part{"0", "zero"}, part{"1", "one"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := parsed.Requests[0].Variables
+	if vars["a"] != "file-1" || vars["b"] != "file-2" {
+		t.Errorf("a = %v, b = %v, want file-1 and file-2", vars["a"], vars["b"])
+	}
+	if list := vars["list"].([]interface{}); list[0] != nil || list[1] != "file-1" {
+		t.Errorf("list = %v, want [<nil> file-1]", list)
+	}
+	if _, data, err := store.ReadAll(context.Background(), "file-2"); err != nil || string(data) != "one" {
+		t.Errorf("stored file-2 = %q, %v", data, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []part
+	}{
+		// Malformed map
+		{"map before operations", []part{{"map", `{}`}, {"operations", operations}}},
+		{"map not JSON", []part{{"operations", operations}, {"map", `{"0": [`}}},
+		{"map is a list", []part{{"operations", operations}, {"map", `[["variables.a"]]`}}},
+		{"map is null", []part{{"operations", operations}, {"map", `null`}}},
+		{"map paths not a list", []part{{"operations", operations}, {"map", `{"0": "variables.a"}`}}},
+		{"map entry without paths", []part{{"operations", operations}, {"map", `{"0": []}`}, {"0", "x"}}},
+
+		// Paths
+		{"missing variable", []part{{"operations", operations}, {"map", `{"0": ["variables.nope"]}`}, {"0", "x"}}},
+		{"index out of range", []part{{"operations", operations}, {"map", `{"0": ["variables.list.2"]}`}, {"0", "x"}}},
+		{"index into an object", []part{{"operations", operations}, {"map", `{"0": ["variables.a.0"]}`}, {"0", "x"}}},
+		{"outside the variables", []part{{"operations", operations}, {"map", `{"0": ["query"]}`}, {"0", "x"}}},
+		{"variables themselves", []part{{"operations", operations}, {"map", `{"0": ["variables"]}`}, {"0", "x"}}},
+		{"value already set", []part{{"operations", operations}, {"map", `{"0": ["variables.set"]}`}, {"0", "x"}}},
+		{"batch path without an index", []part{{"operations", "[" + operations + "]"}, {"map", `{"0": ["variables.a"]}`}, {"0", "x"}}},
+
+		// Duplicates
+		{"duplicate file key", []part{{"operations", operations},
+			{"map", `{"0": ["variables.a"], "0": ["variables.b"]}`}, {"0", "x"}}},
+		{"path listed for two files", []part{{"operations", operations},
+			{"map", `{"0": ["variables.a"], "1": ["variables.a"]}`}, {"0", "x"}, {"1", "y"}}},
+		{"path listed twice for one file", []part{{"operations", operations},
+			{"map", `{"0": ["variables.a", "variables.a"]}`}, {"0", "x"}}},
+		{"file sent twice", []part{{"operations", operations}, {"map", `{"0": ["variables.a"]}`}, {"0", "x"}, {"0", "y"}}},
+
+		// Files
+		{"file not in the map", []part{{"operations", operations}, {"map", `{"0": ["variables.a"]}`}, {"0", "x"}, {"1", "y"}}},
+		{"file in the map not sent", []part{{"operations", operations}, {"map", `{"0": ["variables.a"], "1": ["variables.b"]}`}, {"0", "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := files.NewStore(files.NewMemoryDriver())
+			if _, err := parse(t, store, tt.parts...); !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("got %v, want ErrInvalidRequest", err)
+			}
+			// Files stored before the failure are deleted again
+			if n := store.DeleteAll(context.Background()); n != 0 {
+				t.Errorf("%d files left in the store", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Package usage counts resolver calls, errors and distinct argument sets and
+// rolls them up per hour into the store, so plugin owners can see which
+// capabilities are actually used.
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/instrument"
+	"hc-hello-world-plugin/store"
+)
+
+// maxDistinctPerHour bounds the fingerprints remembered per resolver and
+// hour; beyond it distinct argument counts stop growing
+const maxDistinctPerHour = 10000
+
+// bucket is one resolver's counters for the current hour
+type bucket struct {
+	calls        int
+	errors       int
+	fingerprints map[string]struct{}
+}
+
+// Recorder collects the current hour in memory and writes completed hours
+// to the store
+type Recorder struct {
+	store store.Datasource
+	clock clock.Clock
+
+	mu      sync.Mutex
+	hour    time.Time
+	buckets map[string]*bucket
+}
+
+// NewRecorder creates a recorder that rolls up into s
+func NewRecorder(s store.Datasource) *Recorder {
+	return &Recorder{
+		store:   s,
+		clock:   clock.System,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// WithClock replaces the system clock that decides the current hour; call
+// it before the recorder is used
+func (r *Recorder) WithClock(c clock.Clock) *Recorder {
+	r.clock = clock.OrSystem(c)
+	return r
+}
+
+// Record counts a resolver call; it has instrument.Config.OnCall's signature
+func (r *Recorder) Record(call instrument.Call) {
+	hour := r.clock.Now().UTC().Truncate(time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !hour.Equal(r.hour) {
+		r.flushLocked()
+		r.hour = hour
+	}
+
+	b := r.buckets[call.Resolver]
+	if b == nil {
+		b = &bucket{fingerprints: make(map[string]struct{})}
+		r.buckets[call.Resolver] = b
+	}
+	b.calls++
+	if call.Err != nil {
+		b.errors++
+	}
+	if len(b.fingerprints) < maxDistinctPerHour {
+		b.fingerprints[call.Fingerprint] = struct{}{}
+	}
+}
+
+// Flush writes a completed hour still held in memory to the store. The
+// current hour stays in memory until it is over, so its distinct argument
+// count remains exact.
+func (r *Recorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hour.Before(r.clock.Now().UTC().Truncate(time.Hour)) {
+		r.flushLocked()
+	}
+}
+
+func (r *Recorder) flushLocked() {
+	if len(r.buckets) > 0 {
+		r.store.AddUsageRollups(r.rollupsLocked())
+	}
+	r.buckets = make(map[string]*bucket)
+}
+
+func (r *Recorder) rollupsLocked() []store.UsageRollup {
+	rollups := make([]store.UsageRollup, 0, len(r.buckets))
+	for resolver, b := range r.buckets {
+		rollups = append(rollups, store.UsageRollup{
+			Hour:         r.hour,
+			Resolver:     resolver,
+			Calls:        b.calls,
+			Errors:       b.errors,
+			DistinctArgs: len(b.fingerprints),
+		})
+	}
+	return rollups
+}
+
+// Summary is one resolver's usage over a range of hours
+type Summary struct {
+	Resolver  string    `json:"resolver"`
+	Calls     int       `json:"calls"`
+	Errors    int       `json:"errors"`
+	ErrorRate float64   `json:"errorRate"`
+	LastHour  time.Time `json:"lastHour"`
+	// PeakDistinctArgs is the highest hourly distinct argument count;
+	// distinct sets cannot be added up across hours
+	PeakDistinctArgs int `json:"peakDistinctArgs"`
+}
+
+// Rollups returns the stored rollups since since plus the hour still in
+// memory, ordered by hour then resolver
+func (r *Recorder) Rollups(since time.Time) []store.UsageRollup {
+	rollups := r.store.UsageRollups(since)
+
+	r.mu.Lock()
+	if !r.hour.Before(since.UTC().Truncate(time.Hour)) {
+		rollups = append(rollups, r.rollupsLocked()...)
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(rollups, func(i, j int) bool {
+		if !rollups[i].Hour.Equal(rollups[j].Hour) {
+			return rollups[i].Hour.Before(rollups[j].Hour)
+		}
+		return rollups[i].Resolver < rollups[j].Resolver
+	})
+	return rollups
+}
+
+// Summarize totals rollups per resolver, most called first
+func Summarize(rollups []store.UsageRollup) []Summary {
+	byResolver := make(map[string]*Summary)
+	for _, r := range rollups {
+		s := byResolver[r.Resolver]
+		if s == nil {
+			s = &Summary{Resolver: r.Resolver}
+			byResolver[r.Resolver] = s
+		}
+		s.Calls += r.Calls
+		s.Errors += r.Errors
+		s.PeakDistinctArgs = max(s.PeakDistinctArgs, r.DistinctArgs)
+		if r.Hour.After(s.LastHour) {
+			s.LastHour = r.Hour
+		}
+	}
+
+	result := make([]Summary, 0, len(byResolver))
+	for _, s := range byResolver {
+		if s.Calls > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Resolver < result[j].Resolver
+	})
+	return result
+}
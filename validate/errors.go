@@ -0,0 +1,59 @@
+package validate
+
+import "strings"
+
+// Errors collects the problems with every invalid field of one input so
+// they can be reported together rather than one at a time
+type Errors []*FieldError
+
+// Add appends err, ignoring nil so validator results can be added directly
+func (e *Errors) Add(err *FieldError) {
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+// Without returns the errors that are not for any of fields
+func (e Errors) Without(fields ...string) Errors {
+	var kept Errors
+	for _, err := range e {
+		skip := false
+		for _, field := range fields {
+			if err.Field == field {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			kept = append(kept, err)
+		}
+	}
+	return kept
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Err returns e as an error, or nil when it is empty. Use it instead of
+// returning e directly, which would be a non-nil error even when empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ToList converts the errors into the [FieldError] structure returned in
+// GraphQL responses
+func (e Errors) ToList() []interface{} {
+	list := make([]interface{}, 0, len(e))
+	for _, err := range e {
+		list = append(list, err.ToMap())
+	}
+	return list
+}
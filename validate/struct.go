@@ -0,0 +1,141 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"hc-hello-world-plugin/geo"
+)
+
+// structValidator checks `validate:"..."` struct tags. Field names in errors
+// come from the json tag so they match the GraphQL input names.
+var structValidator = newStructValidator()
+
+func newStructValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// "handle", "phone" and "password" reuse the hand-written validators so
+	// both styles enforce identical rules
+	_ = v.RegisterValidation("handle", func(fl validator.FieldLevel) bool {
+		_, fieldErr := Username("", fl.Field().String())
+		return fieldErr == nil
+	})
+	_ = v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		_, fieldErr := Phone("", fl.Field().String())
+		return fieldErr == nil
+	})
+	_ = v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return Password("", fl.Field().String()) == nil
+	})
+	// "latitude" and "longitude" replace the built-in string checks with the
+	// numeric range checks nearbyUsers applies to its arguments
+	_ = v.RegisterValidation("latitude", func(fl validator.FieldLevel) bool {
+		return geo.ValidateLatitude(fl.Field().Float()) == nil
+	})
+	_ = v.RegisterValidation("longitude", func(fl validator.FieldLevel) bool {
+		return geo.ValidateLongitude(fl.Field().Float()) == nil
+	})
+	return v
+}
+
+// Bind decodes an input object (as returned by sdk.GetObjectArg) into the
+// struct pointed to by dst using its json tags
+func Bind(input map[string]interface{}, dst interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode input: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to bind input: %w", err)
+	}
+	return nil
+}
+
+// Struct validates v against its `validate` struct tags and translates every
+// violation into a FieldError. It returns nil when v is valid.
+func Struct(v interface{}) Errors {
+	err := structValidator.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var violations validator.ValidationErrors
+	if !errors.As(err, &violations) {
+		return Errors{{Field: "input", Code: CodeInvalid, Message: err.Error()}}
+	}
+
+	fieldErrors := make(Errors, 0, len(violations))
+	for _, violation := range violations {
+		fieldErrors = append(fieldErrors, translate(violation))
+	}
+	return fieldErrors
+}
+
+// translate maps a validator tag onto the repo's error codes and messages.
+// Nested fields are reported by path, e.g. "address.city" or "tags[0].key".
+func translate(violation validator.FieldError) *FieldError {
+	field := violation.Field()
+	if _, path, ok := strings.Cut(violation.Namespace(), "."); ok {
+		field = path
+	}
+	switch violation.Tag() {
+	case "required":
+		return &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	case "min":
+		return &FieldError{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must be at least %s %s", field, violation.Param(), unit(violation))}
+	case "max":
+		return &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %s %s", field, violation.Param(), unit(violation))}
+	case "required_with":
+		return &FieldError{Field: field, Code: CodeRequired, Message: fmt.Sprintf("%s is required with %s", field, strings.ToLower(violation.Param()))}
+	case "latitude", "longitude":
+		return &FieldError{Field: field, Code: CodeInvalid, Message: fmt.Sprintf("%s must be between %s", field, coordinateRange[violation.Tag()])}
+	case "email":
+		return &FieldError{Field: field, Code: CodeInvalid, Message: field + " is not a valid address"}
+	case "unique":
+		return &FieldError{Field: field, Code: CodeInvalid, Message: field + " must not contain duplicates"}
+	case "handle":
+		value, _ := violation.Value().(string)
+		if _, fieldErr := Username(field, value); fieldErr != nil {
+			return fieldErr
+		}
+	case "phone":
+		value, _ := violation.Value().(string)
+		if _, fieldErr := Phone(field, value); fieldErr != nil {
+			return fieldErr
+		}
+	case "password":
+		value, _ := violation.Value().(string)
+		if fieldErr := Password(field, value); fieldErr != nil {
+			return fieldErr
+		}
+	}
+	return &FieldError{Field: field, Code: CodeInvalid, Message: fmt.Sprintf("%s failed %s validation", field, violation.Tag())}
+}
+
+// coordinateRange describes the valid range for each coordinate tag
+var coordinateRange = map[string]string{
+	"latitude":  "-90 and 90",
+	"longitude": "-180 and 180",
+}
+
+// unit names what a length limit counts for the violated field
+func unit(violation validator.FieldError) string {
+	switch violation.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	default:
+		return "characters"
+	}
+}
@@ -0,0 +1,209 @@
+// Package validate holds reusable input validators that normalize values and
+// report problems as field-level errors.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/units"
+)
+
+// Error codes returned in FieldError.Code
+const (
+	CodeRequired = "REQUIRED"
+	CodeInvalid  = "INVALID_FORMAT"
+	CodeTooShort = "TOO_SHORT"
+	CodeTooLong  = "TOO_LONG"
+	CodeTaken    = "ALREADY_TAKEN"
+	// CodeVersionConflict means the record changed since the version given
+	CodeVersionConflict = "VERSION_CONFLICT"
+)
+
+// Username length limits
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30
+)
+
+// Password length limits; bcrypt ignores anything past 72 bytes
+const (
+	PasswordMinLength = 8
+	PasswordMaxLength = 72
+)
+
+// maxEmailLength is the practical limit from RFC 5321
+const maxEmailLength = 254
+
+// Phone number digit limits; E.164 allows at most 15
+const (
+	phoneMinDigits = 8
+	phoneMaxDigits = 15
+)
+
+// FieldError describes a validation problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ToMap converts the error into the structure returned in GraphQL responses
+func (e *FieldError) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"code":    e.Code,
+		"field":   e.Field,
+		"message": e.Message,
+	}
+}
+
+// Required fails when the trimmed value is empty
+func Required(field, value string) *FieldError {
+	if strings.TrimSpace(value) == "" {
+		return &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	return nil
+}
+
+// Email validates an email address and returns it trimmed and lowercased
+func Email(field, value string) (string, *FieldError) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if normalized == "" {
+		return "", &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	if len(normalized) > maxEmailLength {
+		return "", &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %d characters", field, maxEmailLength)}
+	}
+
+	// ParseAddress accepts display names ("Jane <jane@x.io>"), so also require
+	// the parsed address to be the whole input
+	addr, err := mail.ParseAddress(normalized)
+	if err != nil || addr.Address != normalized {
+		return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " is not a valid address"}
+	}
+
+	at := strings.LastIndex(normalized, "@")
+	domain := normalized[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " domain is not valid"}
+	}
+
+	return normalized, nil
+}
+
+// Phone validates a phone number in international form and returns it in
+// E.164, e.g. "+1 (415) 555-0123" becomes "+14155550123". Spaces, dots,
+// dashes and parentheses between the digits are dropped.
+func Phone(field, value string) (string, *FieldError) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return "", &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	if !strings.HasPrefix(trimmed, "+") {
+		return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " must start with + and the country code"}
+	}
+
+	digits := make([]byte, 0, len(trimmed))
+	for _, r := range trimmed[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, byte(r))
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " may only contain digits, spaces, '.', '-' and parentheses after the +"}
+		}
+	}
+	if len(digits) < phoneMinDigits {
+		return "", &FieldError{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must have at least %d digits", field, phoneMinDigits)}
+	}
+	if len(digits) > phoneMaxDigits {
+		return "", &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must have at most %d digits", field, phoneMaxDigits)}
+	}
+	if digits[0] == '0' {
+		return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " country code cannot start with 0"}
+	}
+	return "+" + string(digits), nil
+}
+
+// Username validates a username and returns it trimmed and lowercased.
+// Usernames may contain letters, digits, '.', '_' and '-', and must start
+// with a letter or digit.
+func Username(field, value string) (string, *FieldError) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if normalized == "" {
+		return "", &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	if len(normalized) < UsernameMinLength {
+		return "", &FieldError{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must be at least %d characters", field, UsernameMinLength)}
+	}
+	if len(normalized) > UsernameMaxLength {
+		return "", &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %d characters", field, UsernameMaxLength)}
+	}
+
+	for i, r := range normalized {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case (r == '.' || r == '_' || r == '-') && i > 0:
+		default:
+			return "", &FieldError{Field: field, Code: CodeInvalid, Message: field + " may only contain letters, digits, '.', '_' and '-', and must start with a letter or digit"}
+		}
+	}
+
+	return normalized, nil
+}
+
+// Password checks length limits. The value is never normalized or echoed in
+// error messages.
+func Password(field, value string) *FieldError {
+	if value == "" {
+		return &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	if len(value) < PasswordMinLength {
+		return &FieldError{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must be at least %d characters", field, PasswordMinLength)}
+	}
+	if len(value) > PasswordMaxLength {
+		return &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %d bytes", field, PasswordMaxLength)}
+	}
+	return nil
+}
+
+// Duration parses a human-friendly duration such as "30s", "5m" or "1d12h"
+// and checks it lies within [min, max]
+func Duration(field, value string, min, max time.Duration) (time.Duration, *FieldError) {
+	if strings.TrimSpace(value) == "" {
+		return 0, &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	d, err := units.ParseDuration(value)
+	if err != nil {
+		return 0, &FieldError{Field: field, Code: CodeInvalid, Message: field + ` must be a duration such as "30s", "5m" or "1d12h"`}
+	}
+	if d < min {
+		return 0, &FieldError{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must be at least %s", field, min)}
+	}
+	if d > max {
+		return 0, &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %s", field, max)}
+	}
+	return d, nil
+}
+
+// ByteSize parses a size such as "512KB" or "10MiB" and checks it is
+// positive and at most max bytes
+func ByteSize(field, value string, max int64) (int64, *FieldError) {
+	if strings.TrimSpace(value) == "" {
+		return 0, &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+	}
+	n, err := units.ParseByteSize(value)
+	if err != nil || n <= 0 {
+		return 0, &FieldError{Field: field, Code: CodeInvalid, Message: field + ` must be a size such as "512KB" or "10MiB"`}
+	}
+	if n > max {
+		return 0, &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %s", field, units.FormatByteSize(max))}
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+// Package variables reads the raw GraphQL operation variables the host
+// passes in the resolver context.
+//
+// Resolver args already have variables substituted: for
+// `query($n: Int) { getUsers(limit: $n) }` the resolver receives
+// args["limit"], not "$n". The variables map differs from args in three
+// ways: it holds every variable of the operation, including ones bound to
+// other fields; it is keyed by variable name rather than argument name; and
+// its values are JSON-decoded, so every number is a float64 and objects are
+// plain maps, whereas sdk.ParseArgsForResolver converts args to their
+// declared types.
+package variables
+
+import (
+	"context"
+	"sort"
+)
+
+// ContextKey is the host context key holding the variables map. The SDK
+// also copies it into the args as "context_variables"
+// (see sdk.GetAllContextData).
+const ContextKey = "variables"
+
+// FromContext returns the operation's variables, or nil when the host sent
+// none
+func FromContext(ctx context.Context) map[string]interface{} {
+	vars, _ := ctx.Value(ContextKey).(map[string]interface{})
+	return vars
+}
+
+// Names returns the variable names in sorted order
+func Names(vars map[string]interface{}) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,210 @@
+// Package watchdog monitors the plugin's memory use and sheds load when it
+// grows too large: above the soft limit page sizes are halved, above the
+// hard limit expensive resolvers are rejected with RESOURCE_EXHAUSTED.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/apito-io/go-apito-plugin-sdk"
+
+	"hc-hello-world-plugin/limits"
+)
+
+// Config holds the memory thresholds; a zero limit disables that level
+type Config struct {
+	// SoftLimitBytes is the usage above which page sizes are shrunk
+	SoftLimitBytes uint64
+	// HardLimitBytes is the usage above which expensive resolvers are rejected
+	HardLimitBytes uint64
+	// Interval is how often memory is sampled
+	Interval time.Duration
+}
+
+// Level is the current pressure level
+type Level int32
+
+// Pressure levels, in increasing order of severity
+const (
+	LevelOK Level = iota
+	LevelSoft
+	LevelHard
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelSoft:
+		return "soft"
+	case LevelHard:
+		return "hard"
+	default:
+		return "ok"
+	}
+}
+
+// Stats is a snapshot of the watchdog's state
+type Stats struct {
+	Level        string `json:"level"`
+	UsageBytes   uint64 `json:"usageBytes"`
+	ShedRequests uint64 `json:"shedRequests"`
+	ShrunkPages  uint64 `json:"shrunkPages"`
+}
+
+// Watchdog samples memory use in the background
+type Watchdog struct {
+	cfg Config
+
+	level        atomic.Int32
+	usage        atomic.Uint64
+	shedRequests atomic.Uint64
+	shrunkPages  atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a watchdog; call Start to begin sampling
+func New(cfg Config) *Watchdog {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	return &Watchdog{cfg: cfg, stop: make(chan struct{})}
+}
+
+// Enabled reports whether any limit is configured
+func (w *Watchdog) Enabled() bool {
+	return w.cfg.SoftLimitBytes > 0 || w.cfg.HardLimitBytes > 0
+}
+
+// Start samples memory every Interval until Stop is called. It does nothing
+// when no limit is configured.
+func (w *Watchdog) Start() {
+	if !w.Enabled() {
+		return
+	}
+	log.Printf("🐕 [hc-hello-world-plugin] Memory watchdog started (soft=%dMB hard=%dMB interval=%s)",
+		w.cfg.SoftLimitBytes>>20, w.cfg.HardLimitBytes>>20, w.cfg.Interval)
+	w.Sample()
+	go func() {
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Sample()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background sampling
+func (w *Watchdog) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// Sample measures memory use now, updates the pressure level and logs
+// level changes
+func (w *Watchdog) Sample() Level {
+	usage := memoryUsage()
+	w.usage.Store(usage)
+
+	level := LevelOK
+	switch {
+	case w.cfg.HardLimitBytes > 0 && usage >= w.cfg.HardLimitBytes:
+		level = LevelHard
+	case w.cfg.SoftLimitBytes > 0 && usage >= w.cfg.SoftLimitBytes:
+		level = LevelSoft
+	}
+
+	if previous := Level(w.level.Swap(int32(level))); previous != level {
+		log.Printf("🐕 [hc-hello-world-plugin] event=memory_pressure level=%s previous=%s usage_mb=%d",
+			level, previous, usage>>20)
+		if level == LevelHard {
+			// Give the collector a chance before requests start failing
+			runtime.GC()
+		}
+	}
+	return level
+}
+
+// Level returns the pressure level from the last sample
+func (w *Watchdog) Level() Level {
+	return Level(w.level.Load())
+}
+
+// Guard wraps an expensive resolver so it is rejected while memory is above
+// the hard limit
+func (w *Watchdog) Guard(name string, resolver sdk.ResolverFunc) sdk.ResolverFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		if w.Level() == LevelHard {
+			w.shedRequests.Add(1)
+			log.Printf("🐕 [hc-hello-world-plugin] event=load_shed resolver=%s usage_mb=%d", name, w.usage.Load()>>20)
+			return nil, &limits.Error{
+				Code:    limits.CodeResourceExhausted,
+				Message: fmt.Sprintf("%s is temporarily unavailable: the plugin is low on memory, retry later", name),
+			}
+		}
+		return resolver(ctx, args)
+	}
+}
+
+// PageSize halves size (never below 1) while memory is above the soft limit
+func (w *Watchdog) PageSize(resolver string, size int) int {
+	if w.Level() == LevelOK || size <= 1 {
+		return size
+	}
+	shrunk := size / 2
+	w.shrunkPages.Add(1)
+	log.Printf("🐕 [hc-hello-world-plugin] event=page_shrunk resolver=%s from=%d to=%d level=%s", resolver, size, shrunk, w.Level())
+	return shrunk
+}
+
+// Stats returns a snapshot of the watchdog's counters
+func (w *Watchdog) Stats() Stats {
+	return Stats{
+		Level:        w.Level().String(),
+		UsageBytes:   w.usage.Load(),
+		ShedRequests: w.shedRequests.Load(),
+		ShrunkPages:  w.shrunkPages.Load(),
+	}
+}
+
+// memoryUsage returns the larger of the Go heap size and the process RSS.
+// RSS is only available on Linux; elsewhere the heap size is used.
+func memoryUsage() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	usage := stats.HeapAlloc
+	if rss := residentSetSize(); rss > usage {
+		usage = rss
+	}
+	return usage
+}
+
+// residentSetSize reads the RSS from /proc/self/statm, or 0 when unavailable
+func residentSetSize() uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * uint64(os.Getpagesize())
+}
@@ -0,0 +1,171 @@
+// Package weather looks up the current weather for a city with Open-Meteo
+// (https://open-meteo.com), which needs no API key: the geocoding API
+// resolves the city to coordinates and the forecast API reports the
+// conditions there.
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hc-hello-world-plugin/extapi"
+)
+
+// ErrCityNotFound is returned when the geocoding API knows no such city
+var ErrCityNotFound = errors.New("city not found")
+
+// Report is the current weather in a city
+type Report struct {
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	// Timezone is the city's IANA time zone
+	Timezone     string
+	TemperatureC float64
+	// Humidity is the relative humidity in percent
+	Humidity     float64
+	WindSpeedKmh float64
+	// Code is the WMO weather interpretation code; Description names it
+	Code        int
+	Description string
+	// ObservedAt is when the conditions were measured
+	ObservedAt time.Time
+}
+
+// Service looks up weather reports
+type Service struct {
+	geocoding *extapi.Client
+	forecast  *extapi.Client
+}
+
+// New creates a service calling the Open-Meteo geocoding and forecast APIs
+// through the given clients
+func New(geocoding, forecast *extapi.Client) *Service {
+	return &Service{geocoding: geocoding, forecast: forecast}
+}
+
+// Enabled reports whether both APIs are configured
+func (s *Service) Enabled() bool {
+	return s.geocoding.Enabled() && s.forecast.Enabled()
+}
+
+type geocodingResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Country   string  `json:"country"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Timezone  string  `json:"timezone"`
+	} `json:"results"`
+}
+
+type forecastResponse struct {
+	Current struct {
+		Time        string  `json:"time"`
+		Temperature float64 `json:"temperature_2m"`
+		Humidity    float64 `json:"relative_humidity_2m"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		WeatherCode int     `json:"weather_code"`
+	} `json:"current"`
+}
+
+// Current returns the current weather in city; the best geocoding match
+// is used when several cities share the name
+func (s *Service) Current(ctx context.Context, city string) (Report, error) {
+	var places geocodingResponse
+	err := s.geocoding.GetJSON(ctx, "/v1/search", url.Values{
+		"name":     {city},
+		"count":    {"1"},
+		"language": {"en"},
+		"format":   {"json"},
+	}, &places)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to look up %q: %w", city, err)
+	}
+	if len(places.Results) == 0 {
+		return Report{}, fmt.Errorf("%w: %s", ErrCityNotFound, city)
+	}
+	place := places.Results[0]
+
+	var forecast forecastResponse
+	err = s.forecast.GetJSON(ctx, "/v1/forecast", url.Values{
+		"latitude":  {strconv.FormatFloat(place.Latitude, 'f', 4, 64)},
+		"longitude": {strconv.FormatFloat(place.Longitude, 'f', 4, 64)},
+		"current":   {"temperature_2m,relative_humidity_2m,wind_speed_10m,weather_code"},
+		"timezone":  {"GMT"},
+	}, &forecast)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get the weather in %s: %w", place.Name, err)
+	}
+	// Times carry no offset; they are UTC as requested
+	observedAt, err := time.Parse("2006-01-02T15:04", forecast.Current.Time)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid observation time %q from forecast API", forecast.Current.Time)
+	}
+
+	return Report{
+		City:         place.Name,
+		Country:      place.Country,
+		Latitude:     place.Latitude,
+		Longitude:    place.Longitude,
+		Timezone:     place.Timezone,
+		TemperatureC: forecast.Current.Temperature,
+		Humidity:     forecast.Current.Humidity,
+		WindSpeedKmh: forecast.Current.WindSpeed,
+		Code:         forecast.Current.WeatherCode,
+		Description:  Describe(forecast.Current.WeatherCode),
+		ObservedAt:   observedAt,
+	}, nil
+}
+
+// descriptions name the WMO weather interpretation codes used by Open-Meteo
+var descriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snowfall",
+	73: "Moderate snowfall",
+	75: "Heavy snowfall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+// Describe names a WMO weather code
+func Describe(code int) string {
+	if description, ok := descriptions[code]; ok {
+		return description
+	}
+	return "Unknown (WMO code " + strconv.Itoa(code) + ")"
+}
+
+// NormalizeCity trims city and collapses inner whitespace, so equivalent
+// spellings share cache entries
+func NormalizeCity(city string) string {
+	return strings.Join(strings.Fields(city), " ")
+}
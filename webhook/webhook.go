@@ -0,0 +1,361 @@
+// Package webhook delivers events to an outbound HTTP endpoint and keeps a
+// log of every delivery, with its attempts and the endpoint's last
+// response, so failed deliveries can be inspected and resent.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"hc-hello-world-plugin/clock"
+	"hc-hello-world-plugin/outbound"
+	"hc-hello-world-plugin/retry"
+	"hc-hello-world-plugin/tracecontext"
+)
+
+// ErrNotFound is returned for deliveries that are unknown or were dropped
+// from the log
+var ErrNotFound = errors.New("webhook delivery not found")
+
+// ErrPending is returned when replaying a delivery that is still being
+// retried
+var ErrPending = errors.New("webhook delivery is still pending")
+
+// Delivery statuses
+const (
+	// StatusPending deliveries wait for their first attempt or a retry
+	StatusPending = "pending"
+	// StatusDelivered deliveries got a 2xx response
+	StatusDelivered = "delivered"
+	// StatusFailed deliveries ran out of attempts or were rejected with a
+	// status that is not worth retrying
+	StatusFailed = "failed"
+)
+
+// maxResponseBody is how much of the endpoint's response is kept
+const maxResponseBody = 1 << 10
+
+// Delivery is one event sent, or being sent, to the endpoint
+type Delivery struct {
+	ID    string
+	Event string
+	URL   string
+	// Payload is the JSON request body
+	Payload string
+	Status  string
+	// Attempts counts the requests made so far
+	Attempts int
+	// ResponseCode is the last response's status; 0 when no response arrived
+	ResponseCode int
+	// ResponseBody is the start of the last response's body
+	ResponseBody string
+	// LastError describes why the last attempt failed
+	LastError string
+	// NextRetry is when the next attempt is due; zero unless pending
+	NextRetry time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ReplayOf is the ID of the delivery this one resends
+	ReplayOf string
+	// Trace is the trace context of the request that caused the delivery;
+	// every attempt is sent as a child of it
+	Trace tracecontext.Context
+}
+
+// Config configures a Dispatcher
+type Config struct {
+	// URL receives a POST per event; deliveries are disabled when empty
+	URL string
+	// Secret signs each body with HMAC-SHA256 in X-Webhook-Signature
+	Secret string
+	// Policy sets the attempts per delivery and the backoff between them
+	Policy retry.Policy
+	// Timeout bounds each request
+	Timeout time.Duration
+	// History is how many deliveries the log keeps
+	History int
+	// NewID generates delivery IDs
+	NewID func() string
+	// Doer sends the requests, adding the trace context; nil uses a client
+	// limited to Timeout
+	Doer outbound.Doer
+	// Clock stamps deliveries and schedules retries; nil uses the system
+	// clock
+	Clock clock.Clock
+}
+
+// Dispatcher sends deliveries in the background, retrying failures with
+// backoff
+type Dispatcher struct {
+	cfg    Config
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+	// order holds delivery IDs, oldest first
+	order  []string
+	timers map[string]clock.Timer
+}
+
+// New creates a dispatcher
+func New(cfg Config) *Dispatcher {
+	if cfg.Policy.MaxAttempts < 1 {
+		cfg.Policy.MaxAttempts = 1
+	}
+	if cfg.History < 1 {
+		cfg.History = 1
+	}
+	if cfg.Doer == nil {
+		cfg.Doer = &http.Client{Timeout: cfg.Timeout}
+	}
+	cfg.Clock = clock.OrSystem(cfg.Clock)
+	cfg.Doer = tracecontext.Doer(cfg.Doer)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		cfg:        cfg,
+		ctx:        ctx,
+		cancel:     cancel,
+		deliveries: make(map[string]*Delivery),
+		timers:     make(map[string]clock.Timer),
+	}
+}
+
+// Enabled reports whether an endpoint is configured
+func (d *Dispatcher) Enabled() bool {
+	return d.cfg.URL != ""
+}
+
+// Send records a delivery of payload, encoded as JSON, and attempts it in
+// the background as part of ctx's trace
+func (d *Dispatcher) Send(ctx context.Context, event string, payload interface{}) (Delivery, error) {
+	if !d.Enabled() {
+		return Delivery{}, errors.New("no webhook URL configured")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to encode %s payload: %w", event, err)
+	}
+	return d.start(ctx, event, string(body), ""), nil
+}
+
+// Replay resends a delivered or failed delivery's payload as a new
+// delivery, so the original's history is kept. The replay belongs to ctx's
+// trace rather than the original's.
+func (d *Dispatcher) Replay(ctx context.Context, id string) (Delivery, error) {
+	d.mu.Lock()
+	original, ok := d.deliveries[id]
+	if !ok {
+		d.mu.Unlock()
+		return Delivery{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if original.Status == StatusPending {
+		d.mu.Unlock()
+		return Delivery{}, fmt.Errorf("%w: %s, next retry at %s", ErrPending, id, original.NextRetry.Format(time.RFC3339))
+	}
+	event, payload := original.Event, original.Payload
+	d.mu.Unlock()
+
+	return d.start(ctx, event, payload, id), nil
+}
+
+// Get returns a delivery
+func (d *Dispatcher) Get(id string) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delivery, ok := d.deliveries[id]
+	if !ok {
+		return Delivery{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return *delivery, nil
+}
+
+// Deliveries returns up to limit deliveries, most recently updated first.
+// A non-empty status keeps only deliveries with that status; limit <= 0
+// returns all.
+func (d *Dispatcher) Deliveries(status string, limit int) []Delivery {
+	d.mu.Lock()
+	result := make([]Delivery, 0, len(d.deliveries))
+	for _, delivery := range d.deliveries {
+		if status == "" || delivery.Status == status {
+			result = append(result, *delivery)
+		}
+	}
+	d.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].UpdatedAt.Equal(result[j].UpdatedAt) {
+			return result[i].UpdatedAt.After(result[j].UpdatedAt)
+		}
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Close stops pending retries; deliveries in flight are cancelled
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, id)
+	}
+}
+
+// start records a new delivery and makes its first attempt in the background
+func (d *Dispatcher) start(ctx context.Context, event, payload, replayOf string) Delivery {
+	trace, _ := tracecontext.FromContext(ctx)
+	now := d.cfg.Clock.Now()
+	delivery := &Delivery{
+		ID:        d.cfg.NewID(),
+		Event:     event,
+		URL:       d.cfg.URL,
+		Payload:   payload,
+		Status:    StatusPending,
+		NextRetry: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ReplayOf:  replayOf,
+		Trace:     trace,
+	}
+
+	d.mu.Lock()
+	d.deliveries[delivery.ID] = delivery
+	d.order = append(d.order, delivery.ID)
+	d.trim()
+	snapshot := *delivery
+	d.mu.Unlock()
+
+	go d.attempt(delivery.ID)
+	return snapshot
+}
+
+// trim drops the oldest deliveries beyond History, preferring finished ones
+// so a pending delivery is only dropped when everything else is pending too
+func (d *Dispatcher) trim() {
+	for len(d.order) > d.cfg.History {
+		drop := 0
+		for i, id := range d.order {
+			if d.deliveries[id].Status != StatusPending {
+				drop = i
+				break
+			}
+		}
+		id := d.order[drop]
+		if timer, ok := d.timers[id]; ok {
+			timer.Stop()
+			delete(d.timers, id)
+		}
+		delete(d.deliveries, id)
+		d.order = append(d.order[:drop], d.order[drop+1:]...)
+	}
+}
+
+// attempt makes one request for a delivery and records the outcome,
+// scheduling the next attempt when the failure is worth retrying
+func (d *Dispatcher) attempt(id string) {
+	d.mu.Lock()
+	delete(d.timers, id)
+	delivery, ok := d.deliveries[id]
+	if !ok || d.ctx.Err() != nil {
+		d.mu.Unlock()
+		return
+	}
+	event, url, payload := delivery.Event, delivery.URL, delivery.Payload
+	ctx := d.ctx
+	if delivery.Trace.Valid() {
+		ctx = tracecontext.NewContext(ctx, delivery.Trace)
+	}
+	d.mu.Unlock()
+
+	code, body, err := d.post(ctx, id, event, url, payload)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The delivery may have been dropped from the log meanwhile
+	if delivery, ok = d.deliveries[id]; !ok {
+		return
+	}
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.ResponseBody = body
+	delivery.UpdatedAt = d.cfg.Clock.Now()
+	delivery.NextRetry = time.Time{}
+
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+		delivery.LastError = ""
+		log.Printf("🪝 [hc-hello-world-plugin] event=webhook_delivered id=%s name=%s attempts=%d status=%d", id, event, delivery.Attempts, code)
+		return
+	case retry.IsPermanent(err) || delivery.Attempts >= d.cfg.Policy.MaxAttempts || d.ctx.Err() != nil:
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+		log.Printf("❌ [hc-hello-world-plugin] event=webhook_failed id=%s name=%s attempts=%d status=%d error=%q", id, event, delivery.Attempts, code, err)
+		return
+	}
+
+	delay := d.cfg.Policy.Backoff(delivery.Attempts)
+	delivery.LastError = err.Error()
+	delivery.NextRetry = delivery.UpdatedAt.Add(delay)
+	d.timers[id] = d.cfg.Clock.AfterFunc(delay, func() { d.attempt(id) })
+	log.Printf("🔁 [hc-hello-world-plugin] event=webhook_retry id=%s name=%s attempts=%d status=%d retry_in=%s error=%q", id, event, delivery.Attempts, code, delay.Round(time.Millisecond), err)
+}
+
+// post sends a delivery's payload and returns the response status and the
+// start of its body. Client errors other than 408 and 429 are permanent:
+// the endpoint rejected the payload and will do so again.
+func (d *Dispatcher) post(ctx context.Context, id, event, url, payload string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader([]byte(payload)))
+	if err != nil {
+		return 0, "", retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", id)
+	if d.cfg.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+Sign(d.cfg.Secret, []byte(payload)))
+	}
+
+	resp, err := d.cfg.Doer.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Drain the rest so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return code, string(body), nil
+	case code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests:
+		return code, string(body), retry.Permanent(fmt.Errorf("endpoint rejected the delivery with status %d", code))
+	default:
+		return code, string(body), fmt.Errorf("endpoint responded with status %d", code)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in X-Webhook-Signature
+// after "sha256="
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}